@@ -75,6 +75,7 @@ func GetCommand() *cobra.Command {
 	flags.StringVar(&config.Path, "path", "", "Path to the file to import.")
 	flags.IntVar(&config.PPS, "pps", defaultPPS, "How many points per second the import will allow.  By default it is zero and will not throttle importing.")
 	flags.BoolVar(&config.Compressed, "compressed", false, "set to true if the import file is compressed")
+	flags.BoolVar(&config.ValidateOnly, "validate-only", false, "Only check the syntax of the lines in the file and report the position of each error.")
 	return c
 }
 
@@ -18,7 +18,9 @@ import (
 	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/format/line"
 	"github.com/cnosdb/cnosdb/pkg/logger"
 	"github.com/cnosdb/cnosdb/vend/db/pkg/limiter"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
 	"github.com/cnosdb/cnosdb/vend/db/tsdb/engine/tsm1"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/index/tsi1"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -32,11 +34,13 @@ var (
 // Options represents the program execution for "cnosdb-tools compact".
 type Options struct {
 	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
 	Stderr io.Writer
 	Stdout io.Writer
 	Logger *zap.Logger
 
 	path    string
+	level   string
 	force   bool
 	verbose bool
 }
@@ -44,20 +48,34 @@ type Options struct {
 // NewOptions returns a new instance of the export Command.
 func NewOptions() *Options {
 	return &Options{
+		Stdin:  os.Stdin,
 		Stderr: os.Stderr,
 		Stdout: os.Stdout,
+		level:  levelFull,
 	}
 }
 
+// The compaction levels selected with --level.
+const (
+	// levelFull rewrites every TSM file of the shard into as few files as
+	// possible.
+	levelFull = "full"
+
+	// levelOptimize merges only the fully compacted generations that the
+	// engine's optimize planner finds fragmented.
+	levelOptimize = "optimize"
+
+	// levelTSI compacts the shard's TSI index files.
+	levelTSI = "tsi"
+)
+
 var opt = NewOptions()
 
 func GetCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "compact",
-		Short: "fully compacts the specified shard.",
+		Short: "compacts the TSM files or TSI index of the specified shard.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var err error
-			var log = zap.NewNop()
 			if len(args) == 0 || len(args) > 1 {
 				return errors.New("shard path is required, there are can be only one")
 			}
@@ -65,58 +83,138 @@ func GetCommand() *cobra.Command {
 			if opt.path == "" {
 				return errors.New("shard-path is required")
 			}
-			if opt.verbose {
-				log = logger.NewLoggerWithWriter(os.Stdout)
-			}
+			return opt.run()
+		},
+	}
 
-			fmt.Fprintf(opt.Stdout, "opening shard at path %q\n\n", opt.path)
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.level, "level", levelFull, "compaction to run: full, optimize or tsi")
+	c.PersistentFlags().BoolVar(&opt.force, "force", false, "force compaction without prompting")
+	c.PersistentFlags().BoolVar(&opt.verbose, "verbose", false, "Enable verbose logging")
+	return c
+}
 
-			sc, err := newShardCompactor(opt.path, log)
-			if err != nil {
-				return err
-			}
+func (o *Options) run() error {
+	switch o.level {
+	case levelFull, levelOptimize, levelTSI:
+	default:
+		return fmt.Errorf("invalid level %q, must be full, optimize or tsi", o.level)
+	}
 
-			fmt.Fprintln(opt.Stdout)
-			fmt.Fprintln(opt.Stdout, "The following files will be compacted:")
-			fmt.Fprintln(opt.Stdout)
-			fmt.Fprintln(opt.Stdout, sc.String())
-
-			if !opt.force {
-				fmt.Fprint(opt.Stdout, "Proceed? [N/Y] ")
-				scan := bufio.NewScanner(os.Stdin)
-				scan.Scan()
-				if scan.Err() != nil {
-					return fmt.Errorf("error reading STDIN: %v", scan.Err())
-				}
+	var log = zap.NewNop()
+	if o.verbose {
+		log = logger.NewLoggerWithWriter(o.Stdout)
+	}
 
-				if strings.ToLower(scan.Text()) != "y" {
-					return nil
-				}
-			}
+	fmt.Fprintf(o.Stdout, "opening shard at path %q\n\n", o.path)
 
-			fmt.Fprintln(opt.Stdout, "Compacting shard.")
+	if o.level == levelTSI {
+		return o.compactIndex(log)
+	}
 
-			err = sc.CompactShard()
-			if err != nil {
-				return fmt.Errorf("compaction failed: %v", err)
-			}
+	sc, err := newShardCompactor(o.path, log)
+	if err != nil {
+		return err
+	}
 
-			fmt.Fprintln(opt.Stdout, "Compaction succeeded. New files:")
-			for _, f := range sc.newTSM {
-				fmt.Fprintf(opt.Stdout, "  %s\n", f)
+	groups := []tsm1.CompactionGroup{sc.tsm}
+	if o.level == levelOptimize {
+		groups = sc.PlanOptimize()
+		if len(groups) == 0 {
+			sc.close()
+			fmt.Fprintln(o.Stdout, "No generations need optimizing.")
+			return nil
+		}
+	}
+
+	fmt.Fprintln(o.Stdout)
+	fmt.Fprintln(o.Stdout, "The following files will be compacted:")
+	fmt.Fprintln(o.Stdout)
+	if o.level == levelOptimize {
+		for i, group := range groups {
+			fmt.Fprintf(o.Stdout, "Group %d:\n", i+1)
+			for _, f := range group {
+				fmt.Fprintf(o.Stdout, "  %s\n", f)
 			}
+		}
+	} else {
+		fmt.Fprintln(o.Stdout, sc.String())
+	}
 
-			return nil
-		},
+	if ok, err := o.confirm(); err != nil || !ok {
+		sc.close()
+		return err
 	}
 
-	c.SetUsageFunc(func(command *cobra.Command) error {
-		printUsage()
-		return nil
-	})
-	c.PersistentFlags().BoolVar(&opt.force, "force", false, "force compaction without prompting")
-	c.PersistentFlags().BoolVar(&opt.verbose, "verbose", false, "Enable verbose logging")
-	return c
+	fmt.Fprintln(o.Stdout, "Compacting shard.")
+
+	err = sc.CompactShard(groups)
+	if err != nil {
+		return fmt.Errorf("compaction failed: %v", err)
+	}
+
+	fmt.Fprintln(o.Stdout, "Compaction succeeded. New files:")
+	for _, f := range sc.newTSM {
+		fmt.Fprintf(o.Stdout, "  %s\n", f)
+	}
+
+	return nil
+}
+
+// confirm asks whether to go ahead unless --force is set.
+func (o *Options) confirm() (bool, error) {
+	if o.force {
+		return true, nil
+	}
+
+	fmt.Fprint(o.Stdout, "Proceed? [N/Y] ")
+	scan := bufio.NewScanner(o.Stdin)
+	scan.Scan()
+	if scan.Err() != nil {
+		return false, fmt.Errorf("error reading STDIN: %v", scan.Err())
+	}
+	return strings.ToLower(scan.Text()) == "y", nil
+}
+
+// compactIndex compacts the TSI index of the shard. The series file the index
+// refers to is in the shard's database directory, two levels up.
+func (o *Options) compactIndex(log *zap.Logger) error {
+	indexPath := filepath.Join(o.path, "index")
+	if _, err := os.Stat(indexPath); err != nil {
+		return fmt.Errorf("no tsi index at path %q: %v", indexPath, err)
+	}
+	dbPath := filepath.Dir(filepath.Dir(filepath.Clean(o.path)))
+
+	fmt.Fprintf(o.Stdout, "The index at %q will be compacted.\n", indexPath)
+	if ok, err := o.confirm(); err != nil || !ok {
+		return err
+	}
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(dbPath, tsdb.SeriesFileDirectory))
+	sfile.Logger = log
+	if err := sfile.Open(); err != nil {
+		return err
+	}
+	defer sfile.Close()
+
+	idx := tsi1.NewIndex(sfile, filepath.Base(dbPath), tsi1.WithPath(indexPath))
+	idx.WithLogger(log)
+	if err := idx.Open(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(o.Stdout, "Compacting index.")
+	idx.Compact()
+	idx.Wait()
+	if err := idx.Close(); err != nil {
+		return fmt.Errorf("compaction failed: %v", err)
+	}
+
+	fmt.Fprintln(o.Stdout, "Compaction succeeded.")
+	return nil
 }
 
 type shardCompactor struct {
@@ -220,22 +318,47 @@ func (sc *shardCompactor) openFiles() error {
 	return nil
 }
 
-func (sc *shardCompactor) CompactShard() (err error) {
+// CompactShard fully compacts each group of TSM files and replaces them with
+// the result.
+func (sc *shardCompactor) CompactShard(groups []tsm1.CompactionGroup) (err error) {
 	c := tsm1.NewCompactor()
 	c.Dir = sc.path
 	c.Size = tsm1.DefaultSegmentSize
 	c.FileStore = sc
 	c.Open()
 
-	tsmFiles, err := c.CompactFull(sc.tsm)
-	if err == nil {
-		sc.newTSM, err = sc.replace(tsmFiles)
+	var tsmFiles, compacted []string
+	for _, group := range groups {
+		files, err := c.CompactFull(group)
+		if err != nil {
+			return err
+		}
+		tsmFiles = append(tsmFiles, files...)
+		compacted = append(compacted, group...)
 	}
+
+	sc.newTSM, err = sc.replace(tsmFiles, compacted)
 	return err
 }
 
-// replace replaces the existing shard files with temporary tsmFiles
-func (sc *shardCompactor) replace(tsmFiles []string) ([]string, error) {
+// PlanOptimize returns the groups of TSM files the engine would merge in an
+// optimize compaction.
+func (sc *shardCompactor) PlanOptimize() []tsm1.CompactionGroup {
+	return tsm1.NewDefaultPlanner(sc, 0).PlanOptimize()
+}
+
+// close closes the readers of the shard's files.
+func (sc *shardCompactor) close() {
+	for _, r := range sc.readers {
+		r.Close()
+	}
+	sc.readers = nil
+	sc.files = nil
+}
+
+// replace replaces the compacted shard files, and their tombstones, with
+// temporary tsmFiles
+func (sc *shardCompactor) replace(tsmFiles, compacted []string) ([]string, error) {
 	// rename .tsm.tmp → .tsm
 	var newNames []string
 	for _, file := range tsmFiles {
@@ -249,25 +372,67 @@ func (sc *shardCompactor) replace(tsmFiles []string) ([]string, error) {
 	var errs errlist.ErrorList
 
 	// close all readers
-	for _, r := range sc.readers {
-		r.Close()
-	}
+	sc.close()
 
-	sc.readers = nil
-	sc.files = nil
-
-	// remove existing .tsm and .tombstone
-	for _, file := range sc.tsm {
+	// remove compacted .tsm and their .tombstone
+	removed := make(map[string]bool, len(compacted))
+	for _, file := range compacted {
 		errs.Add(os.Remove(file))
+		removed[strings.TrimSuffix(file, "."+tsm1.TSMFileExtension)] = true
 	}
 
 	for _, file := range sc.tombstone {
-		errs.Add(os.Remove(file))
+		if removed[strings.TrimSuffix(file, ".tombstone")] {
+			errs.Add(os.Remove(file))
+		}
 	}
 
 	return newNames, errs.Err()
 }
 
+// Stats returns the stats of the shard's TSM files for the planner.
+func (sc *shardCompactor) Stats() []tsm1.FileStat {
+	stats := make([]tsm1.FileStat, 0, len(sc.readers))
+	for _, r := range sc.readers {
+		stats = append(stats, r.Stats())
+	}
+	return stats
+}
+
+// LastModified returns the time the newest TSM file was modified.
+func (sc *shardCompactor) LastModified() time.Time {
+	var t time.Time
+	for _, r := range sc.readers {
+		if m := time.Unix(0, r.LastModified()); m.After(t) {
+			t = m
+		}
+	}
+	return t
+}
+
+// BlockCount returns the number of values in the block at position idx of
+// the TSM file at path.
+func (sc *shardCompactor) BlockCount(path string, idx int) int {
+	r := sc.files[path]
+	if r == nil || idx < 0 {
+		return 0
+	}
+
+	iter := r.BlockIterator()
+	for i := 0; i < idx; i++ {
+		if !iter.Next() {
+			return 0
+		}
+	}
+	_, _, _, _, _, block, _ := iter.Read()
+	cnt, _ := tsm1.BlockCount(block)
+	return cnt
+}
+
+func (sc *shardCompactor) ParseFileName(path string) (int, int, error) {
+	return tsm1.DefaultParseFileName(path)
+}
+
 func (sc *shardCompactor) NextGeneration() int {
 	panic("not implemented")
 }
@@ -308,6 +473,10 @@ func printUsage() {
 Flags:
       --force         force compaction without prompting
   -h, --help          help for compact
+      --level string  compaction to run (default "full"):
+                        full      rewrite every TSM file of the shard
+                        optimize  merge only fragmented, fully compacted generations
+                        tsi       compact the shard's TSI index
       --verbose       Enable verbose logging`)
 }
 
@@ -0,0 +1,192 @@
+package compact
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/db/models"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/engine/tsm1"
+	"github.com/cnosdb/cnosdb/vend/db/tsdb/index/tsi1"
+)
+
+func TestRun_Full(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// Two level 1 generations, left alone by an optimize.
+	writeTSM(t, dir, 1, 1)
+	writeTSM(t, dir, 2, 1)
+
+	stdout, err := run(dir, levelOptimize)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(stdout, "No generations need optimizing.") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	} else if exp := []string{"000000001-000000001.tsm", "000000002-000000001.tsm"}; !reflect.DeepEqual(tsmFiles(t, dir), exp) {
+		t.Fatalf("unexpected files after optimize: %v", tsmFiles(t, dir))
+	}
+
+	if _, err := run(dir, levelFull); err != nil {
+		t.Fatal(err)
+	} else if exp := []string{"000000002-000000002.tsm"}; !reflect.DeepEqual(tsmFiles(t, dir), exp) {
+		t.Fatalf("unexpected files after full compaction: %v", tsmFiles(t, dir))
+	}
+}
+
+func TestRun_Optimize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// Four fully compacted generations are worth merging.
+	for gen := 1; gen <= 4; gen++ {
+		writeTSM(t, dir, gen, 4)
+	}
+
+	stdout, err := run(dir, levelOptimize)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(stdout, "Group 1:") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	} else if exp := []string{"000000004-000000005.tsm"}; !reflect.DeepEqual(tsmFiles(t, dir), exp) {
+		t.Fatalf("unexpected files after optimize: %v", tsmFiles(t, dir))
+	}
+
+	// The merged generation is left alone.
+	if stdout, err := run(dir, levelOptimize); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(stdout, "No generations need optimizing.") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	}
+}
+
+func TestRun_TSI(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// Lay out a shard as the store does, with the series file in the
+	// database directory.
+	dbPath := filepath.Join(dir, "db0")
+	shardPath := filepath.Join(dbPath, "rp0", "1")
+	if err := os.MkdirAll(shardPath, 0777); err != nil {
+		t.Fatal(err)
+	}
+	writeTSM(t, shardPath, 1, 1)
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(dbPath, tsdb.SeriesFileDirectory))
+	if err := sfile.Open(); err != nil {
+		t.Fatal(err)
+	}
+	idx := tsi1.NewIndex(sfile, "db0", tsi1.WithPath(filepath.Join(shardPath, "index")))
+	if err := idx.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var keys, names [][]byte
+	var tags []models.Tags
+	for i := 0; i < 10; i++ {
+		tt := models.NewTags(map[string]string{"host": fmt.Sprintf("server%d", i)})
+		keys = append(keys, models.MakeKey([]byte("cpu"), tt))
+		names = append(names, []byte("cpu"))
+		tags = append(tags, tt)
+	}
+	if err := idx.CreateSeriesListIfNotExists(keys, names, tags); err != nil {
+		t.Fatal(err)
+	}
+	idx.Close()
+	sfile.Close()
+
+	stdout, err := run(shardPath, levelTSI)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(stdout, "Compaction succeeded.") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	}
+
+	// The TSM files are not touched and the index still holds the series.
+	if exp := []string{"000000001-000000001.tsm"}; !reflect.DeepEqual(tsmFiles(t, shardPath), exp) {
+		t.Fatalf("unexpected files after tsi compaction: %v", tsmFiles(t, shardPath))
+	}
+	if err := sfile.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer sfile.Close()
+	idx = tsi1.NewIndex(sfile, "db0", tsi1.WithPath(filepath.Join(shardPath, "index")))
+	if err := idx.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	if n := idx.SeriesN(); n != 10 {
+		t.Fatalf("unexpected series after tsi compaction: %d", n)
+	}
+
+	// A shard without an index can't be compacted at this level.
+	if _, err := run(dir, levelTSI); err == nil || !strings.Contains(err.Error(), "no tsi index") {
+		t.Fatalf("unexpected error without an index: %v", err)
+	}
+}
+
+func TestRun_InvalidLevel(t *testing.T) {
+	if _, err := run("", "partial"); err == nil || err.Error() != `invalid level "partial", must be full, optimize or tsi` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func run(path, level string) (string, error) {
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.Stderr = ioutil.Discard
+	o.path = path
+	o.level = level
+	o.force = true
+	err := o.run()
+	return stdout.String(), err
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cnosdb-tools-compact-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// writeTSM writes a TSM file for a generation and sequence holding a point
+// per generation.
+func writeTSM(t *testing.T, dir string, gen, seq int) {
+	f, err := os.Create(filepath.Join(dir, tsm1.DefaultFormatFileName(gen, seq)+"."+tsm1.TSMFileExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := tsm1.NewTSMWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(tsm1.SeriesFieldKeyBytes("cpu,host=server0", "value"), []tsm1.Value{tsm1.NewValue(int64(gen), float64(gen))}); err != nil {
+		t.Fatal(err)
+	} else if err := w.WriteIndex(); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tsmFiles(t *testing.T, dir string) []string {
+	paths, err := filepath.Glob(filepath.Join(dir, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	sort.Strings(names)
+	return names
+}
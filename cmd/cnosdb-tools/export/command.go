@@ -47,6 +47,7 @@ type Options struct {
 	conflictPath  string
 	ignore        bool
 	print         bool
+	withDDL       bool
 }
 
 func GetCommand() *cobra.Command {
@@ -67,6 +68,10 @@ func GetCommand() *cobra.Command {
 				return fmt.Errorf("invalid format '%s'", opt.format)
 			}
 
+			if opt.withDDL && opt.format != "line" {
+				return errors.New("with-ddl requires the line format")
+			}
+
 			if opt.conflictPath == "" && !opt.ignore {
 				return errors.New("missing conflict-path")
 			}
@@ -107,6 +112,12 @@ func GetCommand() *cobra.Command {
 				}
 			}
 
+			if opt.withDDL {
+				if err := e.WriteDDL(opt.Stdout); err != nil {
+					return err
+				}
+			}
+
 			var wr format.Writer
 			switch opt.format {
 			case "line":
@@ -142,6 +153,7 @@ func GetCommand() *cobra.Command {
 	c.PersistentFlags().BoolVar(&opt.ignore, "no-conflict-path", false, "Disable writing field conflicts to a file")
 	c.PersistentFlags().Var(&opt.r, "range", "Range of target shards to export (default: all)")
 	c.PersistentFlags().BoolVar(&opt.print, "print-only", false, "Print plan to stderr and exit")
+	c.PersistentFlags().BoolVar(&opt.withDDL, "with-ddl", false, "Write the statements that create the database and retention policy before the data (line format only)")
 	c.PersistentFlags().DurationVar(&opt.shardDuration, "shard-duration", time.Hour*24*7, "Target shard duration")
 
 	return c
@@ -1,6 +1,7 @@
 package export
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/storage"
 	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/server"
 	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
 	"github.com/cnosdb/cnosdb/vend/db/models"
 	"github.com/cnosdb/cnosdb/vend/db/tsdb"
 )
@@ -130,6 +132,49 @@ func (e *exporter) printShardGroups(w io.Writer, base int, target []meta.ShardGr
 	tw.Flush()
 }
 
+// WriteDDL writes the statements that create the exported database and
+// retention policy as they are in the source, in the "# DDL" section read by
+// the line protocol importer, followed by the start of the "# DML" section
+// for the exported data.
+func (e *exporter) WriteDDL(w io.Writer) error {
+	dbi := e.metaClient.Database(e.db)
+	if dbi == nil {
+		return fmt.Errorf("database '%s' does not exist", e.db)
+	}
+	rpi := dbi.RetentionPolicy(e.rp)
+	if rpi == nil {
+		return fmt.Errorf("retention policy '%s' does not exist", e.rp)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# DDL\n")
+	if rpi.Name == dbi.DefaultRetentionPolicy {
+		// Create the policy with the database so it is the default.
+		fmt.Fprintf(&buf, "CREATE DATABASE %s WITH DURATION %s REPLICATION %d",
+			cnosql.QuoteIdent(dbi.Name), cnosql.FormatDuration(rpi.Duration), rpi.ReplicaN)
+		if rpi.ShardGroupDuration > 0 {
+			fmt.Fprintf(&buf, " SHARD DURATION %s", cnosql.FormatDuration(rpi.ShardGroupDuration))
+		}
+		fmt.Fprintf(&buf, " NAME %s\n", cnosql.QuoteIdent(rpi.Name))
+	} else {
+		fmt.Fprintf(&buf, "CREATE DATABASE %s\n", cnosql.QuoteIdent(dbi.Name))
+		stmt := &cnosql.CreateRetentionPolicyStatement{
+			Name:               rpi.Name,
+			Database:           dbi.Name,
+			Duration:           rpi.Duration,
+			Replication:        rpi.ReplicaN,
+			ShardGroupDuration: rpi.ShardGroupDuration,
+		}
+		fmt.Fprintf(&buf, "%s\n", stmt)
+	}
+	buf.WriteString("# DML\n")
+	fmt.Fprintf(&buf, "# CONTEXT-DATABASE:%s\n", dbi.Name)
+	fmt.Fprintf(&buf, "# CONTEXT-RETENTION-POLICY:%s\n", rpi.Name)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
 func (e *exporter) SourceTimeRange() (time.Time, time.Time)  { return e.startDate, e.endDate }
 func (e *exporter) SourceShardGroups() []meta.ShardGroupInfo { return e.sourceGroups }
 func (e *exporter) TargetShardGroups() []meta.ShardGroupInfo { return e.targetGroups }
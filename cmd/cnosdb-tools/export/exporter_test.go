@@ -0,0 +1,189 @@
+package export
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/client"
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/pkg/importer"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+func TestExporter_WriteDDL_RoundTrip(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	for _, rp := range []string{"autogen", "week"} {
+		t.Run(rp, func(t *testing.T) {
+			src := newMetaClient(t)
+			if _, err := src.CreateDatabase("db0"); err != nil {
+				t.Fatal(err)
+			}
+			replicaN := 2
+			if _, err := src.CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "week", Duration: &week, ReplicaN: &replicaN, ShardGroupDuration: 24 * time.Hour}, false); err != nil {
+				t.Fatal(err)
+			}
+
+			e := &exporter{metaClient: &metaClient{src}, db: "db0", rp: rp}
+			var buf bytes.Buffer
+			if err := e.WriteDDL(&buf); err != nil {
+				t.Fatal(err)
+			}
+			buf.WriteString("cpu value=1 1000000000\n")
+
+			// Import the export into an empty meta store.
+			dst := newMetaClient(t)
+			s := newImportServer(t, dst)
+			defer s.Close()
+			if err := s.importFile(buf.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+
+			dbi := dst.Database("db0")
+			if dbi == nil {
+				t.Fatal("db0 not created")
+			}
+			for _, exp := range src.Database("db0").RetentionPolicies {
+				if exp.Name != rp {
+					continue
+				}
+				got := dbi.RetentionPolicy(rp)
+				if got == nil {
+					t.Fatalf("retention policy %s not created", rp)
+				} else if got.Duration != exp.Duration || got.ReplicaN != exp.ReplicaN || got.ShardGroupDuration != exp.ShardGroupDuration {
+					t.Fatalf("unexpected retention policy: got %+v, exp %+v", got, exp)
+				}
+			}
+			if rp == "autogen" && dbi.DefaultRetentionPolicy != "autogen" {
+				t.Fatalf("unexpected default retention policy: %s", dbi.DefaultRetentionPolicy)
+			}
+
+			if exp := []string{"db0/" + rp + ": cpu value=1 1000000000\n"}; !reflect.DeepEqual(s.writes, exp) {
+				t.Fatalf("unexpected writes: %q", s.writes)
+			}
+		})
+	}
+}
+
+// metaClient adds the node shard groups of the tools' server to a meta client.
+type metaClient struct {
+	*meta.Client
+}
+
+func (*metaClient) NodeID() uint64 { return 0 }
+
+func (c *metaClient) NodeShardGroupsByTimeRange(database, policy string, min, max time.Time) ([]meta.ShardGroupInfo, error) {
+	return c.ShardGroupsByTimeRange(database, policy, min, max)
+}
+
+func newMetaClient(t *testing.T) *meta.Client {
+	dir, err := ioutil.TempDir("", "cnosdb-tools-export-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := meta.NewConfig()
+	config.Dir = dir
+	c := meta.NewClient(config)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		os.RemoveAll(dir)
+	})
+	return c
+}
+
+// importServer answers the line protocol importer, running the statements it
+// sends against a meta client and recording the points it writes.
+type importServer struct {
+	*httptest.Server
+	t      *testing.T
+	client *meta.Client
+
+	mu     sync.Mutex
+	writes []string
+}
+
+func newImportServer(t *testing.T, c *meta.Client) *importServer {
+	s := &importServer{t: t, client: c}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *importServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/ping":
+		w.WriteHeader(http.StatusNoContent)
+	case "/query":
+		stmt, err := cnosql.ParseStatement(r.FormValue("q"))
+		if err == nil {
+			err = s.execute(stmt)
+		}
+		if err != nil {
+			s.t.Errorf("statement %q: %v", r.FormValue("q"), err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	case "/write":
+		b, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		s.writes = append(s.writes, r.FormValue("db")+"/"+r.FormValue("rp")+": "+string(b))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// execute applies the statements that WriteDDL writes as the server would.
+func (s *importServer) execute(stmt cnosql.Statement) error {
+	switch stmt := stmt.(type) {
+	case *cnosql.CreateDatabaseStatement:
+		if !stmt.RetentionPolicyCreate {
+			_, err := s.client.CreateDatabase(stmt.Name)
+			return err
+		}
+		_, err := s.client.CreateDatabaseWithRetentionPolicy(stmt.Name, &meta.RetentionPolicySpec{
+			Name:               stmt.RetentionPolicyName,
+			Duration:           stmt.RetentionPolicyDuration,
+			ReplicaN:           stmt.RetentionPolicyReplication,
+			ShardGroupDuration: stmt.RetentionPolicyShardGroupDuration,
+		})
+		return err
+	case *cnosql.CreateRetentionPolicyStatement:
+		_, err := s.client.CreateRetentionPolicy(stmt.Database, &meta.RetentionPolicySpec{
+			Name:               stmt.Name,
+			Duration:           &stmt.Duration,
+			ReplicaN:           &stmt.Replication,
+			ShardGroupDuration: stmt.ShardGroupDuration,
+		}, stmt.Default)
+		return err
+	}
+	s.t.Errorf("unexpected statement: %s", stmt)
+	return nil
+}
+
+func (s *importServer) importFile(b []byte) error {
+	path := filepath.Join(s.t.TempDir(), "export.txt")
+	if err := ioutil.WriteFile(path, b, 0666); err != nil {
+		return err
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return err
+	}
+	config := importer.NewConfig()
+	config.URL = *u
+	config.Path = path
+	config.ClientConfig = &client.HTTPConfig{Addr: s.URL}
+	return importer.NewImporter(*config).Import()
+}
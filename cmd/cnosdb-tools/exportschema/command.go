@@ -0,0 +1,128 @@
+package exportschema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/meta"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools export-schema".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir  string
+	metaAddr string
+	out      string
+}
+
+// NewOptions returns a new instance of the export-schema Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "export-schema",
+		Short: "writes databases, retention policies, subscriptions, continuous queries and users as CnosQL statements.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db, the path of meta.db itself, or - to read it from stdin")
+	c.PersistentFlags().StringVar(&opt.metaAddr, "meta-addr", "", "HTTP address of a meta node of a running cluster, as host:port")
+	c.PersistentFlags().StringVar(&opt.out, "out", "", "file to write the statements to (default stdout)")
+	return c
+}
+
+func (o *Options) run() (err error) {
+	if o.metaDir == "" && o.metaAddr == "" {
+		return errors.New("meta-dir or meta-addr is required")
+	} else if o.metaDir != "" && o.metaAddr != "" {
+		return errors.New("only one of meta-dir and meta-addr can be given")
+	}
+
+	data, err := o.load()
+	if err != nil {
+		return err
+	}
+
+	w := o.Stdout
+	if o.out != "" {
+		f, err := os.Create(o.out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if e := f.Close(); err == nil {
+				err = e
+			}
+		}()
+		w = f
+	}
+
+	// Databases and retention policies go first since subscriptions,
+	// continuous queries and grants refer to them.
+	if err := data.ExportRetentionPoliciesDDL(w); err != nil {
+		return err
+	}
+	if err := data.ExportSubscriptionsDDL(w); err != nil {
+		return err
+	}
+	if err := data.ExportContinuousQueriesDDL(w); err != nil {
+		return err
+	}
+	return data.ExportUsersDDL(w)
+}
+
+// load reads the meta data from meta.db or from the meta service.
+func (o *Options) load() (*meta.Data, error) {
+	if o.metaDir != "" {
+		return metafile.LoadFrom(o.metaDir, o.Stdin)
+	}
+
+	client := meta.NewRemoteClient()
+	client.SetMetaServers([]string{strings.TrimPrefix(o.metaAddr, "http://")})
+	if err := client.Open(); err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	data := client.Data()
+	return &data, nil
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools export-schema [flags]
+
+Reads the schema from meta.db with --meta-dir, or from a running cluster
+with --meta-addr. No points are exported. User passwords are exported as
+placeholders and must be set before the statements are executed.
+
+Flags:
+  -h, --help               help for export-schema
+      --meta-addr string   HTTP address of a meta node of a running cluster, as host:port
+      --meta-dir string    directory containing meta.db, the path of meta.db itself, or - to read it from stdin
+      --out string         file to write the statements to (default stdout)`)
+}
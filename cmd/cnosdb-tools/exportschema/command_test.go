@@ -0,0 +1,192 @@
+package exportschema
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+func TestExportSchema(t *testing.T) {
+	dir := t.TempDir()
+	data := metafiletest.NewData(t, 0, 1)
+	data.Index = 1
+	if err := data.CreateUser("u0", "hash", false); err != nil {
+		t.Fatal(err)
+	}
+	metafiletest.WriteFile(t, dir, data)
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaDir = dir
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := cnosql.ParseQuery(stdout.String())
+	if err != nil {
+		t.Fatalf("exported schema does not parse: %v\n%s", err, stdout.String())
+	} else if len(q.Statements) != 2 {
+		t.Fatalf("unexpected statements:\n%s", stdout.String())
+	}
+}
+
+func TestExportSchema_MetaAddr(t *testing.T) {
+	src := &meta.Data{Index: 2, ClusterID: 100}
+	for _, db := range []string{"db0", "db1"} {
+		if err := src.CreateDatabase(db); err != nil {
+			t.Fatal(err)
+		}
+		if err := src.CreateRetentionPolicy(db, &meta.RetentionPolicyInfo{Name: "autogen", ReplicaN: 1, ShardGroupDuration: 7 * 24 * time.Hour}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "week", ReplicaN: 1, Duration: 7 * 24 * time.Hour, ShardGroupDuration: 24 * time.Hour}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.CreateSubscription("db0", "week", "sub0", "ALL", []string{"udp://h0:9090"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.CreateContinuousQuery("db0", "cq0", `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT mean(value) INTO week.cpu_1h FROM cpu GROUP BY time(1h) END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.CreateUser("admin", "hash0", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.CreateUser("reader", "hash1", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SetPrivilege("reader", "db1", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSnapshotServer(t, src)
+	defer s.Close()
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaAddr = strings.TrimPrefix(s.URL, "http://")
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stdout.String(), "hash") {
+		t.Fatalf("password hash exported:\n%s", stdout.String())
+	}
+
+	q, err := cnosql.ParseQuery(stdout.String())
+	if err != nil {
+		t.Fatalf("exported schema does not parse: %v\n%s", err, stdout.String())
+	}
+
+	// Running the statements against an empty store recreates every object.
+	dst := newMetaClient(t)
+	for _, stmt := range q.Statements {
+		if err := execute(dst, stmt); err != nil {
+			t.Fatalf("statement %q: %v", stmt, err)
+		}
+	}
+	got := dst.Data()
+	if exp, got := countObjects(src), countObjects(&got); exp != got {
+		t.Fatalf("unexpected objects: got %+v, exp %+v", got, exp)
+	}
+
+	o.metaDir = "meta"
+	if err := o.run(); err == nil || err.Error() != "only one of meta-dir and meta-addr can be given" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// newSnapshotServer returns a meta service that serves data and holds every
+// request for later changes until the client goes away.
+func newSnapshotServer(t *testing.T, data *meta.Data) *httptest.Server {
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); index >= data.Index {
+			<-r.Context().Done()
+			return
+		}
+		w.Write(b)
+	}))
+}
+
+func newMetaClient(t *testing.T) *meta.Client {
+	config := meta.NewConfig()
+	config.Dir = t.TempDir()
+	c := meta.NewClient(config)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// execute applies an exported statement as the server would.
+func execute(c *meta.Client, stmt cnosql.Statement) error {
+	switch stmt := stmt.(type) {
+	case *cnosql.CreateDatabaseStatement:
+		_, err := c.CreateDatabaseWithRetentionPolicy(stmt.Name, &meta.RetentionPolicySpec{
+			Name:               stmt.RetentionPolicyName,
+			Duration:           stmt.RetentionPolicyDuration,
+			ReplicaN:           stmt.RetentionPolicyReplication,
+			ShardGroupDuration: stmt.RetentionPolicyShardGroupDuration,
+		})
+		return err
+	case *cnosql.CreateRetentionPolicyStatement:
+		_, err := c.CreateRetentionPolicy(stmt.Database, &meta.RetentionPolicySpec{
+			Name:               stmt.Name,
+			Duration:           &stmt.Duration,
+			ReplicaN:           &stmt.Replication,
+			ShardGroupDuration: stmt.ShardGroupDuration,
+		}, stmt.Default)
+		return err
+	case *cnosql.CreateSubscriptionStatement:
+		return c.CreateSubscription(stmt.Database, stmt.RetentionPolicy, stmt.Name, stmt.Mode, stmt.Destinations)
+	case *cnosql.CreateContinuousQueryStatement:
+		return c.CreateContinuousQuery(stmt.Database, stmt.Name, stmt.String())
+	case *cnosql.CreateUserStatement:
+		_, err := c.CreateUser(stmt.Name, stmt.Password, stmt.Admin)
+		return err
+	case *cnosql.GrantStatement:
+		return c.SetPrivilege(stmt.User, stmt.On, stmt.Privilege)
+	}
+	return fmt.Errorf("unexpected statement: %s", stmt)
+}
+
+// objects counts the schema objects in meta data.
+type objects struct {
+	databases, retentionPolicies, subscriptions, continuousQueries, users, grants int
+}
+
+func countObjects(data *meta.Data) objects {
+	var o objects
+	for _, di := range data.Databases {
+		o.databases++
+		o.continuousQueries += len(di.ContinuousQueries)
+		for _, rpi := range di.RetentionPolicies {
+			o.retentionPolicies++
+			o.subscriptions += len(rpi.Subscriptions)
+		}
+	}
+	for _, ui := range data.Users {
+		o.users++
+		o.grants += len(ui.Privileges)
+	}
+	return o
+}
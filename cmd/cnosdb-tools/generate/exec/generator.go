@@ -56,7 +56,7 @@ func (g *Generator) Run(ctx context.Context, database, shardPath string, groups
 
 			sgi := &groups[n]
 			if len(sgi.Shards) > 1 {
-				ch <- fmt.Errorf("multiple shards for the same owner %v", sgi.Shards[0].Owners)
+				ch <- fmt.Errorf("multiple shards for the same owner %v", sgi.OwnerNodeIDs())
 				return
 			}
 
@@ -97,7 +97,7 @@ func (p *StoragePlan) InitFileSystem(client server.MetaClient) error {
 	for i := 0; i < len(groups); i++ {
 		sgi := &groups[i]
 		if len(sgi.Shards) > 1 {
-			return fmt.Errorf("multiple shards for the same owner %v", sgi.Shards[0].Owners)
+			return fmt.Errorf("multiple shards for the same owner %v", sgi.OwnerNodeIDs())
 		}
 
 		if err = os.MkdirAll(filepath.Join(p.ShardPath(), strconv.Itoa(int(sgi.Shards[0].ID))), 0777); err != nil {
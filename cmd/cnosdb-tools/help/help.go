@@ -9,9 +9,19 @@ Usage: cnosdb-tools command [arguments]
 The commands are:
 
     export               reshapes existing shards to a new shard duration
-    compact-shard        fully compacts the specified shard
+    compact-shard        compacts the TSM files or TSI index of the specified shard
     gen-init             creates database and retention policy metadata 
     gen-exec             generates data
+    verify               checks meta.db against the shards in a data directory and for overlapping shard groups
+    rp-usage             reports shard counts and time span per retention policy
+    rp-timeline          lists when each shard group of a retention policy was created and deleted
+    meta-restore         replaces meta.db with one of its backups
+    export-schema        writes the schema and users as CnosQL statements
+    under-replicated     lists shards with fewer owners than the replica factor
+    users-import         creates users and privileges from a CSV file
+    node-shards          lists the shards owned by a data node
+    meta-compact         snapshots the meta store and compacts its raft log
+    leases               lists the leases held on the meta service and their owners
     help                 display this help message
 
 Use "cnosdb-tools command -help" for more information about a command.
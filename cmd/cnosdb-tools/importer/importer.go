@@ -110,7 +110,7 @@ func (i *importer) StartShardGroup(start int64, end int64) error {
 	if len(existingSg) > 0 {
 		sgi = &existingSg[0]
 		if len(sgi.Shards) > 1 {
-			return fmt.Errorf("multiple shards for the same owner %v and time range %v to %v", sgi.Shards[0].Owners, start, end)
+			return fmt.Errorf("multiple shards for the same owner %v and time range %v to %v", sgi.OwnerNodeIDs(), start, end)
 		}
 
 		shardID = sgi.Shards[0].ID
@@ -0,0 +1,55 @@
+// Package metafile reads the meta data snapshot written by a meta client.
+package metafile
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+// Name is the file name of the meta data snapshot in a meta directory.
+const Name = "meta.db"
+
+// Stdin is the path that names standard input rather than a file.
+const Stdin = "-"
+
+// Load reads a meta.db file. path may be the file itself, the directory
+// containing it, or Stdin to read it from os.Stdin.
+func Load(path string) (*meta.Data, error) {
+	return LoadFrom(path, os.Stdin)
+}
+
+// LoadFrom is like Load but reads from stdin when path is Stdin. The whole
+// image is buffered in memory before it is unmarshalled.
+func LoadFrom(path string, stdin io.Reader) (*meta.Data, error) {
+	var buf []byte
+	if path == Stdin {
+		b, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin: %v", err)
+		}
+		buf, path = b, "stdin"
+	} else {
+		if fi, err := os.Stat(path); err != nil {
+			return nil, err
+		} else if fi.IsDir() {
+			path = filepath.Join(path, Name)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		buf = b
+	}
+
+	data := &meta.Data{}
+	if err := data.UnmarshalBinary(buf); err != nil {
+		return nil, fmt.Errorf("unmarshal %q: %v", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,52 @@
+// Package metafiletest builds meta.db files for the tests of the tools that
+// read them.
+package metafiletest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+// NewData returns meta data with the given number of data nodes, named host0
+// onwards, and a database db0 whose default retention policy rp0 keeps
+// replicaN copies in one hour shard groups.
+func NewData(t testing.TB, nodes, replicaN int) *meta.Data {
+	t.Helper()
+
+	data := &meta.Data{}
+	for i := 0; i < nodes; i++ {
+		host := "host" + strconv.Itoa(i)
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: replicaN, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// WriteFile writes data to the meta.db file in dir and returns the image
+// written.
+func WriteFile(t testing.TB, dir string, data *meta.Data) []byte {
+	t.Helper()
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, metafile.Name), buf, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
@@ -0,0 +1,99 @@
+package leases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools leases".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaAddr string
+
+	// now returns the time leases are reported as expired against,
+	// overridden for testing.
+	now func() time.Time
+}
+
+// NewOptions returns a new instance of the leases Options.
+func NewOptions() *Options {
+	return &Options{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+		now:    time.Now,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "leases",
+		Short: "lists the leases held on the meta service and the nodes that own them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaAddr, "meta-addr", "", "HTTP address of a meta node, as host:port")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaAddr == "" {
+		return errors.New("meta-addr is required")
+	}
+
+	client := meta.NewRemoteClient()
+	client.SetMetaServers([]string{strings.TrimPrefix(o.metaAddr, "http://")})
+	leases, err := client.Leases()
+	if err != nil {
+		return err
+	}
+
+	if len(leases) == 0 {
+		fmt.Fprintln(o.Stdout, "No leases have been acquired.")
+		return nil
+	}
+
+	now := o.now()
+	tw := tabwriter.NewWriter(o.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Name\tOwner\tExpiration\tStatus")
+	for _, l := range leases {
+		status := "held"
+		if l.Expired(now) {
+			status = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", l.Name, l.Owner, l.Expiration.UTC().Format(time.RFC3339), status)
+	}
+	return tw.Flush()
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools leases [flags]
+
+Lists the leases held on the meta service leader, such as the
+"ContinuousQuery" lease, with the data node that owns each one and when it
+expires. An expired lease may be acquired by any node.
+
+Flags:
+  -h, --help               help for leases
+      --meta-addr string   HTTP address of a meta node, as host:port`)
+}
@@ -0,0 +1,55 @@
+package leases
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/leases" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode([]meta.LeaseInfo{
+			{Name: "ContinuousQuery", Owner: 2, Expiration: now.Add(time.Minute)},
+			{Name: "Retention", Owner: 3, Expiration: now.Add(-time.Minute)},
+		})
+	}))
+	defer s.Close()
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaAddr = strings.TrimPrefix(s.URL, "http://")
+	o.now = func() time.Time { return now }
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output:\n%s", stdout.String())
+	}
+	for i, exp := range [][]string{
+		{"ContinuousQuery", "2", "2022-01-01T12:01:00Z", "held"},
+		{"Retention", "3", "2022-01-01T11:59:00Z", "expired"},
+	} {
+		if fields := strings.Fields(lines[i+1]); strings.Join(fields, " ") != strings.Join(exp, " ") {
+			t.Errorf("unexpected line %d: %q", i+1, lines[i+1])
+		}
+	}
+
+	o.metaAddr = ""
+	if err := o.run(); err == nil || err.Error() != "meta-addr is required" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
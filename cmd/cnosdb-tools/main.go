@@ -3,12 +3,23 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/compact"
 	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/export"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/exportschema"
 	genExec "github.com/cnosdb/cnosdb/cmd/cnosdb-tools/generate/exec"
 	genInit "github.com/cnosdb/cnosdb/cmd/cnosdb-tools/generate/init"
 	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/importer"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/leases"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/metacompact"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/metarestore"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/nodeshards"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/rptimeline"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/rpusage"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/underreplicated"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/usersimport"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/verify"
 
 	"github.com/spf13/cobra"
 )
@@ -32,8 +43,43 @@ func main() {
 	export := export.GetCommand()
 	mainCmd.AddCommand(export)
 
+	verifyCmd := verify.GetCommand()
+	mainCmd.AddCommand(verifyCmd)
+
+	rpusage := rpusage.GetCommand()
+	mainCmd.AddCommand(rpusage)
+
+	rptimeline := rptimeline.GetCommand()
+	mainCmd.AddCommand(rptimeline)
+
+	metarestore := metarestore.GetCommand()
+	mainCmd.AddCommand(metarestore)
+
+	exportschema := exportschema.GetCommand()
+	mainCmd.AddCommand(exportschema)
+
+	underreplicated := underreplicated.GetCommand()
+	mainCmd.AddCommand(underreplicated)
+
+	usersimport := usersimport.GetCommand()
+	mainCmd.AddCommand(usersimport)
+
+	nodeshards := nodeshards.GetCommand()
+	mainCmd.AddCommand(nodeshards)
+
+	metacompact := metacompact.GetCommand()
+	mainCmd.AddCommand(metacompact)
+
+	leases := leases.GetCommand()
+	mainCmd.AddCommand(leases)
+
 	if err := mainCmd.Execute(); err != nil {
-		fmt.Printf("Error : %+v\n", err)
+		// verify has already reported the inconsistencies it found, so only
+		// the exit status tells of them.
+		if err != verify.ErrInconsistent {
+			fmt.Fprintf(os.Stderr, "Error : %+v\n", err)
+		}
+		os.Exit(1)
 	}
 
 }
@@ -0,0 +1,125 @@
+package metacompact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/meta"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools meta-compact".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaAddr string
+}
+
+// NewOptions returns a new instance of the meta-compact Options.
+func NewOptions() *Options {
+	return &Options{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "meta-compact",
+		Short: "snapshots the meta store and compacts its raft log.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaAddr, "meta-addr", "", "HTTP address of a meta node, as host:port")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaAddr == "" {
+		return errors.New("meta-addr is required")
+	}
+
+	// Redirects are followed by hand so that the leader can be reported.
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	target := o.metaAddr
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	target += "/force-snapshot"
+
+	for redirects := 0; ; redirects++ {
+		resp, err := client.Post(target, "", nil)
+		if err != nil {
+			return err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			var report meta.SnapshotReport
+			err := json.NewDecoder(resp.Body).Decode(&report)
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("invalid response from %s: %s", hostOf(target), err)
+			}
+			fmt.Fprintf(o.Stdout, "Compacted the raft log on %s.\n", hostOf(target))
+			fmt.Fprintf(o.Stdout, "Last snapshot index: %d -> %d\n", report.PrevSnapshotIndex, report.SnapshotIndex)
+			fmt.Fprintf(o.Stdout, "Log entries removed: %d\n", report.CompactedEntries)
+			return nil
+		case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			resp.Body.Close()
+			leader := resp.Header.Get("Location")
+			if redirects > 0 {
+				return fmt.Errorf("meta node %s is not the leader, the leader is %s", hostOf(target), hostOf(leader))
+			}
+			fmt.Fprintf(o.Stderr, "Meta node %s is not the leader, sending the request to the leader at %s.\n", hostOf(target), hostOf(leader))
+			target = leader
+		default:
+			b, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			return fmt.Errorf("meta node %s returned %s: %s", hostOf(target), resp.Status, strings.TrimSpace(string(b)))
+		}
+	}
+}
+
+// hostOf returns the host:port of u, or u itself if it can't be parsed.
+func hostOf(u string) string {
+	if p, err := url.Parse(u); err == nil && p.Host != "" {
+		return p.Host
+	}
+	return u
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools meta-compact [flags]
+
+Snapshots the meta store on the raft leader and compacts its raft log. If
+the given meta node is not the leader, the request is sent to the leader.
+
+Flags:
+  -h, --help               help for meta-compact
+      --meta-addr string   HTTP address of a meta node, as host:port`)
+}
@@ -0,0 +1,63 @@
+package metacompact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+func TestRun(t *testing.T) {
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/force-snapshot" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(meta.SnapshotReport{PrevSnapshotIndex: 10, SnapshotIndex: 42, CompactedEntries: 32})
+	}))
+	defer leader.Close()
+	follower := httptest.NewServer(http.RedirectHandler(leader.URL+"/force-snapshot", http.StatusTemporaryRedirect))
+	defer follower.Close()
+	// stale redirects to a node that redirects again.
+	stale := httptest.NewServer(http.RedirectHandler(follower.URL+"/force-snapshot", http.StatusTemporaryRedirect))
+	defer stale.Close()
+
+	run := func(addr string) (string, string, error) {
+		var stdout, stderr bytes.Buffer
+		o := NewOptions()
+		o.Stdout = &stdout
+		o.Stderr = &stderr
+		o.metaAddr = strings.TrimPrefix(addr, "http://")
+		err := o.run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	leaderHost := strings.TrimPrefix(leader.URL, "http://")
+	for _, addr := range []string{leader.URL, follower.URL} {
+		stdout, stderr, err := run(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, exp := range []string{"on " + leaderHost, "10 -> 42", "removed: 32"} {
+			if !strings.Contains(stdout, exp) {
+				t.Errorf("output from %s missing %q:\n%s", addr, exp, stdout)
+			}
+		}
+		if redirected := addr == follower.URL; redirected != strings.Contains(stderr, "the leader at "+leaderHost) {
+			t.Errorf("unexpected stderr from %s: %q", addr, stderr)
+		}
+	}
+
+	_, _, err := run(stale.URL)
+	if err == nil || !strings.Contains(err.Error(), "is not the leader, the leader is "+leaderHost) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := run(""); err == nil || err.Error() != "meta-addr is required" {
+		t.Fatalf("unexpected error without meta-addr: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package metarestore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/vend/db/pkg/file"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools meta-restore".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir string
+	from    string
+}
+
+// NewOptions returns a new instance of the meta-restore Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "meta-restore",
+		Short: "replaces meta.db with one of its timestamped backups.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db")
+	c.PersistentFlags().StringVar(&opt.from, "from", "", "path of the meta.db backup to restore, or - for stdin")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+	if o.from == "" {
+		return errors.New("from is required")
+	}
+
+	// Make sure the backup is readable meta data before replacing anything.
+	// It may have come from stdin or a directory, so write out what was
+	// loaded rather than reading o.from again.
+	data, err := metafile.LoadFrom(o.from, o.Stdin)
+	if err != nil {
+		return err
+	}
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(o.metaDir, metafile.Name)
+	tmpFile := filename + "tmp"
+	if err := ioutil.WriteFile(tmpFile, buf, 0666); err != nil {
+		return err
+	}
+	if err := file.RenameFile(tmpFile, filename); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.Stdout, "Restored %s from %s (index %d)\n", filename, o.from, data.Index)
+	return nil
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools meta-restore [flags]
+
+Replaces meta.db with a backup. Stop the server using the meta directory first.
+
+Flags:
+      --from string       path of the meta.db backup to restore, or - for stdin
+  -h, --help              help for meta-restore
+      --meta-dir string   directory containing meta.db`)
+}
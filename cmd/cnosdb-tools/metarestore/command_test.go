@@ -0,0 +1,93 @@
+package metarestore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+)
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+
+	// writeData writes meta data holding db0 and dbs to the meta.db in dir.
+	writeData := func(dir string, dbs ...string) []byte {
+		data := metafiletest.NewData(t, 0, 1)
+		data.Index = 1
+		for _, db := range dbs {
+			if err := data.CreateDatabase(db); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return metafiletest.WriteFile(t, dir, data)
+	}
+	backup := filepath.Join(dir, "meta.db.20220101T000000.000000000Z")
+	if err := ioutil.WriteFile(backup, writeData(t.TempDir()), 0666); err != nil {
+		t.Fatal(err)
+	}
+	writeData(dir, "db1")
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaDir = dir
+	o.from = backup
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := metafile.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	} else if data.Database("db0") == nil || data.Database("db1") != nil {
+		t.Fatalf("unexpected databases after restore: %+v", data.Databases)
+	}
+
+	// A backup that isn't meta data is rejected and nothing is replaced.
+	bad := filepath.Join(dir, "bad")
+	if err := ioutil.WriteFile(bad, []byte("not meta data"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	o.from = bad
+	if err := o.run(); err == nil {
+		t.Fatal("expected error restoring invalid backup")
+	}
+	if data, err := metafile.Load(dir); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db0") == nil {
+		t.Fatal("meta.db replaced by invalid backup")
+	}
+
+	// The backup can be read from stdin.
+	buf, err := ioutil.ReadFile(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeData(dir, "db2")
+	o.Stdin = bytes.NewReader(buf)
+	o.from = metafile.Stdin
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := metafile.Load(dir); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db0") == nil || data.Database("db2") != nil {
+		t.Fatalf("unexpected databases after restore from stdin: %+v", data.Databases)
+	}
+
+	// Or from the directory holding it.
+	src := t.TempDir()
+	writeData(src, "db3")
+	o.from = src
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := metafile.Load(dir); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db3") == nil {
+		t.Fatalf("unexpected databases after restore from directory: %+v", data.Databases)
+	}
+}
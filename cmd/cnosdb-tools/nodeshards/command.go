@@ -0,0 +1,97 @@
+package nodeshards
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools node-shards".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir string
+	nodeID  uint64
+}
+
+// NewOptions returns a new instance of the node-shards Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "node-shards <id>",
+		Short: "lists the shards owned by a data node.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a data node id is required")
+			}
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid data node id %q", args[0])
+			}
+			opt.nodeID = id
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db, the path of meta.db itself, or - to read it from stdin")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+
+	data, err := metafile.LoadFrom(o.metaDir, o.Stdin)
+	if err != nil {
+		return err
+	}
+	if data.DataNode(o.nodeID) == nil {
+		return fmt.Errorf("data node %d not found", o.nodeID)
+	}
+
+	shards := data.ShardsOwnedBy(o.nodeID)
+	if len(shards) == 0 {
+		fmt.Fprintf(o.Stdout, "Data node %d owns no shards.\n", o.nodeID)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(o.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Database\tRetention Policy\tShard Group\tShard\tOwners")
+	for _, s := range shards {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", s.Database, s.RetentionPolicy, s.ShardGroupID, s.ID, len(s.Owners))
+	}
+	return tw.Flush()
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools node-shards <id> [flags]
+
+Flags:
+  -h, --help              help for node-shards
+      --meta-dir string   directory containing meta.db, the path of meta.db itself, or - to read it from stdin`)
+}
@@ -0,0 +1,71 @@
+package nodeshards
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	data := metafiletest.NewData(t, 3, 1)
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// Leave the owner of the last shard without shards.
+	sgi := &data.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+	idle := sgi.Shards[2].Owners[0].NodeID
+	sgi.Shards = sgi.Shards[:2]
+	buf := metafiletest.WriteFile(t, dir, data)
+
+	run := func(id uint64) (string, error) {
+		var stdout bytes.Buffer
+		o := NewOptions()
+		o.Stdout = &stdout
+		o.metaDir = dir
+		o.nodeID = id
+		err := o.run()
+		return stdout.String(), err
+	}
+
+	for _, si := range sgi.Shards {
+		id := si.Owners[0].NodeID
+		out, err := run(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("unexpected output for node %d:\n%s", id, out)
+		} else if fields := strings.Fields(lines[1]); len(fields) != 5 || fields[0] != "db0" || fields[3] != strconv.FormatUint(si.ID, 10) {
+			t.Fatalf("unexpected row for node %d: %q", id, lines[1])
+		}
+	}
+
+	if out, err := run(idle); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(out, "owns no shards.\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	if _, err := run(100); err == nil {
+		t.Fatal("expected error for unknown data node")
+	}
+
+	// The meta.db image can be piped through stdin instead.
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdin = bytes.NewReader(buf)
+	o.Stdout = &stdout
+	o.metaDir = "-"
+	o.nodeID = idle
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(stdout.String(), "owns no shards.\n") {
+		t.Fatalf("unexpected output from stdin:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,117 @@
+package rptimeline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cnosdb/cnosdb"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/meta"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools rp-timeline".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir  string
+	database string
+	rp       string
+}
+
+// NewOptions returns a new instance of the rp-timeline Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "rp-timeline",
+		Short: "lists when each shard group of a retention policy was created and deleted.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db, the path of meta.db itself, or - to read it from stdin")
+	c.PersistentFlags().StringVar(&opt.database, "database", "", "database to report on")
+	c.PersistentFlags().StringVar(&opt.rp, "rp", "", "retention policy to report on")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+	if o.database == "" {
+		return errors.New("database is required")
+	}
+	if o.rp == "" {
+		return errors.New("rp is required")
+	}
+
+	data, err := metafile.LoadFrom(o.metaDir, o.Stdin)
+	if err != nil {
+		return err
+	}
+
+	entries, err := timeline(data, o.database, o.rp)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(o.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Shard Group\tCreated\tDeleted\tShards")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n", e.ID, formatTime(e.CreatedAt), formatTime(e.DeletedAt), e.ShardN)
+	}
+	return tw.Flush()
+}
+
+// timeline returns the shard group timeline of a retention policy, or an
+// error if it doesn't exist.
+func timeline(data *meta.Data, database, rp string) ([]meta.ShardGroupTimelineEntry, error) {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return nil, err
+	} else if rpi == nil {
+		return nil, cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+	return data.ShardGroupTimeline(database, rp), nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools rp-timeline [flags]
+
+Flags:
+      --database string   database to report on
+  -h, --help              help for rp-timeline
+      --meta-dir string   directory containing meta.db, the path of meta.db itself, or - to read it from stdin
+      --rp string         retention policy to report on`)
+}
@@ -0,0 +1,58 @@
+package rptimeline
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	data := metafiletest.NewData(t, 0, 1)
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sgi, err := data.ShardGroupByTimestamp("db0", "rp0", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := data.MarkShardGroupDeleted("db0", "rp0", sgi.ID, start.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	metafiletest.WriteFile(t, dir, data)
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaDir = dir
+	o.database = "db0"
+	o.rp = "rp0"
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected output:\n%s", stdout.String())
+	}
+	// The deleted group is listed first, with its deletion time.
+	if fields := strings.Fields(lines[1]); fields[0] != fmt.Sprint(sgi.ID) || fields[1] != "2022-01-01T00:00:00Z" ||
+		fields[2] != "2022-01-02T00:00:00Z" || fields[3] != "1" {
+		t.Errorf("unexpected deleted group: %s", lines[1])
+	}
+	if fields := strings.Fields(lines[3]); fields[1] != "2022-01-01T02:00:00Z" || fields[2] != "-" {
+		t.Errorf("unexpected live group: %s", lines[3])
+	}
+
+	o.rp = "rp1"
+	if err := o.run(); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
@@ -0,0 +1,124 @@
+package rpusage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cnosdb/cnosdb"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/meta"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools rp-usage".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir  string
+	database string
+	rp       string
+}
+
+// NewOptions returns a new instance of the rp-usage Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "rp-usage",
+		Short: "reports shard group count, shard count and time span for retention policies.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db, the path of meta.db itself, or - to read it from stdin")
+	c.PersistentFlags().StringVar(&opt.database, "database", "", "database to report on")
+	c.PersistentFlags().StringVar(&opt.rp, "rp", "", "retention policy to report on (default all)")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+	if o.database == "" {
+		return errors.New("database is required")
+	}
+
+	data, err := metafile.LoadFrom(o.metaDir, o.Stdin)
+	if err != nil {
+		return err
+	}
+
+	usages, err := usage(data, o.database, o.rp)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(o.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Retention Policy\tShard Groups\tShards\tEarliest\tLatest")
+	for _, u := range usages {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", u.RetentionPolicy, u.ShardGroupN, u.ShardN, formatTime(u.Earliest), formatTime(u.Latest))
+	}
+	return tw.Flush()
+}
+
+// usage returns the usage of rp, or of every retention policy in the database
+// if rp is empty.
+func usage(data *meta.Data, database, rp string) ([]meta.RPUsage, error) {
+	if rp != "" {
+		u, err := data.RetentionPolicyUsage(database, rp)
+		if err != nil {
+			return nil, err
+		}
+		return []meta.RPUsage{u}, nil
+	}
+
+	dbi := data.Database(database)
+	if dbi == nil {
+		return nil, cnosdb.ErrDatabaseNotFound(database)
+	}
+	usages := make([]meta.RPUsage, 0, len(dbi.RetentionPolicies))
+	for i := range dbi.RetentionPolicies {
+		usages = append(usages, dbi.RetentionPolicies[i].Usage())
+	}
+	return usages, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools rp-usage [flags]
+
+Flags:
+      --database string   database to report on
+  -h, --help              help for rp-usage
+      --meta-dir string   directory containing meta.db, the path of meta.db itself, or - to read it from stdin
+      --rp string         retention policy to report on (default all)`)
+}
@@ -0,0 +1,53 @@
+package rpusage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	data := metafiletest.NewData(t, 0, 1)
+	rpi := &meta.RetentionPolicyInfo{Name: "rp1", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, false); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metafiletest.WriteFile(t, dir, data)
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaDir = dir
+	o.database = "db0"
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output:\n%s", stdout.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "rp0" || fields[1] != "3" || fields[2] != "3" ||
+		fields[3] != "2022-01-01T00:00:00Z" || fields[4] != "2022-01-01T03:00:00Z" {
+		t.Errorf("unexpected rp0 usage: %s", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "rp1" || fields[1] != "0" || fields[3] != "-" {
+		t.Errorf("unexpected rp1 usage: %s", lines[2])
+	}
+
+	o.database = "db1"
+	if err := o.run(); err == nil {
+		t.Fatal("expected error for missing database")
+	}
+}
@@ -0,0 +1,90 @@
+package underreplicated
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools under-replicated".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir string
+}
+
+// NewOptions returns a new instance of the under-replicated Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "under-replicated",
+		Short: "lists shards with fewer owners than their retention policy's replica factor.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db, the path of meta.db itself, or - to read it from stdin")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+
+	data, err := metafile.LoadFrom(o.metaDir, o.Stdin)
+	if err != nil {
+		return err
+	}
+
+	shards := data.UnderReplicatedShards()
+	if len(shards) == 0 {
+		fmt.Fprintln(o.Stdout, "No under-replicated shards.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(o.Stdout, 8, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "Database\tRetention Policy\tShard Group\tShard\tOwners\tReplicaN\tMissing")
+	for _, s := range shards {
+		owners := make([]string, len(s.Owners))
+		for i, id := range s.Owners {
+			owners[i] = strconv.FormatUint(id, 10)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%d\t%d\n", s.Database, s.RetentionPolicy, s.ShardGroupID, s.ShardID, strings.Join(owners, ","), s.ReplicaN, s.Missing())
+	}
+	return tw.Flush()
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools under-replicated [flags]
+
+Flags:
+  -h, --help              help for under-replicated
+      --meta-dir string   directory containing meta.db, the path of meta.db itself, or - to read it from stdin`)
+}
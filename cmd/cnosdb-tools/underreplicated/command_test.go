@@ -0,0 +1,43 @@
+package underreplicated
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	data := metafiletest.NewData(t, 2, 2)
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	run := func() string {
+		var stdout bytes.Buffer
+		o := NewOptions()
+		o.Stdout = &stdout
+		o.metaDir = dir
+		if err := o.run(); err != nil {
+			t.Fatal(err)
+		}
+		return stdout.String()
+	}
+
+	metafiletest.WriteFile(t, dir, data)
+	if out := run(); !strings.HasPrefix(out, "No under-replicated shards.") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	si := &data.Database("db0").RetentionPolicy("rp0").ShardGroups[0].Shards[0]
+	si.Owners = si.Owners[1:]
+	metafiletest.WriteFile(t, dir, data)
+	lines := strings.Split(strings.TrimSpace(run()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output:\n%s", strings.Join(lines, "\n"))
+	} else if fields := strings.Fields(lines[1]); len(fields) != 7 || fields[0] != "db0" || fields[6] != "1" {
+		t.Fatalf("unexpected row: %q", lines[1])
+	}
+}
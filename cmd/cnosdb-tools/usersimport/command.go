@@ -0,0 +1,217 @@
+package usersimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+
+	"github.com/spf13/cobra"
+)
+
+// Options represents the program execution for "cnosdb-tools users-import".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir  string
+	file     string
+	failFast bool
+}
+
+// NewOptions returns a new instance of the users-import Options.
+func NewOptions() *Options {
+	return &Options{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "users-import",
+		Short: "creates users and grants privileges from a CSV file.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage()
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db")
+	c.PersistentFlags().StringVar(&opt.file, "file", "", "CSV file of users to import")
+	c.PersistentFlags().BoolVar(&opt.failFast, "fail-fast", false, "stop at the first row that fails")
+	return c
+}
+
+// MetaClient is the subset of meta.MetaClient used to import users.
+type MetaClient interface {
+	CreateUser(name, password string, admin bool) (meta.User, error)
+	CreateUserWithHash(name, hash string, admin bool) (meta.User, error)
+	SetPrivilege(username, database string, p cnosql.Privilege) error
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+	if o.file == "" {
+		return errors.New("file is required")
+	}
+
+	f, err := os.Open(o.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	config := meta.NewConfig()
+	config.Dir = o.metaDir
+	client := meta.NewClient(config)
+	if err := client.Open(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return o.importUsers(client, f)
+}
+
+// importUsers creates a user for each row read from r. Rows that fail are
+// reported and skipped unless failFast is set.
+func (o *Options) importUsers(client MetaClient, r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var row, failed int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		row++
+
+		// Skip the optional header.
+		if row == 1 && strings.EqualFold(record[0], "name") {
+			continue
+		}
+
+		if err := importUser(client, record); err != nil {
+			failed++
+			fmt.Fprintf(o.Stderr, "row %d: %s: %s\n", row, record[0], err)
+			if o.failFast {
+				return fmt.Errorf("row %d: %s", row, err)
+			}
+			continue
+		}
+		fmt.Fprintf(o.Stdout, "row %d: imported %s\n", row, record[0])
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d users failed to import", failed)
+	}
+	return nil
+}
+
+// importUser creates the user described by record: name, password or bcrypt
+// hash, admin and optional database privileges such as "db0=READ;db1=ALL".
+func importUser(client MetaClient, record []string) error {
+	if len(record) < 3 {
+		return errors.New("expected at least name, password and admin columns")
+	}
+	name, password := record[0], record[1]
+	admin, err := strconv.ParseBool(record[2])
+	if err != nil {
+		return fmt.Errorf("invalid admin value %q", record[2])
+	}
+
+	var privileges map[string]cnosql.Privilege
+	if len(record) > 3 && record[3] != "" {
+		if privileges, err = parsePrivileges(record[3]); err != nil {
+			return err
+		}
+	}
+
+	if isBcryptHash(password) {
+		_, err = client.CreateUserWithHash(name, password, admin)
+	} else {
+		_, err = client.CreateUser(name, password, admin)
+	}
+	if err != nil {
+		return err
+	}
+
+	for db, p := range privileges {
+		if err := client.SetPrivilege(name, db, p); err != nil {
+			return fmt.Errorf("grant on %s: %s", db, err)
+		}
+	}
+	return nil
+}
+
+// parsePrivileges parses a list of database=privilege pairs separated by
+// semicolons.
+func parsePrivileges(s string) (map[string]cnosql.Privilege, error) {
+	privileges := make(map[string]cnosql.Privilege)
+	for _, pair := range strings.Split(s, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		i := strings.IndexByte(pair, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid privilege %q, expected database=privilege", pair)
+		}
+
+		var p cnosql.Privilege
+		switch strings.ToUpper(strings.TrimSpace(pair[i+1:])) {
+		case "READ":
+			p = cnosql.ReadPrivilege
+		case "WRITE":
+			p = cnosql.WritePrivilege
+		case "ALL", "ALL PRIVILEGES":
+			p = cnosql.AllPrivileges
+		default:
+			return nil, fmt.Errorf("invalid privilege %q", pair[i+1:])
+		}
+		privileges[strings.TrimSpace(pair[:i])] = p
+	}
+	return privileges, nil
+}
+
+// isBcryptHash reports whether s looks like a bcrypt hash rather than a
+// plain text password.
+func isBcryptHash(s string) bool {
+	return len(s) == 60 && (strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$"))
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  cnosdb-tools users-import [flags]
+
+Each row of the CSV file has the columns name, password, admin and
+privileges. The password may be a bcrypt hash. Privileges are optional
+database=privilege pairs separated by semicolons, where privilege is READ,
+WRITE or ALL. A header row starting with "name" is skipped.
+
+    name,password,admin,privileges
+    alice,secret,false,db0=READ;db1=ALL
+
+Flags:
+      --fail-fast         stop at the first row that fails
+      --file string       CSV file of users to import
+  -h, --help              help for users-import
+      --meta-dir string   directory containing meta.db`)
+}
@@ -0,0 +1,104 @@
+package usersimport
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	data := metafiletest.NewData(t, 0, 1)
+	data.Index = 1
+	if err := data.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	}
+	metafiletest.WriteFile(t, dir, data)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := strings.Join([]string{
+		"name,password,admin,privileges",
+		"alice,secret0,false,db0=READ;db1=ALL",
+		"bob," + string(hash) + ",false,db1=write",
+		"carol,secret2,maybe,",
+		"root,secret3,true,",
+	}, "\n")
+	file := filepath.Join(dir, "users.csv")
+	if err := ioutil.WriteFile(file, []byte(csv), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	o := NewOptions()
+	o.Stdout, o.Stderr = &stdout, &stderr
+	o.metaDir = dir
+	o.file = file
+	if err := o.run(); err == nil || err.Error() != "1 users failed to import" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "row 4: carol:") {
+		t.Fatalf("unexpected stderr: %s", stderr.String())
+	}
+
+	data, err = metafile.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name       string
+		admin      bool
+		privileges map[string]cnosql.Privilege
+	}{
+		{name: "alice", privileges: map[string]cnosql.Privilege{"db0": cnosql.ReadPrivilege, "db1": cnosql.AllPrivileges}},
+		{name: "bob", privileges: map[string]cnosql.Privilege{"db1": cnosql.WritePrivilege}},
+		{name: "root", admin: true},
+	} {
+		u := data.User(tt.name)
+		if u == nil {
+			t.Fatalf("user %s not imported", tt.name)
+		}
+		ui := u.(*meta.UserInfo)
+		if ui.Admin != tt.admin {
+			t.Fatalf("unexpected admin for %s: %v", tt.name, ui.Admin)
+		}
+		for db, p := range tt.privileges {
+			if ui.Privileges[db] != p {
+				t.Fatalf("unexpected privilege for %s on %s: got %s, exp %s", tt.name, db, ui.Privileges[db], p)
+			}
+		}
+	}
+	if u := data.User("bob").(*meta.UserInfo); u.Hash != string(hash) {
+		t.Fatal("hash was not imported as is")
+	}
+	if data.User("carol") != nil {
+		t.Fatal("unexpected user carol")
+	}
+
+	// With fail-fast the import stops at the bad row.
+	file = filepath.Join(dir, "fail.csv")
+	if err := ioutil.WriteFile(file, []byte("dave,pw,nope\neve,pw,false\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	o.file = file
+	o.failFast = true
+	if err := o.run(); err == nil {
+		t.Fatal("expected error")
+	}
+	data, err = metafile.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	} else if data.User("eve") != nil {
+		t.Fatal("unexpected user eve")
+	}
+}
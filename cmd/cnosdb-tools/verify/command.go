@@ -0,0 +1,347 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile"
+	"github.com/cnosdb/cnosdb/meta"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrInconsistent is returned when the meta data and the data directory disagree.
+var ErrInconsistent = errors.New("meta data and data directory are inconsistent")
+
+// Options represents the program execution for "cnosdb-tools verify".
+type Options struct {
+	// Standard input/output, overridden for testing.
+	Stdin  io.Reader
+	Stderr io.Writer
+	Stdout io.Writer
+
+	metaDir string
+	dataDir string
+	json    bool
+	repair  bool
+	nodeID  uint64
+}
+
+// NewOptions returns a new instance of the verify Options.
+func NewOptions() *Options {
+	return &Options{
+		Stdin:  os.Stdin,
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+}
+
+var opt = NewOptions()
+
+func GetCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "verify",
+		Short: "verifies that the shards in meta.db match the shards in the data directory, that no shard groups overlap and that default retention policies exist.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opt.run()
+		},
+		// The report may be JSON read by another program, so nothing but the
+		// report is written to stdout; errors are left to the caller.
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	c.SetUsageFunc(func(command *cobra.Command) error {
+		printUsage(command.ErrOrStderr())
+		return nil
+	})
+	c.PersistentFlags().StringVar(&opt.metaDir, "meta-dir", "", "directory containing meta.db, the path of meta.db itself, or - to read it from stdin")
+	c.PersistentFlags().StringVar(&opt.dataDir, "data-dir", "", "data directory to scan for shards")
+	c.PersistentFlags().BoolVar(&opt.json, "json", false, "output the report as JSON")
+	c.PersistentFlags().BoolVar(&opt.repair, "repair", false, "replace default retention policies that no longer exist")
+	c.PersistentFlags().Uint64Var(&opt.nodeID, "node-id", 0, "only expect directories for shards owned by this data node")
+	return c
+}
+
+func (o *Options) run() error {
+	if o.metaDir == "" {
+		return errors.New("meta-dir is required")
+	}
+	if o.dataDir == "" {
+		return errors.New("data-dir is required")
+	}
+	if o.repair && o.metaDir == metafile.Stdin {
+		return errors.New("repair cannot write to meta.db read from stdin")
+	}
+
+	data, err := metafile.LoadFrom(o.metaDir, o.Stdin)
+	if err != nil {
+		return err
+	}
+
+	report, err := Verify(data, o.dataDir, o.nodeID)
+	if err != nil {
+		return err
+	}
+
+	if o.repair && len(report.DanglingDefaults) > 0 {
+		if err := o.repairDefaults(); err != nil {
+			return err
+		}
+		report.Repaired, report.DanglingDefaults = report.DanglingDefaults, []DanglingDefault{}
+	}
+
+	if o.json {
+		enc := json.NewEncoder(o.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		report.print(o.Stdout)
+	}
+
+	if !report.OK() {
+		return ErrInconsistent
+	}
+	return nil
+}
+
+// repairDefaults replaces the dangling default retention policies in meta.db.
+func (o *Options) repairDefaults() error {
+	dir := o.metaDir
+	if fi, err := os.Stat(dir); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	config := meta.NewConfig()
+	config.Dir = dir
+	client := meta.NewClient(config)
+	if err := client.Open(); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.RepairDefaults()
+}
+
+// ShardRef identifies a shard by its location in the meta data and on disk.
+type ShardRef struct {
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retention_policy"`
+	ShardID         uint64 `json:"shard_id"`
+	Path            string `json:"path"`
+}
+
+// ShardGroupOverlap identifies two shard groups of a retention policy whose
+// time ranges overlap.
+type ShardGroupOverlap struct {
+	Database        string    `json:"database"`
+	RetentionPolicy string    `json:"retention_policy"`
+	ShardGroupIDs   [2]uint64 `json:"shard_group_ids"`
+}
+
+// DanglingDefault identifies a database whose default retention policy does
+// not exist.
+type DanglingDefault struct {
+	Database        string `json:"database"`
+	RetentionPolicy string `json:"retention_policy"`
+}
+
+// Report is the result of comparing the meta data against a data directory.
+type Report struct {
+	// Orphaned lists shard directories that exist on disk but are not in the meta data.
+	Orphaned []ShardRef `json:"orphaned"`
+	// Dangling lists shards in the meta data that have no directory on disk.
+	Dangling []ShardRef `json:"dangling"`
+	// Overlapping lists pairs of shard groups whose time ranges overlap.
+	Overlapping []ShardGroupOverlap `json:"overlapping"`
+	// DanglingDefaults lists databases whose default retention policy does not exist.
+	DanglingDefaults []DanglingDefault `json:"dangling_defaults"`
+	// Repaired lists the dangling defaults replaced by --repair.
+	Repaired []DanglingDefault `json:"repaired,omitempty"`
+}
+
+// OK returns true if no inconsistencies were found.
+func (r *Report) OK() bool {
+	return len(r.Orphaned) == 0 && len(r.Dangling) == 0 && len(r.Overlapping) == 0 && len(r.DanglingDefaults) == 0
+}
+
+func (r *Report) print(w io.Writer) {
+	for _, d := range r.Repaired {
+		fmt.Fprintf(w, "Repaired missing default retention policy: db=%s rp=%s\n", d.Database, d.RetentionPolicy)
+	}
+	if r.OK() {
+		fmt.Fprintln(w, "No inconsistencies found.")
+		return
+	}
+
+	if len(r.Orphaned) > 0 {
+		fmt.Fprintf(w, "Orphaned shard directories (on disk, not in meta): %d\n", len(r.Orphaned))
+		for _, s := range r.Orphaned {
+			fmt.Fprintf(w, "  %s\n", s.Path)
+		}
+	}
+	if len(r.Dangling) > 0 {
+		fmt.Fprintf(w, "Dangling shards (in meta, missing on disk): %d\n", len(r.Dangling))
+		for _, s := range r.Dangling {
+			fmt.Fprintf(w, "  db=%s rp=%s shard=%d path=%s\n", s.Database, s.RetentionPolicy, s.ShardID, s.Path)
+		}
+	}
+	if len(r.Overlapping) > 0 {
+		fmt.Fprintf(w, "Overlapping shard groups: %d\n", len(r.Overlapping))
+		for _, o := range r.Overlapping {
+			fmt.Fprintf(w, "  db=%s rp=%s shard_groups=%d,%d\n", o.Database, o.RetentionPolicy, o.ShardGroupIDs[0], o.ShardGroupIDs[1])
+		}
+	}
+	if len(r.DanglingDefaults) > 0 {
+		fmt.Fprintf(w, "Missing default retention policies (rerun with --repair to replace): %d\n", len(r.DanglingDefaults))
+		for _, d := range r.DanglingDefaults {
+			fmt.Fprintf(w, "  db=%s rp=%s\n", d.Database, d.RetentionPolicy)
+		}
+	}
+}
+
+// Verify compares the shards referenced by data against the shard directories
+// found in dataDir, and checks that no two shard groups of a retention policy
+// overlap and that every default retention policy exists. Shards belonging to
+// deleted shard groups are ignored. If nodeID is not zero, only the shards
+// that node owns are expected in dataDir.
+func Verify(data *meta.Data, dataDir string, nodeID uint64) (*Report, error) {
+	onDisk, err := scanShardDirs(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// inMeta holds every shard, and expected the shards that should have a
+	// directory in dataDir.
+	inMeta := make(map[string]ShardRef)
+	expected := make(map[string]ShardRef)
+	for _, dbi := range data.Databases {
+		for _, rpi := range dbi.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					ref := newShardRef(dataDir, dbi.Name, rpi.Name, si.ID)
+					inMeta[ref.Path] = ref
+					if nodeID == 0 || si.OwnedBy(nodeID) {
+						expected[ref.Path] = ref
+					}
+				}
+			}
+		}
+	}
+
+	report := &Report{Orphaned: []ShardRef{}, Dangling: []ShardRef{}, Overlapping: []ShardGroupOverlap{}, DanglingDefaults: []DanglingDefault{}}
+	for path, ref := range onDisk {
+		if _, ok := inMeta[path]; !ok {
+			report.Orphaned = append(report.Orphaned, ref)
+		}
+	}
+	for path, ref := range expected {
+		if _, ok := onDisk[path]; !ok {
+			report.Dangling = append(report.Dangling, ref)
+		}
+	}
+	sort.Sort(shardRefs(report.Orphaned))
+	sort.Sort(shardRefs(report.Dangling))
+
+	for _, o := range data.DetectOverlappingShardGroups() {
+		report.Overlapping = append(report.Overlapping, ShardGroupOverlap{
+			Database:        o.Database,
+			RetentionPolicy: o.RetentionPolicy,
+			ShardGroupIDs:   o.ShardGroupIDs,
+		})
+	}
+
+	for _, dbi := range data.Databases {
+		if dbi.DefaultRetentionPolicy != "" && dbi.RetentionPolicy(dbi.DefaultRetentionPolicy) == nil {
+			report.DanglingDefaults = append(report.DanglingDefaults, DanglingDefault{
+				Database:        dbi.Name,
+				RetentionPolicy: dbi.DefaultRetentionPolicy,
+			})
+		}
+	}
+	return report, nil
+}
+
+// scanShardDirs walks the <db>/<rp>/<shard id> layout of a data directory.
+func scanShardDirs(dataDir string) (map[string]ShardRef, error) {
+	refs := make(map[string]ShardRef)
+
+	dbs, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, db := range dbs {
+		if !db.IsDir() || strings.HasPrefix(db.Name(), "_") {
+			continue
+		}
+		rps, err := ioutil.ReadDir(filepath.Join(dataDir, db.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, rp := range rps {
+			// Skip the series file and any other non-rp directories.
+			if !rp.IsDir() || strings.HasPrefix(rp.Name(), "_") {
+				continue
+			}
+			shards, err := ioutil.ReadDir(filepath.Join(dataDir, db.Name(), rp.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, sh := range shards {
+				if !sh.IsDir() {
+					continue
+				}
+				id, err := strconv.ParseUint(sh.Name(), 10, 64)
+				if err != nil {
+					continue
+				}
+				ref := newShardRef(dataDir, db.Name(), rp.Name(), id)
+				refs[ref.Path] = ref
+			}
+		}
+	}
+	return refs, nil
+}
+
+func newShardRef(dataDir, db, rp string, id uint64) ShardRef {
+	return ShardRef{
+		Database:        db,
+		RetentionPolicy: rp,
+		ShardID:         id,
+		Path:            filepath.Join(dataDir, db, rp, strconv.FormatUint(id, 10)),
+	}
+}
+
+type shardRefs []ShardRef
+
+func (a shardRefs) Len() int           { return len(a) }
+func (a shardRefs) Less(i, j int) bool { return a[i].Path < a[j].Path }
+func (a shardRefs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, `Usage:
+  cnosdb-tools verify [flags]
+
+Flags:
+      --data-dir string   data directory to scan for shards
+  -h, --help              help for verify
+      --json              output the report as JSON
+      --meta-dir string   directory containing meta.db, the path of meta.db itself, or - to read it from stdin
+      --node-id uint      only expect directories for shards owned by this data node
+      --repair            replace default retention policies that no longer exist`)
+}
@@ -0,0 +1,246 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/cmd/cnosdb-tools/internal/metafile/metafiletest"
+	"github.com/cnosdb/cnosdb/meta"
+)
+
+func TestVerify_ReportsMismatch(t *testing.T) {
+	metaDir, dataDir := t.TempDir(), t.TempDir()
+
+	data := metafiletest.NewData(t, 0, 1)
+	now := time.Now()
+	for _, ts := range []time.Time{now, now.Add(time.Hour)} {
+		if err := data.CreateShardGroup("db0", "rp0", ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sgs, err := data.ShardGroups("db0", "rp0")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(sgs) != 2 {
+		t.Fatalf("unexpected shard group count: %d", len(sgs))
+	}
+	metafiletest.WriteFile(t, metaDir, data)
+
+	// Only the first shard group has files; an unknown shard also exists on disk.
+	present := sgs[0].Shards[0].ID
+	missing := sgs[1].Shards[0].ID
+	for _, id := range []uint64{present, 100} {
+		if err := os.MkdirAll(filepath.Join(dataDir, "db0", "rp0", strconv.FormatUint(id, 10)), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dataDir, "db0", "_series"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaDir = metaDir
+	o.dataDir = dataDir
+	o.json = true
+
+	if err := o.run(); err != ErrInconsistent {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrInconsistent)
+	}
+
+	var report Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Orphaned) != 1 || report.Orphaned[0].ShardID != 100 {
+		t.Errorf("unexpected orphaned shards: %+v", report.Orphaned)
+	}
+	if len(report.Dangling) != 1 || report.Dangling[0].ShardID != missing {
+		t.Errorf("unexpected dangling shards: %+v", report.Dangling)
+	}
+	if exp := filepath.Join(dataDir, "db0", "rp0", strconv.FormatUint(missing, 10)); report.Dangling[0].Path != exp {
+		t.Errorf("unexpected dangling path: got %s, exp %s", report.Dangling[0].Path, exp)
+	}
+}
+
+func TestVerify_Consistent(t *testing.T) {
+	dir := t.TempDir()
+
+	data := metafiletest.NewData(t, 0, 1)
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	sgs, _ := data.ShardGroups("db0", "rp0")
+	if err := os.MkdirAll(filepath.Join(dir, "db0", "rp0", strconv.FormatUint(sgs[0].Shards[0].ID, 10)), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Verify(data, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if !report.OK() {
+		t.Fatalf("unexpected inconsistencies: %+v", report)
+	}
+}
+
+func TestVerify_ReportsOverlap(t *testing.T) {
+	dir := t.TempDir()
+
+	data := metafiletest.NewData(t, 0, 1)
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a group starting half way through the existing one.
+	rp, _ := data.RetentionPolicy("db0", "rp0")
+	sg := rp.ShardGroups[0]
+	rp.ShardGroups = append(rp.ShardGroups, meta.ShardGroupInfo{
+		ID:        sg.ID + 1,
+		StartTime: sg.StartTime.Add(30 * time.Minute),
+		EndTime:   sg.EndTime.Add(30 * time.Minute),
+	})
+
+	report, err := Verify(data, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if report.OK() {
+		t.Fatal("expected overlap to be reported")
+	}
+	if len(report.Overlapping) != 1 || report.Overlapping[0].ShardGroupIDs != [2]uint64{sg.ID, sg.ID + 1} {
+		t.Fatalf("unexpected overlaps: %+v", report.Overlapping)
+	}
+
+	var stdout bytes.Buffer
+	report.print(&stdout)
+	if !bytes.Contains(stdout.Bytes(), []byte("Overlapping shard groups: 1")) {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestVerify_RepairsDanglingDefault(t *testing.T) {
+	metaDir, dataDir := t.TempDir(), t.TempDir()
+
+	// Dropping the default retention policy leaves the database pointing at it.
+	data := metafiletest.NewData(t, 0, 1)
+	for _, name := range []string{"rp2", "rp1"} {
+		rpi := &meta.RetentionPolicyInfo{Name: name, ReplicaN: 1, ShardGroupDuration: time.Hour}
+		if err := data.CreateRetentionPolicy("db0", rpi, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.DropRetentionPolicy("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	}
+	metafiletest.WriteFile(t, metaDir, data)
+
+	var stdout bytes.Buffer
+	o := NewOptions()
+	o.Stdout = &stdout
+	o.metaDir = metaDir
+	o.dataDir = dataDir
+	if err := o.run(); err != ErrInconsistent {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrInconsistent)
+	} else if !bytes.Contains(stdout.Bytes(), []byte("db=db0 rp=rp0")) {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	o.repair = true
+	if err := o.run(); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Contains(stdout.Bytes(), []byte("Repaired missing default retention policy: db=db0 rp=rp0")) {
+		t.Fatalf("unexpected output: %s", stdout.String())
+	}
+
+	// The first remaining policy by name is now the default.
+	b, err := ioutil.ReadFile(filepath.Join(metaDir, "meta.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if name := other.Database("db0").DefaultRetentionPolicy; name != "rp1" {
+		t.Fatalf("unexpected default retention policy: %q", name)
+	}
+}
+
+func TestVerify_NodeID(t *testing.T) {
+	dir := t.TempDir()
+
+	data := metafiletest.NewData(t, 2, 1)
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the shard owned by the first node is on its disk.
+	nodeID := data.DataNodes[0].ID
+	sgs, _ := data.ShardGroups("db0", "rp0")
+	if len(sgs[0].Shards) != 2 {
+		t.Fatalf("unexpected shard count: %d", len(sgs[0].Shards))
+	}
+	var other uint64
+	for _, si := range sgs[0].Shards {
+		if !si.OwnedBy(nodeID) {
+			other = si.ID
+			continue
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "db0", "rp0", strconv.FormatUint(si.ID, 10)), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if report, err := Verify(data, dir, nodeID); err != nil {
+		t.Fatal(err)
+	} else if !report.OK() {
+		t.Fatalf("unexpected inconsistencies: %+v", report)
+	}
+
+	// Without a node every shard is expected.
+	if report, err := Verify(data, dir, 0); err != nil {
+		t.Fatal(err)
+	} else if len(report.Dangling) != 1 || report.Dangling[0].ShardID != other {
+		t.Fatalf("unexpected dangling shards: %+v", report.Dangling)
+	}
+}
+
+func TestCommand_JSONOnlyOnStdout(t *testing.T) {
+	dir := t.TempDir()
+
+	// A database with a missing default retention policy is inconsistent.
+	data := metafiletest.NewData(t, 0, 1)
+	if err := data.DropRetentionPolicy("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	}
+	metafiletest.WriteFile(t, dir, data)
+
+	var stdout, cmdOut, cmdErr bytes.Buffer
+	orig := opt.Stdout
+	opt.Stdout = &stdout
+	defer func() { opt.Stdout = orig }()
+
+	c := GetCommand()
+	c.SetOut(&cmdOut)
+	c.SetErr(&cmdErr)
+	c.SetArgs([]string{"--meta-dir", dir, "--data-dir", dir, "--json"})
+	if err := c.Execute(); err != ErrInconsistent {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrInconsistent)
+	}
+
+	var report Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("stdout is not the JSON report: %s", err)
+	} else if len(report.DanglingDefaults) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if cmdOut.Len() != 0 || cmdErr.Len() != 0 {
+		t.Fatalf("unexpected command output: stdout %q, stderr %q", cmdOut.String(), cmdErr.String())
+	}
+}
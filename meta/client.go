@@ -4,6 +4,7 @@ package meta
 
 import (
 	"bytes"
+	"context"
 	cRand "crypto/rand"
 	"crypto/sha256"
 	"errors"
@@ -14,7 +15,9 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cnosdb/cnosdb"
@@ -31,9 +34,16 @@ const (
 
 	metaFile = "meta.db"
 
+	// metaBackupTimeFormat is the timestamp suffix of meta.db backups.
+	metaBackupTimeFormat = "20060102T150405.000000000Z"
+
 	// ShardGroupDeletedExpiration is the amount of time before a shard group info will be removed from cached
 	// data after it has been marked deleted (2 weeks).
 	ShardGroupDeletedExpiration = -2 * 7 * 24 * time.Hour
+
+	// MaxPrecreatedShardGroups is the maximum number of successive shard groups
+	// precreated for a single retention policy by one call to PrecreateShardGroups.
+	MaxPrecreatedShardGroups = 64
 )
 
 var (
@@ -42,25 +52,54 @@ var (
 
 	// ErrService is returned when the meta service returns an error.
 	ErrService = errors.New("meta service error")
+
+	// ErrSnapshotCorrupt is returned when every meta server returns a snapshot
+	// that cannot be decoded or is too large.
+	ErrSnapshotCorrupt = errors.New("meta servers returned corrupt snapshots")
+
+	// ErrSnapshotTooLarge is returned when a meta server sends a snapshot
+	// larger than the client's maximum snapshot size.
+	ErrSnapshotTooLarge = errors.New("meta server snapshot exceeds maximum size")
+
+	// ErrUnauthorized is returned when the meta service rejects the client's auth token.
+	ErrUnauthorized = errors.New("meta service: unauthorized, check the meta auth token")
+
+	// ErrCloseTimeout is returned by Close when requests to the meta service
+	// are still running after the client's close timeout.
+	ErrCloseTimeout = errors.New("meta client: timed out waiting for requests to finish")
+
+	// ErrMetaStale is returned by a remote client's mutators when it has
+	// been unable to poll the meta service for longer than its maximum
+	// staleness.
+	ErrMetaStale = errors.New("meta client: meta data is stale, meta service unreachable")
 )
 
 type MetaClient interface {
 	Open() error
 	Close() error
+	ApplyConfig(config *Config) error
 
 	NodeID() uint64
 	ClusterID() uint64
+	MetaVersion() (uint64, error)
 
 	Ping(checkAllMetaServers bool) error
 	AcquireLease(name string) (*Lease, error)
+	AcquireLeaseContext(ctx context.Context, name string) (*Lease, error)
+	Leases() ([]LeaseInfo, error)
 	SetMetaServers([]string)
 
 	DataNode(id uint64) (*NodeInfo, error)
 	DataNodes() ([]NodeInfo, error)
+	DataNodeLoad() map[uint64]int
 	CreateDataNode(httpAddr, tcpAddr string) (*NodeInfo, error)
 	DataNodeByHTTPHost(httpAddr string) (*NodeInfo, error)
 	DataNodeByTCPHost(tcpAddr string) (*NodeInfo, error)
 	DeleteDataNode(id uint64) error
+	HeartbeatDataNode(id uint64) error
+	DeadDataNodes(threshold time.Duration) []NodeInfo
+	SetDataNodeDraining(id uint64, draining bool) error
+	DrainingDataNodes() []NodeInfo
 
 	MetaNodes() ([]NodeInfo, error)
 	MetaNodeByAddr(addr string) *NodeInfo
@@ -69,54 +108,100 @@ type MetaClient interface {
 
 	Database(name string) *DatabaseInfo
 	Databases() []DatabaseInfo
+	AllRetentionPolicies() []RetentionPolicyRef
 	CreateDatabase(name string) (*DatabaseInfo, error)
+	CreateDatabaseStrict(name string) (*DatabaseInfo, error)
 	CreateDatabaseWithRetentionPolicy(name string, spec *RetentionPolicySpec) (*DatabaseInfo, error)
 	DropDatabase(name string) error
+	DropDatabaseForce(name string) error
+	DropDatabaseWithReport(name string) (DropReport, error)
+	DropDatabaseWithReportForce(name string) (DropReport, error)
 
 	CreateRetentionPolicy(database string, spec *RetentionPolicySpec, makeDefault bool) (*RetentionPolicyInfo, error)
 	RetentionPolicy(database, name string) (rpi *RetentionPolicyInfo, err error)
+	DefaultRetentionPolicy(database string) (string, error)
+	RetentionPolicyUsage(database, rp string) (RPUsage, error)
 	DropRetentionPolicy(database, name string) error
+	DropRetentionPolicyWithReport(database, name string) (DropReport, error)
 	SetDefaultRetentionPolicy(database, name string) error
+	RepairDefaults() error
 	UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error
+	CopyRetentionPolicy(database, srcName, dstName string) (*RetentionPolicyInfo, error)
+	SetMeasurementRetention(database, rp, measurement string, d time.Duration) error
+	FreezeRetentionPolicy(database, rp string, frozen bool) error
+	SetDatabaseQuota(database string, q DatabaseQuota) error
+	SetDatabaseDefaultShardGroupDuration(database string, d time.Duration) error
+	NewTransaction() *Transaction
 
 	Users() []UserInfo
+	ForEachUser(fn func(name string, admin bool) bool)
 	UserCount() int
 	User(name string) (User, error)
 	CreateUser(name, password string, admin bool) (User, error)
+	CreateUserWithHash(name, hash string, admin bool) (User, error)
 	UpdateUser(name, password string) error
 	DropUser(name string) error
 
 	SetPrivilege(username, database string, p cnosql.Privilege) error
 	SetAdminPrivilege(username string, admin bool) error
 	UserPrivileges(username string) (map[string]cnosql.Privilege, error)
+	UserAuthz(username string) (UserAuthz, error)
 	UserPrivilege(username, database string) (*cnosql.Privilege, error)
+	EffectivePrivilege(username, database string) (cnosql.Privilege, error)
+	AccessibleDatabases(username string) ([]string, error)
 	AdminUserExists() bool
 	Authenticate(username, password string) (User, error)
+	AuthenticateBatch(creds []Credential) []AuthResult
+	InvalidateAuthCache(username string)
+	InvalidateAllAuthCache()
 
 	ShardIDs() []uint64
+	ShardIDsPage(afterID uint64, limit int) ([]uint64, uint64, error)
 	ShardGroupsByTimeRange(database, rp string, min, max time.Time) (a []ShardGroupInfo, err error)
+	HealthyShardGroupsByTimeRange(database, rp string, min, max time.Time, threshold time.Duration) ([]ShardGroupInfo, error)
+	ShardGroupForTimestamp(database, rp string, t time.Time) (*ShardGroupInfo, error)
+	ShardGroupBoundaries(database, rp string) ([]ShardGroupBoundary, error)
+	ShardGroupBoundaryFor(database, rp string, t time.Time) (start, end time.Time, err error)
+	ShardGroupTimeline(database, rp string) []ShardGroupTimelineEntry
+	AllShardGroupsByTimeRange(min, max time.Time) ([]ShardGroupRef, error)
 	ShardsByTimeRange(sources cnosql.Sources, tmin, tmax time.Time) (a []ShardInfo, err error)
 	DropShard(id uint64) error
 	TruncateShardGroups(t time.Time) error
 	PruneShardGroups() error
 	CreateShardGroup(database, rp string, timestamp time.Time) (*ShardGroupInfo, error)
 	DeleteShardGroup(database, rp string, id uint64) error
+	RebalanceShardGroup(database, rp string, id uint64) error
+	ReserveShardIDs(n int) (start uint64, err error)
+	MarkShardGroupDeleted(database, rp string, id uint64, at time.Time) error
 	PrecreateShardGroups(from, to time.Time) error
 	ShardOwner(shardID uint64) (database, rp string, sgi *ShardGroupInfo)
+	UnderReplicatedShards() []UnderReplicatedShard
+	ShardsOwnedBy(nodeID uint64) []NodeShard
 
 	CreateContinuousQuery(database, name, query string) error
+	CreateOrReplaceContinuousQuery(database, name, query string) error
 	DropContinuousQuery(database, name string) error
 
+	ExportContinuousQueriesDDL(w io.Writer) error
+	ExportRetentionPoliciesDDL(w io.Writer) error
+	ExportUsersDDL(w io.Writer) error
+
 	CreateSubscription(database, rp, name, mode string, destinations []string) error
 	DropSubscription(database, rp, name string) error
 
 	SetData(data *Data) error
+	RestoreData(data *Data, preserveNodes bool) error
+	SetMaintenanceMode(on bool) error
 	Data() Data
+	AcquireSnapshot() (*Data, func())
 	WaitForDataChanged() chan struct{}
+	WatchShardGroups(ctx context.Context) <-chan ShardGroupEvent
 
 	Load() error
 	MarshalBinary() ([]byte, error)
 	WithLogger(log *zap.Logger)
+	SetAuthEnabled(enabled bool)
+	SetClearPrivilegesOnAdmin(enabled bool)
 }
 
 var _ MetaClient = &Client{}
@@ -126,6 +211,7 @@ var _ MetaClient = &Client{}
 type Client struct {
 	logger *zap.Logger
 	nodeID uint64
+	clock  clock
 
 	mu        sync.RWMutex
 	closing   chan struct{}
@@ -133,10 +219,46 @@ type Client struct {
 	cacheData *Data
 
 	// Authentication cache.
-	authCache map[string]authUser
+	authCache       map[string]authUser
+	authCacheLimits authCacheLimits
+
+	// authDisabled skips password checks and grants every user all
+	// privileges. It is only set by SetAuthEnabled(false).
+	authDisabled bool
+
+	// clearPrivilegesOnAdmin makes SetAdminPrivilege remove the database
+	// privileges of a user it makes an admin.
+	clearPrivilegesOnAdmin bool
+
+	// readOnly rejects every commit with ErrMetaReadOnly.
+	readOnly bool
+
+	// snapshots is the number of snapshots acquired and not yet released.
+	snapshots int64
+
+	watchers shardGroupWatchers
 
 	path string
 
+	// backupCount is the number of meta.db backups to keep.
+	backupCount int
+
+	// syncPolicy is when meta.db is fsynced after a commit. With
+	// SyncEverySec, dirty is set to 1 by a commit and cleared by syncLoop
+	// once meta.db is synced.
+	syncPolicy string
+	dirty      int32
+
+	// syncLoopStarted is set once syncLoop runs, so that switching to
+	// SyncEverySec again doesn't start another.
+	syncLoopStarted bool
+
+	// walPath is the WAL appended to on every commit by wal, if set.
+	// walStandby is set once the client follows the WAL instead.
+	walPath    string
+	wal        *walWriter
+	walStandby bool
+
 	retentionPolicyAutoCreate bool
 }
 
@@ -144,20 +266,72 @@ type authUser struct {
 	bhash string
 	salt  []byte
 	hash  []byte
+
+	// cached is when the credentials were verified and cached.
+	cached time.Time
+}
+
+// authCacheLimits are the TTL and size limits of an auth cache. Zero means no
+// limit.
+type authCacheLimits struct {
+	ttl  time.Duration
+	size int
+}
+
+// lookup returns the entry of username in cache, unless it has outlived the
+// TTL as of now.
+func (l authCacheLimits) lookup(cache map[string]authUser, username string, now time.Time) (authUser, bool) {
+	au, ok := cache[username]
+	if ok && l.ttl > 0 && now.Sub(au.cached) > l.ttl {
+		return authUser{}, false
+	}
+	return au, ok
+}
+
+// add caches au for username, first dropping the oldest entries so that the
+// cache stays within its size.
+func (l authCacheLimits) add(cache map[string]authUser, username string, au authUser) {
+	if _, ok := cache[username]; !ok && l.size > 0 {
+		for len(cache) >= l.size {
+			var oldest string
+			for name, e := range cache {
+				if oldest == "" || e.cached.Before(cache[oldest].cached) {
+					oldest = name
+				}
+			}
+			delete(cache, oldest)
+		}
+	}
+	cache[username] = au
+}
+
+// authDisabledUser returns the admin user that Authenticate returns for any
+// credentials when authentication is disabled.
+func authDisabledUser(username string) *UserInfo {
+	return &UserInfo{Name: username, Admin: true}
 }
 
 // NewClient returns a new *
 func NewClient(config *Config) *Client {
 	return &Client{
 		cacheData: &Data{
-			ClusterID: uint64(rand.Int63()),
-			Index:     1,
+			ClusterID:            uint64(rand.Int63()),
+			Index:                1,
+			Version:              DataVersion,
+			caseInsensitiveNames: config.CaseInsensitiveNames,
 		},
 		closing:                   make(chan struct{}),
 		changed:                   make(chan struct{}),
 		logger:                    zap.NewNop(),
+		clock:                     realClock{},
 		authCache:                 make(map[string]authUser),
+		authCacheLimits:           authCacheLimits{ttl: time.Duration(config.AuthCacheTTL), size: config.AuthCacheSize},
+		readOnly:                  config.ReadOnly,
 		path:                      config.Dir,
+		backupCount:               config.MetaBackupCount,
+		syncPolicy:                config.SyncPolicy,
+		walPath:                   config.WALPath,
+		wal:                       newWALWriter(config.WALPath, walMaxSize),
 		retentionPolicyAutoCreate: config.RetentionAutoCreate,
 	}
 }
@@ -174,22 +348,75 @@ func (c *Client) Open() error {
 
 	// If this is a brand new instance, persist to disk immediatly.
 	if c.cacheData.Index == 1 {
-		if err := snapshot(c.path, c.cacheData); err != nil {
+		if err := snapshot(c.path, c.cacheData, true); err != nil {
 			return err
 		}
 	}
 
+	if c.syncPolicy == SyncEverySec {
+		c.startSyncLoop()
+	}
+
 	return nil
 }
 
-// Close the meta service cluster connection.
-func (c *Client) Close() error {
+// ApplyConfig applies the settings of config that can change while the
+// client is open: RetentionAutoCreate, MetaBackupCount, SyncPolicy,
+// AuthCacheTTL, AuthCacheSize and ReadOnly. They take effect with the next
+// operation. A config that changes Dir, WALPath or
+// CaseInsensitiveNames, or has an invalid setting, is rejected and nothing is
+// applied.
+func (c *Client) ApplyConfig(config *Config) error {
+	if err := config.validateSettings(); err != nil {
+		return err
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if t, ok := http.DefaultTransport.(*http.Transport); ok {
-		t.CloseIdleConnections()
+	switch {
+	case config.Dir != c.path:
+		return ErrConfigImmutable("dir")
+	case config.WALPath != c.walPath:
+		return ErrConfigImmutable("wal-path")
+	case config.CaseInsensitiveNames != c.cacheData.caseInsensitiveNames:
+		return ErrConfigImmutable("case-insensitive-names")
+	}
+
+	c.retentionPolicyAutoCreate = config.RetentionAutoCreate
+	c.backupCount = config.MetaBackupCount
+	c.authCacheLimits = authCacheLimits{ttl: time.Duration(config.AuthCacheTTL), size: config.AuthCacheSize}
+	c.readOnly = config.ReadOnly
+	if config.SyncPolicy != c.syncPolicy {
+		// Don't leave a change written under SyncEverySec unsynced.
+		if c.syncPolicy == SyncEverySec {
+			if err := c.syncDirty(); err != nil {
+				c.logger.Warn("Failed to sync meta data", zap.Error(err))
+			}
+		}
+		c.syncPolicy = config.SyncPolicy
+		if c.syncPolicy == SyncEverySec {
+			c.startSyncLoop()
+		}
+	}
+	return nil
+}
+
+// startSyncLoop starts syncLoop unless it is already running. c.mu must be
+// held.
+func (c *Client) startSyncLoop() {
+	if c.syncLoopStarted {
+		return
 	}
+	c.syncLoopStarted = true
+	go c.syncLoop(time.Second)
+}
+
+// Close the meta service cluster connection. Closing a closed client does
+// nothing and returns nil.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	select {
 	case <-c.closing:
@@ -197,6 +424,20 @@ func (c *Client) Close() error {
 	default:
 		close(c.closing)
 	}
+	c.watchers.close()
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+
+	// Don't leave the last changes to the operating system.
+	if err := c.syncDirty(); err != nil {
+		c.logger.Warn("Failed to sync meta data", zap.Error(err))
+	}
+
+	if n := atomic.LoadInt64(&c.snapshots); n > 0 {
+		c.logger.Warn("Closing with unreleased meta snapshots", zap.Int64("snapshots", n))
+	}
 
 	return nil
 }
@@ -212,17 +453,43 @@ func (c *Client) ClusterID() uint64 {
 	return c.cacheData.ClusterID
 }
 
+// MetaVersion returns the format version of the meta data.
+func (c *Client) MetaVersion() (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.Version, nil
+}
+
 func (c *Client) Ping(checkAllMetaServers bool) error { return nil }
 
 // AcquireLease attempts to acquire the specified lease.
 func (c *Client) AcquireLease(name string) (*Lease, error) {
 	l := Lease{
 		Name:       name,
-		Expiration: time.Now().Add(DefaultLeaseDuration),
+		Expiration: c.clock.Now().Add(DefaultLeaseDuration),
 	}
 	return &l, nil
 }
 
+// AcquireLeaseContext is like AcquireLease, but returns ctx.Err() if ctx is
+// already done.
+func (c *Client) AcquireLeaseContext(ctx context.Context, name string) (*Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.AcquireLease(name)
+}
+
+// Leases returns an empty list. A local client grants every lease it is asked
+// for without keeping track of them.
+func (c *Client) Leases() ([]LeaseInfo, error) {
+	return []LeaseInfo{}, nil
+}
+
+// setClock replaces the clock used for lease and shard group expiry.
+func (c *Client) setClock(clk clock) { c.clock = clk }
+
 func (c *Client) SetMetaServers([]string) {
 	// Do nothing
 }
@@ -233,18 +500,29 @@ func (c *Client) CreateDataNode(httpAddr, tcpAddr string) (*NodeInfo, error) { r
 func (c *Client) DataNodeByHTTPHost(httpAddr string) (*NodeInfo, error)      { return nil, nil }
 func (c *Client) DataNodeByTCPHost(tcpAddr string) (*NodeInfo, error)        { return nil, nil }
 func (c *Client) DeleteDataNode(id uint64) error                             { return nil }
+func (c *Client) HeartbeatDataNode(id uint64) error                          { return nil }
+func (c *Client) DeadDataNodes(threshold time.Duration) []NodeInfo           { return nil }
+func (c *Client) SetDataNodeDraining(id uint64, draining bool) error         { return nil }
+func (c *Client) DrainingDataNodes() []NodeInfo                              { return nil }
 func (c *Client) MetaNodes() ([]NodeInfo, error)                             { return nil, nil }
 func (c *Client) MetaNodeByAddr(addr string) *NodeInfo                       { return nil }
 func (c *Client) CreateMetaNode(httpAddr, tcpAddr string) (*NodeInfo, error) { return nil, nil }
 func (c *Client) DeleteMetaNode(id uint64) error                             { return nil }
 
+// DataNodeLoad returns the number of live shards owned by each data node.
+func (c *Client) DataNodeLoad() map[uint64]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.DataNodeLoad()
+}
+
 // Database returns info for the requested database.
 func (c *Client) Database(name string) *DatabaseInfo {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	for _, d := range c.cacheData.Databases {
-		if d.Name == name {
+		if c.cacheData.sameDatabaseName(d.Name, name) {
 			return &d
 		}
 	}
@@ -264,14 +542,35 @@ func (c *Client) Databases() []DatabaseInfo {
 	return dbs
 }
 
+// AllRetentionPolicies returns the retention policies of all databases.
+func (c *Client) AllRetentionPolicies() []RetentionPolicyRef {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.AllRetentionPolicies()
+}
+
 // CreateDatabase creates a database or returns it if it already exists.
 func (c *Client) CreateDatabase(name string) (*DatabaseInfo, error) {
+	return c.createDatabase(name, false)
+}
+
+// CreateDatabaseStrict creates a database, returning ErrDatabaseExists if it
+// already exists.
+func (c *Client) CreateDatabaseStrict(name string) (*DatabaseInfo, error) {
+	return c.createDatabase(name, true)
+}
+
+func (c *Client) createDatabase(name string, strict bool) (*DatabaseInfo, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	data := c.cacheData.Clone()
 
 	if db := data.Database(name); db != nil {
+		if strict {
+			return nil, ErrDatabaseExists
+		}
 		return db, nil
 	}
 
@@ -332,7 +631,7 @@ func (c *Client) CreateDatabaseWithRetentionPolicy(name string, spec *RetentionP
 
 	// No existing retention policies, so we can create the provided retention policy as
 	// the new default rp.
-	rpi := spec.NewRetentionPolicyInfo()
+	rpi := db.NewRetentionPolicyInfo(spec)
 	if len(db.RetentionPolicies) == 0 {
 		if err := data.CreateRetentionPolicy(name, rpi, true); err != nil {
 			return nil, err
@@ -363,14 +662,24 @@ func (c *Client) CreateDatabaseWithRetentionPolicy(name string, spec *RetentionP
 	return db, nil
 }
 
-// DropDatabase deletes a database.
+// DropDatabase deletes a database. It returns ErrDatabaseHasSubscriptions if
+// the database has subscriptions, so that they aren't removed unnoticed.
 func (c *Client) DropDatabase(name string) error {
+	return c.dropDatabase(name, false)
+}
+
+// DropDatabaseForce deletes a database along with its subscriptions.
+func (c *Client) DropDatabaseForce(name string) error {
+	return c.dropDatabase(name, true)
+}
+
+func (c *Client) dropDatabase(name string, force bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	data := c.cacheData.Clone()
 
-	if err := data.DropDatabase(name); err != nil {
+	if err := data.dropDatabase(name, force); err != nil {
 		return err
 	}
 
@@ -381,6 +690,43 @@ func (c *Client) DropDatabase(name string) error {
 	return nil
 }
 
+// DropDatabaseWithReport deletes a database and returns the retention policies,
+// shard groups, shards, continuous queries and subscriptions that were removed
+// with it. Dropping a database that does not exist returns an empty report.
+// Like DropDatabase, it returns ErrDatabaseHasSubscriptions if the database
+// has subscriptions.
+func (c *Client) DropDatabaseWithReport(name string) (DropReport, error) {
+	return c.dropDatabaseWithReport(name, false)
+}
+
+// DropDatabaseWithReportForce is like DropDatabaseWithReport but drops the
+// database along with its subscriptions, which the report lists.
+func (c *Client) DropDatabaseWithReportForce(name string) (DropReport, error) {
+	return c.dropDatabaseWithReport(name, true)
+}
+
+func (c *Client) dropDatabaseWithReport(name string, force bool) (DropReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := DropReport{Database: name}
+	if di := c.cacheData.Database(name); di != nil {
+		report = di.dropReport()
+	}
+
+	data := c.cacheData.Clone()
+
+	if err := data.dropDatabase(name, force); err != nil {
+		return DropReport{}, err
+	}
+
+	if err := c.commit(data); err != nil {
+		return DropReport{}, err
+	}
+
+	return report, nil
+}
+
 // CreateRetentionPolicy creates a retention policy on the specified database.
 func (c *Client) CreateRetentionPolicy(database string, spec *RetentionPolicySpec, makeDefault bool) (*RetentionPolicyInfo, error) {
 	c.mu.Lock()
@@ -392,7 +738,7 @@ func (c *Client) CreateRetentionPolicy(database string, spec *RetentionPolicySpe
 		return nil, ErrRetentionPolicyDurationTooLow
 	}
 
-	rp := spec.NewRetentionPolicyInfo()
+	rp := data.Database(database).NewRetentionPolicyInfo(spec)
 	if err := data.CreateRetentionPolicy(database, rp, makeDefault); err != nil {
 		return nil, err
 	}
@@ -417,6 +763,27 @@ func (c *Client) RetentionPolicy(database, name string) (rpi *RetentionPolicyInf
 	return db.RetentionPolicy(name), nil
 }
 
+// DefaultRetentionPolicy returns the name of the default retention policy for a database.
+func (c *Client) DefaultRetentionPolicy(database string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	db := c.cacheData.Database(database)
+	if db == nil {
+		return "", cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	return db.DefaultRetentionPolicy, nil
+}
+
+// RetentionPolicyUsage returns shard group and shard counts and the time span of a retention policy.
+func (c *Client) RetentionPolicyUsage(database, rp string) (RPUsage, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.RetentionPolicyUsage(database, rp)
+}
+
 // DropRetentionPolicy drops a retention policy from a database.
 func (c *Client) DropRetentionPolicy(database, name string) error {
 	c.mu.Lock()
@@ -435,6 +802,15 @@ func (c *Client) DropRetentionPolicy(database, name string) error {
 	return nil
 }
 
+// DropRetentionPolicyWithReport returns the shard groups, shards and
+// subscriptions that dropping a retention policy would remove, and the time
+// span of the data they hold. It does not drop anything.
+func (c *Client) DropRetentionPolicyWithReport(database, name string) (DropReport, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.dropRetentionPolicyReport(database, name)
+}
+
 // SetDefaultRetentionPolicy sets a database's default retention policy.
 func (c *Client) SetDefaultRetentionPolicy(database, name string) error {
 	c.mu.Lock()
@@ -453,6 +829,101 @@ func (c *Client) SetDefaultRetentionPolicy(database, name string) error {
 	return nil
 }
 
+// RepairDefaults replaces every default retention policy that names a policy
+// which no longer exists. See Data.RepairDefaultRetentionPolicy.
+func (c *Client) RepairDefaults() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	repaired := data.repairDefaultRetentionPolicies()
+	if len(repaired) == 0 {
+		return nil
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	c.logger.Info("Repaired default retention policies", zap.Strings("databases", repaired))
+	return nil
+}
+
+// FreezeRetentionPolicy sets whether new shard groups can be created in a
+// retention policy. Creating one in a frozen policy fails with
+// ErrRetentionPolicyFrozen, and none are precreated for it.
+func (c *Client) FreezeRetentionPolicy(database, rp string, frozen bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.FreezeRetentionPolicy(database, rp, frozen); err != nil {
+		return err
+	}
+
+	return c.commit(data)
+}
+
+// SetMeasurementRetention overrides the retention duration of a measurement
+// within a retention policy. A zero duration clears the override.
+func (c *Client) SetMeasurementRetention(database, rp, measurement string, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.SetMeasurementRetention(database, rp, measurement, d); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetDatabaseQuota sets the limits on the retention policies and shard groups
+// of a database. A zero limit means unlimited.
+func (c *Client) SetDatabaseQuota(database string, q DatabaseQuota) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.SetDatabaseQuota(database, q); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetDatabaseDefaultShardGroupDuration sets the shard group duration that
+// retention policies created on a database without one get. A zero duration
+// removes the default.
+func (c *Client) SetDatabaseDefaultShardGroupDuration(database string, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.SetDatabaseDefaultShardGroupDuration(database, d); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // UpdateRetentionPolicy updates a retention policy.
 func (c *Client) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error {
 	c.mu.Lock()
@@ -471,6 +942,26 @@ func (c *Client) UpdateRetentionPolicy(database, name string, rpu *RetentionPoli
 	return nil
 }
 
+// CopyRetentionPolicy creates a retention policy named dstName with the
+// settings of srcName, without its shard groups or subscriptions.
+func (c *Client) CopyRetentionPolicy(database, srcName, dstName string) (*RetentionPolicyInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	rpi, err := data.CopyRetentionPolicy(database, srcName, dstName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.commit(data); err != nil {
+		return nil, err
+	}
+
+	return rpi, nil
+}
+
 // Users returns a slice of UserInfo representing the currently known users.
 func (c *Client) Users() []UserInfo {
 	c.mu.RLock()
@@ -484,6 +975,21 @@ func (c *Client) Users() []UserInfo {
 	return users
 }
 
+// ForEachUser calls fn with the name and admin flag of each user, in order,
+// until fn returns false. Unlike Users it copies nothing and never exposes
+// password hashes. fn is called with the read lock held, so it must not
+// change the meta data.
+func (c *Client) ForEachUser(fn func(name string, admin bool) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i := range c.cacheData.Users {
+		if !fn(c.cacheData.Users[i].Name, c.cacheData.Users[i].Admin) {
+			return
+		}
+	}
+}
+
 // User returns the user with the given name, or ErrUserNotFound.
 func (c *Client) User(name string) (User, error) {
 	c.mu.RLock()
@@ -502,6 +1008,10 @@ func (c *Client) User(name string) (User, error) {
 // This setting is lowered during testing to improve test suite performance.
 var bcryptCost = bcrypt.DefaultCost
 
+// compareHashAndPassword checks a password against a bcrypt hash when
+// authenticating. It is replaced in tests to count the comparisons.
+var compareHashAndPassword = bcrypt.CompareHashAndPassword
+
 // hashWithSalt returns a salted hash of password using salt.
 func (c *Client) hashWithSalt(salt []byte, password string) []byte {
 	hasher := sha256.New()
@@ -554,6 +1064,38 @@ func (c *Client) CreateUser(name, password string, admin bool) (User, error) {
 	return u, nil
 }
 
+// CreateUserWithHash adds a user with an already bcrypt-hashed password.
+func (c *Client) CreateUserWithHash(name, hash string, admin bool) (User, error) {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return nil, ErrInvalidPasswordHash
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	// See if the user already exists.
+	if u := data.user(name); u != nil {
+		if u.Hash != hash || u.Admin != admin {
+			return nil, ErrUserExists
+		}
+		return u, nil
+	}
+
+	if err := data.CreateUser(name, hash, admin); err != nil {
+		return nil, err
+	}
+
+	u := data.user(name)
+
+	if err := c.commit(data); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
 // UpdateUser updates the password of an existing user.
 func (c *Client) UpdateUser(name, password string) error {
 	c.mu.Lock()
@@ -622,6 +1164,11 @@ func (c *Client) SetAdminPrivilege(username string, admin bool) error {
 	if err := data.SetAdminPrivilege(username, admin); err != nil {
 		return err
 	}
+	if admin && c.clearPrivilegesOnAdmin {
+		if err := data.ClearPrivileges(username); err != nil {
+			return err
+		}
+	}
 
 	if err := c.commit(data); err != nil {
 		return err
@@ -642,19 +1189,56 @@ func (c *Client) UserPrivileges(username string) (map[string]cnosql.Privilege, e
 	return p, nil
 }
 
-// UserPrivilege returns the privilege for the given user on the given database.
-func (c *Client) UserPrivilege(username, database string) (*cnosql.Privilege, error) {
+// UserAuthz returns whether the user is an admin and its privileges in one
+// read, for authorization decisions. Every user is an admin when
+// authentication is disabled.
+func (c *Client) UserAuthz(username string) (UserAuthz, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	p, err := c.cacheData.UserPrivilege(username, database)
-	if err != nil {
-		return nil, err
+	if c.authDisabled {
+		return UserAuthz{Admin: true}, nil
 	}
-	return p, nil
+
+	return c.cacheData.UserAuthz(username)
 }
 
-// AdminUserExists returns true if any user has admin privilege.
+// UserPrivilege returns the privilege for the given user on the given database.
+func (c *Client) UserPrivilege(username, database string) (*cnosql.Privilege, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.authDisabled {
+		p := cnosql.AllPrivileges
+		return &p, nil
+	}
+
+	p, err := c.cacheData.UserPrivilege(username, database)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// EffectivePrivilege returns the privilege the user has on the given database,
+// which is AllPrivileges for an admin user.
+func (c *Client) EffectivePrivilege(username, database string) (cnosql.Privilege, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.EffectivePrivilege(username, database)
+}
+
+// AccessibleDatabases returns the sorted names of the databases the user has
+// any privilege on, which is every database for an admin user.
+func (c *Client) AccessibleDatabases(username string) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.AccessibleDatabases(username)
+}
+
+// AdminUserExists returns true if any user has admin privilege.
 func (c *Client) AdminUserExists() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -665,6 +1249,10 @@ func (c *Client) AdminUserExists() bool {
 func (c *Client) Authenticate(username, password string) (User, error) {
 	// Find user.
 	c.mu.RLock()
+	if c.authDisabled {
+		c.mu.RUnlock()
+		return authDisabledUser(username), nil
+	}
 	userInfo := c.cacheData.user(username)
 	c.mu.RUnlock()
 	if userInfo == nil {
@@ -673,11 +1261,12 @@ func (c *Client) Authenticate(username, password string) (User, error) {
 
 	// Check the local auth cache first.
 	c.mu.RLock()
-	au, ok := c.authCache[username]
+	au, ok := c.authCacheLimits.lookup(c.authCache, username, c.clock.Now())
 	c.mu.RUnlock()
 	if ok {
-		// verify the password using the cached salt and hash
-		if bytes.Equal(c.hashWithSalt(au.salt, password), au.hash) {
+		// verify the password using the cached salt and hash, unless the
+		// password has changed since the entry was cached
+		if au.bhash == userInfo.Hash && bytes.Equal(c.hashWithSalt(au.salt, password), au.hash) {
 			return userInfo, nil
 		}
 
@@ -685,7 +1274,7 @@ func (c *Client) Authenticate(username, password string) (User, error) {
 	}
 
 	// Compare password with user hash.
-	if err := bcrypt.CompareHashAndPassword([]byte(userInfo.Hash), []byte(password)); err != nil {
+	if err := compareHashAndPassword([]byte(userInfo.Hash), []byte(password)); err != nil {
 		return nil, ErrAuthenticate
 	}
 
@@ -695,11 +1284,66 @@ func (c *Client) Authenticate(username, password string) (User, error) {
 		return nil, err
 	}
 	c.mu.Lock()
-	c.authCache[username] = authUser{salt: salt, hash: hashed, bhash: userInfo.Hash}
+	c.authCacheLimits.add(c.authCache, username, authUser{salt: salt, hash: hashed, bhash: userInfo.Hash, cached: c.clock.Now()})
 	c.mu.Unlock()
 	return userInfo, nil
 }
 
+// AuthenticateBatch authenticates each of creds as Authenticate does,
+// returning the results in the same order. Repeated credentials are checked
+// once, so a burst of logins by the same user costs a single bcrypt compare.
+func (c *Client) AuthenticateBatch(creds []Credential) []AuthResult {
+	return authenticateBatch(creds, c.Authenticate)
+}
+
+// Credential is a username and password to authenticate.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// AuthResult is the outcome of authenticating a Credential. Err is nil if
+// the credential is valid.
+type AuthResult struct {
+	User User
+	Err  error
+}
+
+// authenticateBatch calls authenticate once for each distinct credential in
+// creds and returns the results in the order of creds.
+func authenticateBatch(creds []Credential, authenticate func(username, password string) (User, error)) []AuthResult {
+	results := make([]AuthResult, len(creds))
+	seen := make(map[Credential]int, len(creds))
+	for i, cred := range creds {
+		if j, ok := seen[cred]; ok {
+			results[i] = results[j]
+			continue
+		}
+		seen[cred] = i
+
+		u, err := authenticate(cred.Username, cred.Password)
+		results[i] = AuthResult{User: u, Err: err}
+	}
+	return results
+}
+
+// InvalidateAuthCache drops the cached credentials of username, so the next
+// Authenticate for that user checks the password against its bcrypt hash.
+func (c *Client) InvalidateAuthCache(username string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.authCache, username)
+}
+
+// InvalidateAllAuthCache drops the cached credentials of every user.
+func (c *Client) InvalidateAllAuthCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.authCache = make(map[string]authUser)
+}
+
 // UserCount returns the number of users stored.
 func (c *Client) UserCount() int {
 	c.mu.RLock()
@@ -727,6 +1371,58 @@ func (c *Client) ShardIDs() []uint64 {
 	return a
 }
 
+// ShardIDsPage returns up to limit shard ids greater than afterID in ascending
+// order, and the cursor for the next page, which is zero on the last page.
+func (c *Client) ShardIDsPage(afterID uint64, limit int) ([]uint64, uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.ShardIDsPage(afterID, limit)
+}
+
+// ShardGroupForTimestamp returns a copy of the shard group that contains t,
+// or ErrShardGroupNotFound if the group has not been created yet.
+func (c *Client) ShardGroupForTimestamp(database, rp string, t time.Time) (*ShardGroupInfo, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.shardGroupForTimestamp(database, rp, t)
+}
+
+// ShardGroupBoundaries returns the time boundaries of every shard group on a
+// database and retention policy, sorted by start time. Deleted groups are
+// included and flagged.
+func (c *Client) ShardGroupBoundaries(database, rp string) ([]ShardGroupBoundary, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.ShardGroupBoundaries(database, rp)
+}
+
+// ShardGroupTimeline returns the creation and deletion times of every shard
+// group on a database and retention policy, sorted by creation time.
+func (c *Client) ShardGroupTimeline(database, rp string) []ShardGroupTimelineEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.ShardGroupTimeline(database, rp)
+}
+
+// ShardGroupBoundaryFor returns the time range of the shard group that would
+// be created for t, without creating it.
+func (c *Client) ShardGroupBoundaryFor(database, rp string, t time.Time) (start, end time.Time, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.ShardGroupBoundaryFor(database, rp, t)
+}
+
+// AllShardGroupsByTimeRange returns the live shard groups of every database
+// and retention policy that may contain data for the time range, sorted by
+// start time.
+func (c *Client) AllShardGroupsByTimeRange(min, max time.Time) ([]ShardGroupRef, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.AllShardGroupsByTimeRange(min, max)
+}
+
 // ShardGroupsByTimeRange returns a list of all shard groups on a database and retention policy that may contain data
 // for the specified time range. ShardGroups are sorted by start time.
 func (c *Client) ShardGroupsByTimeRange(database, rp string, min, max time.Time) (a []ShardGroupInfo, err error) {
@@ -750,6 +1446,16 @@ func (c *Client) ShardGroupsByTimeRange(database, rp string, min, max time.Time)
 	return groups, nil
 }
 
+// HealthyShardGroupsByTimeRange returns the shard groups on a database and
+// retention policy that may contain data for the time range, leaving out
+// groups whose owners have all missed heartbeats for longer than threshold.
+func (c *Client) HealthyShardGroupsByTimeRange(database, rp string, min, max time.Time, threshold time.Duration) ([]ShardGroupInfo, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cacheData.HealthyShardGroupsByTimeRange(database, rp, min, max, c.clock.Now(), threshold)
+}
+
 // ShardsByTimeRange returns a slice of shards that may contain data in the time range.
 func (c *Client) ShardsByTimeRange(sources cnosql.Sources, tmin, tmax time.Time) (a []ShardInfo, err error) {
 	m := make(map[*ShardInfo]struct{})
@@ -779,7 +1485,7 @@ func (c *Client) DropShard(id uint64) error {
 	defer c.mu.Unlock()
 
 	data := c.cacheData.Clone()
-	data.DropShard(id)
+	data.dropShard(id, c.clock.Now())
 	return c.commit(data)
 }
 
@@ -796,7 +1502,7 @@ func (c *Client) TruncateShardGroups(t time.Time) error {
 // PruneShardGroups remove deleted shard groups from the data store.
 func (c *Client) PruneShardGroups() error {
 	var changed bool
-	expiration := time.Now().Add(ShardGroupDeletedExpiration)
+	expiration := c.clock.Now().Add(ShardGroupDeletedExpiration)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	data := c.cacheData.Clone()
@@ -814,6 +1520,7 @@ func (c *Client) PruneShardGroups() error {
 		}
 	}
 	if changed {
+		data.updateDataNodeLoad()
 		return c.commit(data)
 	}
 	return nil
@@ -847,9 +1554,33 @@ func (c *Client) CreateShardGroup(database, rp string, timestamp time.Time) (*Sh
 		return nil, err
 	}
 
+	logShardGroupCreated(c.logger, database, rp, sgi)
 	return sgi, nil
 }
 
+// logShardGroupCreated logs the creation of a shard group for a write.
+func logShardGroupCreated(log *zap.Logger, database, rp string, sgi *ShardGroupInfo) {
+	var owners []uint64
+	seen := make(map[uint64]bool)
+	for _, si := range sgi.Shards {
+		for _, o := range si.Owners {
+			if !seen[o.NodeID] {
+				seen[o.NodeID] = true
+				owners = append(owners, o.NodeID)
+			}
+		}
+	}
+	sort.Sort(uint64Slice(owners))
+
+	log.Info("New shard group created",
+		logger.Database(database),
+		logger.RetentionPolicy(rp),
+		logger.ShardGroup(sgi.ID),
+		zap.Time("start", sgi.StartTime),
+		zap.Time("end", sgi.EndTime),
+		zap.Uint64s("owners", owners))
+}
+
 func createShardGroup(data *Data, database, rp string, timestamp time.Time) (*ShardGroupInfo, error) {
 	// It is the responsibility of the caller to check if it exists before calling this method.
 	if rg, _ := data.ShardGroupByTimestamp(database, rp, timestamp); rg != nil {
@@ -878,7 +1609,46 @@ func (c *Client) DeleteShardGroup(database, rp string, id uint64) error {
 
 	data := c.cacheData.Clone()
 
-	if err := data.DeleteShardGroup(database, rp, id); err != nil {
+	if err := data.MarkShardGroupDeleted(database, rp, id, c.clock.Now()); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReserveShardIDs reserves n consecutive shard IDs and returns the first of
+// them. Shards created afterwards never use a reserved ID.
+func (c *Client) ReserveShardIDs(n int) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	start, err := data.ReserveShardIDs(n)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.commit(data); err != nil {
+		return 0, err
+	}
+
+	return start, nil
+}
+
+// RebalanceShardGroup reassigns the owners of a shard group's shards over the
+// current data nodes. The shards' data is not moved.
+func (c *Client) RebalanceShardGroup(database, rp string, id uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.RebalanceShardGroup(database, rp, id); err != nil {
 		return err
 	}
 
@@ -889,60 +1659,147 @@ func (c *Client) DeleteShardGroup(database, rp string, id uint64) error {
 	return nil
 }
 
+// MarkShardGroupDeleted marks a shard group as deleted as of the given time without
+// removing it. PruneShardGroups removes it once ShardGroupDeletedExpiration has passed.
+func (c *Client) MarkShardGroupDeleted(database, rp string, id uint64, at time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	if err := data.MarkShardGroupDeleted(database, rp, id, at); err != nil {
+		return err
+	}
+
+	return c.commit(data)
+}
+
 // PrecreateShardGroups creates shard groups whose endtime is before the 'to' time passed in, but
 // is yet to expire before 'from'. This is to avoid the need for these shards to be created when data
 // for the corresponding time range arrives. Shard creation involves Raft consensus, and precreation
-// avoids taking the hit at write-time.
+// avoids taking the hit at write-time. It returns ErrShardGroupsNotCreated if none of the
+// groups could be created.
 func (c *Client) PrecreateShardGroups(from, to time.Time) error {
+	// Plan against a copy so the write lock is only held to apply the plan.
+	c.mu.RLock()
+	data := c.cacheData.Clone()
+	c.mu.RUnlock()
+
+	reqs := planShardGroupPrecreation(data, from, to, c.logger)
+	if len(reqs) == 0 {
+		return nil
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	data := c.cacheData.Clone()
-	var changed bool
 
+	data = c.cacheData.Clone()
+	failed := data.createShardGroups(reqs)
+	for _, f := range failed {
+		c.logger.Warn("Failed to precreate shard group",
+			logger.Database(f.req.database),
+			logger.RetentionPolicy(f.req.rp),
+			zap.Time("timestamp", f.req.timestamp),
+			zap.Error(f.err))
+	}
+	if len(failed) == len(reqs) {
+		return ErrShardGroupsNotCreated
+	}
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		if sgi, _ := data.ShardGroupByTimestamp(req.database, req.rp, req.timestamp); sgi != nil {
+			c.logger.Info("New shard group successfully precreated",
+				logger.ShardGroup(sgi.ID),
+				logger.Database(req.database),
+				logger.RetentionPolicy(req.rp))
+		}
+	}
+	return nil
+}
+
+// shardGroupRequest identifies a shard group to create by a time it contains.
+type shardGroupRequest struct {
+	database  string
+	rp        string
+	timestamp time.Time
+}
+
+// shardGroupFailure is a shard group request that could not be created.
+type shardGroupFailure struct {
+	req shardGroupRequest
+	err error
+}
+
+// planShardGroupPrecreation returns the shard groups that PrecreateShardGroups
+// should create, in the order they are needed. The groups are created in data
+// while planning, so data should be a copy.
+func planShardGroupPrecreation(data *Data, from, to time.Time, log *zap.Logger) []shardGroupRequest {
+	var reqs []shardGroupRequest
 	for _, di := range data.Databases {
 		for _, rp := range di.RetentionPolicies {
 			if len(rp.ShardGroups) == 0 {
 				// No data was ever written to this shard group, or all groups have been deleted.
 				continue
+			} else if rp.Frozen {
+				continue
 			}
-			g := rp.ShardGroups[len(rp.ShardGroups)-1] // Get the last shard group in time.
-			if !g.Deleted() && g.EndTime.Before(to) && g.EndTime.After(from) {
-				// ShardGroup is not deleted, will end before the future time, but is still yet to expire.
-				// This last check is important, so the system doesn't create shards groups wholly
+			g := &rp.ShardGroups[len(rp.ShardGroups)-1] // Get the last shard group in time.
+			if g.Deleted() || !g.EndTime.After(from) {
+				// The last check is important, so the system doesn't create shards groups wholly
 				// in the past.
+				continue
+			}
+
+			// Create successive shard groups until the last one ends after the future time.
+			for n := 0; !g.EndTime.After(to); n++ {
+				if n == MaxPrecreatedShardGroups {
+					log.Warn("Reached maximum number of precreated shard groups",
+						logger.Database(di.Name),
+						logger.RetentionPolicy(rp.Name),
+						zap.Int("max", MaxPrecreatedShardGroups))
+					break
+				}
 
-				// Create successive shard group.
 				nextShardGroupTime := g.EndTime.Add(1 * time.Nanosecond)
-				// if it already exists, continue
+				// if it already exists, move on to the one after it
 				if rg, _ := data.ShardGroupByTimestamp(di.Name, rp.Name, nextShardGroupTime); rg != nil {
-					c.logger.Info("shard group already exists",
+					log.Info("shard group already exists",
 						logger.ShardGroup(rg.ID),
 						logger.Database(di.Name),
 						logger.RetentionPolicy(rp.Name))
+					g = rg
 					continue
 				}
 				newGroup, err := createShardGroup(data, di.Name, rp.Name, nextShardGroupTime)
 				if err != nil {
-					c.logger.Info("Failed to precreate successive shard group",
+					log.Info("Failed to precreate successive shard group",
 						zap.Uint64("group_id", g.ID), zap.Error(err))
-					continue
+					break
 				}
-				changed = true
-				c.logger.Info("New shard group successfully precreated",
-					logger.ShardGroup(newGroup.ID),
-					logger.Database(di.Name),
-					logger.RetentionPolicy(rp.Name))
+				reqs = append(reqs, shardGroupRequest{database: di.Name, rp: rp.Name, timestamp: nextShardGroupTime})
+				g = newGroup
 			}
 		}
 	}
+	return reqs
+}
 
-	if changed {
-		if err := c.commit(data); err != nil {
-			return err
-		}
-	}
+// UnderReplicatedShards returns the live shards that have fewer owners than
+// their retention policy's replica factor.
+func (c *Client) UnderReplicatedShards() []UnderReplicatedShard {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.UnderReplicatedShards()
+}
 
-	return nil
+// ShardsOwnedBy returns the live shards owned by the given data node.
+func (c *Client) ShardsOwnedBy(nodeID uint64) []NodeShard {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cacheData.ShardsOwnedBy(nodeID)
 }
 
 // ShardOwner returns the owning shard group info for a specific shard.
@@ -989,6 +1846,30 @@ func (c *Client) CreateContinuousQuery(database, name, query string) error {
 	return nil
 }
 
+// CreateOrReplaceContinuousQuery saves a continuous query with the given name for the
+// given database, replacing the query if one with that name already exists. Nothing is
+// committed if the stored query is already identical.
+func (c *Client) CreateOrReplaceContinuousQuery(database, name, query string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cacheData.hasContinuousQuery(database, name, query) {
+		return nil
+	}
+
+	data := c.cacheData.Clone()
+
+	if err := data.CreateOrReplaceContinuousQuery(database, name, query); err != nil {
+		return err
+	}
+
+	if err := c.commit(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // DropContinuousQuery removes the continuous query with the given name on the given database.
 func (c *Client) DropContinuousQuery(database, name string) error {
 	c.mu.Lock()
@@ -1007,6 +1888,33 @@ func (c *Client) DropContinuousQuery(database, name string) error {
 	return nil
 }
 
+// ExportContinuousQueriesDDL writes a CREATE CONTINUOUS QUERY statement for
+// every continuous query to w.
+func (c *Client) ExportContinuousQueriesDDL(w io.Writer) error {
+	c.mu.RLock()
+	data := c.cacheData
+	c.mu.RUnlock()
+	return data.ExportContinuousQueriesDDL(w)
+}
+
+// ExportRetentionPoliciesDDL writes the statements that recreate every
+// database and retention policy to w.
+func (c *Client) ExportRetentionPoliciesDDL(w io.Writer) error {
+	c.mu.RLock()
+	data := c.cacheData
+	c.mu.RUnlock()
+	return data.ExportRetentionPoliciesDDL(w)
+}
+
+// ExportUsersDDL writes the statements that recreate every user and their
+// privileges to w, with placeholder passwords.
+func (c *Client) ExportUsersDDL(w io.Writer) error {
+	c.mu.RLock()
+	data := c.cacheData
+	c.mu.RUnlock()
+	return data.ExportUsersDDL(w)
+}
+
 // CreateSubscription creates a subscription against the given database and retention policy.
 func (c *Client) CreateSubscription(database, rp, name, mode string, destinations []string) error {
 	c.mu.Lock()
@@ -1045,17 +1953,44 @@ func (c *Client) DropSubscription(database, rp, name string) error {
 
 // SetData overwrites the underlying data in the meta store.
 func (c *Client) SetData(data *Data) error {
+	return c.RestoreData(data, false)
+}
+
+// RestoreData overwrites the underlying data in the meta store. If
+// preserveNodes is set, the current cluster ID and nodes are kept and only
+// the databases, retention policies and users are restored.
+func (c *Client) RestoreData(data *Data, preserveNodes bool) error {
 	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	d := data.Clone()
+	d.caseInsensitiveNames = c.cacheData.caseInsensitiveNames
+	if preserveNodes {
+		d.preserveNodes(c.cacheData)
+		if a := d.UnderReplicatedShards(); len(a) > 0 {
+			c.logger.Warn("Restored shards are under-replicated", zap.Int("shards", len(a)))
+		}
+	}
 
-	if err := c.commit(d); err != nil {
-		return err
+	return c.commit(d)
+}
+
+// SetMaintenanceMode turns maintenance mode on or off. While it is on, every
+// other change to the meta data fails with ErrMaintenanceMode.
+func (c *Client) SetMaintenanceMode(on bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.readOnly {
+		return ErrMetaReadOnly
+	} else if c.cacheData.MaintenanceMode == on {
+		return nil
 	}
 
-	c.mu.Unlock()
+	data := c.cacheData.Clone()
+	data.MaintenanceMode = on
 
-	return nil
+	return c.save(data)
 }
 
 // Data returns a clone of the underlying data in the meta store.
@@ -1066,6 +2001,20 @@ func (c *Client) Data() Data {
 	return *d
 }
 
+// AcquireSnapshot returns the current meta data without copying it, along with
+// a func to release it when done. The returned data is never modified by the
+// client, since every change is made to a copy that then replaces the cached
+// data, and it must not be modified by the caller either.
+//
+// A snapshot keeps its version of the meta data alive until it is released and
+// no longer referenced, so holding many snapshots across updates retains memory.
+func (c *Client) AcquireSnapshot() (*Data, func()) {
+	c.mu.RLock()
+	data := c.cacheData
+	c.mu.RUnlock()
+	return data, acquireSnapshot(&c.snapshots)
+}
+
 // WaitForDataChanged returns a channel that will get closed when
 // the metastore data has changed.
 func (c *Client) WaitForDataChanged() chan struct{} {
@@ -1074,17 +2023,52 @@ func (c *Client) WaitForDataChanged() chan struct{} {
 	return c.changed
 }
 
+// WatchShardGroups returns a channel of shard group creations, deletions and
+// expirations, found by comparing each commit with the data before it. The
+// channel is closed when ctx is done or the client is closed.
+func (c *Client) WatchShardGroups(ctx context.Context) <-chan ShardGroupEvent {
+	return c.watchers.watch(ctx)
+}
+
 // commit writes data to the underlying store.
 // This method assumes c's mutex is already locked.
 func (c *Client) commit(data *Data) error {
+	if c.readOnly {
+		return ErrMetaReadOnly
+	} else if c.cacheData.MaintenanceMode {
+		return ErrMaintenanceMode
+	}
+	return c.save(data)
+}
+
+// save writes data to meta.db and replaces the cached data with it, whether
+// or not the meta data is in maintenance mode.
+func (c *Client) save(data *Data) error {
 	data.Index++
 
 	// try to write to disk before updating in memory
-	if err := snapshot(c.path, data); err != nil {
+	if err := snapshot(c.path, data, c.syncPolicy == SyncAlways || c.syncPolicy == ""); err != nil {
 		return err
 	}
+	if c.syncPolicy == SyncEverySec {
+		atomic.StoreInt32(&c.dirty, 1)
+	}
+	if c.backupCount > 0 {
+		// The change is already on disk, so a failed backup doesn't fail it.
+		if err := backupSnapshot(c.path, c.clock.Now(), c.backupCount); err != nil {
+			c.logger.Warn("Failed to back up meta data", zap.Error(err))
+		}
+	}
+	if c.walPath != "" && !c.walStandby {
+		// Like backups, a standby that misses this record catches up
+		// with the next one.
+		if err := c.wal.write(data); err != nil {
+			c.logger.Warn("Failed to write meta data to WAL", zap.String("path", c.walPath), zap.Error(err))
+		}
+	}
 
 	// update in memory
+	c.watchers.publish(c.cacheData, data, c.clock.Now())
 	c.cacheData = data
 
 	// close channels to signal changes
@@ -1108,8 +2092,73 @@ func (c *Client) WithLogger(log *zap.Logger) {
 	c.logger = log.With(zap.String("service", "meta-client"))
 }
 
-// snapshot saves the current meta data to disk.
-func snapshot(path string, data *Data) error {
+// SetAuthEnabled sets whether Authenticate checks passwords. When disabled,
+// Authenticate returns an admin user for any credentials and UserPrivilege
+// returns AllPrivileges. Authentication is enabled by default.
+func (c *Client) SetAuthEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !enabled {
+		c.logger.Warn("Authentication is disabled, every user is treated as an admin")
+	}
+	c.authDisabled = !enabled
+}
+
+// SetClearPrivilegesOnAdmin sets whether SetAdminPrivilege removes the
+// database privileges of a user it makes an admin. Admins have every
+// privilege, so the grants only clutter UserPrivileges, and revoking admin
+// later leaves the user with none. Grants are kept by default.
+func (c *Client) SetClearPrivilegesOnAdmin(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearPrivilegesOnAdmin = enabled
+}
+
+// syncLoop fsyncs meta.db every interval if it changed, until the client
+// is closed.
+func (c *Client) syncLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closing:
+			return
+		case <-ticker.C:
+			if err := c.syncDirty(); err != nil {
+				c.logger.Warn("Failed to sync meta data", zap.Error(err))
+			}
+		}
+	}
+}
+
+// syncDirty fsyncs meta.db if a commit wrote it without syncing. It does not
+// lock c's mutex, so commits are not held up by the disk.
+func (c *Client) syncDirty() error {
+	if !atomic.CompareAndSwapInt32(&c.dirty, 1, 0) {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Join(c.path, metaFile))
+	if err == nil {
+		err = syncFile(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		// Try again next time.
+		atomic.StoreInt32(&c.dirty, 1)
+	}
+	return err
+}
+
+// syncFile fsyncs f. It is a variable so tests can count syncs.
+var syncFile = (*os.File).Sync
+
+// snapshot saves the current meta data to disk. Unless sync is set, the file
+// is not fsynced and may only be in the operating system's cache.
+func snapshot(path string, data *Data, sync bool) error {
 	filename := filepath.Join(path, metaFile)
 	tmpFile := filename + "tmp"
 
@@ -1130,8 +2179,10 @@ func snapshot(path string, data *Data) error {
 		return err
 	}
 
-	if err = f.Sync(); err != nil {
-		return err
+	if sync {
+		if err = syncFile(f); err != nil {
+			return err
+		}
 	}
 
 	//close file handle before renaming to support Windows
@@ -1142,6 +2193,58 @@ func snapshot(path string, data *Data) error {
 	return file.RenameFile(tmpFile, filename)
 }
 
+// backupSnapshot copies the meta file in path to a backup named after t, then
+// removes all but the newest n backups.
+func backupSnapshot(path string, t time.Time, n int) error {
+	b, err := ioutil.ReadFile(filepath.Join(path, metaFile))
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(path, metaFile+"."+t.UTC().Format(metaBackupTimeFormat))
+	tmpFile := filename + "tmp"
+	if err := ioutil.WriteFile(tmpFile, b, 0666); err != nil {
+		return err
+	}
+	if err := file.RenameFile(tmpFile, filename); err != nil {
+		return err
+	}
+
+	backups, err := MetaBackups(path)
+	if err != nil {
+		return err
+	}
+	for len(backups) > n {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// MetaBackups returns the paths of the meta.db backups in dir, oldest first.
+func MetaBackups(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, metaFile+".") {
+			continue
+		}
+		if _, err := time.Parse(metaBackupTimeFormat, strings.TrimPrefix(name, metaFile+".")); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	// The timestamps sort chronologically by name; ReadDir returns them sorted.
+	return backups, nil
+}
+
 // Load loads the current meta data from disk.
 func (c *Client) Load() error {
 	file := filepath.Join(c.path, metaFile)
@@ -1160,12 +2263,25 @@ func (c *Client) Load() error {
 		return err
 	}
 
-	if err := c.cacheData.UnmarshalBinary(data); err != nil {
+	// Replace rather than overwrite the cached data, it may be held by a snapshot.
+	d := &Data{caseInsensitiveNames: c.cacheData.caseInsensitiveNames}
+	if err := d.UnmarshalBinary(data); err != nil {
 		return err
 	}
+	c.cacheData = d
 	return nil
 }
 
+// acquireSnapshot counts a new snapshot in n and returns the func that releases it.
+// Calling the release func more than once has no further effect.
+func acquireSnapshot(n *int64) func() {
+	atomic.AddInt64(n, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { atomic.AddInt64(n, -1) })
+	}
+}
+
 type uint64Slice []uint64
 
 func (a uint64Slice) Len() int           { return len(a) }
@@ -0,0 +1,253 @@
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/common/pkg/toml"
+)
+
+// countSyncs counts the calls to syncFile until the returned func is called.
+func countSyncs() (*int64, func()) {
+	var n int64
+	orig := syncFile
+	syncFile = func(f *os.File) error {
+		atomic.AddInt64(&n, 1)
+		return orig(f)
+	}
+	return &n, func() { syncFile = orig }
+}
+
+// countCompares counts the calls to compareHashAndPassword until the
+// returned func is called.
+func countCompares() (*int64, func()) {
+	var n int64
+	orig := compareHashAndPassword
+	compareHashAndPassword = func(hash, password []byte) error {
+		atomic.AddInt64(&n, 1)
+		return orig(hash, password)
+	}
+	return &n, func() { compareHashAndPassword = orig }
+}
+
+func TestClient_ApplyConfig_SyncPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnosdb-meta-sync-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.Dir = dir
+	config.SyncPolicy = SyncEverySec
+	c := NewClient(config)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	n, restore := countSyncs()
+	defer restore()
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leaving everysec syncs the change it left unsynced, and the next
+	// commit syncs under the new policy.
+	other := *config
+	other.SyncPolicy = SyncAlways
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	} else if got := atomic.LoadInt64(n); got != 1 {
+		t.Fatalf("unexpected syncs on apply: got %d, exp 1", got)
+	}
+	if _, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if got := atomic.LoadInt64(n); got != 2 {
+		t.Fatalf("unexpected syncs on commit: got %d, exp 2", got)
+	}
+}
+
+func TestClient_ApplyConfig_AuthCache(t *testing.T) {
+	c, clk := newTestClockClient(t)
+	defer os.RemoveAll(c.path)
+	defer c.Close()
+	for _, name := range []string{"u0", "u1"} {
+		if _, err := c.CreateUser(name, "pass", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	other := *NewConfig()
+	other.Dir = c.path
+	other.AuthCacheTTL = toml.Duration(time.Minute)
+	other.AuthCacheSize = 1
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	}
+
+	n, restore := countCompares()
+	defer restore()
+	authenticate := func(name string, compares int64) {
+		t.Helper()
+		if _, err := c.Authenticate(name, "pass"); err != nil {
+			t.Fatal(err)
+		} else if got := atomic.LoadInt64(n); got != compares {
+			t.Fatalf("unexpected compares after %s: got %d, exp %d", name, got, compares)
+		}
+	}
+
+	// Within the TTL the cached credentials are used.
+	authenticate("u0", 1)
+	clk.add(time.Minute)
+	authenticate("u0", 1)
+
+	// Past the TTL they are checked again.
+	clk.add(time.Second)
+	authenticate("u0", 2)
+
+	// Caching u1 drops u0, the oldest entry.
+	clk.add(time.Second)
+	authenticate("u1", 3)
+	authenticate("u0", 4)
+	if len(c.authCache) != 1 {
+		t.Fatalf("unexpected auth cache size: %d", len(c.authCache))
+	}
+}
+
+func TestClient_SyncPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy string
+		syncs  int64
+	}{
+		{policy: SyncAlways, syncs: 1},
+		{policy: SyncEverySec, syncs: 0},
+		{policy: SyncNone, syncs: 0},
+	} {
+		t.Run(tt.policy, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "cnosdb-meta-sync-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			config := NewConfig()
+			config.Dir = dir
+			config.SyncPolicy = tt.policy
+			c := NewClient(config)
+			if err := c.Open(); err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			n, restore := countSyncs()
+			defer restore()
+			if _, err := c.CreateDatabase("db0"); err != nil {
+				t.Fatal(err)
+			}
+			if got := atomic.LoadInt64(n); got != tt.syncs {
+				t.Fatalf("unexpected syncs on commit: got %d, exp %d", got, tt.syncs)
+			}
+
+			// The change is applied in memory and written to meta.db
+			// whether or not it was synced.
+			if c.Database("db0") == nil {
+				t.Fatal("expected db0 in memory")
+			}
+			other := NewClient(config)
+			if err := other.Load(); err != nil {
+				t.Fatal(err)
+			} else if other.Data().Index != c.Data().Index || other.Database("db0") == nil {
+				t.Fatal("meta.db does not match the data in memory")
+			}
+
+			// Only everysec has a change left to sync.
+			if err := c.syncDirty(); err != nil {
+				t.Fatal(err)
+			}
+			exp := tt.syncs
+			if tt.policy == SyncEverySec {
+				exp++
+			}
+			if got := atomic.LoadInt64(n); got != exp {
+				t.Fatalf("unexpected syncs after syncDirty: got %d, exp %d", got, exp)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_SyncPolicy(t *testing.T) {
+	c := NewConfig()
+	c.Dir = "/tmp/meta"
+	for _, policy := range []string{"", SyncAlways, SyncEverySec, SyncNone} {
+		c.SyncPolicy = policy
+		if err := c.Validate(); err != nil {
+			t.Fatalf("policy %q: %v", policy, err)
+		}
+	}
+	c.SyncPolicy = "sometimes"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown sync policy")
+	}
+}
+
+func TestClient_AuthenticateBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnosdb-meta-auth-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := NewConfig()
+	config.Dir = dir
+	c := NewClient(config)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.CreateUser("fred", "supersecure", false); err != nil {
+		t.Fatal(err)
+	}
+
+	n, restore := countCompares()
+	defer restore()
+
+	good := Credential{Username: "fred", Password: "supersecure"}
+	bad := Credential{Username: "fred", Password: "wrong"}
+	creds := []Credential{good, good, bad, good, bad, {Username: "jim", Password: "x"}}
+	results := c.AuthenticateBatch(creds)
+
+	// One compare for the good password and one for the bad one.
+	if got := atomic.LoadInt64(n); got != 2 {
+		t.Fatalf("unexpected bcrypt compares: got %d, exp 2", got)
+	}
+	if len(results) != len(creds) {
+		t.Fatalf("unexpected result count: %d", len(results))
+	}
+	for i, cred := range creds {
+		r := results[i]
+		switch cred {
+		case good:
+			if r.Err != nil || r.User == nil || r.User.ID() != "fred" {
+				t.Fatalf("result %d: unexpected result: %+v", i, r)
+			}
+		case bad:
+			if r.Err != ErrAuthenticate {
+				t.Fatalf("result %d: unexpected error: %v", i, r.Err)
+			}
+		default:
+			if r.Err != ErrUserNotFound {
+				t.Fatalf("result %d: unexpected error: %v", i, r.Err)
+			}
+		}
+	}
+
+	// The good password was cached, so a later batch needs no compare for it.
+	c.AuthenticateBatch([]Credential{good, good})
+	if got := atomic.LoadInt64(n); got != 2 {
+		t.Fatalf("unexpected bcrypt compares after caching: got %d, exp 2", got)
+	}
+}
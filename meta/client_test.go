@@ -0,0 +1,1408 @@
+package meta_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb"
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMetaClient_PrecreateShardGroups(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{
+		Name:               "rp0",
+		ShardGroupDuration: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	if _, err := c.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	}
+
+	// A ten hour window needs ten more groups of the minimum one hour duration.
+	if err := c.PrecreateShardGroups(now, now.Add(10*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := c.ShardGroupsByTimeRange("db0", "rp0", now, now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 11 {
+		t.Fatalf("unexpected shard group count: got %d, exp 11", len(groups))
+	}
+	for i := 1; i < len(groups); i++ {
+		if !groups[i].StartTime.Equal(groups[i-1].EndTime) {
+			t.Fatalf("shard group %d is not contiguous: start %s, previous end %s", i, groups[i].StartTime, groups[i-1].EndTime)
+		}
+	}
+	if end := groups[len(groups)-1].EndTime; !end.After(now.Add(10 * time.Hour)) {
+		t.Fatalf("precreated groups end at %s, before the window", end)
+	}
+
+	// Calling again with the same window is a no-op.
+	if err := c.PrecreateShardGroups(now, now.Add(10*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ := c.ShardGroupsByTimeRange("db0", "rp0", now, now.Add(24*time.Hour)); len(groups) != 11 {
+		t.Fatalf("unexpected shard group count: got %d, exp 11", len(groups))
+	}
+}
+
+func TestMetaClient_PrecreateShardGroups_Cap(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{
+		Name:               "rp0",
+		ShardGroupDuration: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	if _, err := c.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.PrecreateShardGroups(now, now.Add(30*24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := c.ShardGroupsByTimeRange("db0", "rp0", now, now.Add(30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := meta.MaxPrecreatedShardGroups + 1; len(groups) != exp {
+		t.Fatalf("unexpected shard group count: got %d, exp %d", len(groups), exp)
+	}
+}
+
+func TestMetaClient_CreateShardGroup_Log(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+	core, logs := observer.New(zap.InfoLevel)
+	c.WithLogger(zap.New(core))
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{
+		Name:               "rp0",
+		ShardGroupDuration: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	sgi, err := c.CreateShardGroup("db0", "rp0", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := logs.FilterMessage("New shard group created")
+	if n := created.Len(); n != 1 {
+		t.Fatalf("unexpected creation logs: %d", n)
+	}
+	fields := created.All()[0].ContextMap()
+	if fields["db_instance"] != "db0" || fields["db_rp"] != "rp0" || fields["db_shard_group"] != sgi.ID {
+		t.Fatalf("unexpected fields: %v", fields)
+	} else if start, ok := fields["start"].(time.Time); !ok || !start.Equal(sgi.StartTime) {
+		t.Fatalf("unexpected start: %v", fields["start"])
+	} else if end, ok := fields["end"].(time.Time); !ok || !end.Equal(sgi.EndTime) {
+		t.Fatalf("unexpected end: %v", fields["end"])
+	} else if _, ok := fields["owners"]; !ok {
+		t.Fatalf("expected owners in %v", fields)
+	}
+
+	// Returning the existing group logs nothing.
+	if _, err := c.CreateShardGroup("db0", "rp0", now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if n := logs.FilterMessage("New shard group created").Len(); n != 1 {
+		t.Fatalf("unexpected creation logs after returning an existing group: %d", n)
+	}
+}
+
+func TestMetaClient_FreezeRetentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{
+		Name:               "rp0",
+		ShardGroupDuration: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	if _, err := c.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.FreezeRetentionPolicy("db0", "rp0", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes still find the existing shard group.
+	if sgi, err := c.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	} else if sgi == nil {
+		t.Fatal("expected the existing shard group")
+	}
+
+	// New shard groups are neither created nor precreated.
+	if _, err := c.CreateShardGroup("db0", "rp0", now.Add(time.Hour)); err != meta.ErrRetentionPolicyFrozen {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrRetentionPolicyFrozen)
+	}
+	if err := c.PrecreateShardGroups(now, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ := c.ShardGroupsByTimeRange("db0", "rp0", now, now.Add(24*time.Hour)); len(groups) != 1 {
+		t.Fatalf("unexpected shard group count while frozen: %d", len(groups))
+	}
+
+	// Creation resumes once the policy is unfrozen.
+	if err := c.FreezeRetentionPolicy("db0", "rp0", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PrecreateShardGroups(now, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ := c.ShardGroupsByTimeRange("db0", "rp0", now, now.Add(24*time.Hour)); len(groups) != 3 {
+		t.Fatalf("unexpected shard group count after unfreezing: %d", len(groups))
+	}
+}
+
+func TestMetaClient_PrecreateShardGroups_SingleCommit(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	now := time.Now().Truncate(time.Hour)
+	for i := 0; i < 20; i++ {
+		db := fmt.Sprintf("db%d", i)
+		if _, err := c.CreateDatabaseWithRetentionPolicy(db, &meta.RetentionPolicySpec{
+			Name:               "rp0",
+			ShardGroupDuration: time.Hour,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.CreateShardGroup(db, "rp0", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index := c.Data().Index
+	if err := c.PrecreateShardGroups(now, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Data().Index; got != index+1 {
+		t.Fatalf("unexpected commits: got %d, exp 1", got-index)
+	}
+	for i := 0; i < 20; i++ {
+		groups, err := c.ShardGroupsByTimeRange(fmt.Sprintf("db%d", i), "rp0", now, now.Add(24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(groups) != 3 {
+			t.Fatalf("unexpected shard group count for db%d: got %d, exp 3", i, len(groups))
+		}
+	}
+}
+
+func TestMetaClient_DefaultRetentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.DefaultRetentionPolicy("db0"); err == nil || err.Error() != cnosdb.ErrDatabaseNotFound("db0").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := c.DefaultRetentionPolicy("db0"); err != nil {
+		t.Fatal(err)
+	} else if name != "rp0" {
+		t.Fatalf("unexpected default retention policy: %s", name)
+	}
+
+	if _, err := c.CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp1"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetDefaultRetentionPolicy("db0", "rp1"); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := c.DefaultRetentionPolicy("db0"); err != nil {
+		t.Fatal(err)
+	} else if name != "rp1" {
+		t.Fatalf("unexpected default retention policy: %s", name)
+	}
+}
+
+func TestMetaClient_AllRetentionPolicies(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db1", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateRetentionPolicy("db1", &meta.RetentionPolicySpec{Name: "rp1"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, ref := range c.AllRetentionPolicies() {
+		got = append(got, ref.Database+"."+ref.Name)
+	}
+	if exp := []string{"db0.rp0", "db1.rp0", "db1.rp1"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected retention policies: got %v, exp %v", got, exp)
+	}
+}
+
+func TestMetaClient_SetClearPrivilegesOnAdmin(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"u0", "u1"} {
+		if _, err := c.CreateUser(name, "pass", false); err != nil {
+			t.Fatal(err)
+		} else if err := c.SetPrivilege(name, "db0", cnosql.WritePrivilege); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Grants are kept unless the client is told to clear them.
+	if err := c.SetAdminPrivilege("u0", true); err != nil {
+		t.Fatal(err)
+	} else if p, err := c.UserPrivileges("u0"); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 1 {
+		t.Fatalf("unexpected privileges of u0: %v", p)
+	}
+
+	c.SetClearPrivilegesOnAdmin(true)
+	if err := c.SetAdminPrivilege("u1", true); err != nil {
+		t.Fatal(err)
+	} else if p, err := c.UserPrivileges("u1"); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 0 {
+		t.Fatalf("unexpected privileges of u1: %v", p)
+	}
+
+	// Revoking admin leaves the user without grants.
+	if err := c.SetAdminPrivilege("u1", false); err != nil {
+		t.Fatal(err)
+	} else if p, err := c.UserPrivileges("u1"); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 0 {
+		t.Fatalf("unexpected privileges of u1 after revoking admin: %v", p)
+	}
+}
+
+func TestMetaClient_MarkShardGroupDeleted(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	old, err := c.CreateShardGroup("db0", "rp0", now.Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := c.CreateShardGroup("db0", "rp0", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One group is marked deleted beyond the expiration, the other just now.
+	expired := now.Add(meta.ShardGroupDeletedExpiration).Add(-time.Hour)
+	if err := c.MarkShardGroupDeleted("db0", "rp0", old.ID, expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkShardGroupDeleted("db0", "rp0", recent.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkShardGroupDeleted("db0", "rp0", 100, now); err != meta.ErrShardGroupNotFound {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrShardGroupNotFound)
+	}
+	if err := c.MarkShardGroupDeleted("db0", "rp0", recent.ID, time.Time{}); err != meta.ErrShardGroupDeletedAtRequired {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrShardGroupDeletedAtRequired)
+	}
+
+	rpi, err := c.RetentionPolicy("db0", "rp0")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rpi.ShardGroups) != 2 {
+		t.Fatalf("unexpected shard group count before prune: %d", len(rpi.ShardGroups))
+	} else if got := rpi.ShardGroups[0].DeletedAt; !got.Equal(expired) {
+		t.Fatalf("unexpected deleted at: got %s, exp %s", got, expired)
+	}
+
+	if err := c.PruneShardGroups(); err != nil {
+		t.Fatal(err)
+	}
+
+	rpi, _ = c.RetentionPolicy("db0", "rp0")
+	if len(rpi.ShardGroups) != 1 {
+		t.Fatalf("unexpected shard group count after prune: %d", len(rpi.ShardGroups))
+	} else if sg := rpi.ShardGroups[0]; sg.ID != recent.ID || !sg.Deleted() {
+		t.Fatalf("unexpected remaining shard group: %+v", sg)
+	}
+}
+
+func TestMetaClient_AcquireSnapshot(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, release := c.AcquireSnapshot()
+	defer release()
+	index := snap.Index
+
+	if _, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if snap.Index != index {
+		t.Fatalf("snapshot index changed: got %d, exp %d", snap.Index, index)
+	}
+	if snap.Database("db0") == nil {
+		t.Fatal("expected db0 in snapshot")
+	}
+	if snap.Database("db1") != nil {
+		t.Fatal("unexpected db1 in snapshot")
+	}
+
+	// The client itself sees the changes.
+	if c.Database("db0") != nil || c.Database("db1") == nil {
+		t.Fatal("client data not updated")
+	}
+
+	// Releasing more than once is harmless.
+	release()
+	release()
+}
+
+func TestMetaClient_CreateOrReplaceContinuousQuery(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	q0 := `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO foo_count FROM foo GROUP BY time(10m) END`
+	q1 := `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT mean(value) INTO foo_mean FROM foo GROUP BY time(10m) END`
+
+	// Create a new continuous query.
+	if err := c.CreateOrReplaceContinuousQuery("db0", "cq0", q0); err != nil {
+		t.Fatal(err)
+	}
+	db := c.Database("db0")
+	if len(db.ContinuousQueries) != 1 || db.ContinuousQueries[0].Query != q0 {
+		t.Fatalf("unexpected continuous queries: %+v", db.ContinuousQueries)
+	}
+
+	// An identical query is a no-op and does not commit.
+	index := c.Data().Index
+	if err := c.CreateOrReplaceContinuousQuery("db0", "cq0", q0); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Data().Index; got != index {
+		t.Fatalf("unexpected index: got %d, exp %d", got, index)
+	}
+
+	// A different query replaces the existing one.
+	if err := c.CreateOrReplaceContinuousQuery("db0", "cq0", q1); err != nil {
+		t.Fatal(err)
+	}
+	db = c.Database("db0")
+	if len(db.ContinuousQueries) != 1 || db.ContinuousQueries[0].Query != q1 {
+		t.Fatalf("unexpected continuous queries: %+v", db.ContinuousQueries)
+	}
+
+	// The query must be a valid CREATE CONTINUOUS QUERY statement.
+	if err := c.CreateOrReplaceContinuousQuery("db0", "cq0", "SELECT * FROM foo"); err != meta.ErrInvalidContinuousQuery {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrInvalidContinuousQuery)
+	}
+	if err := c.CreateOrReplaceContinuousQuery("db0", "cq0", "CREATE CONTINUOUS"); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if err := c.CreateOrReplaceContinuousQuery("db0", "cq1", q0); err != meta.ErrContinuousQueryMismatch {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrContinuousQueryMismatch)
+	}
+	if db := c.Database("db0"); db.ContinuousQueries[0].Query != q1 {
+		t.Fatalf("continuous query changed by invalid query: %s", db.ContinuousQueries[0].Query)
+	}
+}
+
+func TestMetaClient_DropDatabaseWithReport(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp1"}, false); err != nil {
+		t.Fatal(err)
+	}
+	sg0, err := c.CreateShardGroup("db0", "rp0", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg1, err := c.CreateShardGroup("db0", "rp1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateContinuousQuery("db0", "cq0", `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO foo_count FROM foo GROUP BY time(10m) END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateSubscription("db0", "rp1", "sub0", "ALL", []string{"udp://example.com:9090"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscription has to be acknowledged by forcing the drop.
+	if _, err := c.DropDatabaseWithReport("db0"); err == nil {
+		t.Fatal("expected error dropping a database with subscriptions")
+	} else if _, ok := err.(meta.ErrDatabaseHasSubscriptions); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	} else if c.Database("db0") == nil {
+		t.Fatal("database dropped")
+	}
+
+	report, err := c.DropDatabaseWithReportForce("db0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Database("db0") != nil {
+		t.Fatal("database not dropped")
+	}
+
+	if exp := []string{"rp0", "rp1"}; !reflect.DeepEqual(report.RetentionPolicies, exp) {
+		t.Errorf("unexpected retention policies: got %v, exp %v", report.RetentionPolicies, exp)
+	}
+	if exp := []uint64{sg0.ID, sg1.ID}; !reflect.DeepEqual(report.ShardGroupIDs, exp) {
+		t.Errorf("unexpected shard groups: got %v, exp %v", report.ShardGroupIDs, exp)
+	}
+	if exp := []uint64{sg0.Shards[0].ID, sg1.Shards[0].ID}; !reflect.DeepEqual(report.ShardIDs, exp) {
+		t.Errorf("unexpected shards: got %v, exp %v", report.ShardIDs, exp)
+	}
+	if exp := []string{"cq0"}; !reflect.DeepEqual(report.ContinuousQueries, exp) {
+		t.Errorf("unexpected continuous queries: got %v, exp %v", report.ContinuousQueries, exp)
+	}
+	if exp := map[string][]string{"rp1": {"sub0"}}; !reflect.DeepEqual(report.Subscriptions, exp) {
+		t.Errorf("unexpected subscriptions: got %v, exp %v", report.Subscriptions, exp)
+	}
+
+	// Dropping a missing database reports nothing.
+	if report, err := c.DropDatabaseWithReport("db0"); err != nil {
+		t.Fatal(err)
+	} else if len(report.RetentionPolicies) != 0 || len(report.ShardGroupIDs) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestMetaClient_DropDatabase_Subscriptions(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sub0", "sub1"} {
+		if err := c.CreateSubscription("db0", "rp0", name, "ALL", []string{"udp://example.com:9090"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Dropping is refused while the database has subscriptions.
+	exp := meta.ErrDatabaseHasSubscriptions{Database: "db0", Subscriptions: []string{"sub0", "sub1"}, RetentionPolicies: []string{"rp0", "rp0"}}
+	if err := c.DropDatabase("db0"); !reflect.DeepEqual(err, exp) {
+		t.Fatalf("unexpected error: got %v, exp %v", err, exp)
+	} else if msg := `database db0 has subscriptions sub0, sub1, drop them first: DROP SUBSCRIPTION sub0 ON db0.rp0; DROP SUBSCRIPTION sub1 ON db0.rp0`; err.Error() != msg {
+		t.Fatalf("unexpected message: %s", err)
+	} else if c.Database("db0") == nil {
+		t.Fatal("database dropped")
+	}
+
+	if err := c.DropDatabaseForce("db0"); err != nil {
+		t.Fatal(err)
+	} else if c.Database("db0") != nil {
+		t.Fatal("database not dropped")
+	}
+
+	// A database without subscriptions drops as before.
+	if _, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if err := c.DropDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if c.Database("db1") != nil {
+		t.Fatal("database not dropped")
+	}
+}
+
+func TestMetaClient_DropRetentionPolicyWithReport(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp1"}, false); err != nil {
+		t.Fatal(err)
+	}
+	var groups []*meta.ShardGroupInfo
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		sgi, err := c.CreateShardGroup("db0", "rp0", now.Add(time.Duration(i)*7*24*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		groups = append(groups, sgi)
+	}
+	if _, err := c.CreateShardGroup("db0", "rp1", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteShardGroup("db0", "rp0", groups[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := c.DropRetentionPolicyWithReport("db0", "rp0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []uint64{groups[1].ID, groups[2].ID}; !reflect.DeepEqual(report.ShardGroupIDs, exp) {
+		t.Errorf("unexpected shard groups: got %v, exp %v", report.ShardGroupIDs, exp)
+	}
+	if exp := []uint64{groups[1].Shards[0].ID, groups[2].Shards[0].ID}; !reflect.DeepEqual(report.ShardIDs, exp) {
+		t.Errorf("unexpected shards: got %v, exp %v", report.ShardIDs, exp)
+	}
+	if !report.Earliest.Equal(groups[1].StartTime) || !report.Latest.Equal(groups[2].EndTime) {
+		t.Errorf("unexpected time span: [%s, %s)", report.Earliest, report.Latest)
+	}
+
+	// The retention policy is left in place.
+	if rpi, err := c.RetentionPolicy("db0", "rp0"); err != nil || rpi == nil {
+		t.Fatalf("retention policy dropped: %v", err)
+	}
+
+	if _, err := c.DropRetentionPolicyWithReport("db0", "no_rp"); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
+
+func TestMetaClient_WatchShardGroups(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := c.WatchShardGroups(ctx)
+
+	sgi, err := c.CreateShardGroup("db0", "rp0", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteShardGroup("db0", "rp0", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []meta.ShardGroupEventType{meta.ShardGroupCreated, meta.ShardGroupDeleted} {
+		select {
+		case ev := <-events:
+			if ev.Type != exp || ev.Database != "db0" || ev.RetentionPolicy != "rp0" || ev.ShardGroup.ID != sgi.ID {
+				t.Fatalf("unexpected event: got %s %+v, exp %s", ev.Type, ev, exp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event", exp)
+		}
+	}
+
+	// The channel is closed once the context is canceled.
+	cancel()
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event after cancel: %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+
+	// Committing without a watcher must not block.
+	if _, err := c.CreateShardGroup("db0", "rp0", time.Now().Add(-24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the client stops the remaining watchers.
+	events = c.WatchShardGroups(context.Background())
+	c.Close()
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event after close: %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after client close")
+	}
+}
+
+func TestMetaClient_MetaBackupCount(t *testing.T) {
+	t.Parallel()
+
+	path := testTempDir()
+	defer os.RemoveAll(path)
+
+	config := meta.NewConfig()
+	config.Dir = path
+	config.MetaBackupCount = 3
+
+	c := meta.NewClient(config)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.CreateDatabase(fmt.Sprintf("db%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := meta.MetaBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(backups) != 3 {
+		t.Fatalf("unexpected backup count: got %d, exp 3", len(backups))
+	}
+
+	// The newest backup matches the current meta data.
+	b, err := ioutil.ReadFile(backups[len(backups)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data meta.Data
+	if err := data.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db4") == nil {
+		t.Fatal("newest backup is missing db4")
+	}
+}
+
+func TestMetaClient_ApplyConfig(t *testing.T) {
+	t.Parallel()
+
+	path := testTempDir()
+	defer os.RemoveAll(path)
+
+	config := meta.NewConfig()
+	config.Dir = path
+
+	c := meta.NewClient(config)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// An immutable or invalid setting rejects the whole config.
+	other := *config
+	other.Dir = path + "-other"
+	other.RetentionAutoCreate = false
+	if err := c.ApplyConfig(&other); err == nil || err.Error() != meta.ErrConfigImmutable("dir").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other = *config
+	other.SyncPolicy = "sometimes"
+	other.RetentionAutoCreate = false
+	if err := c.ApplyConfig(&other); err == nil {
+		t.Fatal("expected error for invalid sync policy")
+	}
+	if db, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if db.RetentionPolicy("autogen") == nil {
+		t.Fatal("rejected config was applied")
+	}
+
+	// The changed settings apply to the next operation.
+	other = *config
+	other.RetentionAutoCreate = false
+	other.MetaBackupCount = 2
+	other.SyncPolicy = meta.SyncEverySec
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	}
+	if db, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if len(db.RetentionPolicies) != 0 {
+		t.Fatalf("unexpected retention policies: %+v", db.RetentionPolicies)
+	}
+	if backups, err := meta.MetaBackups(path); err != nil {
+		t.Fatal(err)
+	} else if len(backups) != 1 {
+		t.Fatalf("unexpected backup count: got %d, exp 1", len(backups))
+	}
+
+	// A read-only client rejects changes until the setting is cleared.
+	other.ReadOnly = true
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateDatabase("db2"); err != meta.ErrMetaReadOnly {
+		t.Fatalf("unexpected error: %v", err)
+	} else if c.Database("db2") != nil {
+		t.Fatal("database created by a read-only client")
+	}
+	other.ReadOnly = false
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateDatabase("db2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMetaClient_ReserveShardIDs_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	const n, reservations = 3, 50
+	starts := make(chan uint64, reservations)
+	var wg sync.WaitGroup
+	for i := 0; i < reservations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start, err := c.ReserveShardIDs(n)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			starts <- start
+		}()
+	}
+	wg.Wait()
+	close(starts)
+
+	reserved := make(map[uint64]bool)
+	for start := range starts {
+		for id := start; id < start+n; id++ {
+			if reserved[id] {
+				t.Fatalf("shard id %d reserved twice", id)
+			}
+			reserved[id] = true
+		}
+	}
+	if len(reserved) != n*reservations {
+		t.Fatalf("unexpected reserved ids: %d", len(reserved))
+	} else if max := c.Data().MaxShardID; max != n*reservations {
+		t.Fatalf("unexpected max shard id: %d", max)
+	}
+}
+
+func TestMetaClient_SetDatabaseDefaultShardGroupDuration(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := c.SetDatabaseDefaultShardGroupDuration("db0", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new retention policy without a shard group duration inherits the
+	// database's default.
+	month := 30 * 24 * time.Hour
+	rpi, err := c.CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0", Duration: &month}, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if rpi.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", rpi.ShardGroupDuration)
+	} else if rpi, _ := c.RetentionPolicy("db0", "rp0"); rpi.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected stored shard group duration: %s", rpi.ShardGroupDuration)
+	}
+
+	// So does one created in a transaction, or as the first retention
+	// policy of an existing database.
+	if err := c.NewTransaction().CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp2", Duration: &month}, false).Commit(); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := c.RetentionPolicy("db0", "rp2"); rpi.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected shard group duration in transaction: %s", rpi.ShardGroupDuration)
+	}
+	if _, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if err := c.DropRetentionPolicy("db1", "autogen"); err != nil {
+		t.Fatal(err)
+	} else if err := c.SetDatabaseDefaultShardGroupDuration("db1", time.Hour); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.CreateDatabaseWithRetentionPolicy("db1", &meta.RetentionPolicySpec{Name: "rp0", Duration: &month}); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := c.RetentionPolicy("db1", "rp0"); rpi.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected shard group duration of existing database: %s", rpi.ShardGroupDuration)
+	}
+
+	// Without a default, it is inferred from the retention policy duration.
+	if err := c.SetDatabaseDefaultShardGroupDuration("db0", 0); err != nil {
+		t.Fatal(err)
+	}
+	if rpi, err := c.CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp1", Duration: &month}, false); err != nil {
+		t.Fatal(err)
+	} else if rpi.ShardGroupDuration != 24*time.Hour {
+		t.Fatalf("unexpected inferred shard group duration: %s", rpi.ShardGroupDuration)
+	}
+
+	if err := c.SetDatabaseDefaultShardGroupDuration("no_db", time.Hour); err == nil {
+		t.Fatal("expected error for missing database")
+	}
+}
+
+func TestMetaClient_MaintenanceMode(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetMaintenanceMode(true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.CreateDatabase("db1"); err != meta.ErrMaintenanceMode {
+		t.Fatalf("unexpected error creating database: %v", err)
+	} else if err := c.DropDatabase("db0"); err != meta.ErrMaintenanceMode {
+		t.Fatalf("unexpected error dropping database: %v", err)
+	} else if _, err := c.CreateUser("admin", "pass", true); err != meta.ErrMaintenanceMode {
+		t.Fatalf("unexpected error creating user: %v", err)
+	}
+
+	// Reads are still served.
+	if c.Database("db0") == nil {
+		t.Fatal("expected db0")
+	} else if c.Database("db1") != nil {
+		t.Fatal("expected db1 not to be created")
+	}
+
+	if err := c.SetMaintenanceMode(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if err := c.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newClient() (string, *meta.Client) {
+	path := testTempDir()
+	config := meta.NewConfig()
+	config.Dir = path
+
+	c := meta.NewClient(config)
+	if err := c.Open(); err != nil {
+		panic(err)
+	}
+	return path, c
+}
+
+func testTempDir() string {
+	dir, err := ioutil.TempDir("", "cnosdb-metaclient-test")
+	if err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+func TestMetaClient_ForEachUser(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	c.SetData(newUsersData(3))
+
+	type user struct {
+		name  string
+		admin bool
+	}
+	var got []user
+	c.ForEachUser(func(name string, admin bool) bool {
+		got = append(got, user{name, admin})
+		return true
+	})
+	if exp := []user{{"user0", true}, {"user1", false}, {"user2", false}}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected users: got %v, exp %v", got, exp)
+	}
+
+	// Returning false stops the iteration.
+	var n int
+	c.ForEachUser(func(name string, admin bool) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("unexpected calls after stopping: %d", n)
+	}
+}
+
+func BenchmarkMetaClient_Users(b *testing.B) {
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+	if err := c.SetData(newUsersData(10000)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		names := make([]string, 0, c.UserCount())
+		for _, u := range c.Users() {
+			names = append(names, u.Name)
+		}
+	}
+}
+
+func BenchmarkMetaClient_ForEachUser(b *testing.B) {
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+	if err := c.SetData(newUsersData(10000)); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		names := make([]string, 0, c.UserCount())
+		c.ForEachUser(func(name string, admin bool) bool {
+			names = append(names, name)
+			return true
+		})
+	}
+}
+
+// newUsersData returns meta data with n users, of which only the first is
+// an admin.
+func newUsersData(n int) *meta.Data {
+	data := &meta.Data{Index: 2}
+	for i := 0; i < n; i++ {
+		data.Users = append(data.Users, meta.UserInfo{Name: fmt.Sprintf("user%d", i), Hash: "hash", Admin: i == 0})
+	}
+	return data
+}
+
+func TestMetaClient_UserAuthz(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.CreateUser("admin", "pass", true); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.CreateUser("u0", "pass", false); err != nil {
+		t.Fatal(err)
+	} else if err := c.SetPrivilege("u0", "db0", cnosql.WritePrivilege); err != nil {
+		t.Fatal(err)
+	}
+
+	if authz, err := c.UserAuthz("admin"); err != nil {
+		t.Fatal(err)
+	} else if !authz.Admin {
+		t.Fatalf("expected admin: %+v", authz)
+	}
+	if authz, err := c.UserAuthz("u0"); err != nil {
+		t.Fatal(err)
+	} else if exp := map[string]cnosql.Privilege{"db0": cnosql.WritePrivilege}; authz.Admin || !reflect.DeepEqual(authz.Privileges, exp) {
+		t.Fatalf("unexpected authz: %+v", authz)
+	}
+	if _, err := c.UserAuthz("nobody"); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Every user is an admin with authentication disabled.
+	c.SetAuthEnabled(false)
+	if authz, err := c.UserAuthz("nobody"); err != nil {
+		t.Fatal(err)
+	} else if !authz.Admin {
+		t.Fatalf("expected admin with auth disabled: %+v", authz)
+	}
+}
+
+func TestMetaClient_SetAuthEnabled(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateUser("u0", "pass", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Authentication is enabled by default.
+	if _, err := c.Authenticate("u0", "wrong"); err != meta.ErrAuthenticate {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u, err := c.Authenticate("u0", "pass"); err != nil {
+		t.Fatal(err)
+	} else if u.(*meta.UserInfo).Admin {
+		t.Fatal("expected non-admin user")
+	}
+	if p, err := c.UserPrivilege("u0", "db0"); err != nil {
+		t.Fatal(err)
+	} else if *p != cnosql.NoPrivileges {
+		t.Fatalf("unexpected privilege: %s", p)
+	}
+
+	c.SetAuthEnabled(false)
+	u, err := c.Authenticate("nobody", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if ui := u.(*meta.UserInfo); !ui.Admin || ui.Name != "nobody" {
+		t.Fatalf("unexpected user: %+v", ui)
+	}
+	if p, err := c.UserPrivilege("u0", "db0"); err != nil {
+		t.Fatal(err)
+	} else if *p != cnosql.AllPrivileges {
+		t.Fatalf("unexpected privilege: %s", p)
+	}
+	// The stored grants, which REVOKE works from, are still reported.
+	if privs, err := c.UserPrivileges("u0"); err != nil {
+		t.Fatal(err)
+	} else if len(privs) != 0 {
+		t.Fatalf("unexpected stored privileges: %v", privs)
+	}
+
+	c.SetAuthEnabled(true)
+	if _, err := c.Authenticate("nobody", ""); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMetaClient_ShardGroupForTimestamp(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &meta.RetentionPolicySpec{
+		Name:               "rp0",
+		ShardGroupDuration: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Hour)
+	if _, err := c.ShardGroupForTimestamp("db0", "rp0", now); err != meta.ErrShardGroupNotFound {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrShardGroupNotFound)
+	}
+
+	sgi, err := c.CreateShardGroup("db0", "rp0", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ShardGroupForTimestamp("db0", "rp0", now.Add(30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	} else if got.ID != sgi.ID {
+		t.Fatalf("unexpected shard group: got %d, exp %d", got.ID, sgi.ID)
+	}
+
+	// The next hour has no group yet.
+	if _, err := c.ShardGroupForTimestamp("db0", "rp0", now.Add(time.Hour)); err != meta.ErrShardGroupNotFound {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrShardGroupNotFound)
+	}
+	if _, err := c.ShardGroupForTimestamp("db0", "rp1", now); err == nil || err == meta.ErrShardGroupNotFound {
+		t.Fatalf("unexpected error for missing retention policy: %v", err)
+	}
+}
+
+func TestMetaClient_CaseInsensitiveNames(t *testing.T) {
+	t.Parallel()
+
+	// Names are case-sensitive by default.
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	for _, name := range []string{"MyDB", "mydb"} {
+		if _, err := c.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(c.Databases()); n != 2 {
+		t.Fatalf("unexpected database count: %d", n)
+	}
+	if db := c.Database("MYDB"); db != nil {
+		t.Fatalf("unexpected database: %s", db.Name)
+	}
+
+	path := testTempDir()
+	defer os.RemoveAll(path)
+	config := meta.NewConfig()
+	config.Dir = path
+	config.CaseInsensitiveNames = true
+
+	ci := meta.NewClient(config)
+	if err := ci.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if db, err := ci.CreateDatabase("MyDB"); err != nil {
+		t.Fatal(err)
+	} else if db.Name != "mydb" {
+		t.Fatalf("unexpected database name: %s", db.Name)
+	}
+	if db, err := ci.CreateDatabase("MYDB"); err != nil {
+		t.Fatal(err)
+	} else if db.Name != "mydb" {
+		t.Fatalf("unexpected database name: %s", db.Name)
+	}
+	if n := len(ci.Databases()); n != 1 {
+		t.Fatalf("unexpected database count: %d", n)
+	}
+
+	if _, err := ci.CreateUser("u0", "pass", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ci.SetPrivilege("u0", "MyDb", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ci.UserPrivilege("u0", "MYDB"); err != nil {
+		t.Fatal(err)
+	} else if *p != cnosql.ReadPrivilege {
+		t.Fatalf("unexpected privilege: %s", p)
+	}
+	ci.Close()
+
+	// The setting survives reloading the data from disk.
+	ci = meta.NewClient(config)
+	if err := ci.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer ci.Close()
+	if db := ci.Database("MyDB"); db == nil {
+		t.Fatal("expected database to exist")
+	}
+	if err := ci.DropDatabase("MYDB"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ci.Databases()); n != 0 {
+		t.Fatalf("unexpected database count: %d", n)
+	}
+	if privs, err := ci.UserPrivileges("u0"); err != nil {
+		t.Fatal(err)
+	} else if len(privs) != 0 {
+		t.Fatalf("unexpected privileges: %v", privs)
+	}
+}
+
+func TestMetaClient_CreateDatabaseStrict(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	db, err := c.CreateDatabaseStrict("db0")
+	if err != nil {
+		t.Fatal(err)
+	} else if db.Name != "db0" || db.DefaultRetentionPolicy != meta.DefaultRetentionPolicyName {
+		t.Fatalf("unexpected database: %+v", db)
+	}
+
+	if _, err := c.CreateDatabaseStrict("db0"); err != meta.ErrDatabaseExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The lenient variant still returns the existing database.
+	if db, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if db.Name != "db0" {
+		t.Fatalf("unexpected database: %+v", db)
+	}
+}
+
+func TestMetaClient_Transaction(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+	defer c.Close()
+
+	// Record the data seen by a watcher after every change.
+	var seen []meta.Data
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	changed := c.WaitForDataChanged()
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-changed:
+				changed = c.WaitForDataChanged()
+				seen = append(seen, c.Data())
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	index := c.Data().Index
+	duration := 24 * time.Hour
+	err := c.NewTransaction().
+		CreateDatabase("db0").
+		CreateRetentionPolicy("db0", &meta.RetentionPolicySpec{Name: "rp0", Duration: &duration}, false).
+		SetDefaultRetentionPolicy("db0", "rp0").
+		Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := index + 1; c.Data().Index != exp {
+		t.Fatalf("unexpected index: got %d, exp %d", c.Data().Index, exp)
+	}
+
+	// A failure rolls back the whole transaction.
+	err = c.NewTransaction().
+		CreateDatabase("db1").
+		DropRetentionPolicy("db0", "rp0").
+		SetDefaultRetentionPolicy("db1", "missing").
+		Commit()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if c.Database("db1") != nil {
+		t.Fatal("expected db1 not to be created")
+	} else if rpi, _ := c.RetentionPolicy("db0", "rp0"); rpi == nil {
+		t.Fatal("expected rp0 not to be dropped")
+	} else if exp := index + 1; c.Data().Index != exp {
+		t.Fatalf("unexpected index: got %d, exp %d", c.Data().Index, exp)
+	}
+
+	// Invalid changes fail the commit without applying the others.
+	short := time.Minute
+	err = c.NewTransaction().
+		CreateDatabase("db2").
+		CreateRetentionPolicy("db2", &meta.RetentionPolicySpec{Name: "rp0", Duration: &short}, true).
+		Commit()
+	if err != meta.ErrRetentionPolicyDurationTooLow {
+		t.Fatalf("unexpected error: %v", err)
+	} else if c.Database("db2") != nil {
+		t.Fatal("expected db2 not to be created")
+	}
+
+	close(stop)
+	<-done
+	for _, data := range seen {
+		if di := data.Database("db0"); di != nil && di.DefaultRetentionPolicy != "rp0" {
+			t.Fatalf("observed intermediate state at index %d: %+v", data.Index, di)
+		} else if data.Database("db1") != nil || data.Database("db2") != nil {
+			t.Fatalf("observed rolled back database at index %d", data.Index)
+		}
+	}
+}
+
+func TestMetaClient_Close_Twice(t *testing.T) {
+	t.Parallel()
+
+	d, c := newClient()
+	defer os.RemoveAll(d)
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- c.Close() }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+package meta
+
+import (
+	"time"
+)
+
+// clock tells the current time. Tests replace it to control time-based behavior
+// such as lease and shard group expiry.
+type clock interface {
+	Now() time.Time
+}
+
+// realClock is a clock that returns the system time.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,101 @@
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_PruneShardGroups_Clock(t *testing.T) {
+	c, clk := newTestClockClient(t)
+	defer os.RemoveAll(c.path)
+	defer c.Close()
+
+	if _, err := c.CreateDatabaseWithRetentionPolicy("db0", &RetentionPolicySpec{Name: "rp0"}); err != nil {
+		t.Fatal(err)
+	}
+	sgi, err := c.CreateShardGroup("db0", "rp0", clk.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteShardGroup("db0", "rp0", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The group is kept until the deleted expiration has passed.
+	clk.add(-ShardGroupDeletedExpiration - time.Minute)
+	if err := c.PruneShardGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if rpi, _ := c.RetentionPolicy("db0", "rp0"); len(rpi.ShardGroups) != 1 {
+		t.Fatalf("shard group pruned before expiration")
+	}
+
+	clk.add(2 * time.Minute)
+	if err := c.PruneShardGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if rpi, _ := c.RetentionPolicy("db0", "rp0"); len(rpi.ShardGroups) != 0 {
+		t.Fatalf("shard group not pruned after expiration")
+	}
+}
+
+func TestClient_AcquireLease_Clock(t *testing.T) {
+	c, clk := newTestClockClient(t)
+	defer os.RemoveAll(c.path)
+	defer c.Close()
+
+	l, err := c.AcquireLease("cq")
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := clk.Now().Add(DefaultLeaseDuration); !l.Expiration.Equal(exp) {
+		t.Fatalf("unexpected expiration: got %s, exp %s", l.Expiration, exp)
+	}
+
+	clk.add(DefaultLeaseDuration + time.Second)
+	if !clk.Now().After(l.Expiration) {
+		t.Fatal("lease not expired")
+	}
+	if l2, err := c.AcquireLease("cq"); err != nil {
+		t.Fatal(err)
+	} else if !l2.Expiration.After(l.Expiration) {
+		t.Fatalf("lease not renewed: %s", l2.Expiration)
+	}
+}
+
+// testClock is a clock that only moves when told to.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestClockClient(t *testing.T) (*Client, *testClock) {
+	dir, err := ioutil.TempDir("", "cnosdb-meta-clock-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := NewConfig()
+	config.Dir = dir
+
+	c := NewClient(config)
+	clk := &testClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.setClock(clk)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return c, clk
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/cnosdb/cnosdb/pkg/logger"
+	itoml "github.com/cnosdb/cnosdb/vend/common/pkg/toml"
 
 	"github.com/BurntSushi/toml"
 	"go.uber.org/zap"
@@ -23,6 +24,25 @@ const (
 	DefaultLoggingEnabled = true
 )
 
+// Sync policies for meta.db, from most to least durable.
+const (
+	// SyncAlways fsyncs meta.db before a change is applied, so a change
+	// that returned survives a crash or power loss. Every change waits
+	// for the disk.
+	SyncAlways = "always"
+
+	// SyncEverySec writes meta.db on each change and fsyncs it in the
+	// background at most once a second. A power loss can lose the changes
+	// of the last second, and as meta.db is renamed into place before it
+	// is synced, it may leave the file empty or half written.
+	SyncEverySec = "everysec"
+
+	// SyncNone never fsyncs meta.db and leaves flushing it to the
+	// operating system. Only use it where the meta data can be rebuilt,
+	// such as in tests.
+	SyncNone = "none"
+)
+
 // Config represents the meta configuration.
 type Config struct {
 	Dir                 string `toml:"dir"`
@@ -30,12 +50,82 @@ type Config struct {
 	Hostname            string `toml:"hostname"`
 	HTTPD               *ServerConfig
 	Log                 *logger.Config
+
+	// CaseInsensitiveNames makes database names case-insensitive. New
+	// databases are created under their lower case name, so MyDB and mydb
+	// cannot coexist.
+	CaseInsensitiveNames bool `toml:"case-insensitive-names"`
+
+	// MetaBackupCount is the number of timestamped copies of meta.db kept
+	// in Dir, one written after each change. Zero disables backups.
+	MetaBackupCount int `toml:"meta-backup-count"`
+
+	// WALPath is the log a single-node meta client appends its data to
+	// after each change, for a standby to follow with TailWAL. Empty
+	// disables the WAL.
+	WALPath string `toml:"wal-path"`
+
+	// SyncPolicy is when meta.db is fsynced after a change: SyncAlways,
+	// SyncEverySec or SyncNone. Empty is SyncAlways.
+	SyncPolicy string `toml:"sync-policy"`
+
+	// LeaseMaxBackoff caps a remote client's wait between attempts to
+	// acquire a lease, and LeaseJitter is the fraction of each wait, between
+	// 0 and 1, that is randomized. Zero LeaseMaxBackoff removes the cap.
+	LeaseMaxBackoff itoml.Duration `toml:"lease-max-backoff"`
+	LeaseJitter     float64        `toml:"lease-jitter"`
+
+	// MinPollInterval is the least time between a remote client's requests
+	// for updates. Zero removes the limit.
+	MinPollInterval itoml.Duration `toml:"min-poll-interval"`
+
+	// AuthCacheDisabled makes a remote client check every password against
+	// its bcrypt hash instead of caching the credentials it has verified.
+	AuthCacheDisabled bool `toml:"auth-cache-disabled"`
+
+	// AuthCacheTTL is how long verified credentials stay cached. Zero keeps
+	// them until the password changes. AuthCacheSize is the most users whose
+	// credentials are cached, the oldest being dropped first. Zero means no
+	// limit.
+	AuthCacheTTL  itoml.Duration `toml:"auth-cache-ttl"`
+	AuthCacheSize int            `toml:"auth-cache-size"`
+
+	// Timeout bounds each attempt of a remote client to send a command to a
+	// metaserver. An attempt that runs out of time is retried like one that
+	// failed. Zero means no limit.
+	Timeout itoml.Duration `toml:"timeout"`
+
+	// MaxRetries is how many times a remote client retries a command that
+	// failed, waiting RetryInterval after each failure.
+	MaxRetries    int            `toml:"max-retries"`
+	RetryInterval itoml.Duration `toml:"retry-interval"`
+
+	// ReadOnly rejects every change to the meta data made through the
+	// client with ErrMetaReadOnly. Reads are still served.
+	ReadOnly bool `toml:"read-only"`
+
+	// TLSCertFile and TLSKeyFile are the PEM client certificate and key,
+	// and TLSCAFile the PEM CA certificates, a remote client connects to the
+	// metaservers with. Setting any of them makes the client use TLS.
+	TLSCertFile string `toml:"tls-cert-file"`
+	TLSKeyFile  string `toml:"tls-key-file"`
+	TLSCAFile   string `toml:"tls-ca-file"`
+
+	// TLSReloadInterval is how often a remote client reads the TLS files
+	// again, so that a rotated certificate is used without a restart. Zero
+	// disables reloading.
+	TLSReloadInterval itoml.Duration `toml:"tls-reload-interval"`
 }
 
 // NewConfig builds a new configuration with default values.
 func NewConfig() *Config {
 	return &Config{
 		RetentionAutoCreate: true,
+		SyncPolicy:          SyncAlways,
+		LeaseMaxBackoff:     itoml.Duration(DefaultLeaseMaxBackoff),
+		LeaseJitter:         DefaultLeaseJitter,
+		MaxRetries:          DefaultMaxRetries,
+		RetryInterval:       itoml.Duration(DefaultRetryInterval),
 	}
 }
 
@@ -111,5 +201,46 @@ func (c *Config) Validate() error {
 	if c.Dir == "" {
 		return errors.New("Meta.Dir must be specified")
 	}
+	return c.validateSettings()
+}
+
+// validateSettings checks the settings that can be changed while a client
+// is open.
+func (c *Config) validateSettings() error {
+	switch c.SyncPolicy {
+	case "", SyncAlways, SyncEverySec, SyncNone:
+	default:
+		return fmt.Errorf("unknown Meta.SyncPolicy %q", c.SyncPolicy)
+	}
+	if c.MetaBackupCount < 0 {
+		return errors.New("Meta.MetaBackupCount must not be negative")
+	}
+	if c.LeaseMaxBackoff < 0 {
+		return errors.New("Meta.LeaseMaxBackoff must not be negative")
+	}
+	if c.LeaseJitter < 0 || c.LeaseJitter > 1 {
+		return errors.New("Meta.LeaseJitter must be between 0 and 1")
+	}
+	if c.MinPollInterval < 0 {
+		return errors.New("Meta.MinPollInterval must not be negative")
+	}
+	if c.AuthCacheTTL < 0 {
+		return errors.New("Meta.AuthCacheTTL must not be negative")
+	}
+	if c.AuthCacheSize < 0 {
+		return errors.New("Meta.AuthCacheSize must not be negative")
+	}
+	if c.Timeout < 0 {
+		return errors.New("Meta.Timeout must not be negative")
+	}
+	if c.MaxRetries < 0 {
+		return errors.New("Meta.MaxRetries must not be negative")
+	}
+	if c.RetryInterval < 0 {
+		return errors.New("Meta.RetryInterval must not be negative")
+	}
+	if c.TLSReloadInterval < 0 {
+		return errors.New("Meta.TLSReloadInterval must not be negative")
+	}
 	return nil
 }
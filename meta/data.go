@@ -1,6 +1,7 @@
 package meta
 
 import (
+	"container/heap"
 	"errors"
 	"fmt"
 	"net"
@@ -38,6 +39,10 @@ const (
 	// MaxNameLen is the maximum length of a database or retention policy name.
 	// CnosDB uses the name for the directory name on disk.
 	MaxNameLen = 255
+
+	// DataVersion is the meta data format version written by this build.
+	// Nodes refuse to run against meta data with a newer version.
+	DataVersion = 1
 )
 
 // Data represents the top level collection of all metadata.
@@ -50,10 +55,23 @@ type Data struct {
 	Databases []DatabaseInfo
 	Users     []UserInfo
 
+	// Version is the format version the data was decoded from, or zero if
+	// it predates versioning. Data is always encoded as DataVersion.
+	Version uint64
+
+	// MaintenanceMode freezes the data. While it is set, every change other
+	// than clearing it is rejected with ErrMaintenanceMode.
+	MaintenanceMode bool
+
 	// adminUserExists provides a constant time mechanism for determining
 	// if there is at least one admin user.
 	adminUserExists bool
 
+	// caseInsensitiveNames folds the case of database names, so that
+	// MyDB and mydb name the same database. It is set from the meta
+	// config and is not persisted.
+	caseInsensitiveNames bool
+
 	MaxNodeID       uint64
 	MaxShardGroupID uint64
 	MaxShardID      uint64
@@ -193,6 +211,65 @@ func (data *Data) CreateDataNode(host, tcpHost string) error {
 	return nil
 }
 
+// HeartbeatDataNode records that the data node checked in at t. Heartbeats
+// that arrive out of order never move LastSeen back.
+func (data *Data) HeartbeatDataNode(id uint64, t time.Time) error {
+	n := data.DataNode(id)
+	if n == nil {
+		return ErrNodeNotFound
+	}
+	if t.After(n.LastSeen) {
+		n.LastSeen = t.UTC()
+	}
+	return nil
+}
+
+// DeadDataNodes returns the data nodes that have not checked in within
+// threshold of now, including those that never have.
+func (data *Data) DeadDataNodes(now time.Time, threshold time.Duration) []NodeInfo {
+	var a []NodeInfo
+	cutoff := now.Add(-threshold)
+	for _, n := range data.DataNodes {
+		if n.LastSeen.Before(cutoff) {
+			a = append(a, n)
+		}
+	}
+	return a
+}
+
+// SetDataNodeDraining sets whether the data node is draining. A draining node
+// keeps the shards it owns but is not assigned shards in new shard groups.
+func (data *Data) SetDataNodeDraining(id uint64, draining bool) error {
+	n := data.DataNode(id)
+	if n == nil {
+		return ErrNodeNotFound
+	}
+	n.Draining = draining
+	return nil
+}
+
+// DrainingDataNodes returns the data nodes that are draining.
+func (data *Data) DrainingDataNodes() []NodeInfo {
+	var a []NodeInfo
+	for _, n := range data.DataNodes {
+		if n.Draining {
+			a = append(a, n)
+		}
+	}
+	return a
+}
+
+// placementDataNodes returns the data nodes that new shards can be placed on.
+func (data *Data) placementDataNodes() []NodeInfo {
+	a := make([]NodeInfo, 0, len(data.DataNodes))
+	for _, n := range data.DataNodes {
+		if !n.Draining {
+			a = append(a, n)
+		}
+	}
+	return a
+}
+
 // setDataNode adds a data node with a pre-specified nodeID.
 // this should only be used when the cluster is upgrading from 0.9 to 0.10
 func (data *Data) setDataNode(nodeID uint64, host, tcpHost string) error {
@@ -219,6 +296,12 @@ func (data *Data) setDataNode(nodeID uint64, host, tcpHost string) error {
 // would otherwise become orphaned by the removal of the node from the
 // cluster.
 func (data *Data) DeleteDataNode(id uint64) error {
+	return data.deleteDataNode(id, time.Now())
+}
+
+// deleteDataNode is DeleteDataNode, marking a shard group left without an
+// owned shard deleted at now.
+func (data *Data) deleteDataNode(id uint64, now time.Time) error {
 	var nodes []NodeInfo
 
 	// Remove the data node from the store's list.
@@ -274,7 +357,7 @@ func (data *Data) DeleteDataNode(id uint64) error {
 				// Mark the shard group as deleted if it has no shards,
 				// or all of its shards are orphaned.
 				if len(rg.Shards) == 0 || len(orphanedShards) == len(rg.Shards) {
-					data.Databases[di].RetentionPolicies[ti].ShardGroups[ri].DeletedAt = time.Now().UTC()
+					data.Databases[di].RetentionPolicies[ti].ShardGroups[ri].DeletedAt = now.UTC()
 					continue
 				}
 
@@ -300,9 +383,51 @@ func (data *Data) DeleteDataNode(id uint64) error {
 			}
 		}
 	}
+	data.updateDataNodeLoad()
 	return nil
 }
 
+// DataNodeLoad returns the number of shards each data node owns in live
+// shard groups, keyed by node ID.
+func (data *Data) DataNodeLoad() map[uint64]int {
+	load := make(map[uint64]int, len(data.DataNodes))
+	for _, n := range data.DataNodes {
+		load[n.ID] = n.ShardCount
+	}
+	return load
+}
+
+// updateDataNodeLoad recounts the shards owned by each data node. It must be
+// called whenever shard groups or their owners change.
+func (data *Data) updateDataNodeLoad() {
+	if len(data.DataNodes) == 0 {
+		return
+	}
+
+	live := make(map[uint64]int)
+	pending := make(map[uint64]int)
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				counts := live
+				if sgi.Deleted() {
+					counts = pending
+				}
+				for _, sh := range sgi.Shards {
+					for _, o := range sh.Owners {
+						counts[o.NodeID]++
+					}
+				}
+			}
+		}
+	}
+
+	for i := range data.DataNodes {
+		n := &data.DataNodes[i]
+		n.ShardCount, n.PendingShards = live[n.ID], pending[n.ID]
+	}
+}
+
 // newShardOwner sets the owner of the provided shard to the data node
 // that currently owns the fewest number of shards. If multiple nodes
 // own the same (fewest) number of shards, then one of those nodes
@@ -332,41 +457,80 @@ func newShardOwner(s ShardInfo, ownerFreqs map[int]int) (uint64, error) {
 // Database returns a DatabaseInfo by the database name.
 func (data *Data) Database(name string) *DatabaseInfo {
 	for i := range data.Databases {
-		if data.Databases[i].Name == name {
+		if data.sameDatabaseName(data.Databases[i].Name, name) {
 			return &data.Databases[i]
 		}
 	}
 	return nil
 }
 
+// databaseName returns the name a new database is stored under: name
+// itself, or name in lower case if names are case-insensitive.
+func (data *Data) databaseName(name string) string {
+	if data.caseInsensitiveNames {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// sameDatabaseName returns true if a and b name the same database.
+func (data *Data) sameDatabaseName(a, b string) bool {
+	if data.caseInsensitiveNames {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
 // CreateDatabase creates a new database.
 // It returns an error if name is blank or if a database with the same name already exists.
+// If names are case-insensitive the database is created under the lower case
+// name, and a name differing only in case refers to the existing database.
 func (data *Data) CreateDatabase(name string) error {
 	if name == "" {
 		return ErrDatabaseNameRequired
 	} else if len(name) > MaxNameLen {
 		return ErrNameTooLong
+	} else if !ValidName(name) {
+		return ErrInvalidName
 	} else if data.Database(name) != nil {
 		return nil
 	}
 
 	// Append new node.
-	data.Databases = append(data.Databases, DatabaseInfo{Name: name})
+	data.Databases = append(data.Databases, DatabaseInfo{Name: data.databaseName(name)})
 
 	return nil
 }
 
 // DropDatabase removes a database by name. It does not return an error
-// if the database cannot be found.
+// if the database cannot be found. It returns ErrDatabaseHasSubscriptions if
+// the database has subscriptions; DropDatabaseForce drops it regardless.
 func (data *Data) DropDatabase(name string) error {
+	return data.dropDatabase(name, false)
+}
+
+// DropDatabaseForce removes a database by name along with its subscriptions.
+// It does not return an error if the database cannot be found.
+func (data *Data) DropDatabaseForce(name string) error {
+	return data.dropDatabase(name, true)
+}
+
+func (data *Data) dropDatabase(name string, force bool) error {
 	for i := range data.Databases {
-		if data.Databases[i].Name == name {
+		if data.sameDatabaseName(data.Databases[i].Name, name) {
+			if !force {
+				if err := data.Databases[i].CheckDrop(); err != nil {
+					return err
+				}
+			}
+			name = data.Databases[i].Name
 			data.Databases = append(data.Databases[:i], data.Databases[i+1:]...)
 
 			// Remove all user privileges associated with this database.
 			for i := range data.Users {
 				delete(data.Users[i].Privileges, name)
 			}
+			data.updateDataNodeLoad()
 			break
 		}
 	}
@@ -388,6 +552,24 @@ func (data *Data) RetentionPolicy(database, name string) (*RetentionPolicyInfo,
 	return nil, nil
 }
 
+// AllRetentionPolicies returns every retention policy of every database. The
+// policies share their shard groups with data, which must not be modified.
+func (data *Data) AllRetentionPolicies() []RetentionPolicyRef {
+	var n int
+	for i := range data.Databases {
+		n += len(data.Databases[i].RetentionPolicies)
+	}
+
+	a := make([]RetentionPolicyRef, 0, n)
+	for i := range data.Databases {
+		di := &data.Databases[i]
+		for _, rpi := range di.RetentionPolicies {
+			a = append(a, RetentionPolicyRef{Database: di.Name, RetentionPolicyInfo: rpi})
+		}
+	}
+	return a
+}
+
 // CreateRetentionPolicy creates a new retention policy on a database.
 // It returns an error if name is blank or if the database does not exist.
 func (data *Data) CreateRetentionPolicy(database string, rpi *RetentionPolicyInfo, makeDefault bool) error {
@@ -398,26 +580,35 @@ func (data *Data) CreateRetentionPolicy(database string, rpi *RetentionPolicyInf
 		return ErrRetentionPolicyNameRequired
 	} else if len(rpi.Name) > MaxNameLen {
 		return ErrNameTooLong
+	} else if !ValidName(rpi.Name) {
+		return ErrInvalidName
 	} else if rpi.ReplicaN < 1 {
 		return ErrReplicationFactorTooLow
+	} else if !data.canReplicate(rpi.ReplicaN) {
+		return ErrReplicaNTooHigh
+	} else if rpi.ShardsPerGroup < 0 {
+		return ErrInvalidShardsPerGroup
 	}
 
+	// Find database.
+	di := data.Database(database)
+
 	// Normalise ShardDuration before comparing to any existing
 	// retention policies. The client is supposed to do this, but
-	// do it again to verify input.
-	rpi.ShardGroupDuration = normalisedShardDuration(rpi.ShardGroupDuration, rpi.Duration)
+	// do it again to verify input. An unset duration is the
+	// database's default.
+	rpi.ShardGroupDuration = di.shardGroupDuration(rpi.ShardGroupDuration, rpi.Duration)
 
 	if rpi.Duration > 0 && rpi.Duration < rpi.ShardGroupDuration {
 		return ErrIncompatibleDurations
 	}
 
-	// Find database.
-	di := data.Database(database)
 	if di == nil {
 		return cnosdb.ErrDatabaseNotFound(database)
 	} else if rp := di.RetentionPolicy(rpi.Name); rp != nil {
 		// Retention policy with that name already exists. Make sure they're the same.
-		if rp.ReplicaN != rpi.ReplicaN || rp.Duration != rpi.Duration || rp.ShardGroupDuration != rpi.ShardGroupDuration {
+		if rp.ReplicaN != rpi.ReplicaN || rp.Duration != rpi.Duration || rp.ShardGroupDuration != rpi.ShardGroupDuration ||
+			!sameShardKeyTags(rp.ShardKeyTags, rpi.ShardKeyTags) || !sameTenantNodes(rp.TenantNodes, rpi.TenantNodes) {
 			return ErrRetentionPolicyExists
 		}
 		// if they want to make it default, and it's not the default, it's not an identical command so it's an error
@@ -425,6 +616,8 @@ func (data *Data) CreateRetentionPolicy(database string, rpi *RetentionPolicyInf
 			return ErrRetentionPolicyConflict
 		}
 		return nil
+	} else if max := di.Quota.MaxRetentionPolicies; max > 0 && len(di.RetentionPolicies) >= max {
+		return ErrQuotaExceeded
 	}
 
 	// Append copy of new retention policy.
@@ -451,6 +644,7 @@ func (data *Data) DropRetentionPolicy(database, name string) error {
 	for i := range di.RetentionPolicies {
 		if di.RetentionPolicies[i].Name == name {
 			di.RetentionPolicies = append(di.RetentionPolicies[:i], di.RetentionPolicies[i+1:]...)
+			data.updateDataNodeLoad()
 			break
 		}
 	}
@@ -458,12 +652,56 @@ func (data *Data) DropRetentionPolicy(database, name string) error {
 	return nil
 }
 
+// CopyRetentionPolicy creates a retention policy named dstName with the
+// settings of srcName. Shard groups and subscriptions are not copied.
+func (data *Data) CopyRetentionPolicy(database, srcName, dstName string) (*RetentionPolicyInfo, error) {
+	// Validate the new name.
+	if dstName == "" {
+		return nil, ErrRetentionPolicyNameRequired
+	} else if len(dstName) > MaxNameLen {
+		return nil, ErrNameTooLong
+	} else if !ValidName(dstName) {
+		return nil, ErrInvalidName
+	}
+
+	di := data.Database(database)
+	if di == nil {
+		return nil, cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	src := di.RetentionPolicy(srcName)
+	if src == nil {
+		return nil, cnosdb.ErrRetentionPolicyNotFound(srcName)
+	} else if di.RetentionPolicy(dstName) != nil {
+		return nil, ErrRetentionPolicyNameExists
+	} else if max := di.Quota.MaxRetentionPolicies; max > 0 && len(di.RetentionPolicies) >= max {
+		return nil, ErrQuotaExceeded
+	}
+
+	rpi := src.clone()
+	rpi.Name = dstName
+	rpi.ShardGroups = nil
+	rpi.Subscriptions = nil
+	di.RetentionPolicies = append(di.RetentionPolicies, rpi)
+
+	rpi = rpi.clone()
+	return &rpi, nil
+}
+
+// canReplicate returns true if there are enough data nodes to hold replicaN
+// copies of a shard. Without any data nodes, as with the single-node client,
+// there is nothing to check against.
+func (data *Data) canReplicate(replicaN int) bool {
+	return len(data.DataNodes) == 0 || replicaN <= len(data.DataNodes)
+}
+
 // RetentionPolicyUpdate represents retention policy fields to be updated.
 type RetentionPolicyUpdate struct {
 	Name               *string
 	Duration           *time.Duration
 	ReplicaN           *int
 	ShardGroupDuration *time.Duration
+	ShardsPerGroup     *int
 }
 
 // SetName sets the RetentionPolicyUpdate.Name.
@@ -478,6 +716,9 @@ func (rpu *RetentionPolicyUpdate) SetReplicaN(v int) { rpu.ReplicaN = &v }
 // SetShardGroupDuration sets the RetentionPolicyUpdate.ShardGroupDuration.
 func (rpu *RetentionPolicyUpdate) SetShardGroupDuration(v time.Duration) { rpu.ShardGroupDuration = &v }
 
+// SetShardsPerGroup sets the RetentionPolicyUpdate.ShardsPerGroup.
+func (rpu *RetentionPolicyUpdate) SetShardsPerGroup(v int) { rpu.ShardsPerGroup = &v }
+
 // UpdateRetentionPolicy updates an existing retention policy.
 func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error {
 	// Find database.
@@ -502,6 +743,14 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 		return ErrRetentionPolicyDurationTooLow
 	}
 
+	if rpu.ReplicaN != nil && !data.canReplicate(*rpu.ReplicaN) {
+		return ErrReplicaNTooHigh
+	}
+
+	if rpu.ShardsPerGroup != nil && *rpu.ShardsPerGroup < 1 {
+		return ErrInvalidShardsPerGroup
+	}
+
 	// Enforce duration is at least the shard duration
 	if (rpu.Duration != nil && *rpu.Duration > 0 &&
 		((rpu.ShardGroupDuration != nil && *rpu.Duration < *rpu.ShardGroupDuration) ||
@@ -524,6 +773,9 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 	if rpu.ShardGroupDuration != nil {
 		rpi.ShardGroupDuration = normalisedShardDuration(*rpu.ShardGroupDuration, rpi.Duration)
 	}
+	if rpu.ShardsPerGroup != nil {
+		rpi.ShardsPerGroup = *rpu.ShardsPerGroup
+	}
 
 	if di.DefaultRetentionPolicy != rpi.Name && makeDefault {
 		di.DefaultRetentionPolicy = rpi.Name
@@ -548,12 +800,127 @@ func (data *Data) SetDefaultRetentionPolicy(database, name string) error {
 	return nil
 }
 
+// RepairDefaultRetentionPolicy replaces the default retention policy of a
+// database if it names a policy that no longer exists, as it does after the
+// default policy is dropped. The policy named DefaultRetentionPolicyName is
+// preferred, then the first by name; if the database has no policies the
+// default is cleared. It returns whether the default was changed.
+func (data *Data) RepairDefaultRetentionPolicy(database string) (changed bool) {
+	di := data.Database(database)
+	if di == nil || di.DefaultRetentionPolicy == "" || di.RetentionPolicy(di.DefaultRetentionPolicy) != nil {
+		return false
+	}
+
+	var name string
+	for _, rpi := range di.RetentionPolicies {
+		if rpi.Name == DefaultRetentionPolicyName {
+			name = rpi.Name
+			break
+		} else if name == "" || rpi.Name < name {
+			name = rpi.Name
+		}
+	}
+	di.DefaultRetentionPolicy = name
+	return true
+}
+
+// repairDefaultRetentionPolicies repairs the default retention policy of every
+// database and returns the names of those whose default changed.
+func (data *Data) repairDefaultRetentionPolicies() []string {
+	var a []string
+	for _, di := range data.Databases {
+		if data.RepairDefaultRetentionPolicy(di.Name) {
+			a = append(a, di.Name)
+		}
+	}
+	return a
+}
+
+// SetDatabaseQuota sets the quota of a database. Objects that already exceed
+// the new quota are kept, but no more can be created.
+func (data *Data) SetDatabaseQuota(database string, q DatabaseQuota) error {
+	if q.MaxRetentionPolicies < 0 || q.MaxShardGroups < 0 {
+		return ErrInvalidQuota
+	}
+
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+	di.Quota = q
+	return nil
+}
+
+// SetDatabaseDefaultShardGroupDuration sets the shard group duration that new
+// retention policies on a database get when their spec doesn't set one. A
+// zero duration removes the default.
+func (data *Data) SetDatabaseDefaultShardGroupDuration(database string, d time.Duration) error {
+	if d < 0 {
+		return ErrShardGroupDurationInvalid
+	}
+
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+	di.DefaultShardGroupDuration = d
+	return nil
+}
+
+// FreezeRetentionPolicy sets whether new shard groups can be created in a
+// retention policy. Shard groups aren't precreated for a frozen policy.
+func (data *Data) FreezeRetentionPolicy(database, rp string, frozen bool) error {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+	rpi.Frozen = frozen
+	return nil
+}
+
+// SetMeasurementRetention overrides how long data for a measurement is kept in
+// a retention policy. A zero duration removes the override.
+func (data *Data) SetMeasurementRetention(database, rp, measurement string, d time.Duration) error {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+
+	if d < 0 || (rpi.Duration != 0 && d > rpi.Duration) {
+		return ErrMeasurementRetentionInvalid
+	}
+
+	if d == 0 {
+		delete(rpi.MeasurementRetention, measurement)
+		if len(rpi.MeasurementRetention) == 0 {
+			rpi.MeasurementRetention = nil
+		}
+		return nil
+	}
+
+	if rpi.MeasurementRetention == nil {
+		rpi.MeasurementRetention = make(map[string]time.Duration)
+	}
+	rpi.MeasurementRetention[measurement] = d
+	return nil
+}
+
 // DropShard removes a shard by ID.
 //
 // DropShard won't return an error if the shard can't be found, which
 // allows the command to be re-run in the case that the meta store
 // succeeds but a data node fails.
 func (data *Data) DropShard(id uint64) {
+	data.dropShard(id, time.Now())
+}
+
+// dropShard is DropShard, marking a shard group left without shards deleted
+// at now.
+func (data *Data) dropShard(id uint64, now time.Time) {
 	found := -1
 	for dbidx, dbi := range data.Databases {
 		for rpidx, rpi := range dbi.RetentionPolicies {
@@ -571,8 +938,9 @@ func (data *Data) DropShard(id uint64) {
 
 					if len(shards) == 1 {
 						// We just deleted the last shard in the shard group.
-						data.Databases[dbidx].RetentionPolicies[rpidx].ShardGroups[sgidx].DeletedAt = time.Now()
+						data.Databases[dbidx].RetentionPolicies[rpidx].ShardGroups[sgidx].DeletedAt = now.UTC()
 					}
+					data.updateDataNodeLoad()
 					return
 				}
 			}
@@ -619,6 +987,264 @@ func (data *Data) ShardGroupsByTimeRange(database, rp string, tmin, tmax time.Ti
 	return groups, nil
 }
 
+// HealthyShardGroupsByTimeRange is like ShardGroupsByTimeRange, but leaves out
+// shard groups that no node can serve because every owner of every shard has
+// not checked in within threshold of now.
+func (data *Data) HealthyShardGroupsByTimeRange(database, rp string, tmin, tmax, now time.Time, threshold time.Duration) ([]ShardGroupInfo, error) {
+	groups, err := data.ShardGroupsByTimeRange(database, rp, tmin, tmax)
+	if err != nil {
+		return nil, err
+	}
+
+	dead := make(map[uint64]struct{})
+	for _, n := range data.DeadDataNodes(now, threshold) {
+		dead[n.ID] = struct{}{}
+	}
+	if len(dead) == 0 {
+		return groups, nil
+	}
+
+	healthy := groups[:0]
+	for _, g := range groups {
+		if g.hasLiveOwner(dead) {
+			healthy = append(healthy, g)
+		}
+	}
+	return healthy, nil
+}
+
+// AllShardGroupsByTimeRange returns the live shard groups of every database and
+// retention policy that may contain data for the specified time range, sorted
+// by start time.
+func (data *Data) AllShardGroupsByTimeRange(tmin, tmax time.Time) ([]ShardGroupRef, error) {
+	var a []ShardGroupRef
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() || !sgi.Overlaps(tmin, tmax) {
+					continue
+				}
+				a = append(a, ShardGroupRef{
+					Database:        di.Name,
+					RetentionPolicy: rpi.Name,
+					ShardGroup:      sgi.clone(),
+				})
+			}
+		}
+	}
+	sort.SliceStable(a, func(i, j int) bool {
+		return a[i].ShardGroup.StartTime.Before(a[j].ShardGroup.StartTime)
+	})
+	return a, nil
+}
+
+// ShardIDsPage returns up to limit shard IDs greater than afterID in ascending
+// order, and the cursor for the next page. The cursor is zero on the last page.
+func (data *Data) ShardIDsPage(afterID uint64, limit int) ([]uint64, uint64, error) {
+	if limit <= 0 {
+		return nil, 0, ErrInvalidPageLimit
+	}
+
+	// Keep only the limit smallest IDs, so that a page doesn't collect and
+	// sort every shard ID after the cursor.
+	var h shardIDHeap
+	var more bool
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				for _, si := range sgi.Shards {
+					if si.ID <= afterID {
+						continue
+					} else if len(h) < limit {
+						heap.Push(&h, si.ID)
+						continue
+					}
+					more = true
+					if si.ID < h[0] {
+						h[0] = si.ID
+						heap.Fix(&h, 0)
+					}
+				}
+			}
+		}
+	}
+
+	a := []uint64(h)
+	sort.Sort(uint64Slice(a))
+	if !more {
+		return a, 0, nil
+	}
+	return a, a[len(a)-1], nil
+}
+
+// shardIDHeap is a max-heap of shard IDs.
+type shardIDHeap []uint64
+
+func (h shardIDHeap) Len() int            { return len(h) }
+func (h shardIDHeap) Less(i, j int) bool  { return h[i] > h[j] }
+func (h shardIDHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *shardIDHeap) Push(x interface{}) { *h = append(*h, x.(uint64)) }
+
+func (h *shardIDHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// ShardGroupsForWrite returns the shard groups that overlap the time range and
+// have not yet expired under the retention policy duration. ErrShardGroupsExpired
+// is returned if the whole range is beyond the retention policy duration.
+func (data *Data) ShardGroupsForWrite(database, rp string, tmin, tmax time.Time) ([]ShardGroupInfo, error) {
+	return data.shardGroupsForWrite(database, rp, tmin, tmax, time.Now())
+}
+
+func (data *Data) shardGroupsForWrite(database, rp string, tmin, tmax, now time.Time) ([]ShardGroupInfo, error) {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return nil, err
+	} else if rpi == nil {
+		return nil, cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+
+	// A zero duration means data is kept forever.
+	var expiredBefore time.Time
+	if rpi.Duration != 0 {
+		expiredBefore = now.Add(-rpi.Duration)
+		if tmax.Before(expiredBefore) {
+			return nil, ErrShardGroupsExpired
+		}
+	}
+
+	groups := make([]ShardGroupInfo, 0, len(rpi.ShardGroups))
+	for _, g := range rpi.ShardGroups {
+		if g.Deleted() || !g.Overlaps(tmin, tmax) || g.EndTime.Before(expiredBefore) {
+			continue
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// RetentionPolicyUsage returns the shard group and shard counts and the time span
+// covered by the live shard groups of a retention policy.
+func (data *Data) RetentionPolicyUsage(database, rp string) (RPUsage, error) {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return RPUsage{}, err
+	} else if rpi == nil {
+		return RPUsage{}, cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+	return rpi.Usage(), nil
+}
+
+// ShardGroupTimeline returns when each shard group of a retention policy,
+// including deleted groups, was created and deleted, sorted by creation
+// time. It returns nil if the database or retention policy doesn't exist.
+func (data *Data) ShardGroupTimeline(database, rp string) []ShardGroupTimelineEntry {
+	rpi, _ := data.RetentionPolicy(database, rp)
+	if rpi == nil {
+		return nil
+	}
+
+	entries := make([]ShardGroupTimelineEntry, 0, len(rpi.ShardGroups))
+	for i := range rpi.ShardGroups {
+		sgi := &rpi.ShardGroups[i]
+		entries = append(entries, ShardGroupTimelineEntry{
+			ID:        sgi.ID,
+			CreatedAt: sgi.StartTime,
+			DeletedAt: sgi.DeletedAt,
+			ShardN:    len(sgi.Shards),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries
+}
+
+// ShardGroupBoundaries returns the time boundaries of every shard group on a
+// database and retention policy, including deleted groups, sorted by start time.
+func (data *Data) ShardGroupBoundaries(database, rp string) ([]ShardGroupBoundary, error) {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return nil, err
+	} else if rpi == nil {
+		return nil, cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+
+	a := make([]ShardGroupBoundary, 0, len(rpi.ShardGroups))
+	for i := range rpi.ShardGroups {
+		sgi := &rpi.ShardGroups[i]
+		a = append(a, ShardGroupBoundary{
+			ID:        sgi.ID,
+			StartTime: sgi.StartTime,
+			EndTime:   sgi.EndTime,
+			Deleted:   sgi.Deleted(),
+		})
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].StartTime.Equal(a[j].StartTime) {
+			return a[i].ID < a[j].ID
+		}
+		return a[i].StartTime.Before(a[j].StartTime)
+	})
+	return a, nil
+}
+
+// ShardGroupBoundaryFor returns the time range of the shard group that
+// CreateShardGroup would create for t on a database and retention policy,
+// aligned to the policy's shard group duration. It does not check whether a
+// group already contains t.
+func (data *Data) ShardGroupBoundaryFor(database, rp string, t time.Time) (start, end time.Time, err error) {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	} else if rpi == nil {
+		return time.Time{}, time.Time{}, cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+	start, end = shardGroupBounds(t, rpi.ShardGroupDuration)
+	return start, end, nil
+}
+
+// MissingShardGroups returns, for the timestamps of a batch of writes to a
+// database and retention policy, the start time of each shard group that
+// CreateShardGroup would have to create for them, once per group and in
+// ascending order. Timestamps that fall in an existing group are left out.
+// It returns nil if the retention policy doesn't exist.
+func (data *Data) MissingShardGroups(database, rp string, timestamps []time.Time) []time.Time {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil || rpi == nil {
+		return nil
+	}
+
+	var missing []time.Time
+	seen := make(map[int64]struct{})
+	for _, t := range timestamps {
+		if rpi.ShardGroupByTimestamp(t) != nil {
+			continue
+		}
+		start, _ := shardGroupBounds(t, rpi.ShardGroupDuration)
+		if _, ok := seen[start.UnixNano()]; ok {
+			continue
+		}
+		seen[start.UnixNano()] = struct{}{}
+		missing = append(missing, start)
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Before(missing[j]) })
+	return missing
+}
+
+// shardGroupBounds returns the start and end of the shard group of duration d
+// that contains t.
+func shardGroupBounds(t time.Time, d time.Duration) (start, end time.Time) {
+	start = t.Truncate(d).UTC()
+	end = start.Add(d).UTC()
+	if end.After(time.Unix(0, models.MaxNanoTime)) {
+		// ShardGroup range is [start, end) so add one to the max time.
+		end = time.Unix(0, models.MaxNanoTime+1)
+	}
+	return start, end
+}
+
 // ShardGroupByTimestamp returns the shard group on a database and retention policy for a given timestamp.
 func (data *Data) ShardGroupByTimestamp(database, rp string, timestamp time.Time) (*ShardGroupInfo, error) {
 	// Find retention policy.
@@ -632,6 +1258,19 @@ func (data *Data) ShardGroupByTimestamp(database, rp string, timestamp time.Time
 	return rpi.ShardGroupByTimestamp(timestamp), nil
 }
 
+// shardGroupForTimestamp returns a copy of the shard group containing
+// timestamp, or ErrShardGroupNotFound if there is none.
+func (data *Data) shardGroupForTimestamp(database, rp string, timestamp time.Time) (*ShardGroupInfo, error) {
+	sgi, err := data.ShardGroupByTimestamp(database, rp, timestamp)
+	if err != nil {
+		return nil, err
+	} else if sgi == nil {
+		return nil, ErrShardGroupNotFound
+	}
+	other := sgi.clone()
+	return &other, nil
+}
+
 // CreateShardGroup creates a shard group on a database and retention policy for a given timestamp.
 func (data *Data) CreateShardGroupDeprecated(database, rp string, timestamp time.Time) error {
 	// Find retention policy.
@@ -651,12 +1290,7 @@ func (data *Data) CreateShardGroupDeprecated(database, rp string, timestamp time
 	data.MaxShardGroupID++
 	sgi := ShardGroupInfo{}
 	sgi.ID = data.MaxShardGroupID
-	sgi.StartTime = timestamp.Truncate(rpi.ShardGroupDuration).UTC()
-	sgi.EndTime = sgi.StartTime.Add(rpi.ShardGroupDuration).UTC()
-	if sgi.EndTime.After(time.Unix(0, models.MaxNanoTime)) {
-		// ShardGroup range is [start, end) so add one to the max time.
-		sgi.EndTime = time.Unix(0, models.MaxNanoTime+1)
-	}
+	sgi.StartTime, sgi.EndTime = shardGroupBounds(timestamp, rpi.ShardGroupDuration)
 
 	data.MaxShardID++
 	sgi.Shards = []ShardInfo{
@@ -674,8 +1308,14 @@ func (data *Data) CreateShardGroupDeprecated(database, rp string, timestamp time
 
 // CreateShardGroup creates a shard group on a database and retention policy for a given timestamp.
 func (data *Data) CreateShardGroup(database, rp string, timestamp time.Time) error {
+	// Draining nodes take no new shards.
+	nodes := data.placementDataNodes()
+	if len(nodes) == 0 && len(data.DataNodes) > 0 {
+		return ErrNodesRequired
+	}
+
 	singleMode := false
-	dataNodeCount := len(data.DataNodes)
+	dataNodeCount := len(nodes)
 	if dataNodeCount == 0 {
 		dataNodeCount = 1
 		singleMode = true
@@ -694,6 +1334,14 @@ func (data *Data) CreateShardGroup(database, rp string, timestamp time.Time) err
 		return nil
 	}
 
+	if rpi.Frozen {
+		return ErrRetentionPolicyFrozen
+	}
+
+	if di := data.Database(database); di.Quota.MaxShardGroups > 0 && di.liveShardGroupN() >= di.Quota.MaxShardGroups {
+		return ErrQuotaExceeded
+	}
+
 	// Require at least one replica but no more replicas than nodes.
 	replicaN := rpi.ReplicaN
 	if replicaN == 0 {
@@ -707,16 +1355,17 @@ func (data *Data) CreateShardGroup(database, rp string, timestamp time.Time) err
 	// replicated the correct number of times.
 	shardN := dataNodeCount / replicaN
 
+	// The retention policy may ask for more shards to spread writes over.
+	// Round robin below still spreads their owners evenly.
+	if n := rpi.shardsPerGroup(); n > shardN {
+		shardN = n
+	}
+
 	// Create the shard group.
 	data.MaxShardGroupID++
 	sgi := ShardGroupInfo{}
 	sgi.ID = data.MaxShardGroupID
-	sgi.StartTime = timestamp.Truncate(rpi.ShardGroupDuration).UTC()
-	sgi.EndTime = sgi.StartTime.Add(rpi.ShardGroupDuration).UTC()
-	if sgi.EndTime.After(time.Unix(0, models.MaxNanoTime)) {
-		// ShardGroup range is [start, end) so add one to the max time.
-		sgi.EndTime = time.Unix(0, models.MaxNanoTime+1)
-	}
+	sgi.StartTime, sgi.EndTime = shardGroupBounds(timestamp, rpi.ShardGroupDuration)
 
 	data.MaxShardID++
 	sgi.Shards = []ShardInfo{
@@ -738,17 +1387,9 @@ func (data *Data) CreateShardGroup(database, rp string, timestamp time.Time) err
 			}
 		}
 	} else {
-		// Assign data nodes to shards via round robin.
 		// Start from a repeatably "random" place in the node list.
-		nodeIndex := int(data.Index % uint64(dataNodeCount))
-		for i := range sgi.Shards {
-			si := &sgi.Shards[i]
-			for j := 0; j < replicaN; j++ {
-				nodeID := data.DataNodes[nodeIndex%dataNodeCount].ID
-				si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
-				nodeIndex++
-			}
-		}
+		AssignShardOwners(sgi.Shards, nodes, replicaN, int(data.Index%uint64(dataNodeCount)))
+		sgi.Shards = append(sgi.Shards, data.newTenantShards(rpi, nodes)...)
 	}
 
 	// Retention policy has a new shard group, so update the retention policy. ShardGroups
@@ -756,12 +1397,278 @@ func (data *Data) CreateShardGroup(database, rp string, timestamp time.Time) err
 	// assume this to be the case.
 	rpi.ShardGroups = append(rpi.ShardGroups, sgi)
 	sort.Sort(ShardGroupInfos(rpi.ShardGroups))
+	data.updateDataNodeLoad()
+
+	return nil
+}
+
+// newTenantShards returns the shards of a new shard group in rpi that are
+// placed for its tenants, ordered by tenant. Each tenant gets a shard per
+// replica set of its nodes, owned only by those nodes; nodes that are not
+// among nodes are left out. Tenants with no such node get no shards.
+func (data *Data) newTenantShards(rpi *RetentionPolicyInfo, nodes []NodeInfo) []ShardInfo {
+	if len(rpi.TenantNodes) == 0 {
+		return nil
+	}
+
+	tenants := make([]string, 0, len(rpi.TenantNodes))
+	for tenant := range rpi.TenantNodes {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	var a []ShardInfo
+	for _, tenant := range tenants {
+		var group []NodeInfo
+		for _, id := range rpi.TenantNodes[tenant] {
+			for _, n := range nodes {
+				if n.ID == id {
+					group = append(group, n)
+					break
+				}
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		replicaN := rpi.ReplicaN
+		if replicaN == 0 {
+			replicaN = 1
+		} else if replicaN > len(group) {
+			replicaN = len(group)
+		}
+
+		shards := make([]ShardInfo, len(group)/replicaN)
+		for i := range shards {
+			data.MaxShardID++
+			shards[i] = ShardInfo{ID: data.MaxShardID, Tenant: tenant}
+		}
+		AssignShardOwners(shards, group, replicaN, int(data.Index%uint64(len(group))))
+		a = append(a, shards...)
+	}
+	return a
+}
+
+// AssignShardOwners replaces the owners of shards with replicaN data nodes
+// each, assigned round robin over nodes starting at the node at index start.
+func AssignShardOwners(shards []ShardInfo, nodes []NodeInfo, replicaN, start int) {
+	nodeIndex := start
+	for i := range shards {
+		si := &shards[i]
+		si.Owners = make([]ShardOwner, 0, replicaN)
+		for j := 0; j < replicaN; j++ {
+			si.Owners = append(si.Owners, ShardOwner{NodeID: nodes[nodeIndex%len(nodes)].ID})
+			nodeIndex++
+		}
+	}
+}
+
+// ReserveShardIDs advances the shard ID counter by n and returns the first of
+// the n IDs reserved. No shard created afterwards will use a reserved ID.
+func (data *Data) ReserveShardIDs(n int) (uint64, error) {
+	if n <= 0 {
+		return 0, ErrInvalidShardIDCount
+	}
+	start := data.MaxShardID + 1
+	data.MaxShardID += uint64(n)
+	return start, nil
+}
+
+// RebalanceShardGroup reassigns the owners of the shards in a live shard
+// group over the data nodes that take new shards, starting with the nodes
+// that own the fewest shards outside the group, so that nodes added since the
+// group was created get a share of it. Only the meta data changes; copying the
+// shards to their new owners is left to the caller.
+func (data *Data) RebalanceShardGroup(database, rp string, id uint64) error {
+	rpi, err := data.RetentionPolicy(database, rp)
+	if err != nil {
+		return err
+	} else if rpi == nil {
+		return cnosdb.ErrRetentionPolicyNotFound(rp)
+	}
+
+	var sgi *ShardGroupInfo
+	for i := range rpi.ShardGroups {
+		if rpi.ShardGroups[i].ID == id && !rpi.ShardGroups[i].Deleted() {
+			sgi = &rpi.ShardGroups[i]
+			break
+		}
+	}
+	if sgi == nil {
+		return ErrShardGroupNotFound
+	}
+
+	nodes := data.placementDataNodes()
+	if len(nodes) == 0 {
+		return ErrNodesRequired
+	}
+
+	// Count the live shards each node owns outside this group.
+	load := make(map[uint64]int, len(nodes))
+	for _, di := range data.Databases {
+		for _, rp := range di.RetentionPolicies {
+			for _, g := range rp.ShardGroups {
+				if g.Deleted() || g.ID == id {
+					continue
+				}
+				for _, si := range g.Shards {
+					for _, o := range si.Owners {
+						load[o.NodeID]++
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if load[nodes[i].ID] != load[nodes[j].ID] {
+			return load[nodes[i].ID] < load[nodes[j].ID]
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	replicaN := rpi.ReplicaN
+	if replicaN == 0 {
+		replicaN = 1
+	} else if replicaN > len(nodes) {
+		replicaN = len(nodes)
+	}
+
+	// Shards placed for tenants stay on their tenants' nodes.
+	_, n := sgi.tenantShards("")
+	AssignShardOwners(sgi.Shards[:n], nodes, replicaN, 0)
+	data.updateDataNodeLoad()
 
 	return nil
 }
 
+// UnderReplicatedShards returns the shards in live shard groups that have
+// fewer owners than their retention policy's replica factor.
+func (data *Data) UnderReplicatedShards() []UnderReplicatedShard {
+	var a []UnderReplicatedShard
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					if len(si.Owners) >= rpi.ReplicaN {
+						continue
+					}
+					owners := make([]uint64, len(si.Owners))
+					for i, o := range si.Owners {
+						owners[i] = o.NodeID
+					}
+					a = append(a, UnderReplicatedShard{
+						Database:        di.Name,
+						RetentionPolicy: rpi.Name,
+						ShardGroupID:    sgi.ID,
+						ShardID:         si.ID,
+						Owners:          owners,
+						ReplicaN:        rpi.ReplicaN,
+					})
+				}
+			}
+		}
+	}
+	return a
+}
+
+// DetectOverlappingShardGroups returns every pair of live shard groups in the
+// same retention policy whose time ranges overlap. A truncated group is taken
+// to end when it was truncated.
+func (data *Data) DetectOverlappingShardGroups() []OverlapReport {
+	var a []OverlapReport
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			var groups []*ShardGroupInfo
+			for i := range rpi.ShardGroups {
+				if sgi := &rpi.ShardGroups[i]; !sgi.Deleted() {
+					groups = append(groups, sgi)
+				}
+			}
+			sort.SliceStable(groups, func(i, j int) bool {
+				return groups[i].StartTime.Before(groups[j].StartTime)
+			})
+
+			for i, sgi := range groups {
+				end := sgi.EndTime
+				if sgi.Truncated() && sgi.TruncatedAt.Before(end) {
+					end = sgi.TruncatedAt
+				}
+				// Groups are sorted by start, so only the ones that start
+				// before this one ends can overlap it.
+				for _, other := range groups[i+1:] {
+					if !other.StartTime.Before(end) {
+						break
+					}
+					a = append(a, OverlapReport{
+						Database:        di.Name,
+						RetentionPolicy: rpi.Name,
+						ShardGroupIDs:   [2]uint64{sgi.ID, other.ID},
+					})
+				}
+			}
+		}
+	}
+	return a
+}
+
+// ShardsOwnedBy returns the shards in live shard groups that are owned by the
+// given data node.
+func (data *Data) ShardsOwnedBy(nodeID uint64) []NodeShard {
+	var a []NodeShard
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					if !si.OwnedBy(nodeID) {
+						continue
+					}
+					a = append(a, NodeShard{
+						Database:        di.Name,
+						RetentionPolicy: rpi.Name,
+						ShardGroupID:    sgi.ID,
+						ShardInfo:       si.clone(),
+					})
+				}
+			}
+		}
+	}
+	return a
+}
+
+// createShardGroups creates a shard group for each request. Requests for
+// groups that already exist are ignored. A request that fails, for example
+// because its retention policy was dropped, is skipped so the other groups
+// are still created; the skipped requests are returned with their errors.
+func (data *Data) createShardGroups(reqs []shardGroupRequest) []shardGroupFailure {
+	var failed []shardGroupFailure
+	for _, req := range reqs {
+		if err := data.CreateShardGroup(req.database, req.rp, req.timestamp); err != nil {
+			failed = append(failed, shardGroupFailure{req: req, err: err})
+		}
+	}
+	return failed
+}
+
 // DeleteShardGroup removes a shard group from a database and retention policy by id.
 func (data *Data) DeleteShardGroup(database, rp string, id uint64) error {
+	return data.MarkShardGroupDeleted(database, rp, id, time.Now())
+}
+
+// MarkShardGroupDeleted sets the deletion timestamp of a shard group to at.
+// The group is kept until it is pruned after ShardGroupDeletedExpiration.
+// A zero at would leave the group live, so it is rejected.
+func (data *Data) MarkShardGroupDeleted(database, rp string, id uint64, at time.Time) error {
+	if at.IsZero() {
+		return ErrShardGroupDeletedAtRequired
+	}
+
 	// Find retention policy.
 	rpi, err := data.RetentionPolicy(database, rp)
 	if err != nil {
@@ -773,7 +1680,8 @@ func (data *Data) DeleteShardGroup(database, rp string, id uint64) error {
 	// Find shard group by ID and set its deletion timestamp.
 	for i := range rpi.ShardGroups {
 		if rpi.ShardGroups[i].ID == id {
-			rpi.ShardGroups[i].DeletedAt = time.Now().UTC()
+			rpi.ShardGroups[i].DeletedAt = at.UTC()
+			data.updateDataNodeLoad()
 			return nil
 		}
 	}
@@ -810,6 +1718,66 @@ func (data *Data) CreateContinuousQuery(database, name, query string) error {
 	return nil
 }
 
+// CreateOrReplaceContinuousQuery adds a named continuous query to a database,
+// replacing the query of an existing continuous query with the same name.
+func (data *Data) CreateOrReplaceContinuousQuery(database, name, query string) error {
+	stmt, err := validateContinuousQuery(query)
+	if err != nil {
+		return err
+	}
+	if stmt.Name != name || !data.sameDatabaseName(stmt.Database, database) {
+		return ErrContinuousQueryMismatch
+	}
+
+	di := data.Database(database)
+	if di == nil {
+		return cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	for i := range di.ContinuousQueries {
+		if di.ContinuousQueries[i].Name == name {
+			di.ContinuousQueries[i].Query = query
+			return nil
+		}
+	}
+
+	di.ContinuousQueries = append(di.ContinuousQueries, ContinuousQueryInfo{
+		Name:  name,
+		Query: query,
+	})
+
+	return nil
+}
+
+// hasContinuousQuery returns true if database has a continuous query with the
+// given name and exactly the given query.
+func (data *Data) hasContinuousQuery(database, name, query string) bool {
+	di := data.Database(database)
+	if di == nil {
+		return false
+	}
+	for _, cq := range di.ContinuousQueries {
+		if cq.Name == name {
+			return cq.Query == query
+		}
+	}
+	return false
+}
+
+// validateContinuousQuery parses query as a CREATE CONTINUOUS QUERY
+// statement, returning an error if it isn't one.
+func validateContinuousQuery(query string) (*cnosql.CreateContinuousQueryStatement, error) {
+	stmt, err := cnosql.ParseStatement(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", ErrInvalidContinuousQuery, err)
+	}
+	cq, ok := stmt.(*cnosql.CreateContinuousQueryStatement)
+	if !ok {
+		return nil, ErrInvalidContinuousQuery
+	}
+	return cq, nil
+}
+
 // DropContinuousQuery removes a continuous query.
 func (data *Data) DropContinuousQuery(database, name string) error {
 	di := data.Database(database)
@@ -974,14 +1942,15 @@ func (data *Data) SetPrivilege(name, database string, p cnosql.Privilege) error
 		return ErrUserNotFound
 	}
 
-	if data.Database(database) == nil {
+	di := data.Database(database)
+	if di == nil {
 		return cnosdb.ErrDatabaseNotFound(database)
 	}
 
 	if ui.Privileges == nil {
 		ui.Privileges = make(map[string]cnosql.Privilege)
 	}
-	ui.Privileges[database] = p
+	ui.Privileges[di.Name] = p
 
 	return nil
 }
@@ -1001,35 +1970,103 @@ func (data *Data) SetAdminPrivilege(name string, admin bool) error {
 	return nil
 }
 
-// AdminUserExists returns true if an admin user exists.
-func (data Data) AdminUserExists() bool {
-	return data.adminUserExists
+// ClearPrivileges removes every per-database privilege granted to a user.
+func (data *Data) ClearPrivileges(name string) error {
+	ui := data.user(name)
+	if ui == nil {
+		return ErrUserNotFound
+	}
+
+	ui.Privileges = nil
+	return nil
+}
+
+// AdminUserExists returns true if an admin user exists.
+func (data Data) AdminUserExists() bool {
+	return data.adminUserExists
+}
+
+// UserPrivileges gets the privileges for a user.
+func (data *Data) UserPrivileges(name string) (map[string]cnosql.Privilege, error) {
+	ui := data.user(name)
+	if ui == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return ui.Privileges, nil
+}
+
+// UserAuthz returns whether a user is an admin together with a copy of its
+// privileges.
+func (data *Data) UserAuthz(name string) (UserAuthz, error) {
+	ui := data.user(name)
+	if ui == nil {
+		return UserAuthz{}, ErrUserNotFound
+	}
+
+	authz := UserAuthz{Admin: ui.Admin}
+	if ui.Privileges != nil {
+		authz.Privileges = make(map[string]cnosql.Privilege, len(ui.Privileges))
+		for db, p := range ui.Privileges {
+			authz.Privileges[db] = p
+		}
+	}
+	return authz, nil
+}
+
+// UserPrivilege gets the privilege for a user on a database.
+func (data *Data) UserPrivilege(name, database string) (*cnosql.Privilege, error) {
+	ui := data.user(name)
+	if ui == nil {
+		return nil, ErrUserNotFound
+	}
+
+	for db, p := range ui.Privileges {
+		if data.sameDatabaseName(db, database) {
+			return &p, nil
+		}
+	}
+
+	return cnosql.NewPrivilege(cnosql.NoPrivileges), nil
 }
 
-// UserPrivileges gets the privileges for a user.
-func (data *Data) UserPrivileges(name string) (map[string]cnosql.Privilege, error) {
+// EffectivePrivilege returns the privilege the user actually has on the database:
+// AllPrivileges for an admin, or the explicit grant otherwise.
+func (data *Data) EffectivePrivilege(name, database string) (cnosql.Privilege, error) {
 	ui := data.user(name)
 	if ui == nil {
-		return nil, ErrUserNotFound
+		return cnosql.NoPrivileges, ErrUserNotFound
+	} else if ui.Admin {
+		return cnosql.AllPrivileges, nil
 	}
 
-	return ui.Privileges, nil
+	if p, ok := ui.Privileges[database]; ok {
+		return p, nil
+	}
+	if di := data.Database(database); di != nil {
+		if p, ok := ui.Privileges[di.Name]; ok {
+			return p, nil
+		}
+	}
+	return cnosql.NoPrivileges, nil
 }
 
-// UserPrivilege gets the privilege for a user on a database.
-func (data *Data) UserPrivilege(name, database string) (*cnosql.Privilege, error) {
+// AccessibleDatabases returns the sorted names of the databases the user has
+// any privilege on, which is every database for an admin.
+func (data *Data) AccessibleDatabases(name string) ([]string, error) {
 	ui := data.user(name)
 	if ui == nil {
 		return nil, ErrUserNotFound
 	}
 
-	for db, p := range ui.Privileges {
-		if db == database {
-			return &p, nil
+	names := make([]string, 0, len(data.Databases))
+	for _, di := range data.Databases {
+		if p, _ := data.EffectivePrivilege(name, di.Name); p != cnosql.NoPrivileges {
+			names = append(names, di.Name)
 		}
 	}
-
-	return cnosql.NewPrivilege(cnosql.NoPrivileges), nil
+	sort.Strings(names)
+	return names, nil
 }
 
 // Clone returns a copy of data with a new version.
@@ -1068,6 +2105,49 @@ func (data *Data) Clone() *Data {
 	return &other
 }
 
+// preserveNodes replaces the cluster identity and node lists of data with
+// those of current, so that restoring data doesn't change cluster membership.
+// Shard owners are remapped to the current node with the same TCP address;
+// owners with no such node are dropped, leaving the shard under-replicated.
+func (data *Data) preserveNodes(current *Data) {
+	nodes := current.Clone()
+
+	byHost := make(map[string]uint64, len(nodes.DataNodes))
+	for _, n := range nodes.DataNodes {
+		byHost[n.TCPHost] = n.ID
+	}
+	remap := make(map[uint64]uint64, len(data.DataNodes))
+	for _, n := range data.DataNodes {
+		if id, ok := byHost[n.TCPHost]; ok {
+			remap[n.ID] = id
+		}
+	}
+
+	for i := range data.Databases {
+		for j := range data.Databases[i].RetentionPolicies {
+			rpi := &data.Databases[i].RetentionPolicies[j]
+			for k := range rpi.ShardGroups {
+				for l := range rpi.ShardGroups[k].Shards {
+					si := &rpi.ShardGroups[k].Shards[l]
+					remapped := ShardInfo{ID: si.ID}
+					for _, o := range si.Owners {
+						if id, ok := remap[o.NodeID]; ok && !remapped.OwnedBy(id) {
+							remapped.Owners = append(remapped.Owners, ShardOwner{NodeID: id})
+						}
+					}
+					si.Owners = remapped.Owners
+				}
+			}
+		}
+	}
+
+	data.ClusterID = nodes.ClusterID
+	data.MetaNodes = nodes.MetaNodes
+	data.DataNodes = nodes.DataNodes
+	data.MaxNodeID = nodes.MaxNodeID
+	data.updateDataNodeLoad()
+}
+
 // marshal serializes data to a protobuf representation.
 func (data *Data) marshal() *internal.Data {
 	pb := &internal.Data{
@@ -1078,6 +2158,10 @@ func (data *Data) marshal() *internal.Data {
 		MaxNodeID:       proto.Uint64(data.MaxNodeID),
 		MaxShardGroupID: proto.Uint64(data.MaxShardGroupID),
 		MaxShardID:      proto.Uint64(data.MaxShardID),
+
+		Version: proto.Uint64(DataVersion),
+
+		MaintenanceMode: proto.Bool(data.MaintenanceMode),
 	}
 
 	pb.DataNodes = make([]*internal.NodeInfo, len(data.DataNodes))
@@ -1112,6 +2196,8 @@ func (data *Data) unmarshal(pb *internal.Data) {
 	data.MaxNodeID = pb.GetMaxNodeID()
 	data.MaxShardGroupID = pb.GetMaxShardGroupID()
 	data.MaxShardID = pb.GetMaxShardID()
+	data.Version = pb.GetVersion()
+	data.MaintenanceMode = pb.GetMaintenanceMode()
 
 	data.DataNodes = make([]NodeInfo, len(pb.GetDataNodes()))
 	for i, x := range pb.GetDataNodes() {
@@ -1284,7 +2370,8 @@ func (data *Data) importOneDB(other Data, backupDBName, restoreDBName, backupRPN
 				// to avoid any issues if they ever export this DB again to bring back to Enterprise.
 				// sgImport.Shards[k].Owners = []ShardOwner{}
 
-				dataNodeCount := len(data.DataNodes)
+				nodes := data.placementDataNodes()
+				dataNodeCount := len(nodes)
 				if dataNodeCount == 0 {
 					dataNodeCount = 1
 				}
@@ -1292,13 +2379,14 @@ func (data *Data) importOneDB(other Data, backupDBName, restoreDBName, backupRPN
 					sgImport.Shards[k].Owners = []ShardOwner{{NodeID: 0}}
 				} else {
 					nodeIndex := int(data.Index % uint64(dataNodeCount))
-					nodeID := data.DataNodes[nodeIndex%dataNodeCount].ID
+					nodeID := nodes[nodeIndex%dataNodeCount].ID
 					sgImport.Shards[k].Owners = []ShardOwner{{NodeID: nodeID}}
 					nodeIndex++
 				}
 			}
 		}
 	}
+	data.updateDataNodeLoad()
 
 	return restoreDBName, nil
 }
@@ -1308,6 +2396,19 @@ type NodeInfo struct {
 	ID      uint64
 	Host    string
 	TCPHost string
+
+	// ShardCount is the number of shards the node owns in live shard groups.
+	ShardCount int
+	// PendingShards is the number of shards the node owns in shard groups
+	// that are marked deleted but not yet pruned.
+	PendingShards int
+
+	// LastSeen is the time of the last heartbeat from the node, or zero if
+	// it has never sent one.
+	LastSeen time.Time
+
+	// Draining is set while the node is being emptied before removal.
+	Draining bool
 }
 
 // NodeInfos is a slice of NodeInfo used for sorting
@@ -1331,6 +2432,18 @@ func (n NodeInfo) marshal() *internal.NodeInfo {
 	pb.ID = proto.Uint64(n.ID)
 	pb.Host = proto.String(n.Host)
 	pb.TCPHost = proto.String(n.TCPHost)
+	if n.ShardCount != 0 {
+		pb.ShardCount = proto.Uint32(uint32(n.ShardCount))
+	}
+	if n.PendingShards != 0 {
+		pb.PendingShards = proto.Uint32(uint32(n.PendingShards))
+	}
+	if !n.LastSeen.IsZero() {
+		pb.LastSeen = proto.Int64(n.LastSeen.UnixNano())
+	}
+	if n.Draining {
+		pb.Draining = proto.Bool(true)
+	}
 	return pb
 }
 
@@ -1339,6 +2452,12 @@ func (n *NodeInfo) unmarshal(pb *internal.NodeInfo) {
 	n.ID = pb.GetID()
 	n.Host = pb.GetHost()
 	n.TCPHost = pb.GetTCPHost()
+	n.ShardCount = int(pb.GetShardCount())
+	n.PendingShards = int(pb.GetPendingShards())
+	if pb.LastSeen != nil {
+		n.LastSeen = time.Unix(0, pb.GetLastSeen()).UTC()
+	}
+	n.Draining = pb.GetDraining()
 }
 
 // DatabaseInfo represents information about a database in the system.
@@ -1347,6 +2466,33 @@ type DatabaseInfo struct {
 	DefaultRetentionPolicy string
 	RetentionPolicies      []RetentionPolicyInfo
 	ContinuousQueries      []ContinuousQueryInfo
+	Quota                  DatabaseQuota
+
+	// DefaultShardGroupDuration is the shard group duration of new
+	// retention policies that don't specify one. If zero, it is inferred
+	// from the retention policy duration.
+	DefaultShardGroupDuration time.Duration
+}
+
+// DatabaseQuota limits the objects that can be created in a database. A zero
+// limit means unlimited.
+type DatabaseQuota struct {
+	MaxRetentionPolicies int
+	MaxShardGroups       int
+}
+
+// marshal serializes to a protobuf representation.
+func (q DatabaseQuota) marshal() *internal.DatabaseQuota {
+	return &internal.DatabaseQuota{
+		MaxRetentionPolicies: proto.Uint32(uint32(q.MaxRetentionPolicies)),
+		MaxShardGroups:       proto.Uint32(uint32(q.MaxShardGroups)),
+	}
+}
+
+// unmarshal deserializes from a protobuf representation.
+func (q *DatabaseQuota) unmarshal(pb *internal.DatabaseQuota) {
+	q.MaxRetentionPolicies = int(pb.GetMaxRetentionPolicies())
+	q.MaxShardGroups = int(pb.GetMaxShardGroups())
 }
 
 // RetentionPolicy returns a retention policy by name.
@@ -1391,6 +2537,118 @@ func (di DatabaseInfo) ShardInfos() []ShardInfo {
 	return infos
 }
 
+// DropReport lists everything removed from the meta data by dropping a
+// database or retention policy.
+type DropReport struct {
+	Database          string
+	RetentionPolicies []string
+	ShardGroupIDs     []uint64
+	ShardIDs          []uint64
+	ContinuousQueries []string
+
+	// Subscriptions maps a retention policy name to the names of its subscriptions.
+	Subscriptions map[string][]string
+
+	// Earliest and Latest bound the data held by the live shard groups.
+	Earliest time.Time
+	Latest   time.Time
+}
+
+// extendSpan widens the reported time span to cover u.
+func (r *DropReport) extendSpan(u RPUsage) {
+	if u.ShardGroupN == 0 {
+		return
+	}
+	if r.Earliest.IsZero() || u.Earliest.Before(r.Earliest) {
+		r.Earliest = u.Earliest
+	}
+	if u.Latest.After(r.Latest) {
+		r.Latest = u.Latest
+	}
+}
+
+// CheckDrop returns ErrDatabaseHasSubscriptions if di has subscriptions, which
+// dropping it without force is refused for.
+func (di DatabaseInfo) CheckDrop() error {
+	var names, rps []string
+	for _, rpi := range di.RetentionPolicies {
+		for _, sub := range rpi.Subscriptions {
+			names = append(names, sub.Name)
+			rps = append(rps, rpi.Name)
+		}
+	}
+	if len(names) > 0 {
+		return ErrDatabaseHasSubscriptions{Database: di.Name, Subscriptions: names, RetentionPolicies: rps}
+	}
+	return nil
+}
+
+// dropReport returns the objects nested under di.
+func (di DatabaseInfo) dropReport() DropReport {
+	r := DropReport{Database: di.Name, Subscriptions: make(map[string][]string)}
+	for _, rpi := range di.RetentionPolicies {
+		r.RetentionPolicies = append(r.RetentionPolicies, rpi.Name)
+		r.extendSpan(rpi.Usage())
+		for _, sgi := range rpi.ShardGroups {
+			r.ShardGroupIDs = append(r.ShardGroupIDs, sgi.ID)
+			for _, si := range sgi.Shards {
+				r.ShardIDs = append(r.ShardIDs, si.ID)
+			}
+		}
+		for _, sub := range rpi.Subscriptions {
+			r.Subscriptions[rpi.Name] = append(r.Subscriptions[rpi.Name], sub.Name)
+		}
+	}
+	for _, cq := range di.ContinuousQueries {
+		r.ContinuousQueries = append(r.ContinuousQueries, cq.Name)
+	}
+	return r
+}
+
+// dropRetentionPolicyReport returns the live shard groups and shards and the
+// subscriptions that dropping a retention policy would remove.
+func (data *Data) dropRetentionPolicyReport(database, name string) (DropReport, error) {
+	rpi, err := data.RetentionPolicy(database, name)
+	if err != nil {
+		return DropReport{}, err
+	} else if rpi == nil {
+		return DropReport{}, cnosdb.ErrRetentionPolicyNotFound(name)
+	}
+
+	r := DropReport{
+		Database:          database,
+		RetentionPolicies: []string{rpi.Name},
+		Subscriptions:     make(map[string][]string),
+	}
+	r.extendSpan(rpi.Usage())
+	for _, sgi := range rpi.ShardGroups {
+		if sgi.Deleted() {
+			continue
+		}
+		r.ShardGroupIDs = append(r.ShardGroupIDs, sgi.ID)
+		for _, si := range sgi.Shards {
+			r.ShardIDs = append(r.ShardIDs, si.ID)
+		}
+	}
+	for _, sub := range rpi.Subscriptions {
+		r.Subscriptions[rpi.Name] = append(r.Subscriptions[rpi.Name], sub.Name)
+	}
+	return r, nil
+}
+
+// liveShardGroupN returns the number of shard groups in di that are not deleted.
+func (di *DatabaseInfo) liveShardGroupN() int {
+	var n int
+	for i := range di.RetentionPolicies {
+		for j := range di.RetentionPolicies[i].ShardGroups {
+			if !di.RetentionPolicies[i].ShardGroups[j].Deleted() {
+				n++
+			}
+		}
+	}
+	return n
+}
+
 // clone returns a deep copy of di.
 func (di DatabaseInfo) clone() DatabaseInfo {
 	other := di
@@ -1428,6 +2686,13 @@ func (di DatabaseInfo) marshal() *internal.DatabaseInfo {
 	for i := range di.ContinuousQueries {
 		pb.ContinuousQueries[i] = di.ContinuousQueries[i].marshal()
 	}
+
+	if di.Quota != (DatabaseQuota{}) {
+		pb.Quota = di.Quota.marshal()
+	}
+	if di.DefaultShardGroupDuration != 0 {
+		pb.DefaultShardGroupDuration = proto.Int64(int64(di.DefaultShardGroupDuration))
+	}
 	return pb
 }
 
@@ -1449,6 +2714,11 @@ func (di *DatabaseInfo) unmarshal(pb *internal.DatabaseInfo) {
 			di.ContinuousQueries[i].unmarshal(x)
 		}
 	}
+
+	if pb.Quota != nil {
+		di.Quota.unmarshal(pb.GetQuota())
+	}
+	di.DefaultShardGroupDuration = time.Duration(pb.GetDefaultShardGroupDuration())
 }
 
 // RetentionPolicySpec represents the specification for a new retention policy.
@@ -1457,6 +2727,9 @@ type RetentionPolicySpec struct {
 	ReplicaN           *int
 	Duration           *time.Duration
 	ShardGroupDuration time.Duration
+	ShardsPerGroup     *int
+	ShardKeyTags       []string
+	TenantNodes        map[string][]uint64
 }
 
 // NewRetentionPolicyInfo creates a new retention policy info from the specification.
@@ -1464,6 +2737,37 @@ func (s *RetentionPolicySpec) NewRetentionPolicyInfo() *RetentionPolicyInfo {
 	return DefaultRetentionPolicyInfo().Apply(s)
 }
 
+// NewRetentionPolicyInfo creates a new retention policy info from spec for
+// the database. If spec doesn't set a shard group duration, the database's
+// default is used, unless it is longer than the retention policy duration,
+// in which case the duration is inferred as usual.
+func (di *DatabaseInfo) NewRetentionPolicyInfo(spec *RetentionPolicySpec) *RetentionPolicyInfo {
+	rpi := spec.NewRetentionPolicyInfo()
+	rpi.ShardGroupDuration = di.shardGroupDuration(spec.ShardGroupDuration, rpi.Duration)
+	return rpi
+}
+
+// shardGroupDuration returns the normalised shard group duration of a new
+// retention policy in di with shard group duration sgd and duration d. If sgd
+// is zero, the database's default is used unless it is longer than d.
+func (di *DatabaseInfo) shardGroupDuration(sgd, d time.Duration) time.Duration {
+	if sgd == 0 && di != nil && di.DefaultShardGroupDuration != 0 {
+		if def := normalisedShardDuration(di.DefaultShardGroupDuration, d); d == 0 || def <= d {
+			return def
+		}
+	}
+	return normalisedShardDuration(sgd, d)
+}
+
+// commandRetentionPolicyInfo returns the retention policy info that a command
+// creating a policy from s carries. Unless s sets it, the shard group duration
+// is left zero for the metaservice to resolve against the database's default.
+func (s *RetentionPolicySpec) commandRetentionPolicyInfo() *RetentionPolicyInfo {
+	rpi := s.NewRetentionPolicyInfo()
+	rpi.ShardGroupDuration = s.ShardGroupDuration
+	return rpi
+}
+
 // Matches checks if this retention policy specification matches
 // an existing retention policy.
 func (s *RetentionPolicySpec) Matches(rpi *RetentionPolicyInfo) bool {
@@ -1475,6 +2779,10 @@ func (s *RetentionPolicySpec) Matches(rpi *RetentionPolicyInfo) bool {
 		return false
 	} else if s.ReplicaN != nil && *s.ReplicaN != rpi.ReplicaN {
 		return false
+	} else if s.ShardsPerGroup != nil && *s.ShardsPerGroup != rpi.shardsPerGroup() {
+		return false
+	} else if !s.matchesTenancy(rpi) {
+		return false
 	}
 
 	// Normalise ShardDuration before comparing to any existing retention policy.
@@ -1484,6 +2792,15 @@ func (s *RetentionPolicySpec) Matches(rpi *RetentionPolicyInfo) bool {
 	return sgDuration == rpi.ShardGroupDuration
 }
 
+// matchesTenancy returns true if the shard key tags and tenant nodes that s
+// sets, if any, are those of rpi.
+func (s *RetentionPolicySpec) matchesTenancy(rpi *RetentionPolicyInfo) bool {
+	if s.ShardKeyTags != nil && !sameShardKeyTags(s.ShardKeyTags, rpi.ShardKeyTags) {
+		return false
+	}
+	return s.TenantNodes == nil || sameTenantNodes(s.TenantNodes, rpi.TenantNodes)
+}
+
 // marshal serializes to a protobuf representation.
 func (s *RetentionPolicySpec) marshal() *internal.RetentionPolicySpec {
 	pb := &internal.RetentionPolicySpec{}
@@ -1499,6 +2816,11 @@ func (s *RetentionPolicySpec) marshal() *internal.RetentionPolicySpec {
 	if s.ReplicaN != nil {
 		pb.ReplicaN = proto.Uint32(uint32(*s.ReplicaN))
 	}
+	if s.ShardsPerGroup != nil {
+		pb.ShardsPerGroup = proto.Uint32(uint32(*s.ShardsPerGroup))
+	}
+	pb.ShardKeyTags = s.ShardKeyTags
+	pb.TenantNodes = marshalTenantNodes(s.TenantNodes)
 	return pb
 }
 
@@ -1518,6 +2840,14 @@ func (s *RetentionPolicySpec) unmarshal(pb *internal.RetentionPolicySpec) {
 		replicaN := int(pb.GetReplicaN())
 		s.ReplicaN = &replicaN
 	}
+	if pb.ShardsPerGroup != nil {
+		shardsPerGroup := int(pb.GetShardsPerGroup())
+		s.ShardsPerGroup = &shardsPerGroup
+	}
+	if len(pb.GetShardKeyTags()) > 0 {
+		s.ShardKeyTags = pb.GetShardKeyTags()
+	}
+	s.TenantNodes = unmarshalTenantNodes(pb.GetTenantNodes())
 }
 
 // MarshalBinary encodes RetentionPolicySpec to a binary format.
@@ -1543,6 +2873,27 @@ type RetentionPolicyInfo struct {
 	ShardGroupDuration time.Duration
 	ShardGroups        []ShardGroupInfo
 	Subscriptions      []SubscriptionInfo
+
+	// MeasurementRetention maps measurement names to a retention duration
+	// shorter than the policy's own.
+	MeasurementRetention map[string]time.Duration
+
+	// ShardsPerGroup is the least number of shards in each new shard group,
+	// to spread writes over more shards than there are data nodes to hold
+	// them. Zero is the same as one.
+	ShardsPerGroup int
+
+	// ShardKeyTags are the tags whose values name the tenant of a point.
+	// TenantNodes maps tenants to the data nodes that hold their shards.
+	// Each new shard group gets shards of its own for those tenants, owned
+	// only by their nodes; the points of every other tenant go to the shards
+	// shared by all nodes.
+	ShardKeyTags []string
+	TenantNodes  map[string][]uint64
+
+	// Frozen stops new shard groups from being created in the policy, such
+	// as while its index is rebuilt. Existing shard groups are unaffected.
+	Frozen bool
 }
 
 // NewRetentionPolicyInfo returns a new instance of RetentionPolicyInfo
@@ -1568,13 +2919,26 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 		ReplicaN:           rpi.ReplicaN,
 		Duration:           rpi.Duration,
 		ShardGroupDuration: rpi.ShardGroupDuration,
+		ShardsPerGroup:     rpi.ShardsPerGroup,
+		ShardKeyTags:       rpi.ShardKeyTags,
+		TenantNodes:        rpi.TenantNodes,
+		Frozen:             rpi.Frozen,
 	}
 	if spec.Name != "" {
 		rp.Name = spec.Name
 	}
+	if spec.ShardKeyTags != nil {
+		rp.ShardKeyTags = spec.ShardKeyTags
+	}
+	if spec.TenantNodes != nil {
+		rp.TenantNodes = spec.TenantNodes
+	}
 	if spec.ReplicaN != nil {
 		rp.ReplicaN = *spec.ReplicaN
 	}
+	if spec.ShardsPerGroup != nil {
+		rp.ShardsPerGroup = *spec.ShardsPerGroup
+	}
 	if spec.Duration != nil {
 		rp.Duration = *spec.Duration
 	}
@@ -1582,6 +2946,36 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 	return rp
 }
 
+// shardsPerGroup returns the least number of shards in a new shard group.
+func (rpi *RetentionPolicyInfo) shardsPerGroup() int {
+	if rpi.ShardsPerGroup < 1 {
+		return 1
+	}
+	return rpi.ShardsPerGroup
+}
+
+// Tenant returns the tenant of a point with tags: the values of the shard key
+// tags, joined by commas. It is empty if the policy has no shard key tags or
+// the point has none of them.
+func (rpi *RetentionPolicyInfo) Tenant(tags models.Tags) string {
+	if len(rpi.ShardKeyTags) == 0 {
+		return ""
+	} else if len(rpi.ShardKeyTags) == 1 {
+		return tags.GetString(rpi.ShardKeyTags[0])
+	}
+
+	values := make([]string, len(rpi.ShardKeyTags))
+	var found bool
+	for i, key := range rpi.ShardKeyTags {
+		values[i] = tags.GetString(key)
+		found = found || values[i] != ""
+	}
+	if !found {
+		return ""
+	}
+	return strings.Join(values, ",")
+}
+
 // ShardGroupByTimestamp returns the shard group in the retention policy that contains the timestamp,
 // or nil if no shard group matches.
 func (rpi *RetentionPolicyInfo) ShardGroupByTimestamp(timestamp time.Time) *ShardGroupInfo {
@@ -1609,6 +3003,26 @@ func (rpi *RetentionPolicyInfo) ExpiredShardGroups(t time.Time) []*ShardGroupInf
 	return groups
 }
 
+// Usage returns the usage of the retention policy. Deleted shard groups are skipped.
+func (rpi *RetentionPolicyInfo) Usage() RPUsage {
+	u := RPUsage{RetentionPolicy: rpi.Name}
+	for i := range rpi.ShardGroups {
+		sgi := &rpi.ShardGroups[i]
+		if sgi.Deleted() {
+			continue
+		}
+		u.ShardGroupN++
+		u.ShardN += len(sgi.Shards)
+		if u.Earliest.IsZero() || sgi.StartTime.Before(u.Earliest) {
+			u.Earliest = sgi.StartTime
+		}
+		if sgi.EndTime.After(u.Latest) {
+			u.Latest = sgi.EndTime
+		}
+	}
+	return u
+}
+
 // DeletedShardGroups returns the ShardGroups which are marked as deleted.
 func (rpi *RetentionPolicyInfo) DeletedShardGroups() []*ShardGroupInfo {
 	var groups = make([]*ShardGroupInfo, 0)
@@ -1628,6 +3042,14 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		Duration:           proto.Int64(int64(rpi.Duration)),
 		ShardGroupDuration: proto.Int64(int64(rpi.ShardGroupDuration)),
 	}
+	if rpi.ShardsPerGroup > 0 {
+		pb.ShardsPerGroup = proto.Uint32(uint32(rpi.ShardsPerGroup))
+	}
+	pb.ShardKeyTags = rpi.ShardKeyTags
+	pb.TenantNodes = marshalTenantNodes(rpi.TenantNodes)
+	if rpi.Frozen {
+		pb.Frozen = proto.Bool(true)
+	}
 
 	pb.ShardGroups = make([]*internal.ShardGroupInfo, len(rpi.ShardGroups))
 	for i, sgi := range rpi.ShardGroups {
@@ -1639,6 +3061,22 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		pb.Subscriptions[i] = sub.marshal()
 	}
 
+	if len(rpi.MeasurementRetention) > 0 {
+		names := make([]string, 0, len(rpi.MeasurementRetention))
+		for name := range rpi.MeasurementRetention {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		pb.MeasurementRetention = make([]*internal.MeasurementRetention, len(names))
+		for i, name := range names {
+			pb.MeasurementRetention[i] = &internal.MeasurementRetention{
+				Name:     proto.String(name),
+				Duration: proto.Int64(int64(rpi.MeasurementRetention[name])),
+			}
+		}
+	}
+
 	return pb
 }
 
@@ -1648,6 +3086,12 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 	rpi.ReplicaN = int(pb.GetReplicaN())
 	rpi.Duration = time.Duration(pb.GetDuration())
 	rpi.ShardGroupDuration = time.Duration(pb.GetShardGroupDuration())
+	rpi.ShardsPerGroup = int(pb.GetShardsPerGroup())
+	if len(pb.GetShardKeyTags()) > 0 {
+		rpi.ShardKeyTags = pb.GetShardKeyTags()
+	}
+	rpi.TenantNodes = unmarshalTenantNodes(pb.GetTenantNodes())
+	rpi.Frozen = pb.GetFrozen()
 
 	if len(pb.GetShardGroups()) > 0 {
 		rpi.ShardGroups = make([]ShardGroupInfo, len(pb.GetShardGroups()))
@@ -1661,6 +3105,12 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 			rpi.Subscriptions[i].unmarshal(x)
 		}
 	}
+	if len(pb.GetMeasurementRetention()) > 0 {
+		rpi.MeasurementRetention = make(map[string]time.Duration, len(pb.GetMeasurementRetention()))
+		for _, x := range pb.GetMeasurementRetention() {
+			rpi.MeasurementRetention[x.GetName()] = time.Duration(x.GetDuration())
+		}
+	}
 }
 
 // clone returns a deep copy of rpi.
@@ -1674,9 +3124,96 @@ func (rpi RetentionPolicyInfo) clone() RetentionPolicyInfo {
 		}
 	}
 
+	if rpi.MeasurementRetention != nil {
+		other.MeasurementRetention = make(map[string]time.Duration, len(rpi.MeasurementRetention))
+		for name, d := range rpi.MeasurementRetention {
+			other.MeasurementRetention[name] = d
+		}
+	}
+
+	if rpi.ShardKeyTags != nil {
+		other.ShardKeyTags = append([]string(nil), rpi.ShardKeyTags...)
+	}
+	if rpi.TenantNodes != nil {
+		other.TenantNodes = make(map[string][]uint64, len(rpi.TenantNodes))
+		for tenant, ids := range rpi.TenantNodes {
+			other.TenantNodes[tenant] = append([]uint64(nil), ids...)
+		}
+	}
+
 	return other
 }
 
+// sameShardKeyTags returns true if a and b name the same tags in the same
+// order.
+func sameShardKeyTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTenantNodes returns true if a and b place every tenant on the same
+// nodes, in the same order.
+func sameTenantNodes(a, b map[string][]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for tenant, ids := range a {
+		other, ok := b[tenant]
+		if !ok || len(ids) != len(other) {
+			return false
+		}
+		for i := range ids {
+			if ids[i] != other[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// marshalTenantNodes returns the protobuf form of m, ordered by tenant.
+func marshalTenantNodes(m map[string][]uint64) []*internal.TenantNodes {
+	if len(m) == 0 {
+		return nil
+	}
+
+	tenants := make([]string, 0, len(m))
+	for tenant := range m {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	a := make([]*internal.TenantNodes, len(tenants))
+	for i, tenant := range tenants {
+		a[i] = &internal.TenantNodes{
+			Tenant:  proto.String(tenant),
+			NodeIDs: m[tenant],
+		}
+	}
+	return a
+}
+
+// unmarshalTenantNodes returns the tenant to node mapping held in a, or nil
+// if a is empty.
+func unmarshalTenantNodes(a []*internal.TenantNodes) map[string][]uint64 {
+	if len(a) == 0 {
+		return nil
+	}
+
+	m := make(map[string][]uint64, len(a))
+	for _, x := range a {
+		m[x.GetTenant()] = x.GetNodeIDs()
+	}
+	return m
+}
+
 // MarshalBinary encodes rpi to a binary format.
 func (rpi *RetentionPolicyInfo) MarshalBinary() ([]byte, error) {
 	return proto.Marshal(rpi.marshal())
@@ -1692,6 +3229,89 @@ func (rpi *RetentionPolicyInfo) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// RPUsage summarizes the live shard groups of a retention policy.
+type RPUsage struct {
+	RetentionPolicy string
+	ShardGroupN     int
+	ShardN          int
+
+	// Earliest is the start time of the earliest shard group and Latest the
+	// end time of the latest. Both are zero if the policy has no shard groups.
+	Earliest time.Time
+	Latest   time.Time
+}
+
+// ShardGroupTimelineEntry is the lifetime of a shard group.
+type ShardGroupTimelineEntry struct {
+	ID uint64
+
+	// CreatedAt is when the group was created. Meta data doesn't record
+	// it, so the group's start time stands in for it, which is late for
+	// precreated groups.
+	CreatedAt time.Time
+
+	// DeletedAt is when the group was deleted, or zero if it is live.
+	DeletedAt time.Time
+
+	ShardN int
+}
+
+// ShardGroupBoundary is the time range [StartTime, EndTime) covered by a
+// shard group.
+type ShardGroupBoundary struct {
+	ID        uint64
+	StartTime time.Time
+	EndTime   time.Time
+	Deleted   bool
+}
+
+// RetentionPolicyRef is a retention policy with the name of its database.
+type RetentionPolicyRef struct {
+	Database string
+	RetentionPolicyInfo
+}
+
+// OverlapReport identifies two live shard groups of a retention policy whose
+// time ranges overlap. The group that starts first is listed first.
+type OverlapReport struct {
+	Database        string
+	RetentionPolicy string
+	ShardGroupIDs   [2]uint64
+}
+
+// UnderReplicatedShard is a live shard with fewer owners than the replica
+// factor of its retention policy.
+type UnderReplicatedShard struct {
+	Database        string
+	RetentionPolicy string
+	ShardGroupID    uint64
+	ShardID         uint64
+	Owners          []uint64
+	ReplicaN        int
+}
+
+// Missing returns the number of owners the shard is short of.
+func (s UnderReplicatedShard) Missing() int {
+	return s.ReplicaN - len(s.Owners)
+}
+
+// NodeShard is a shard along with the database, retention policy and shard
+// group it belongs to.
+type NodeShard struct {
+	Database        string
+	RetentionPolicy string
+	ShardGroupID    uint64
+	ShardInfo
+}
+
+// ShardGroupRef is a shard group along with the database and retention policy
+// it belongs to.
+type ShardGroupRef struct {
+	Database        string
+	RetentionPolicy string
+	ShardGroup      ShardGroupInfo
+}
+
 // groupDuration returns the default duration for a shard group based on a retention policy duration.
 func groupDuration(d time.Duration) time.Duration {
 	if d >= 180*24*time.Hour || d == 0 { // 6 months or 0
@@ -1768,6 +3388,36 @@ func (sgi *ShardGroupInfo) Overlaps(min, max time.Time) bool {
 	return !sgi.StartTime.After(max) && sgi.EndTime.After(min)
 }
 
+// OwnerNodeIDs returns the IDs of the data nodes that own a shard in the
+// group, in ascending order and without duplicates.
+func (sgi *ShardGroupInfo) OwnerNodeIDs() []uint64 {
+	seen := make(map[uint64]struct{})
+	var ids []uint64
+	for _, si := range sgi.Shards {
+		for _, o := range si.Owners {
+			if _, ok := seen[o.NodeID]; !ok {
+				seen[o.NodeID] = struct{}{}
+				ids = append(ids, o.NodeID)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// hasLiveOwner returns true if any shard in the group has an owner that is not
+// in dead.
+func (sgi *ShardGroupInfo) hasLiveOwner(dead map[uint64]struct{}) bool {
+	for _, si := range sgi.Shards {
+		for _, o := range si.Owners {
+			if _, ok := dead[o.NodeID]; !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Deleted returns whether this ShardGroup has been deleted.
 func (sgi *ShardGroupInfo) Deleted() bool {
 	return !sgi.DeletedAt.IsZero()
@@ -1796,12 +3446,38 @@ type hashIDer interface {
 	HashID() uint64
 }
 
-// ShardFor returns the ShardInfo for a Point or other hashIDer.
+// ShardFor returns the ShardInfo for a Point or other hashIDer. It is one of
+// the shards shared by all tenants.
 func (sgi *ShardGroupInfo) ShardFor(p hashIDer) ShardInfo {
-	if len(sgi.Shards) == 1 {
-		return sgi.Shards[0]
+	return sgi.ShardForTenant("", p)
+}
+
+// ShardForTenant returns the ShardInfo for a Point or other hashIDer of
+// tenant. Tenants without shards of their own in the group use the shared
+// shards.
+func (sgi *ShardGroupInfo) ShardForTenant(tenant string, p hashIDer) ShardInfo {
+	i, n := sgi.tenantShards(tenant)
+	if n == 0 {
+		i, n = sgi.tenantShards("")
+	}
+	if n == 1 {
+		return sgi.Shards[i]
 	}
-	return sgi.Shards[p.HashID()%uint64(len(sgi.Shards))]
+	return sgi.Shards[i+int(p.HashID()%uint64(n))]
+}
+
+// tenantShards returns the index and number of the shards of tenant. The
+// shards of a tenant are adjacent, and the shared shards come first.
+func (sgi *ShardGroupInfo) tenantShards(tenant string) (int, int) {
+	i := 0
+	for i < len(sgi.Shards) && sgi.Shards[i].Tenant != tenant {
+		i++
+	}
+	n := 0
+	for i+n < len(sgi.Shards) && sgi.Shards[i+n].Tenant == tenant {
+		n++
+	}
+	return i, n
 }
 
 // marshal serializes to a protobuf representation.
@@ -1856,6 +3532,10 @@ func (sgi *ShardGroupInfo) unmarshal(pb *internal.ShardGroupInfo) {
 type ShardInfo struct {
 	ID     uint64
 	Owners []ShardOwner
+
+	// Tenant is set on the shards placed for a tenant of the retention
+	// policy's TenantNodes.
+	Tenant string
 }
 
 // OwnedBy determines whether the shard's owner IDs includes nodeID.
@@ -1887,6 +3567,9 @@ func (si ShardInfo) marshal() *internal.ShardInfo {
 	pb := &internal.ShardInfo{
 		ID: proto.Uint64(si.ID),
 	}
+	if si.Tenant != "" {
+		pb.Tenant = proto.String(si.Tenant)
+	}
 
 	pb.Owners = make([]*internal.ShardOwner, len(si.Owners))
 	for i := range si.Owners {
@@ -1909,6 +3592,7 @@ func (si *ShardInfo) UnmarshalBinary(buf []byte) error {
 // unmarshal deserializes from a protobuf representation.
 func (si *ShardInfo) unmarshal(pb *internal.ShardInfo) {
 	si.ID = pb.GetID()
+	si.Tenant = pb.GetTenant()
 
 	// If deprecated "OwnerIDs" exists then convert it to "Owners" format.
 	if len(pb.GetOwnerIDs()) > 0 {
@@ -2020,6 +3704,13 @@ type UserInfo struct {
 	Privileges map[string]cnosql.Privilege
 }
 
+// UserAuthz is what a user is authorized to do: whether it is an admin and
+// its privileges mapped by database name.
+type UserAuthz struct {
+	Admin      bool
+	Privileges map[string]cnosql.Privilege
+}
+
 type User interface {
 	query.FineAuthorizer
 	ID() string
@@ -2130,13 +3821,18 @@ func NewLeases(d time.Duration) *Leases {
 // If nodeID already owns the named and unexpired lease, the lease expiration is extended.
 // If a different node owns the lease, an error is returned.
 func (leases *Leases) Acquire(name string, nodeID uint64) (*Lease, error) {
+	return leases.acquire(name, nodeID, time.Now())
+}
+
+// acquire is Acquire, judging and extending expiration from now.
+func (leases *Leases) acquire(name string, nodeID uint64, now time.Time) (*Lease, error) {
 	leases.mu.Lock()
 	defer leases.mu.Unlock()
 
 	l := leases.m[name]
 	if l != nil {
-		if time.Now().After(l.Expiration) || l.Owner == nodeID {
-			l.Expiration = time.Now().Add(leases.d)
+		if now.After(l.Expiration) || l.Owner == nodeID {
+			l.Expiration = now.Add(leases.d)
 			l.Owner = nodeID
 			return l, nil
 		}
@@ -2145,7 +3841,7 @@ func (leases *Leases) Acquire(name string, nodeID uint64) (*Lease, error) {
 
 	l = &Lease{
 		Name:       name,
-		Expiration: time.Now().Add(leases.d),
+		Expiration: now.Add(leases.d),
 		Owner:      nodeID,
 	}
 
@@ -2154,6 +3850,33 @@ func (leases *Leases) Acquire(name string, nodeID uint64) (*Lease, error) {
 	return l, nil
 }
 
+// LeaseInfo describes a lease and the node that holds it.
+type LeaseInfo struct {
+	Name       string    `json:"name"`
+	Owner      uint64    `json:"owner"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// Expired returns true if the lease has expired at t, so that any node may
+// acquire it.
+func (l LeaseInfo) Expired(t time.Time) bool {
+	return t.After(l.Expiration)
+}
+
+// List returns every lease that has been acquired, sorted by name. Expired
+// leases are included.
+func (leases *Leases) List() []LeaseInfo {
+	leases.mu.Lock()
+	defer leases.mu.Unlock()
+
+	a := make([]LeaseInfo, 0, len(leases.m))
+	for _, l := range leases.m {
+		a = append(a, LeaseInfo{Name: l.Name, Owner: l.Owner, Expiration: l.Expiration})
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].Name < a[j].Name })
+	return a
+}
+
 // MarshalTime converts t to nanoseconds since epoch. A zero time returns 0.
 func MarshalTime(t time.Time) int64 {
 	if t.IsZero() {
@@ -2171,7 +3894,9 @@ func UnmarshalTime(v int64) time.Time {
 	return time.Unix(0, v).UTC()
 }
 
-// ValidName checks to see if the given name can would be valid for DB/RP name
+// ValidName checks to see if the given name can would be valid for DB/RP name.
+// Names are used as directory names on disk, so they must be printable, not
+// only whitespace, and free of path separators.
 func ValidName(name string) bool {
 	for _, r := range name {
 		if !unicode.IsPrint(r) {
@@ -2179,7 +3904,7 @@ func ValidName(name string) bool {
 		}
 	}
 
-	return name != "" &&
+	return strings.TrimSpace(name) != "" &&
 		name != "." &&
 		name != ".." &&
 		!strings.ContainsAny(name, `/\`)
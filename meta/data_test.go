@@ -0,0 +1,1713 @@
+package meta_test
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/cnosdb/cnosdb/vend/db/models"
+)
+
+func TestData_RetentionPolicyUsage(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if u, err := data.RetentionPolicyUsage("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	} else if u.ShardGroupN != 0 || u.ShardN != 0 || !u.Earliest.IsZero() || !u.Latest.IsZero() {
+		t.Fatalf("unexpected usage of empty retention policy: %+v", u)
+	}
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Delete the first and last groups; only the middle two should be counted.
+	sgs, _ := data.ShardGroups("db0", "rp0")
+	for _, sg := range []meta.ShardGroupInfo{sgs[0], sgs[3]} {
+		if err := data.DeleteShardGroup("db0", "rp0", sg.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u, err := data.RetentionPolicyUsage("db0", "rp0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.RetentionPolicy != "rp0" {
+		t.Errorf("unexpected retention policy: %s", u.RetentionPolicy)
+	}
+	if u.ShardGroupN != 2 {
+		t.Errorf("unexpected shard group count: got %d, exp 2", u.ShardGroupN)
+	}
+	if u.ShardN != 2 {
+		t.Errorf("unexpected shard count: got %d, exp 2", u.ShardN)
+	}
+	if exp := start.Add(time.Hour); !u.Earliest.Equal(exp) {
+		t.Errorf("unexpected earliest time: got %s, exp %s", u.Earliest, exp)
+	}
+	if exp := start.Add(3 * time.Hour); !u.Latest.Equal(exp) {
+		t.Errorf("unexpected latest time: got %s, exp %s", u.Latest, exp)
+	}
+
+	if _, err := data.RetentionPolicyUsage("db0", "rp1"); err == nil {
+		t.Error("expected error for missing retention policy")
+	}
+	if _, err := data.RetentionPolicyUsage("db1", "rp0"); err == nil {
+		t.Error("expected error for missing database")
+	}
+}
+
+func TestData_ShardGroupsForWrite(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// One group ends just after the retention cutoff, the other well before it.
+	now := time.Now()
+	inside := now.Add(-24*time.Hour + time.Minute)
+	outside := now.Add(-26 * time.Hour)
+	for _, ts := range []time.Time{inside, outside} {
+		if err := data.CreateShardGroup("db0", "rp0", ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := data.ShardGroupsForWrite("db0", "rp0", now.Add(-30*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 1 {
+		t.Fatalf("unexpected shard group count: got %d, exp 1", len(groups))
+	} else if !groups[0].Contains(inside) {
+		t.Fatalf("unexpected shard group: %s - %s", groups[0].StartTime, groups[0].EndTime)
+	}
+
+	// ShardGroupsByTimeRange still returns both.
+	if groups, err := data.ShardGroupsByTimeRange("db0", "rp0", now.Add(-30*time.Hour), now); err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 2 {
+		t.Fatalf("unexpected shard group count: got %d, exp 2", len(groups))
+	}
+
+	if _, err := data.ShardGroupsForWrite("db0", "rp0", now.Add(-30*time.Hour), now.Add(-25*time.Hour)); err != meta.ErrShardGroupsExpired {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrShardGroupsExpired)
+	}
+}
+
+func TestData_CreateDatabase_ValidateName(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{name: "db0"},
+		{name: "my-db.v2"},
+		{name: "数据库"},
+		{name: "db with spaces"},
+		{name: strings.Repeat("a", meta.MaxNameLen)},
+		{name: "", err: meta.ErrDatabaseNameRequired},
+		{name: strings.Repeat("a", meta.MaxNameLen+1), err: meta.ErrNameTooLong},
+		{name: "   ", err: meta.ErrInvalidName},
+		{name: ".", err: meta.ErrInvalidName},
+		{name: "..", err: meta.ErrInvalidName},
+		{name: "a/b", err: meta.ErrInvalidName},
+		{name: `a\b`, err: meta.ErrInvalidName},
+		{name: "a\nb", err: meta.ErrInvalidName},
+		{name: "a\x00b", err: meta.ErrInvalidName},
+	} {
+		data := &meta.Data{}
+		if err := data.CreateDatabase(tt.name); err != tt.err {
+			t.Errorf("CreateDatabase(%q): got %v, exp %v", tt.name, err, tt.err)
+		}
+
+		if tt.name == "" {
+			continue
+		}
+		if err := data.CreateDatabase("db0"); err != nil {
+			t.Fatal(err)
+		}
+		rpi := &meta.RetentionPolicyInfo{Name: tt.name, ReplicaN: 1}
+		if err := data.CreateRetentionPolicy("db0", rpi, false); err != tt.err {
+			t.Errorf("CreateRetentionPolicy(%q): got %v, exp %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestData_EffectivePrivilege(t *testing.T) {
+	data := &meta.Data{}
+	for _, u := range []struct {
+		name  string
+		admin bool
+	}{{"admin", true}, {"scoped", false}, {"nogrant", false}} {
+		if err := data.CreateUser(u.name, "hash", u.admin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, db := range []string{"db0", "db1"} {
+		if err := data.CreateDatabase(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.SetPrivilege("scoped", "db0", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		user     string
+		database string
+		exp      cnosql.Privilege
+	}{
+		{user: "admin", database: "db0", exp: cnosql.AllPrivileges},
+		{user: "admin", database: "db1", exp: cnosql.AllPrivileges},
+		{user: "scoped", database: "db0", exp: cnosql.ReadPrivilege},
+		{user: "scoped", database: "db1", exp: cnosql.NoPrivileges},
+		{user: "nogrant", database: "db0", exp: cnosql.NoPrivileges},
+	} {
+		if p, err := data.EffectivePrivilege(tt.user, tt.database); err != nil {
+			t.Fatal(err)
+		} else if p != tt.exp {
+			t.Errorf("EffectivePrivilege(%q, %q): got %s, exp %s", tt.user, tt.database, p, tt.exp)
+		}
+	}
+
+	if _, err := data.EffectivePrivilege("nobody", "db0"); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUserNotFound)
+	}
+}
+
+func TestData_AccessibleDatabases(t *testing.T) {
+	data := &meta.Data{}
+	for _, u := range []struct {
+		name  string
+		admin bool
+	}{{"admin", true}, {"scoped", false}, {"nogrant", false}} {
+		if err := data.CreateUser(u.name, "hash", u.admin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, db := range []string{"db2", "db0", "db1"} {
+		if err := data.CreateDatabase(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.SetPrivilege("scoped", "db2", cnosql.WritePrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetPrivilege("scoped", "db0", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetPrivilege("scoped", "db1", cnosql.NoPrivileges); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		user string
+		exp  []string
+	}{
+		{user: "admin", exp: []string{"db0", "db1", "db2"}},
+		{user: "scoped", exp: []string{"db0", "db2"}},
+		{user: "nogrant", exp: []string{}},
+	} {
+		if names, err := data.AccessibleDatabases(tt.user); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(names, tt.exp) {
+			t.Errorf("AccessibleDatabases(%q): got %v, exp %v", tt.user, names, tt.exp)
+		}
+	}
+
+	if _, err := data.AccessibleDatabases("nobody"); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUserNotFound)
+	}
+}
+
+func TestData_UserAuthz(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateUser("admin", "hash", true); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateUser("scoped", "hash", false); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetPrivilege("scoped", "db0", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	}
+
+	if authz, err := data.UserAuthz("admin"); err != nil {
+		t.Fatal(err)
+	} else if !authz.Admin || len(authz.Privileges) != 0 {
+		t.Fatalf("unexpected admin authz: %+v", authz)
+	}
+
+	authz, err := data.UserAuthz("scoped")
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := map[string]cnosql.Privilege{"db0": cnosql.ReadPrivilege}; authz.Admin || !reflect.DeepEqual(authz.Privileges, exp) {
+		t.Fatalf("unexpected scoped authz: %+v", authz)
+	}
+
+	// The privileges are a copy.
+	authz.Privileges["db0"] = cnosql.AllPrivileges
+	if p, _ := data.UserPrivilege("scoped", "db0"); *p != cnosql.ReadPrivilege {
+		t.Fatalf("privileges changed through authz: %s", p)
+	}
+
+	if _, err := data.UserAuthz("nobody"); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUserNotFound)
+	}
+}
+
+func TestData_SetMeasurementRetention(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 7 * 24 * time.Hour, ShardGroupDuration: 24 * time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.SetMeasurementRetention("db0", "rp0", "cpu", 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetMeasurementRetention("db0", "rp0", "mem", 48*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overriding an existing measurement replaces its duration.
+	if err := data.SetMeasurementRetention("db0", "rp0", "cpu", 12*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]time.Duration{"cpu": 12 * time.Hour, "mem": 48 * time.Hour}
+	if got := data.Database("db0").RetentionPolicy("rp0").MeasurementRetention; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected overrides: got %v, exp %v", got, exp)
+	}
+
+	// Overrides survive a marshal round trip.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.Database("db0").RetentionPolicy("rp0").MeasurementRetention; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected overrides after unmarshal: got %v, exp %v", got, exp)
+	}
+
+	// A zero duration clears the override.
+	if err := data.SetMeasurementRetention("db0", "rp0", "cpu", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetMeasurementRetention("db0", "rp0", "mem", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := data.Database("db0").RetentionPolicy("rp0").MeasurementRetention; got != nil {
+		t.Fatalf("expected no overrides, got %v", got)
+	}
+
+	for _, d := range []time.Duration{-time.Hour, 8 * 24 * time.Hour} {
+		if err := data.SetMeasurementRetention("db0", "rp0", "cpu", d); err != meta.ErrMeasurementRetentionInvalid {
+			t.Fatalf("unexpected error for %s: %v", d, err)
+		}
+	}
+	if err := data.SetMeasurementRetention("db0", "rp1", "cpu", time.Hour); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
+
+func TestData_FreezeRetentionPolicy(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 7 * 24 * time.Hour, ShardGroupDuration: 24 * time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := data.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.FreezeRetentionPolicy("db0", "rp0", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The flag survives a marshal round trip.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if !other.Database("db0").RetentionPolicy("rp0").Frozen {
+		t.Fatal("expected rp0 to be frozen after unmarshal")
+	}
+
+	// Existing shard groups are still found, but no new ones are created.
+	if err := data.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", now.Add(24*time.Hour)); err != meta.ErrRetentionPolicyFrozen {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrRetentionPolicyFrozen)
+	}
+
+	if err := data.FreezeRetentionPolicy("db0", "rp0", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if n := len(data.Database("db0").RetentionPolicy("rp0").ShardGroups); n != 2 {
+		t.Fatalf("unexpected shard group count: %d", n)
+	}
+
+	if err := data.FreezeRetentionPolicy("db0", "rp1", true); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
+
+func TestData_DataNodeLoad(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1", "host2"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each group gets one shard per data node.
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exp := map[uint64]int{1: 2, 2: 2, 3: 2}
+	if got := data.DataNodeLoad(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected load: got %v, exp %v", got, exp)
+	}
+
+	// Deleting a group moves its shards to pending.
+	sgi := data.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+	if err := data.DeleteShardGroup("db0", "rp0", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+	exp = map[uint64]int{1: 1, 2: 1, 3: 1}
+	if got := data.DataNodeLoad(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected load after delete: got %v, exp %v", got, exp)
+	}
+	for _, n := range data.DataNodes {
+		if n.PendingShards != 1 {
+			t.Fatalf("unexpected pending shards on node %d: %d", n.ID, n.PendingShards)
+		}
+	}
+
+	// Dropping the database releases every shard.
+	if err := data.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	exp = map[uint64]int{1: 0, 2: 0, 3: 0}
+	if got := data.DataNodeLoad(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected load after drop: got %v, exp %v", got, exp)
+	}
+}
+
+func TestData_ImportData_DataNodeLoad(t *testing.T) {
+	backup := meta.Data{}
+	if err := backup.CreateDataNode("backup0:8086", "backup0:8088"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := backup.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := backup.CreateShardGroup("db0", "rp0", now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := data.ImportData(backup, "", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The imported shards are counted against the nodes they were placed on.
+	exp := make(map[uint64]int)
+	for _, sgi := range data.Database("db0").RetentionPolicy("rp0").ShardGroups {
+		for _, sh := range sgi.Shards {
+			for _, o := range sh.Owners {
+				exp[o.NodeID]++
+			}
+		}
+	}
+	if exp[1]+exp[2] != 2 {
+		t.Fatalf("unexpected imported owners: %v", exp)
+	}
+	for _, n := range data.DataNodes {
+		if _, ok := exp[n.ID]; !ok {
+			exp[n.ID] = 0
+		}
+	}
+	if got := data.DataNodeLoad(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected load: got %v, exp %v", got, exp)
+	}
+}
+
+func TestData_UnderReplicatedShards(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a := data.UnderReplicatedShards(); len(a) != 0 {
+		t.Fatalf("unexpected under-replicated shards: %+v", a)
+	}
+
+	// Drop one owner of the second group's shard.
+	sgi := &data.Database("db0").RetentionPolicy("rp0").ShardGroups[1]
+	si := &sgi.Shards[0]
+	si.Owners = si.Owners[:1]
+
+	exp := []meta.UnderReplicatedShard{{
+		Database:        "db0",
+		RetentionPolicy: "rp0",
+		ShardGroupID:    sgi.ID,
+		ShardID:         si.ID,
+		Owners:          []uint64{si.Owners[0].NodeID},
+		ReplicaN:        2,
+	}}
+	a := data.UnderReplicatedShards()
+	if !reflect.DeepEqual(a, exp) {
+		t.Fatalf("unexpected under-replicated shards: got %+v, exp %+v", a, exp)
+	} else if a[0].Missing() != 1 {
+		t.Fatalf("unexpected missing owner count: %d", a[0].Missing())
+	}
+
+	// Deleted groups are not reported.
+	if err := data.DeleteShardGroup("db0", "rp0", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+	if a := data.UnderReplicatedShards(); len(a) != 0 {
+		t.Fatalf("unexpected under-replicated shards: %+v", a)
+	}
+}
+
+func TestData_ShardGroupBoundaries(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the groups out of order.
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, h := range []int{2, 0, 1} {
+		if err := data.CreateShardGroup("db0", "rp0", base.Add(time.Duration(h)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sgi, err := data.ShardGroupByTimestamp("db0", "rp0", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := data.DeleteShardGroup("db0", "rp0", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := data.ShardGroupBoundaries("db0", "rp0")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(a) != 3 {
+		t.Fatalf("unexpected boundary count: %d", len(a))
+	}
+	for i, b := range a {
+		start := base.Add(time.Duration(i) * time.Hour)
+		if !b.StartTime.Equal(start) || !b.EndTime.Equal(start.Add(time.Hour)) {
+			t.Fatalf("unexpected boundary %d: [%s, %s)", i, b.StartTime, b.EndTime)
+		}
+		if exp := b.ID == sgi.ID; b.Deleted != exp {
+			t.Fatalf("unexpected deleted flag on group %d: %v", b.ID, b.Deleted)
+		}
+	}
+	if a[0].ID != sgi.ID {
+		t.Fatalf("expected deleted group first, got %d", a[0].ID)
+	}
+
+	if _, err := data.ShardGroupBoundaries("db0", "no_rp"); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
+
+func TestData_ShardGroupTimeline(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDataNode("node1:8086", "node1:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the groups out of order and delete the earliest.
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, h := range []int{2, 0, 1} {
+		if err := data.CreateShardGroup("db0", "rp0", base.Add(time.Duration(h)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sgi, err := data.ShardGroupByTimestamp("db0", "rp0", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletedAt := base.Add(48 * time.Hour)
+	if err := data.MarkShardGroupDeleted("db0", "rp0", sgi.ID, deletedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := data.ShardGroupTimeline("db0", "rp0")
+	if len(entries) != 3 {
+		t.Fatalf("unexpected entry count: %d", len(entries))
+	}
+	for i, e := range entries {
+		if exp := base.Add(time.Duration(i) * time.Hour); !e.CreatedAt.Equal(exp) {
+			t.Fatalf("unexpected creation time of entry %d: %s", i, e.CreatedAt)
+		} else if e.ShardN != 1 {
+			t.Fatalf("unexpected shard count of entry %d: %d", i, e.ShardN)
+		}
+	}
+	if e := entries[0]; e.ID != sgi.ID || !e.DeletedAt.Equal(deletedAt) {
+		t.Fatalf("unexpected deleted entry: %+v", e)
+	}
+	for _, e := range entries[1:] {
+		if !e.DeletedAt.IsZero() {
+			t.Fatalf("unexpected deletion time on live group %d: %s", e.ID, e.DeletedAt)
+		}
+	}
+
+	if entries := data.ShardGroupTimeline("db0", "no_rp"); entries != nil {
+		t.Fatalf("unexpected entries for missing retention policy: %+v", entries)
+	}
+}
+
+func TestData_ShardGroupBoundaryFor(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: 24 * time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		t          time.Time
+		start, end time.Time
+	}{
+		{t: day, start: day, end: day.Add(24 * time.Hour)},
+		{t: day.Add(time.Nanosecond), start: day, end: day.Add(24 * time.Hour)},
+		{t: day.Add(24*time.Hour - time.Nanosecond), start: day, end: day.Add(24 * time.Hour)},
+		{t: day.Add(-time.Nanosecond), start: day.Add(-24 * time.Hour), end: day},
+		{t: time.Unix(0, models.MaxNanoTime), start: time.Unix(0, models.MaxNanoTime).Truncate(24 * time.Hour), end: time.Unix(0, models.MaxNanoTime+1)},
+	} {
+		start, end, err := data.ShardGroupBoundaryFor("db0", "rp0", tt.t)
+		if err != nil {
+			t.Fatal(err)
+		} else if !start.Equal(tt.start) || !end.Equal(tt.end) {
+			t.Fatalf("%s: unexpected boundary [%s, %s), exp [%s, %s)", tt.t, start, end, tt.start, tt.end)
+		}
+	}
+
+	// The boundary matches the group that is then created, and nothing is
+	// created by asking.
+	start, end, err := data.ShardGroupBoundaryFor("db0", "rp0", day.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	} else if n := len(data.Database("db0").RetentionPolicy("rp0").ShardGroups); n != 0 {
+		t.Fatalf("unexpected shard groups: %d", n)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", day.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	sgi := data.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+	if !sgi.StartTime.Equal(start) || !sgi.EndTime.Equal(end) {
+		t.Fatalf("created group [%s, %s) does not match boundary [%s, %s)", sgi.StartTime, sgi.EndTime, start, end)
+	}
+
+	if _, _, err := data.ShardGroupBoundaryFor("db0", "no_rp", day); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
+
+func TestData_MissingShardGroups(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: 24 * time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	if err := data.CreateShardGroup("db0", "rp0", day); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamps := []time.Time{
+		day.Add(48*time.Hour + time.Hour),
+		day.Add(time.Hour),
+		day.Add(-time.Nanosecond),
+		day.Add(48*time.Hour + 2*time.Hour),
+		day.Add(23 * time.Hour),
+		day.Add(-12 * time.Hour),
+	}
+	exp := []time.Time{day.Add(-24 * time.Hour), day.Add(48 * time.Hour)}
+	if got := data.MissingShardGroups("db0", "rp0", timestamps); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected missing shard groups: got %v, exp %v", got, exp)
+	}
+
+	// Creating the groups leaves nothing missing.
+	for _, ts := range exp {
+		if err := data.CreateShardGroup("db0", "rp0", ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := data.MissingShardGroups("db0", "rp0", timestamps); len(got) != 0 {
+		t.Fatalf("unexpected missing shard groups: %v", got)
+	}
+	if n := len(data.Database("db0").RetentionPolicy("rp0").ShardGroups); n != 3 {
+		t.Fatalf("unexpected shard group count: %d", n)
+	}
+
+	if got := data.MissingShardGroups("db0", "no_rp", timestamps); got != nil {
+		t.Fatalf("unexpected missing shard groups for missing retention policy: %v", got)
+	}
+}
+
+func TestData_AllShardGroupsByTimeRange(t *testing.T) {
+	data := &meta.Data{}
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, x := range []struct {
+		db, rp string
+		hours  []int
+	}{
+		{db: "db0", rp: "rp0", hours: []int{3, 0, 1}},
+		{db: "db1", rp: "rp1", hours: []int{5, 2, 1}},
+	} {
+		if err := data.CreateDatabase(x.db); err != nil {
+			t.Fatal(err)
+		}
+		rpi := &meta.RetentionPolicyInfo{Name: x.rp, ReplicaN: 1, ShardGroupDuration: time.Hour}
+		if err := data.CreateRetentionPolicy(x.db, rpi, true); err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range x.hours {
+			if err := data.CreateShardGroup(x.db, x.rp, base.Add(time.Duration(h)*time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	sgi, err := data.ShardGroupByTimestamp("db1", "rp1", base.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := data.DeleteShardGroup("db1", "rp1", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := data.AllShardGroupsByTimeRange(base.Add(time.Hour), base.Add(3*time.Hour+30*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []struct {
+		db, rp string
+		hour   int
+	}{
+		{db: "db0", rp: "rp0", hour: 1},
+		{db: "db1", rp: "rp1", hour: 2},
+		{db: "db0", rp: "rp0", hour: 3},
+	}
+	if len(a) != len(exp) {
+		t.Fatalf("unexpected shard group count: got %d, exp %d", len(a), len(exp))
+	}
+	for i, ref := range a {
+		if ref.Database != exp[i].db || ref.RetentionPolicy != exp[i].rp ||
+			!ref.ShardGroup.StartTime.Equal(base.Add(time.Duration(exp[i].hour)*time.Hour)) {
+			t.Errorf("unexpected shard group %d: %s.%s starting %s", i, ref.Database, ref.RetentionPolicy, ref.ShardGroup.StartTime)
+		}
+	}
+}
+
+func TestData_DeadDataNodes(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1", "host2"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := data.HeartbeatDataNode(data.DataNodes[0].ID, now.Add(-10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.HeartbeatDataNode(data.DataNodes[1].ID, now.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// host1 checked in too long ago and host2 never has.
+	dead := data.DeadDataNodes(now, 30*time.Second)
+	if len(dead) != 2 || dead[0].Host != "host1:8086" || dead[1].Host != "host2:8086" {
+		t.Fatalf("unexpected dead nodes: %+v", dead)
+	}
+	if dead := data.DeadDataNodes(now, 2*time.Minute); len(dead) != 1 || dead[0].Host != "host2:8086" {
+		t.Fatalf("unexpected dead nodes with a longer threshold: %+v", dead)
+	}
+}
+
+func TestData_SetDataNodeDraining(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1", "host2"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	drained := data.DataNodes[1].ID
+	if err := data.SetDataNodeDraining(drained, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetDataNodeDraining(100, true); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error for unknown node: %v", err)
+	}
+
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for h := 0; h < 3; h++ {
+		data.Index++ // vary the starting node
+		if err := data.CreateShardGroup("db0", "rp0", base.Add(time.Duration(h)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The draining node is still a data node but owns none of the new shards.
+	if len(data.DataNodes) != 3 {
+		t.Fatalf("unexpected data node count: %d", len(data.DataNodes))
+	}
+	if a := data.DrainingDataNodes(); len(a) != 1 || a[0].ID != drained {
+		t.Fatalf("unexpected draining nodes: %+v", a)
+	}
+	for _, sgi := range data.Databases[0].RetentionPolicies[0].ShardGroups {
+		for _, si := range sgi.Shards {
+			if len(si.Owners) != 2 {
+				t.Fatalf("unexpected owners of shard %d: %v", si.ID, si.Owners)
+			} else if si.OwnedBy(drained) {
+				t.Fatalf("shard %d placed on draining node", si.ID)
+			}
+		}
+	}
+
+	// With every node draining there is nowhere to place new shards.
+	for _, n := range data.DataNodes {
+		if err := data.SetDataNodeDraining(n.ID, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateShardGroup("db0", "rp0", base.Add(5*time.Hour)); err != meta.ErrNodesRequired {
+		t.Fatalf("unexpected error with every node draining: %v", err)
+	}
+}
+
+func TestData_ShardIDsPage(t *testing.T) {
+	data := &meta.Data{Databases: []meta.DatabaseInfo{
+		{Name: "db0", RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "rp0", ShardGroups: []meta.ShardGroupInfo{
+			{ID: 1, Shards: []meta.ShardInfo{{ID: 5}, {ID: 2}}},
+			{ID: 2, Shards: []meta.ShardInfo{{ID: 9}}},
+		}}}},
+		{Name: "db1", RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "rp0", ShardGroups: []meta.ShardGroupInfo{
+			{ID: 3, Shards: []meta.ShardInfo{{ID: 7}, {ID: 3}}},
+		}}}},
+	}}
+
+	for _, tt := range []struct {
+		after uint64
+		limit int
+		ids   []uint64
+		next  uint64
+	}{
+		{after: 0, limit: 1, ids: []uint64{2}, next: 2},
+		{after: 0, limit: 2, ids: []uint64{2, 3}, next: 3},
+		{after: 3, limit: 2, ids: []uint64{5, 7}, next: 7},
+		{after: 7, limit: 2, ids: []uint64{9}, next: 0},
+		{after: 3, limit: 3, ids: []uint64{5, 7, 9}, next: 0},
+		{after: 4, limit: 1, ids: []uint64{5}, next: 5},
+		{after: 9, limit: 2, ids: nil, next: 0},
+	} {
+		ids, next, err := data.ShardIDsPage(tt.after, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids, tt.ids) || next != tt.next {
+			t.Errorf("ShardIDsPage(%d, %d) = %v, %d; exp %v, %d", tt.after, tt.limit, ids, next, tt.ids, tt.next)
+		}
+	}
+
+	if _, _, err := data.ShardIDsPage(0, 0); err != meta.ErrInvalidPageLimit {
+		t.Fatalf("unexpected error for zero limit: %v", err)
+	}
+}
+
+func TestData_HealthyShardGroupsByTimeRange(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := now.Add(-24 * time.Hour)
+	group := func(id uint64, hour int, owners ...uint64) meta.ShardGroupInfo {
+		sgi := meta.ShardGroupInfo{
+			ID:        id,
+			StartTime: base.Add(time.Duration(hour) * time.Hour),
+			EndTime:   base.Add(time.Duration(hour+1) * time.Hour),
+		}
+		for i, o := range owners {
+			sgi.Shards = append(sgi.Shards, meta.ShardInfo{ID: id*10 + uint64(i), Owners: []meta.ShardOwner{{NodeID: o}}})
+		}
+		return sgi
+	}
+	data := &meta.Data{
+		DataNodes: []meta.NodeInfo{
+			{ID: 1, Host: "host0:8086", LastSeen: now.Add(-time.Hour)},
+			{ID: 2, Host: "host1:8086", LastSeen: now},
+		},
+		Databases: []meta.DatabaseInfo{{Name: "db0", RetentionPolicies: []meta.RetentionPolicyInfo{{Name: "rp0", ShardGroups: []meta.ShardGroupInfo{
+			group(1, 0, 1),    // sole owner is dead
+			group(2, 1, 2),    // sole owner is alive
+			group(3, 2, 1, 2), // one shard can still be served
+		}}}}},
+	}
+
+	groups, err := data.HealthyShardGroupsByTimeRange("db0", "rp0", base, now, now, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 2 || groups[0].ID != 2 || groups[1].ID != 3 {
+		t.Fatalf("unexpected shard groups: %+v", groups)
+	}
+
+	// With a longer threshold every node is alive.
+	if groups, err := data.HealthyShardGroupsByTimeRange("db0", "rp0", base, now, now, 2*time.Hour); err != nil {
+		t.Fatal(err)
+	} else if len(groups) != 3 {
+		t.Fatalf("unexpected shard group count: %d", len(groups))
+	}
+
+	if _, err := data.HealthyShardGroupsByTimeRange("db0", "no_rp", base, now, now, time.Minute); err == nil {
+		t.Fatal("expected error for missing retention policy")
+	}
+}
+
+func TestData_RepairDefaultRetentionPolicy(t *testing.T) {
+	data := &meta.Data{}
+	for _, name := range []string{"db0", "db1", "db2"} {
+		if err := data.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, x := range []struct{ db, rp string }{
+		{"db0", "rp0"}, {"db0", "rp1"}, {"db0", meta.DefaultRetentionPolicyName},
+		{"db1", "rp0"},
+		{"db2", "rp0"}, {"db2", "rp1"},
+	} {
+		rpi := &meta.RetentionPolicyInfo{Name: x.rp, ReplicaN: 1, ShardGroupDuration: time.Hour}
+		if err := data.CreateRetentionPolicy(x.db, rpi, x.rp == "rp0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A default that exists is left alone.
+	if data.RepairDefaultRetentionPolicy("db0") {
+		t.Fatal("expected valid default to be kept")
+	} else if data.RepairDefaultRetentionPolicy("missing") {
+		t.Fatal("expected missing database to be ignored")
+	}
+
+	for _, name := range []string{"db0", "db1", "db2"} {
+		if err := data.DropRetentionPolicy(name, "rp0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct{ db, exp string }{
+		{db: "db0", exp: meta.DefaultRetentionPolicyName},
+		{db: "db1", exp: ""},
+		{db: "db2", exp: "rp1"},
+	} {
+		if !data.RepairDefaultRetentionPolicy(tt.db) {
+			t.Fatalf("expected dangling default of %s to be repaired", tt.db)
+		} else if name := data.Database(tt.db).DefaultRetentionPolicy; name != tt.exp {
+			t.Fatalf("unexpected default of %s: got %q, exp %q", tt.db, name, tt.exp)
+		}
+	}
+
+	// A cleared default is not dangling.
+	if data.RepairDefaultRetentionPolicy("db1") {
+		t.Fatal("expected cleared default to be kept")
+	}
+}
+
+func TestData_AllRetentionPolicies(t *testing.T) {
+	data := &meta.Data{}
+	if refs := data.AllRetentionPolicies(); len(refs) != 0 {
+		t.Fatalf("unexpected retention policies without databases: %+v", refs)
+	}
+
+	for _, name := range []string{"db0", "db1", "db2"} {
+		if err := data.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, x := range []struct{ db, rp string }{{"db0", "rp0"}, {"db0", "rp1"}, {"db2", "rp0"}} {
+		rpi := &meta.RetentionPolicyInfo{Name: x.rp, ReplicaN: 1, ShardGroupDuration: time.Hour}
+		if err := data.CreateRetentionPolicy(x.db, rpi, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateShardGroup("db2", "rp0", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	refs := data.AllRetentionPolicies()
+	var got []string
+	for _, ref := range refs {
+		got = append(got, ref.Database+"."+ref.Name)
+	}
+	if exp := []string{"db0.rp0", "db0.rp1", "db2.rp0"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected retention policies: got %v, exp %v", got, exp)
+	}
+	if sgs := refs[2].ShardGroups; len(sgs) != 1 {
+		t.Fatalf("unexpected shard groups of db2.rp0: %+v", sgs)
+	}
+}
+
+func TestData_DetectOverlappingShardGroups(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Adjacent groups do not overlap.
+	if a := data.DetectOverlappingShardGroups(); len(a) != 0 {
+		t.Fatalf("unexpected overlaps: %+v", a)
+	}
+
+	// A group spanning the end of the first and the start of the second
+	// overlaps both. Deleted groups are ignored.
+	rp, _ := data.RetentionPolicy("db0", "rp0")
+	sgs := rp.ShardGroups
+	rp.ShardGroups = append(rp.ShardGroups,
+		meta.ShardGroupInfo{ID: 100, StartTime: start.Add(30 * time.Minute), EndTime: start.Add(90 * time.Minute)},
+		meta.ShardGroupInfo{ID: 101, StartTime: start, EndTime: start.Add(3 * time.Hour), DeletedAt: start},
+	)
+	exp := []meta.OverlapReport{
+		{Database: "db0", RetentionPolicy: "rp0", ShardGroupIDs: [2]uint64{sgs[0].ID, 100}},
+		{Database: "db0", RetentionPolicy: "rp0", ShardGroupIDs: [2]uint64{100, sgs[1].ID}},
+	}
+	if a := data.DetectOverlappingShardGroups(); !reflect.DeepEqual(a, exp) {
+		t.Fatalf("unexpected overlaps: got %+v, exp %+v", a, exp)
+	}
+}
+
+func TestData_ShardsOwnedBy(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each group has one shard on each node.
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := data.Database("db0").RetentionPolicy("rp0").ShardGroups[0].ID
+	if err := data.DeleteShardGroup("db0", "rp0", deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[uint64]uint64)
+	for _, n := range data.DataNodes {
+		shards := data.ShardsOwnedBy(n.ID)
+		if len(shards) != 2 {
+			t.Fatalf("unexpected shard count for node %d: %d", n.ID, len(shards))
+		}
+		for _, s := range shards {
+			if s.Database != "db0" || s.RetentionPolicy != "rp0" {
+				t.Fatalf("unexpected shard context: %+v", s)
+			} else if s.ShardGroupID == deleted {
+				t.Fatalf("unexpected shard from deleted group: %+v", s)
+			} else if !s.OwnedBy(n.ID) {
+				t.Fatalf("shard %d not owned by node %d", s.ID, n.ID)
+			}
+			if prev, ok := seen[s.ID]; ok {
+				t.Fatalf("shard %d reported for nodes %d and %d", s.ID, prev, n.ID)
+			}
+			seen[s.ID] = n.ID
+		}
+	}
+
+	if shards := data.ShardsOwnedBy(100); len(shards) != 0 {
+		t.Fatalf("unexpected shards for unknown node: %+v", shards)
+	}
+}
+
+func TestData_SetDatabaseQuota(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetDatabaseQuota("db0", meta.DatabaseQuota{MaxRetentionPolicies: 2, MaxShardGroups: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	newRP := func(name string) *meta.RetentionPolicyInfo {
+		return &meta.RetentionPolicyInfo{Name: name, ReplicaN: 1, ShardGroupDuration: time.Hour}
+	}
+	for _, name := range []string{"rp0", "rp1"} {
+		if err := data.CreateRetentionPolicy("db0", newRP(name), name == "rp0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateRetentionPolicy("db0", newRP("rp2"), false); err != meta.ErrQuotaExceeded {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrQuotaExceeded)
+	}
+	// Re-creating an existing policy is not limited.
+	if err := data.CreateRetentionPolicy("db0", newRP("rp1"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The shard group limit covers every retention policy in the database.
+	now := time.Now()
+	if err := data.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateShardGroup("db0", "rp1", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", now.Add(time.Hour)); err != meta.ErrQuotaExceeded {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrQuotaExceeded)
+	}
+
+	// Deleted groups do not count against the quota.
+	sgi := data.Database("db0").RetentionPolicy("rp1").ShardGroups[0]
+	if err := data.DeleteShardGroup("db0", "rp1", sgi.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The quota survives a marshal round trip.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if q := other.Database("db0").Quota; q != (meta.DatabaseQuota{MaxRetentionPolicies: 2, MaxShardGroups: 2}) {
+		t.Fatalf("unexpected quota: %+v", q)
+	}
+
+	// Zero means unlimited.
+	if err := data.SetDatabaseQuota("db0", meta.DatabaseQuota{}); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRetentionPolicy("db0", newRP("rp2"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.SetDatabaseQuota("db0", meta.DatabaseQuota{MaxShardGroups: -1}); err != meta.ErrInvalidQuota {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrInvalidQuota)
+	}
+	if err := data.SetDatabaseQuota("no_db", meta.DatabaseQuota{}); err == nil {
+		t.Fatal("expected error for missing database")
+	}
+}
+
+func TestData_SetDatabaseDefaultShardGroupDuration(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetDatabaseDefaultShardGroupDuration("db0", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default survives a marshal round trip.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if d := other.Database("db0").DefaultShardGroupDuration; d != time.Hour {
+		t.Fatalf("unexpected default shard group duration: %s", d)
+	}
+
+	month := 30 * 24 * time.Hour
+	twoDays := 2 * 24 * time.Hour
+	for _, tt := range []struct {
+		name     string
+		def      time.Duration
+		spec     meta.RetentionPolicySpec
+		expected time.Duration
+	}{
+		{name: "inherited", def: time.Hour, spec: meta.RetentionPolicySpec{Duration: &month}, expected: time.Hour},
+		{name: "inherited infinite", def: time.Hour, spec: meta.RetentionPolicySpec{}, expected: time.Hour},
+		{name: "specified", def: time.Hour, spec: meta.RetentionPolicySpec{Duration: &month, ShardGroupDuration: 2 * time.Hour}, expected: 2 * time.Hour},
+		{name: "longer than retention", def: 7 * 24 * time.Hour, spec: meta.RetentionPolicySpec{Duration: &twoDays}, expected: 24 * time.Hour},
+		{name: "no default", def: 0, spec: meta.RetentionPolicySpec{Duration: &month}, expected: 24 * time.Hour},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := data.SetDatabaseDefaultShardGroupDuration("db0", tt.def); err != nil {
+				t.Fatal(err)
+			}
+			if d := data.Database("db0").NewRetentionPolicyInfo(&tt.spec).ShardGroupDuration; d != tt.expected {
+				t.Fatalf("unexpected shard group duration: got %s, exp %s", d, tt.expected)
+			}
+		})
+	}
+
+	if err := data.SetDatabaseDefaultShardGroupDuration("db0", -time.Hour); err != meta.ErrShardGroupDurationInvalid {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrShardGroupDurationInvalid)
+	} else if err := data.SetDatabaseDefaultShardGroupDuration("no_db", time.Hour); err == nil {
+		t.Fatal("expected error for missing database")
+	}
+}
+
+func TestData_CopyRetentionPolicy(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDataNode("host0:8086", "host0:8088"); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetMeasurementRetention("db0", "rp0", "cpu", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := data.CopyRetentionPolicy("db0", "rp0", "rp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := data.Database("db0").RetentionPolicy("rp0")
+	dst := data.Database("db0").RetentionPolicy("rp1")
+	if dst == nil {
+		t.Fatal("expected copied retention policy")
+	} else if !reflect.DeepEqual(cp, dst) {
+		t.Fatalf("returned retention policy differs from stored one: %+v != %+v", cp, dst)
+	}
+	if dst.ReplicaN != src.ReplicaN || dst.Duration != src.Duration || dst.ShardGroupDuration != src.ShardGroupDuration {
+		t.Fatalf("unexpected settings: %+v", dst)
+	} else if dst.MeasurementRetention["cpu"] != time.Hour {
+		t.Fatalf("unexpected measurement retention: %v", dst.MeasurementRetention)
+	} else if len(dst.ShardGroups) != 0 {
+		t.Fatalf("unexpected shard groups: %+v", dst.ShardGroups)
+	} else if len(src.ShardGroups) != 1 {
+		t.Fatalf("source lost its shard groups: %+v", src.ShardGroups)
+	}
+
+	// The copy does not share state with its source.
+	dst.MeasurementRetention["cpu"] = 2 * time.Hour
+	if src.MeasurementRetention["cpu"] != time.Hour {
+		t.Fatal("copy shares measurement retention with its source")
+	}
+
+	if _, err := data.CopyRetentionPolicy("db0", "rp0", "rp1"); err != meta.ErrRetentionPolicyNameExists {
+		t.Fatalf("unexpected error copying onto an existing name: %v", err)
+	}
+	if _, err := data.CopyRetentionPolicy("db0", "rp2", "rp3"); err == nil {
+		t.Fatal("expected error copying a missing retention policy")
+	}
+	if n := len(data.Database("db0").RetentionPolicies); n != 2 {
+		t.Fatalf("unexpected retention policy count: %d", n)
+	}
+}
+
+func TestShardGroupInfo_OwnerNodeIDs(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1", "host2", "host3"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	sgi := &data.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+	if len(sgi.Shards) != 2 {
+		t.Fatalf("unexpected shard count: %d", len(sgi.Shards))
+	}
+	for _, si := range sgi.Shards {
+		if len(si.Owners) != 2 {
+			t.Fatalf("unexpected owners of shard %d: %v", si.ID, si.Owners)
+		}
+	}
+	if ids, exp := sgi.OwnerNodeIDs(), []uint64{1, 2, 3, 4}; !reflect.DeepEqual(ids, exp) {
+		t.Fatalf("unexpected owners: got %v, exp %v", ids, exp)
+	}
+
+	// A node owning more than one shard is listed once.
+	sgi.Shards[1].Owners = append(sgi.Shards[1].Owners, sgi.Shards[0].Owners[0])
+	if ids, exp := sgi.OwnerNodeIDs(), []uint64{1, 2, 3, 4}; !reflect.DeepEqual(ids, exp) {
+		t.Fatalf("unexpected owners with a shared owner: got %v, exp %v", ids, exp)
+	}
+
+	if ids := (&meta.ShardGroupInfo{}).OwnerNodeIDs(); len(ids) != 0 {
+		t.Fatalf("unexpected owners of empty group: %v", ids)
+	}
+}
+
+func TestData_CreateRetentionPolicy_ReplicaNTooHigh(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"host0", "host1"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 3, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, false); err != meta.ErrReplicaNTooHigh {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrReplicaNTooHigh)
+	}
+	rpi.ReplicaN = 2
+	if err := data.CreateRetentionPolicy("db0", rpi, false); err != nil {
+		t.Fatal(err)
+	}
+
+	rpu := &meta.RetentionPolicyUpdate{}
+	rpu.SetReplicaN(3)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", rpu, false); err != meta.ErrReplicaNTooHigh {
+		t.Fatalf("unexpected update error: got %v, exp %v", err, meta.ErrReplicaNTooHigh)
+	}
+	rpu.SetReplicaN(1)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", rpu, false); err != nil {
+		t.Fatal(err)
+	} else if n := data.Database("db0").RetentionPolicy("rp0").ReplicaN; n != 1 {
+		t.Fatalf("unexpected replica count: %d", n)
+	}
+
+	// Without data nodes, as on a single node, any replication factor is accepted.
+	single := &meta.Data{}
+	if err := single.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi = &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 3, ShardGroupDuration: time.Hour}
+	if err := single.CreateRetentionPolicy("db0", rpi, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestData_CreateShardGroup_ShardsPerGroup(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		nodes          int
+		replicaN       int
+		shardsPerGroup int
+		expShards      int
+	}{
+		{name: "default", nodes: 3, replicaN: 1, expShards: 3},
+		{name: "more shards than nodes", nodes: 3, replicaN: 1, shardsPerGroup: 6, expShards: 6},
+		{name: "replicated", nodes: 3, replicaN: 2, shardsPerGroup: 3, expShards: 3},
+		{name: "fewer shards than nodes", nodes: 4, replicaN: 1, shardsPerGroup: 2, expShards: 4},
+		{name: "single node", shardsPerGroup: 4, replicaN: 1, expShards: 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &meta.Data{}
+			for i := 0; i < tt.nodes; i++ {
+				host := "host" + strconv.Itoa(i)
+				if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := data.CreateDatabase("db0"); err != nil {
+				t.Fatal(err)
+			}
+			rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: tt.replicaN, ShardGroupDuration: time.Hour, ShardsPerGroup: tt.shardsPerGroup}
+			if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+				t.Fatal(err)
+			}
+
+			sgi := data.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+			if len(sgi.Shards) != tt.expShards {
+				t.Fatalf("unexpected shard count: got %d, exp %d", len(sgi.Shards), tt.expShards)
+			}
+
+			// Every shard is fully replicated on distinct nodes, and
+			// every node owns the same number of shards.
+			owned := make(map[uint64]int)
+			for _, si := range sgi.Shards {
+				if len(si.Owners) != tt.replicaN {
+					t.Fatalf("unexpected owners of shard %d: %v", si.ID, si.Owners)
+				}
+				seen := make(map[uint64]bool)
+				for _, o := range si.Owners {
+					if seen[o.NodeID] {
+						t.Fatalf("node %d owns shard %d twice", o.NodeID, si.ID)
+					}
+					seen[o.NodeID] = true
+					owned[o.NodeID]++
+				}
+			}
+			if tt.nodes > 0 {
+				exp := tt.expShards * tt.replicaN / tt.nodes
+				for id, n := range owned {
+					if n != exp {
+						t.Fatalf("unbalanced owners: node %d owns %d shards, exp %d: %v", id, n, exp, owned)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRetentionPolicySpec_Matches_Tenancy(t *testing.T) {
+	rpi := &meta.RetentionPolicyInfo{
+		Name:               "rp0",
+		ReplicaN:           1,
+		ShardGroupDuration: time.Hour,
+		ShardKeyTags:       []string{"tenant"},
+		TenantNodes:        map[string][]uint64{"acme": {1, 2}},
+	}
+
+	for i, tt := range []struct {
+		spec meta.RetentionPolicySpec
+		exp  bool
+	}{
+		{spec: meta.RetentionPolicySpec{Name: "rp0", ShardGroupDuration: time.Hour}, exp: true},
+		{spec: meta.RetentionPolicySpec{Name: "rp0", ShardGroupDuration: time.Hour, ShardKeyTags: []string{"tenant"}, TenantNodes: map[string][]uint64{"acme": {1, 2}}}, exp: true},
+		{spec: meta.RetentionPolicySpec{Name: "rp0", ShardGroupDuration: time.Hour, ShardKeyTags: []string{"host"}}, exp: false},
+		{spec: meta.RetentionPolicySpec{Name: "rp0", ShardGroupDuration: time.Hour, TenantNodes: map[string][]uint64{"acme": {1}}}, exp: false},
+		{spec: meta.RetentionPolicySpec{Name: "rp0", ShardGroupDuration: time.Hour, TenantNodes: map[string][]uint64{"globex": {1, 2}}}, exp: false},
+	} {
+		if got := tt.spec.Matches(rpi); got != tt.exp {
+			t.Errorf("%d. unexpected match: got %v, exp %v", i, got, tt.exp)
+		}
+	}
+
+	// Creating the policy again with other tenant nodes is refused.
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRetentionPolicy("db0", rpi, false); err != nil {
+		t.Fatal(err)
+	}
+	other := *rpi
+	other.TenantNodes = map[string][]uint64{"acme": {3}}
+	if err := data.CreateRetentionPolicy("db0", &other, false); err != meta.ErrRetentionPolicyExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_CreateShardGroup_TenantNodes(t *testing.T) {
+	data := &meta.Data{}
+	for i := 1; i <= 4; i++ {
+		host := "host" + strconv.Itoa(i)
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{
+		Name:               "rp0",
+		ReplicaN:           1,
+		ShardGroupDuration: time.Hour,
+		ShardKeyTags:       []string{"tenant"},
+		TenantNodes: map[string][]uint64{
+			"acme":   {3, 4},
+			"globex": {2},
+			"gone":   {9},
+		},
+	}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The routing settings survive a round trip through the binary format.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	rp := other.Database("db0").RetentionPolicy("rp0")
+	if !reflect.DeepEqual(rp.ShardKeyTags, rpi.ShardKeyTags) || !reflect.DeepEqual(rp.TenantNodes, rpi.TenantNodes) {
+		t.Fatalf("unexpected routing after round trip: %v %v", rp.ShardKeyTags, rp.TenantNodes)
+	}
+
+	if err := other.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	sgi := other.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+
+	// Every node shares a shard, and each tenant with a live node gets a
+	// shard per node of its own, owned only by its nodes.
+	owners := make(map[string][]uint64)
+	for _, si := range sgi.Shards {
+		for _, o := range si.Owners {
+			owners[si.Tenant] = append(owners[si.Tenant], o.NodeID)
+		}
+	}
+	for _, a := range owners {
+		sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	}
+	if exp := map[string][]uint64{
+		"":       {1, 2, 3, 4},
+		"acme":   {3, 4},
+		"globex": {2},
+	}; !reflect.DeepEqual(owners, exp) {
+		t.Fatalf("unexpected owners: got %v, exp %v", owners, exp)
+	}
+
+	// Points are routed by the value of the shard key tag.
+	for _, tt := range []struct {
+		tags  map[string]string
+		nodes []uint64
+	}{
+		{tags: map[string]string{"tenant": "acme"}, nodes: []uint64{3, 4}},
+		{tags: map[string]string{"tenant": "globex"}, nodes: []uint64{2}},
+		{tags: map[string]string{"tenant": "initech"}, nodes: []uint64{1, 2, 3, 4}},
+		{tags: map[string]string{"host": "a"}, nodes: []uint64{1, 2, 3, 4}},
+	} {
+		for i := 0; i < 16; i++ {
+			tags := models.NewTags(tt.tags)
+			p := models.MustNewPoint("cpu"+strconv.Itoa(i), tags, models.Fields{"v": 1.0}, time.Now())
+			si := sgi.ShardForTenant(rp.Tenant(tags), p)
+			if len(si.Owners) != 1 || !containsNodeID(tt.nodes, si.Owners[0].NodeID) {
+				t.Fatalf("point with tags %v routed to shard %+v", tt.tags, si)
+			}
+		}
+	}
+}
+
+func containsNodeID(a []uint64, id uint64) bool {
+	for _, x := range a {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestData_UpdateRetentionPolicy_ShardsPerGroup(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rpu := &meta.RetentionPolicyUpdate{}
+	rpu.SetShardsPerGroup(0)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", rpu, false); err != meta.ErrInvalidShardsPerGroup {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrInvalidShardsPerGroup)
+	}
+	rpu.SetShardsPerGroup(2)
+	if err := data.UpdateRetentionPolicy("db0", "rp0", rpu, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The setting survives a round trip through the binary format.
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other meta.Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if n := other.Database("db0").RetentionPolicy("rp0").ShardsPerGroup; n != 2 {
+		t.Fatalf("unexpected shards per group: %d", n)
+	}
+
+	if err := other.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	} else if n := len(other.Database("db0").RetentionPolicy("rp0").ShardGroups[0].Shards); n != 2 {
+		t.Fatalf("unexpected shard count: %d", n)
+	}
+}
+
+func TestData_RebalanceShardGroup(t *testing.T) {
+	data := &meta.Data{}
+	for _, host := range []string{"node1", "node2"} {
+		if err := data.CreateDataNode(host+":8086", host+":8088"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := data.CreateShardGroup("db0", "rp0", start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each node owns one shard of each group until a third node is added.
+	if err := data.CreateDataNode("node3:8086", "node3:8088"); err != nil {
+		t.Fatal(err)
+	}
+	sgs, _ := data.ShardGroups("db0", "rp0")
+	if owners := sgs[0].OwnerNodeIDs(); !reflect.DeepEqual(owners, []uint64{1, 2}) {
+		t.Fatalf("unexpected owners before rebalancing: %v", owners)
+	}
+
+	if err := data.RebalanceShardGroup("db0", "rp0", sgs[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	sgs, _ = data.ShardGroups("db0", "rp0")
+	if owners := sgs[0].OwnerNodeIDs(); !reflect.DeepEqual(owners, []uint64{1, 3}) {
+		t.Fatalf("unexpected owners after rebalancing: %v", owners)
+	} else if len(sgs[0].Shards) != 2 {
+		t.Fatalf("unexpected shard count: %d", len(sgs[0].Shards))
+	} else if n := data.DataNode(3); n.ShardCount != 1 {
+		t.Fatalf("unexpected shard count on node 3: %d", n.ShardCount)
+	}
+
+	if err := data.RebalanceShardGroup("db0", "rp0", 100); err != meta.ErrShardGroupNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestData_ReserveShardIDs(t *testing.T) {
+	data := &meta.Data{MaxShardID: 4}
+	if start, err := data.ReserveShardIDs(3); err != nil {
+		t.Fatal(err)
+	} else if start != 5 || data.MaxShardID != 7 {
+		t.Fatalf("unexpected reservation: start %d, max shard id %d", start, data.MaxShardID)
+	}
+
+	// Shards created afterwards use IDs above the reservation.
+	if err := data.CreateDataNode("node1:8086", "node1:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateShardGroup("db0", "rp0", time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if sgs, _ := data.ShardGroups("db0", "rp0"); sgs[0].Shards[0].ID <= 7 {
+		t.Fatalf("unexpected shard id: %d", sgs[0].Shards[0].ID)
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := data.ReserveShardIDs(n); err != meta.ErrInvalidShardIDCount {
+			t.Fatalf("unexpected error reserving %d: %v", n, err)
+		}
+	}
+}
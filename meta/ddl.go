@@ -0,0 +1,133 @@
+package meta
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// DDLPasswordPlaceholder is written in place of user passwords by
+// ExportUsersDDL, since only password hashes are stored.
+const DDLPasswordPlaceholder = "[REDACTED]"
+
+// ExportContinuousQueriesDDL writes a CREATE CONTINUOUS QUERY statement for
+// every continuous query, one per line.
+func (data *Data) ExportContinuousQueriesDDL(w io.Writer) error {
+	for _, di := range data.Databases {
+		for _, cqi := range di.ContinuousQueries {
+			if _, err := fmt.Fprintf(w, "%s;\n", strings.TrimSpace(cqi.Query)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportRetentionPoliciesDDL writes the statements that recreate every
+// database and its retention policies, one per line. The default retention
+// policy is created along with its database.
+func (data *Data) ExportRetentionPoliciesDDL(w io.Writer) error {
+	for _, di := range data.Databases {
+		if _, err := fmt.Fprintf(w, "%s;\n", createDatabaseDDL(&di)); err != nil {
+			return err
+		}
+
+		for _, rpi := range di.RetentionPolicies {
+			if rpi.Name == di.DefaultRetentionPolicy {
+				continue
+			}
+			stmt := &cnosql.CreateRetentionPolicyStatement{
+				Name:               rpi.Name,
+				Database:           di.Name,
+				Duration:           rpi.Duration,
+				Replication:        rpi.ReplicaN,
+				ShardGroupDuration: rpi.ShardGroupDuration,
+			}
+			if _, err := fmt.Fprintf(w, "%s;\n", stmt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportSubscriptionsDDL writes a CREATE SUBSCRIPTION statement for every
+// subscription, one per line.
+func (data *Data) ExportSubscriptionsDDL(w io.Writer) error {
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, si := range rpi.Subscriptions {
+				stmt := &cnosql.CreateSubscriptionStatement{
+					Name:            si.Name,
+					Database:        di.Name,
+					RetentionPolicy: rpi.Name,
+					Destinations:    si.Destinations,
+					Mode:            si.Mode,
+				}
+				if _, err := fmt.Fprintf(w, "%s;\n", stmt); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// createDatabaseDDL returns a CREATE DATABASE statement for di that also
+// creates its default retention policy.
+func createDatabaseDDL(di *DatabaseInfo) string {
+	var buf strings.Builder
+	buf.WriteString("CREATE DATABASE ")
+	buf.WriteString(cnosql.QuoteIdent(di.Name))
+
+	rpi := di.RetentionPolicy(di.DefaultRetentionPolicy)
+	if rpi == nil {
+		return buf.String()
+	}
+	buf.WriteString(" WITH DURATION ")
+	buf.WriteString(cnosql.FormatDuration(rpi.Duration))
+	buf.WriteString(" REPLICATION ")
+	buf.WriteString(strconv.Itoa(rpi.ReplicaN))
+	if rpi.ShardGroupDuration > 0 {
+		buf.WriteString(" SHARD DURATION ")
+		buf.WriteString(cnosql.FormatDuration(rpi.ShardGroupDuration))
+	}
+	buf.WriteString(" NAME ")
+	buf.WriteString(cnosql.QuoteIdent(rpi.Name))
+	return buf.String()
+}
+
+// ExportUsersDDL writes the statements that recreate every user and their
+// privileges, one per line. Passwords are written as DDLPasswordPlaceholder
+// and must be replaced before the statements are executed.
+func (data *Data) ExportUsersDDL(w io.Writer) error {
+	for _, ui := range data.Users {
+		stmt := "CREATE USER " + cnosql.QuoteIdent(ui.Name) + " WITH PASSWORD " + cnosql.QuoteString(DDLPasswordPlaceholder)
+		if ui.Admin {
+			stmt += " WITH ALL PRIVILEGES"
+		}
+		if _, err := fmt.Fprintf(w, "%s;\n", stmt); err != nil {
+			return err
+		}
+
+		databases := make([]string, 0, len(ui.Privileges))
+		for db, p := range ui.Privileges {
+			if p != cnosql.NoPrivileges {
+				databases = append(databases, db)
+			}
+		}
+		sort.Strings(databases)
+
+		for _, db := range databases {
+			grant := &cnosql.GrantStatement{Privilege: ui.Privileges[db], On: db, User: ui.Name}
+			if _, err := fmt.Fprintf(w, "%s;\n", grant); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package meta_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+func TestData_ExportDDL(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, rpi := range []*meta.RetentionPolicyInfo{
+		{Name: "rp0", ReplicaN: 1, Duration: 7 * 24 * time.Hour, ShardGroupDuration: 24 * time.Hour},
+		{Name: "rp 1", ReplicaN: 2, Duration: 0, ShardGroupDuration: 90 * time.Minute},
+	} {
+		if err := data.CreateRetentionPolicy("db0", rpi, rpi.Name == "rp0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.CreateContinuousQuery("db0", "cq0", `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT mean(value) INTO "rp 1".cpu_1h FROM cpu GROUP BY time(1h) END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateUser("admin", "hash0", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateUser("reader", "hash1", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.SetPrivilege("reader", "db0", cnosql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	for _, export := range []func(io.Writer) error{
+		data.ExportRetentionPoliciesDDL,
+		data.ExportContinuousQueriesDDL,
+		data.ExportUsersDDL,
+	} {
+		if err := export(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Contains(buf.String(), "hash") {
+		t.Fatalf("password hash exported:\n%s", buf.String())
+	}
+
+	q, err := cnosql.ParseQuery(buf.String())
+	if err != nil {
+		t.Fatalf("exported DDL does not parse: %v\n%s", err, buf.String())
+	}
+	if len(q.Statements) != 6 {
+		t.Fatalf("unexpected statement count: %d\n%s", len(q.Statements), buf.String())
+	}
+
+	db, ok := q.Statements[0].(*cnosql.CreateDatabaseStatement)
+	if !ok {
+		t.Fatalf("unexpected statement: %s", q.Statements[0])
+	} else if db.Name != "db0" || db.RetentionPolicyName != "rp0" || *db.RetentionPolicyDuration != 7*24*time.Hour {
+		t.Fatalf("unexpected database statement: %s", db)
+	}
+	rp, ok := q.Statements[1].(*cnosql.CreateRetentionPolicyStatement)
+	if !ok {
+		t.Fatalf("unexpected statement: %s", q.Statements[1])
+	} else if rp.Name != "rp 1" || rp.Replication != 2 || rp.ShardGroupDuration != 90*time.Minute {
+		t.Fatalf("unexpected retention policy statement: %s", rp)
+	}
+	if cq, ok := q.Statements[2].(*cnosql.CreateContinuousQueryStatement); !ok || cq.Name != "cq0" {
+		t.Fatalf("unexpected statement: %s", q.Statements[2])
+	}
+	if u, ok := q.Statements[3].(*cnosql.CreateUserStatement); !ok || u.Name != "admin" || !u.Admin || u.Password != meta.DDLPasswordPlaceholder {
+		t.Fatalf("unexpected statement: %s", q.Statements[3])
+	}
+	if g, ok := q.Statements[5].(*cnosql.GrantStatement); !ok || g.User != "reader" || g.On != "db0" || g.Privilege != cnosql.ReadPrivilege {
+		t.Fatalf("unexpected statement: %s", q.Statements[5])
+	}
+}
+
+func TestData_ExportSubscriptionsDDL(t *testing.T) {
+	data := &meta.Data{}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateSubscription("db0", "rp0", "sub0", "ANY", []string{"udp://h0:9090", "udp://h1:9090"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := data.ExportSubscriptionsDDL(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := cnosql.ParseQuery(buf.String())
+	if err != nil {
+		t.Fatalf("exported DDL does not parse: %v\n%s", err, buf.String())
+	} else if len(q.Statements) != 1 {
+		t.Fatalf("unexpected statement count: %d\n%s", len(q.Statements), buf.String())
+	}
+	sub, ok := q.Statements[0].(*cnosql.CreateSubscriptionStatement)
+	if !ok {
+		t.Fatalf("unexpected statement: %s", q.Statements[0])
+	} else if sub.Name != "sub0" || sub.Database != "db0" || sub.RetentionPolicy != "rp0" || sub.Mode != "ANY" || len(sub.Destinations) != 2 {
+		t.Fatalf("unexpected subscription statement: %s", sub)
+	}
+}
@@ -0,0 +1,194 @@
+package meta
+
+import (
+	"errors"
+	"reflect"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// A RemoteClient polling for updates may ask a metaserver for a delta
+// instead of the full data. The server answers with only the databases and
+// users that were added, changed or dropped since the client's index, plus
+// the rest of the data, which is small. It can only do so while it still
+// holds the data at that index; otherwise, and for servers that predate
+// deltas, the full data is sent. The response carries metaDeltaHeader when
+// it is a delta.
+
+// metaDeltaHeader marks a snapshot response that holds a delta.
+const metaDeltaHeader = "X-CnosDB-Meta-Delta"
+
+// deltaHistorySize is the number of recent versions of the data a store keeps
+// to compute deltas from.
+const deltaHistorySize = 32
+
+// errDeltaBase is returned when a delta is not based on the cached data, as
+// happens when the cache changes while a poll is waiting.
+var errDeltaBase = errors.New("meta delta does not apply to the cached data")
+
+// dataVersion is the data as of an applied log index.
+type dataVersion struct {
+	index uint64
+	data  *Data
+}
+
+// dataHistory holds the most recent versions of the data, oldest first. The
+// versions are shared with the store and must not be modified.
+type dataHistory struct {
+	versions []dataVersion
+}
+
+// add records data as the version at index, forgetting the oldest version
+// once the history is full.
+func (h *dataHistory) add(index uint64, data *Data) {
+	if len(h.versions) >= deltaHistorySize {
+		copy(h.versions, h.versions[1:])
+		h.versions = h.versions[:len(h.versions)-1]
+	}
+	h.versions = append(h.versions, dataVersion{index: index, data: data})
+}
+
+// at returns the data at index, or nil if it is not held.
+func (h *dataHistory) at(index uint64) *Data {
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		if h.versions[i].index == index {
+			return h.versions[i].data
+		}
+	}
+	return nil
+}
+
+// reset forgets every version.
+func (h *dataHistory) reset() {
+	h.versions = nil
+}
+
+// deltaSince returns the change from the data at index to the current data,
+// or nil if the data at index is no longer held.
+func (s *store) deltaSince(index uint64) *internal.DataDelta {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	base := s.history.at(index)
+	if base == nil {
+		return nil
+	}
+	return newDataDelta(index, base, s.data)
+}
+
+// newDataDelta returns the change from base, the data at baseIndex, to data.
+func newDataDelta(baseIndex uint64, base, data *Data) *internal.DataDelta {
+	pb := &internal.DataDelta{BaseIndex: proto.Uint64(baseIndex)}
+
+	// Marshal a copy that holds only the changed databases and users.
+	other := *data
+	other.Databases, other.Users = nil, nil
+
+	prevDBs := make(map[string]*DatabaseInfo, len(base.Databases))
+	for i := range base.Databases {
+		prevDBs[base.Databases[i].Name] = &base.Databases[i]
+	}
+	dbs := make(map[string]struct{}, len(data.Databases))
+	for i := range data.Databases {
+		di := &data.Databases[i]
+		dbs[di.Name] = struct{}{}
+		if prev, ok := prevDBs[di.Name]; !ok || !reflect.DeepEqual(prev, di) {
+			other.Databases = append(other.Databases, *di)
+		}
+	}
+	for _, di := range base.Databases {
+		if _, ok := dbs[di.Name]; !ok {
+			pb.DroppedDatabases = append(pb.DroppedDatabases, di.Name)
+		}
+	}
+
+	prevUsers := make(map[string]*UserInfo, len(base.Users))
+	for i := range base.Users {
+		prevUsers[base.Users[i].Name] = &base.Users[i]
+	}
+	users := make(map[string]struct{}, len(data.Users))
+	for i := range data.Users {
+		ui := &data.Users[i]
+		users[ui.Name] = struct{}{}
+		if prev, ok := prevUsers[ui.Name]; !ok || !reflect.DeepEqual(prev, ui) {
+			other.Users = append(other.Users, *ui)
+		}
+	}
+	for _, ui := range base.Users {
+		if _, ok := users[ui.Name]; !ok {
+			pb.DroppedUsers = append(pb.DroppedUsers, ui.Name)
+		}
+	}
+
+	pb.Data = other.marshal()
+	return pb
+}
+
+// applyDataDelta returns the data that results from applying pb to base. It
+// returns errDeltaBase if pb is not based on base. Databases and users keep
+// their order, and new ones follow in the order the server has them.
+func applyDataDelta(base *Data, pb *internal.DataDelta) (*Data, error) {
+	if pb.GetBaseIndex() != base.Index {
+		return nil, errDeltaBase
+	}
+
+	data := &Data{caseInsensitiveNames: base.caseInsensitiveNames}
+	data.unmarshal(pb.GetData())
+
+	droppedDBs := make(map[string]struct{}, len(pb.GetDroppedDatabases()))
+	for _, name := range pb.GetDroppedDatabases() {
+		droppedDBs[name] = struct{}{}
+	}
+	changedDBs := make(map[string]DatabaseInfo, len(data.Databases))
+	for _, di := range data.Databases {
+		changedDBs[di.Name] = di
+	}
+	dbs := make([]DatabaseInfo, 0, len(base.Databases)+len(data.Databases))
+	for _, di := range base.Databases {
+		if _, ok := droppedDBs[di.Name]; ok {
+			continue
+		} else if changed, ok := changedDBs[di.Name]; ok {
+			dbs = append(dbs, changed)
+			delete(changedDBs, di.Name)
+			continue
+		}
+		dbs = append(dbs, di.clone())
+	}
+	for _, di := range data.Databases {
+		if _, ok := changedDBs[di.Name]; ok {
+			dbs = append(dbs, di)
+		}
+	}
+	data.Databases = dbs
+
+	droppedUsers := make(map[string]struct{}, len(pb.GetDroppedUsers()))
+	for _, name := range pb.GetDroppedUsers() {
+		droppedUsers[name] = struct{}{}
+	}
+	changedUsers := make(map[string]UserInfo, len(data.Users))
+	for _, ui := range data.Users {
+		changedUsers[ui.Name] = ui
+	}
+	users := make([]UserInfo, 0, len(base.Users)+len(data.Users))
+	for _, ui := range base.Users {
+		if _, ok := droppedUsers[ui.Name]; ok {
+			continue
+		} else if changed, ok := changedUsers[ui.Name]; ok {
+			users = append(users, changed)
+			delete(changedUsers, ui.Name)
+			continue
+		}
+		users = append(users, ui.clone())
+	}
+	for _, ui := range data.Users {
+		if _, ok := changedUsers[ui.Name]; ok {
+			users = append(users, ui)
+		}
+	}
+	data.Users = users
+
+	data.adminUserExists = data.hasAdminUser()
+	return data, nil
+}
@@ -0,0 +1,218 @@
+package meta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/raft"
+)
+
+// newTestDeltaData returns data at index 10 with two databases and two users.
+func newTestDeltaData(t *testing.T) *Data {
+	t.Helper()
+
+	data := &Data{Index: 10, ClusterID: 100}
+	for _, name := range []string{"db0", "db1"} {
+		if err := data.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+		rpi := &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+		if err := data.CreateRetentionPolicy(name, rpi, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"u0", "u1"} {
+		if err := data.CreateUser(name, "hash", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return roundTripData(t, data)
+}
+
+// roundTripData returns data as a client decodes it from a full snapshot.
+func roundTripData(t *testing.T, data *Data) *Data {
+	t.Helper()
+
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := &Data{}
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	return other
+}
+
+func TestDataDelta(t *testing.T) {
+	base := newTestDeltaData(t)
+
+	data := base.Clone()
+	data.Index = 12
+	if err := data.CreateShardGroup("db0", "rp0", time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	} else if err := data.DropDatabase("db1"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db2"); err != nil {
+		t.Fatal(err)
+	} else if err := data.UpdateUser("u0", "newhash"); err != nil {
+		t.Fatal(err)
+	} else if err := data.DropUser("u1"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateUser("u2", "hash", true); err != nil {
+		t.Fatal(err)
+	}
+
+	pb := newDataDelta(base.Index, base, data)
+	var names []string
+	for _, di := range pb.GetData().GetDatabases() {
+		names = append(names, di.GetName())
+	}
+	for _, ui := range pb.GetData().GetUsers() {
+		names = append(names, ui.GetName())
+	}
+	if exp := []string{"db0", "db2", "u0", "u2"}; !reflect.DeepEqual(names, exp) {
+		t.Fatalf("unexpected changes: got %v, exp %v", names, exp)
+	} else if !reflect.DeepEqual(pb.GetDroppedDatabases(), []string{"db1"}) || !reflect.DeepEqual(pb.GetDroppedUsers(), []string{"u1"}) {
+		t.Fatalf("unexpected drops: %v, %v", pb.GetDroppedDatabases(), pb.GetDroppedUsers())
+	}
+
+	// Applying the delta sent over the wire gives the same data as a full
+	// snapshot.
+	b, err := proto.Marshal(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent internal.DataDelta
+	if err := proto.Unmarshal(b, &sent); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := applyDataDelta(base, &sent)
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := roundTripData(t, data); !reflect.DeepEqual(applied, exp) {
+		t.Fatalf("unexpected data:\ngot %+v\nexp %+v", applied, exp)
+	} else if !applied.AdminUserExists() {
+		t.Fatal("expected admin user to exist")
+	}
+
+	base.Index = 11
+	if _, err := applyDataDelta(base, &sent); err != errDeltaBase {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStore_deltaSince(t *testing.T) {
+	fsm := newTestStoreFSM()
+	fsm.config = NewConfig()
+	s := (*store)(fsm)
+
+	apply := func(index uint64, name string) {
+		b, err := proto.Marshal(newTestCreateDatabaseCommand(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsm.Apply(&raft.Log{Index: index, Data: b})
+	}
+	apply(2, "db0")
+	apply(3, "db1")
+
+	if pb := s.deltaSince(2); pb == nil {
+		t.Fatal("expected delta from index 2")
+	} else if len(pb.GetData().GetDatabases()) != 1 || pb.GetData().GetDatabases()[0].GetName() != "db1" {
+		t.Fatalf("unexpected delta: %+v", pb)
+	} else if pb.GetData().GetIndex() != 3 {
+		t.Fatalf("unexpected index: %d", pb.GetData().GetIndex())
+	}
+
+	// Versions older than the history, or from before a restore, are gone.
+	for i := 0; i < deltaHistorySize; i++ {
+		apply(uint64(4+i), "db1")
+	}
+	if s.deltaSince(2) != nil {
+		t.Fatal("expected no delta from an evicted index")
+	} else if s.deltaSince(4) == nil {
+		t.Fatal("expected delta from index 4")
+	}
+	s.history.reset()
+	if s.deltaSince(4) != nil {
+		t.Fatal("expected no delta after reset")
+	}
+}
+
+func TestRemoteClient_getSnapshot_Delta(t *testing.T) {
+	base := newTestDeltaData(t)
+	data := base.Clone()
+	data.Index = 11
+	if err := data.CreateDatabase("db2"); err != nil {
+		t.Fatal(err)
+	}
+	full, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var requests []string
+	var baseIndex uint64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RawQuery)
+		idx := baseIndex
+		mu.Unlock()
+
+		if r.URL.Query().Get("delta") == "true" {
+			b, err := proto.Marshal(newDataDelta(idx, base, data))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			w.Header().Set(metaDeltaHeader, "true")
+			w.Write(b)
+			return
+		}
+		w.Write(full)
+	}))
+	defer ts.Close()
+	server := strings.TrimPrefix(ts.URL, "http://")
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{server})
+	c.cacheData = base
+
+	getSnapshot := func(idx uint64) []string {
+		t.Helper()
+
+		mu.Lock()
+		requests, baseIndex = nil, idx
+		mu.Unlock()
+
+		got, err := c.getSnapshot(server, base.Index, nil)
+		if err != nil {
+			t.Fatal(err)
+		} else if exp := roundTripData(t, data); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected data:\ngot %+v\nexp %+v", got, exp)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	if reqs := getSnapshot(base.Index); len(reqs) != 1 || !strings.Contains(reqs[0], "delta=true") {
+		t.Fatalf("unexpected requests: %v", reqs)
+	}
+
+	// A delta that is not based on the cached data is dropped for the full
+	// data.
+	if reqs := getSnapshot(base.Index - 1); len(reqs) != 2 || strings.Contains(reqs[1], "delta") {
+		t.Fatalf("unexpected requests: %v", reqs)
+	}
+}
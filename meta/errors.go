@@ -3,6 +3,9 @@ package meta
 import (
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/cnosdb/cnosdb/vend/cnosql"
 )
 
 var (
@@ -11,8 +14,47 @@ var (
 
 	// ErrStoreClosed is returned when closing an already closed store.
 	ErrStoreClosed = errors.New("raft store already closed")
+
+	// ErrMaintenanceMode is returned when changing the meta data while it is
+	// in maintenance mode.
+	ErrMaintenanceMode = errors.New("meta data is read-only in maintenance mode")
+
+	// ErrMetaReadOnly is returned when changing the meta data through a
+	// client configured as read-only.
+	ErrMetaReadOnly = errors.New("meta client is read-only")
+
+	// ErrInvalidWriteConsistency is returned when setting a write consistency
+	// level other than leader, quorum or all.
+	ErrInvalidWriteConsistency = errors.New("write consistency must be leader, quorum or all")
+
+	// ErrWriteNotAcknowledged is returned when a command was committed but
+	// not enough meta servers applied it in time for the quorum or all write
+	// consistency level.
+	ErrWriteNotAcknowledged = errors.New("command not acknowledged by enough meta servers")
+
+	// ErrTLSNotConfigured is returned when reloading the TLS files of a
+	// client that has none set.
+	ErrTLSNotConfigured = errors.New("no TLS certificate or CA files configured")
+
+	// ErrInvalidTransactionCommand is returned when a transaction holds a
+	// command that a Transaction cannot build.
+	ErrInvalidTransactionCommand = errors.New("command not allowed in a transaction")
 )
 
+// ErrNotLeader is returned when a command is applied on a meta node that is not
+// the raft leader. Leader is the HTTP address of the current leader, or empty
+// if no leader is known.
+type ErrNotLeader struct {
+	Leader string
+}
+
+func (e ErrNotLeader) Error() string {
+	if e.Leader == "" {
+		return "node is not the leader, no leader elected"
+	}
+	return fmt.Sprintf("node is not the leader, leader is %s", e.Leader)
+}
+
 var (
 	// ErrNodeExists is returned when creating an already existing node.
 	ErrNodeExists = errors.New("node already exists")
@@ -48,8 +90,40 @@ var (
 
 	// ErrInvalidName is returned when attempting to create a database or retention policy with an invalid name
 	ErrInvalidName = errors.New("invalid name")
+
+	// ErrQuotaExceeded is returned when creating a retention policy or shard
+	// group would exceed the quota of its database.
+	ErrQuotaExceeded = errors.New("database quota exceeded")
+
+	// ErrInvalidQuota is returned when setting a database quota with a
+	// negative limit.
+	ErrInvalidQuota = errors.New("database quota limits must not be negative")
 )
 
+// ErrDatabaseHasSubscriptions is returned when dropping a database that has
+// subscriptions without force, as dropping it would remove them. The message
+// lists the DROP SUBSCRIPTION statements that remove them first.
+type ErrDatabaseHasSubscriptions struct {
+	Database string
+
+	// Subscriptions are the names of the subscriptions, and
+	// RetentionPolicies the retention policy of each.
+	Subscriptions     []string
+	RetentionPolicies []string
+}
+
+func (e ErrDatabaseHasSubscriptions) Error() string {
+	stmts := make([]string, len(e.Subscriptions))
+	for i, name := range e.Subscriptions {
+		stmts[i] = (&cnosql.DropSubscriptionStatement{Name: name, Database: e.Database, RetentionPolicy: e.RetentionPolicies[i]}).String()
+	}
+	return fmt.Sprintf("database %s has subscriptions %s, drop them first: %s", e.Database, strings.Join(e.Subscriptions, ", "), strings.Join(stmts, "; "))
+}
+
+// ErrInvalidPageLimit is returned when requesting a page of shard IDs with a
+// limit that is not positive.
+var ErrInvalidPageLimit = errors.New("page limit must be positive")
+
 var (
 	// ErrRetentionPolicyExists is returned when creating an already existing retention policy.
 	ErrRetentionPolicyExists = errors.New("retention policy already exists")
@@ -85,9 +159,29 @@ var (
 	// duration.
 	ErrIncompatibleDurations = errors.New("retention policy duration must be greater than the shard duration")
 
+	// ErrRetentionPolicyFrozen is returned when creating a shard group in a
+	// frozen retention policy.
+	ErrRetentionPolicyFrozen = errors.New("retention policy is frozen")
+
+	// ErrShardGroupDurationInvalid is returned when setting a negative
+	// default shard group duration on a database.
+	ErrShardGroupDurationInvalid = errors.New("shard group duration must not be negative")
+
+	// ErrMeasurementRetentionInvalid is returned when a measurement retention
+	// override is negative or longer than its retention policy duration.
+	ErrMeasurementRetentionInvalid = errors.New("measurement retention must be positive and no longer than the retention policy duration")
+
 	// ErrReplicationFactorTooLow is returned when the replication factor is not in an
 	// acceptable range.
 	ErrReplicationFactorTooLow = errors.New("replication factor must be greater than 0")
+
+	// ErrReplicaNTooHigh is returned when the replication factor is greater
+	// than the number of data nodes.
+	ErrReplicaNTooHigh = errors.New("replication factor must not exceed the number of data nodes")
+
+	// ErrInvalidShardsPerGroup is returned when the number of shards per
+	// shard group is not positive.
+	ErrInvalidShardsPerGroup = errors.New("shards per group must be greater than 0")
 )
 
 var (
@@ -97,9 +191,29 @@ var (
 	// ErrShardGroupNotFound is returned when mutating a shard group that doesn't exist.
 	ErrShardGroupNotFound = errors.New("shard group not found")
 
+	// ErrShardGroupsNotCreated is returned when none of a batch of shard
+	// groups could be created.
+	ErrShardGroupsNotCreated = errors.New("no shard group in the batch could be created")
+
+	// ErrShardGroupDeletedAtRequired is returned when marking a shard group
+	// deleted without a deletion time.
+	ErrShardGroupDeletedAtRequired = errors.New("shard group deletion time required")
+
+	// ErrShardGroupsExpired is returned when every shard group in a time range
+	// is older than the retention policy duration.
+	ErrShardGroupsExpired = errors.New("time range is beyond retention policy duration")
+
 	// ErrShardNotReplicated is returned if the node requested to be dropped has
 	// the last copy of a shard present and the force keyword was not used
 	ErrShardNotReplicated = errors.New("shard not replicated")
+
+	// ErrInvalidShardIDCount is returned when reserving fewer than one
+	// shard ID.
+	ErrInvalidShardIDCount = errors.New("shard id count must be greater than 0")
+
+	// ErrShardIDsReserved is returned when the shard IDs asked for have
+	// already been taken by another reservation or shard.
+	ErrShardIDsReserved = errors.New("shard ids already reserved")
 )
 
 var (
@@ -108,6 +222,15 @@ var (
 
 	// ErrContinuousQueryNotFound is returned when removing a continuous query that doesn't exist.
 	ErrContinuousQueryNotFound = errors.New("continuous query not found")
+
+	// ErrInvalidContinuousQuery is returned when a continuous query is not a
+	// valid CREATE CONTINUOUS QUERY statement.
+	ErrInvalidContinuousQuery = errors.New("invalid continuous query")
+
+	// ErrContinuousQueryMismatch is returned when a continuous query
+	// statement names a different continuous query or database than the
+	// one it is saved as.
+	ErrContinuousQueryMismatch = errors.New("continuous query statement does not match its name or database")
 )
 
 var (
@@ -133,6 +256,26 @@ var (
 	// ErrUsernameRequired is returned when creating a user without a username.
 	ErrUsernameRequired = errors.New("username required")
 
+	// ErrInvalidPasswordHash is returned when creating a user with a password
+	// hash that is not a bcrypt hash.
+	ErrInvalidPasswordHash = errors.New("invalid bcrypt password hash")
+
 	// ErrAuthenticate is returned when authentication fails.
 	ErrAuthenticate = errors.New("authentication failed")
 )
+
+// ErrConfigImmutable is returned when applying a config that changes a setting
+// that can only be set when the client is created.
+func ErrConfigImmutable(name string) error {
+	return fmt.Errorf("meta config %s cannot be changed at runtime", name)
+}
+
+// ErrUnsupportedMetaVersion is returned when the meta data has a newer format
+// version than this build supports.
+func ErrUnsupportedMetaVersion(version uint64) error {
+	return fmt.Errorf("unsupported meta data version %d, this node supports up to %d", version, DataVersion)
+}
+
+// ErrMergeConflict is returned by Data.MergeFrom with the MergeFail strategy
+// when the data being merged conflicts with the existing data.
+var ErrMergeConflict = errors.New("meta data merge conflict")
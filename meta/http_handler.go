@@ -14,14 +14,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cnosdb/cnosdb"
 	internal "github.com/cnosdb/cnosdb/meta/internal"
 	"github.com/cnosdb/cnosdb/pkg/logger"
 	"github.com/cnosdb/cnosdb/pkg/uuid"
 
-
 	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/mux"
-	"github.com/hashicorp/raft"
 	"go.uber.org/zap"
 )
 
@@ -35,6 +34,10 @@ type route struct {
 	HandlerFunc    interface{}
 }
 
+// writeConsistencyTimeout is how long a command sent with the quorum or all
+// write consistency level waits for the other meta servers to apply it.
+const writeConsistencyTimeout = 10 * time.Second
+
 type Handler struct {
 	Version string
 
@@ -47,10 +50,18 @@ type Handler struct {
 	store        interface {
 		afterIndex(index uint64) <-chan struct{}
 		index() uint64
+		version() uint64
+		ready() bool
+		shardIDsPage(afterID uint64, limit int) ([]uint64, uint64, error)
 		leader() string
 		leaderHTTP() string
 		snapshot() (*Data, error)
-		apply(b []byte) error
+		deltaSince(index uint64) *internal.DataDelta
+		leaderSnapshot() (*Data, error)
+		forceSnapshot() (SnapshotReport, error)
+		raftStats() RaftStats
+		database(name string) *DatabaseInfo
+		applyResult(b []byte) (uint64, error)
 		joinCluster(peers []string) (*NodeInfo, error)
 		addMetaNode(n *NodeInfo) (*NodeInfo, error)
 		removeMetaNode(n *NodeInfo) (*NodeInfo, error)
@@ -64,6 +75,9 @@ type Handler struct {
 	mu      sync.RWMutex
 	closing chan struct{}
 	leases  *Leases
+
+	// clock times leases.
+	clock clock
 }
 
 // 创建 Handler 的实例，并设置 router
@@ -71,6 +85,8 @@ func NewHandler(conf *ServerConfig) *Handler {
 	h := &Handler{
 		config: conf,
 		router: mux.NewRouter(),
+		leases: NewLeases(time.Duration(conf.LeaseDuration)),
+		clock:  realClock{},
 	}
 
 	h.AddRoutes([]route{
@@ -78,10 +94,26 @@ func NewHandler(conf *ServerConfig) *Handler {
 			"snapshot", http.MethodGet, "/", true, true,
 			h.serveSnapshot,
 		},
+		{
+			"database", http.MethodGet, "/database", true, true,
+			h.serveDatabase,
+		},
 		{
 			"ping", http.MethodGet, "/ping", true, true,
 			h.servePing,
 		},
+		{
+			"ready", http.MethodGet, "/ready", true, true,
+			h.serveReady,
+		},
+		{
+			"version", http.MethodGet, "/version", true, true,
+			h.serveVersion,
+		},
+		{
+			"shard-ids", http.MethodGet, "/shard-ids", true, true,
+			h.serveShardIDs,
+		},
 		{
 			"node", http.MethodGet, "/node", true, true,
 			h.serveGetNode,
@@ -90,6 +122,10 @@ func NewHandler(conf *ServerConfig) *Handler {
 			"lease", http.MethodGet, "/lease", true, true,
 			h.serveLease,
 		},
+		{
+			"leases", http.MethodGet, "/leases", true, true,
+			h.serveLeases,
+		},
 		{
 			"peers", http.MethodGet, "/peers", true, true,
 			h.servePeers,
@@ -98,6 +134,14 @@ func NewHandler(conf *ServerConfig) *Handler {
 			"meta-servers", http.MethodGet, "/meta-servers", true, true,
 			h.serveMetaServers,
 		},
+		{
+			"meta-stats", http.MethodGet, "/debug/meta-stats", true, true,
+			h.serveMetaStats,
+		},
+		{
+			"force-snapshot", http.MethodPost, "/force-snapshot", true, true,
+			h.serveForceSnapshot,
+		},
 		{
 			"execute", http.MethodPost, "/execute", true, true,
 			h.serveExecute,
@@ -144,14 +188,50 @@ func (h *Handler) serveSnapshot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("consistency") == "leader" {
+		h.serveLeaderSnapshot(w, r)
+		return
+	}
+
 	// get the current index that client has
 	index, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
 	if err != nil {
-		http.Error(w, "error parsing index", http.StatusBadRequest)
+		h.httpError(errors.New("error parsing index"), w, http.StatusBadRequest)
+		return
+	}
+
+	// If the client set a wait, answer with no content once it passes
+	// without a change, so that the client knows its data is current.
+	var timeout <-chan time.Time
+	if s := r.URL.Query().Get("wait"); s != "" {
+		wait, err := time.ParseDuration(s)
+		if err != nil || wait <= 0 {
+			h.httpError(errors.New("error parsing wait"), w, http.StatusBadRequest)
+			return
+		}
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		timeout = t.C
 	}
 
 	select {
 	case <-h.store.afterIndex(index):
+		// Send only what changed if the client asked for it and the data
+		// at its index is still held.
+		if r.URL.Query().Get("delta") == "true" {
+			if delta := h.store.deltaSince(index); delta != nil {
+				b, err := proto.Marshal(delta)
+				if err != nil {
+					h.httpError(err, w, http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set(metaDeltaHeader, "true")
+				w.Header().Add("Content-Type", "application/octet-stream")
+				w.Write(b)
+				return
+			}
+		}
+
 		// Send updated snapshot to client.
 		ss, err := h.store.snapshot()
 		if err != nil {
@@ -166,6 +246,9 @@ func (h *Handler) serveSnapshot(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "application/octet-stream")
 		w.Write(b)
 		return
+	case <-timeout:
+		w.WriteHeader(http.StatusNoContent)
+		return
 	case <-w.(http.CloseNotifier).CloseNotify():
 		// Client closed the connection so we're done.
 		return
@@ -175,8 +258,76 @@ func (h *Handler) serveSnapshot(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveLeaderSnapshot returns the leader's data without waiting for it to
+// change. Followers redirect the client to the leader.
+func (h *Handler) serveLeaderSnapshot(w http.ResponseWriter, r *http.Request) {
+	ss, err := h.store.leaderSnapshot()
+	if e, ok := err.(ErrNotLeader); ok {
+		if e.Leader == "" {
+			h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
+			return
+		}
+		scheme := "http://"
+		if h.config.HTTPSEnabled {
+			scheme = "https://"
+		}
+		http.Redirect(w, r, scheme+e.Leader+"/?"+r.URL.RawQuery, http.StatusTemporaryRedirect)
+		return
+	} else if err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	b, err := ss.MarshalBinary()
+	if err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/octet-stream")
+	w.Write(b)
+}
+
+// serveDatabase returns a single database, so clients that need only one
+// database don't have to fetch a full snapshot.
+func (h *Handler) serveDatabase(w http.ResponseWriter, r *http.Request) {
+	if h.isClosed() {
+		h.httpError(fmt.Errorf("server closed"), w, http.StatusServiceUnavailable)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		h.httpError(errors.New("database name required"), w, http.StatusBadRequest)
+		return
+	}
+
+	di := h.store.database(name)
+	if di == nil {
+		h.httpError(cnosdb.ErrDatabaseNotFound(name), w, http.StatusNotFound)
+		return
+	}
+
+	b, err := proto.Marshal(di.marshal())
+	if err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/octet-stream")
+	w.Write(b)
+}
+
 // servePing will return if the server is up, or if specified will check the status
 // of the other meta-servers as well
+// serveReady answers 200 if the meta node can serve requests and 503 if not,
+// for health probes.
+func (h *Handler) serveReady(w http.ResponseWriter, r *http.Request) {
+	if !h.store.ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (h *Handler) servePing(w http.ResponseWriter, r *http.Request) {
 	// if they're not asking to check all servers, just return who we think
 	// the leader is
@@ -238,14 +389,14 @@ func (h *Handler) serveLease(w http.ResponseWriter, r *http.Request) {
 	// Get the requested lease name.
 	name = q.Get("name")
 	if name == "" {
-		http.Error(w, "lease name required", http.StatusBadRequest)
+		h.httpError(errors.New("lease name required"), w, http.StatusBadRequest)
 		return
 	}
 
 	// Get the ID of the requesting node.
 	nodeIDStr = q.Get("nodeid")
 	if nodeIDStr == "" {
-		http.Error(w, "node ID required", http.StatusBadRequest)
+		h.httpError(errors.New("node ID required"), w, http.StatusBadRequest)
 		return
 	}
 
@@ -270,13 +421,13 @@ func (h *Handler) serveLease(w http.ResponseWriter, r *http.Request) {
 	// Convert node ID to an int.
 	nodeID, err := strconv.ParseUint(nodeIDStr, 10, 64)
 	if err != nil {
-		http.Error(w, "invalid node ID", http.StatusBadRequest)
+		h.httpError(errors.New("invalid node ID"), w, http.StatusBadRequest)
 		return
 	}
 
 	// Try to acquire the requested lease.
 	// Always returns a lease. err determins if we own it.
-	l, err := h.leases.Acquire(name, nodeID)
+	l, err := h.leases.acquire(name, nodeID, h.clock.Now())
 	// Marshal the lease to JSON.
 	b, e := json.Marshal(l)
 	if e != nil {
@@ -297,6 +448,29 @@ func (h *Handler) serveLease(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// serveLeases writes the leases held on the leader as JSON.
+func (h *Handler) serveLeases(w http.ResponseWriter, r *http.Request) {
+	// Leases are only acquired on the leader.
+	leader := h.store.leaderHTTP()
+	if leader != h.s.remoteAddr(h.s.httpAddr) {
+		if leader == "" {
+			h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
+			return
+		}
+		scheme := "http://"
+		if h.config.HTTPSEnabled {
+			scheme = "https://"
+		}
+		http.Redirect(w, r, scheme+leader+"/leases", http.StatusTemporaryRedirect)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.leases.List()); err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+	}
+}
+
 func (h *Handler) servePeers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
@@ -306,6 +480,114 @@ func (h *Handler) servePeers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// SnapshotReport is the response to a request to force a snapshot of the
+// meta store.
+type SnapshotReport struct {
+	// PrevSnapshotIndex and SnapshotIndex are the last log index included in
+	// the latest snapshot before and after the request.
+	PrevSnapshotIndex uint64 `json:"prevSnapshotIndex"`
+	SnapshotIndex     uint64 `json:"snapshotIndex"`
+
+	// CompactedEntries is the number of entries removed from the raft log.
+	CompactedEntries uint64 `json:"compactedEntries"`
+}
+
+// serveForceSnapshot snapshots the meta store and compacts the raft log.
+// Followers redirect the client to the leader.
+func (h *Handler) serveForceSnapshot(w http.ResponseWriter, r *http.Request) {
+	if h.isClosed() {
+		h.httpError(fmt.Errorf("server closed"), w, http.StatusServiceUnavailable)
+		return
+	}
+
+	report, err := h.store.forceSnapshot()
+	if e, ok := err.(ErrNotLeader); ok {
+		if e.Leader == "" {
+			h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
+			return
+		}
+		scheme := "http://"
+		if h.config.HTTPSEnabled {
+			scheme = "https://"
+		}
+		http.Redirect(w, r, scheme+e.Leader+"/force-snapshot", http.StatusTemporaryRedirect)
+		return
+	} else if err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+	}
+}
+
+// serveMetaStats returns the raft apply latency and backlog of this node.
+func (h *Handler) serveMetaStats(w http.ResponseWriter, r *http.Request) {
+	if h.isClosed() {
+		h.httpError(fmt.Errorf("server closed"), w, http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.store.raftStats()); err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+	}
+}
+
+// shardIDsPage is the response to a request for a page of shard IDs.
+type shardIDsPage struct {
+	IDs  []uint64 `json:"ids"`
+	Next uint64   `json:"next"`
+}
+
+// serveShardIDs returns a page of shard IDs after the "after" cursor, holding
+// no more than "limit" IDs.
+func (h *Handler) serveShardIDs(w http.ResponseWriter, r *http.Request) {
+	if h.isClosed() {
+		h.httpError(fmt.Errorf("server closed"), w, http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	var after uint64
+	if s := q.Get("after"); s != "" {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			h.httpError(errors.New("error parsing after"), w, http.StatusBadRequest)
+			return
+		}
+		after = v
+	}
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil {
+		h.httpError(errors.New("error parsing limit"), w, http.StatusBadRequest)
+		return
+	}
+
+	ids, next, err := h.store.shardIDsPage(after, limit)
+	if err != nil {
+		h.httpError(err, w, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shardIDsPage{IDs: ids, Next: next}); err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+	}
+}
+
+// serveVersion returns the format version of the meta data.
+func (h *Handler) serveVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Version uint64 `json:"version"`
+	}{h.store.version()}); err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+	}
+}
+
 func (h *Handler) serveMetaServers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
@@ -335,12 +617,18 @@ func (h *Handler) serveExecute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	consistency := r.URL.Query().Get("consistency")
+	if consistency != "" && !validWriteConsistency(consistency) {
+		h.httpError(ErrInvalidWriteConsistency, w, http.StatusBadRequest)
+		return
+	}
+
 	// Apply the command to the store.
 	var resp *internal.Response
-	if err := h.store.apply(body); err != nil {
+	if result, err := h.store.applyResult(body); err != nil {
 		// If we aren't the leader, redirect client to the leader.
-		if err == raft.ErrNotLeader {
-			l := h.store.leaderHTTP()
+		if e, ok := err.(ErrNotLeader); ok {
+			l := e.Leader
 			if l == "" {
 				// No cluster leader. Client will have to try again later.
 				h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
@@ -352,6 +640,9 @@ func (h *Handler) serveExecute(w http.ResponseWriter, r *http.Request) {
 			}
 
 			l = scheme + l + "/execute"
+			if r.URL.RawQuery != "" {
+				l += "?" + r.URL.RawQuery
+			}
 			http.Redirect(w, r, l, http.StatusTemporaryRedirect)
 			return
 		}
@@ -367,6 +658,26 @@ func (h *Handler) serveExecute(w http.ResponseWriter, r *http.Request) {
 			OK:    proto.Bool(false),
 			Index: proto.Uint64(h.store.index()),
 		}
+		if result != 0 {
+			resp.Result = proto.Uint64(result)
+		}
+
+		// Raft committing the command doesn't mean the followers have
+		// applied it; wait for them if asked.
+		if consistency == WriteConsistencyQuorum || consistency == WriteConsistencyAll {
+			others := h.store.otherMetaServersHTTP()
+			n := len(others)
+			if consistency == WriteConsistencyQuorum {
+				// A majority of every meta server, of which the leader is
+				// one.
+				n = (len(others) + 1) / 2
+			}
+			if err := h.waitForMetaServers(others, n, resp.GetIndex(), r.Header.Get("Authorization")); err != nil {
+				h.logger.Info("Command not acknowledged by enough meta servers",
+					zap.String("consistency", consistency), zap.Error(err))
+				resp.Error = proto.String(ErrWriteNotAcknowledged.Error())
+			}
+		}
 	}
 
 	// Marshal the response.
@@ -381,6 +692,62 @@ func (h *Handler) serveExecute(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// waitForMetaServers blocks until n of servers have applied the command at
+// index, or returns an error once too many of them fail to within
+// writeConsistencyTimeout. auth is sent as the Authorization header.
+func (h *Handler) waitForMetaServers(servers []string, n int, index uint64, auth string) error {
+	if n <= 0 {
+		return nil
+	}
+	scheme := "http://"
+	if h.config.HTTPSEnabled {
+		scheme = "https://"
+	}
+	client := &http.Client{Timeout: writeConsistencyTimeout}
+
+	errs := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s string) {
+			// A snapshot request returns once the server is past the index
+			// asked for.
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s/?index=%d&delta=true", scheme, s, index-1), nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer resp.Body.Close()
+			io.Copy(ioutil.Discard, resp.Body)
+			if resp.StatusCode != http.StatusOK {
+				errs <- fmt.Errorf("meta server %s returned %s", s, resp.Status)
+				return
+			}
+			errs <- nil
+		}(s)
+	}
+
+	// The channel is buffered, so servers still being waited on don't block
+	// once the outcome is known.
+	var acked, failed int
+	for range servers {
+		if err := <-errs; err != nil {
+			if failed++; len(servers)-failed < n {
+				return err
+			}
+		} else if acked++; acked >= n {
+			return nil
+		}
+	}
+	return fmt.Errorf("%d of %d meta servers acknowledged, need %d", acked, len(servers), n)
+}
+
 func (h *Handler) serveJoinCluster(w http.ResponseWriter, r *http.Request) {
 	if h.isClosed() {
 		h.httpError(fmt.Errorf("server closed"), w, http.StatusServiceUnavailable)
@@ -427,8 +794,8 @@ func (h *Handler) serveAddMeta(w http.ResponseWriter, r *http.Request) {
 	}
 
 	node, err := h.store.addMetaNode(n)
-	if err == raft.ErrNotLeader {
-		l := h.store.leaderHTTP()
+	if e, ok := err.(ErrNotLeader); ok {
+		l := e.Leader
 		if l == "" {
 			// No cluster leader. Client will have to try again later.
 			h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
@@ -476,8 +843,8 @@ func (h *Handler) serveRemoveMeta(w http.ResponseWriter, r *http.Request) {
 	}
 
 	node, err := h.store.removeMetaNode(n)
-	if err == raft.ErrNotLeader {
-		l := h.store.leaderHTTP()
+	if e, ok := err.(ErrNotLeader); ok {
+		l := e.Leader
 		if l == "" {
 			// No cluster leader. Client will have to try again later.
 			h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
@@ -623,9 +990,17 @@ func WrapWithGzipResponseWriter(inner http.Handler) http.Handler {
 	})
 }
 
+// errorResponse is the JSON body of an error response from the meta service.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
 func (h *Handler) httpError(err error, w http.ResponseWriter, status int) {
 	if h.config.LoggingEnabled {
 		h.logger.Error("http error", zap.Error(err))
 	}
-	http.Error(w, err.Error(), status)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&errorResponse{Error: err.Error()})
 }
@@ -0,0 +1,586 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+)
+
+func TestHandler_httpError(t *testing.T) {
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	w := httptest.NewRecorder()
+	h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != `{"error":"no leader"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestRemoteClient_ErrorResponses(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "json", contentType: "application/json", body: `{"error":"something broke"}`},
+		{name: "json charset", contentType: "application/json; charset=utf-8", body: `{"error":"something broke"}`},
+		{name: "text", contentType: "text/plain; charset=utf-8", body: "something broke\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if r.URL.Path == "/lease" {
+					w.WriteHeader(http.StatusBadRequest)
+				} else {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			c := NewRemoteClient()
+			c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+
+			_, err := c.exec(context.Background(), ts.URL+"/execute", "", internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command,
+				&internal.DropDatabaseCommand{Name: proto.String("db0")})
+			if exp := "meta service returned 500 Internal Server Error: something broke"; err == nil || err.Error() != exp {
+				t.Errorf("unexpected exec error: got %v, exp %s", err, exp)
+			}
+
+			_, err = c.getSnapshot(strings.TrimPrefix(ts.URL, "http://"), 0, nil)
+			if exp := "meta server returned non-200: 500 Internal Server Error: something broke"; err == nil || err.Error() != exp {
+				t.Errorf("unexpected snapshot error: got %v, exp %s", err, exp)
+			}
+
+			_, err = c.acquireLease(context.Background(), "cq")
+			if exp := "meta service: something broke"; err == nil || err.Error() != exp {
+				t.Errorf("unexpected lease error: got %v, exp %s", err, exp)
+			}
+		})
+	}
+}
+
+func TestHandler_serveSnapshot_LeaderConsistency(t *testing.T) {
+	stores := newTestRaftCluster(t, "node0", "node1")
+	defer func() {
+		for _, s := range stores {
+			s.raftState.raft.Shutdown()
+		}
+	}()
+	leader, follower := waitForTestLeader(t, stores)
+
+	b, err := proto.Marshal(newTestCreateDatabaseCommand("db0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.apply(b); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(s *store) *httptest.ResponseRecorder {
+		h := NewHandler(NewServerConfig())
+		h.logger = zap.NewNop()
+		h.store = s
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?index=0&consistency=leader", nil))
+		return w
+	}
+
+	// The follower redirects to the leader.
+	w := serve(follower)
+	if w.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("unexpected status from follower: %d", w.Code)
+	} else if loc, exp := w.Header().Get("Location"), "http://"+leader.httpAddr+"/?index=0&consistency=leader"; loc != exp {
+		t.Fatalf("unexpected redirect: got %s, exp %s", loc, exp)
+	}
+
+	// The leader answers with its own data.
+	w = serve(leader)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status from leader: %d: %s", w.Code, w.Body.String())
+	}
+	var data Data
+	if err := data.UnmarshalBinary(w.Body.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db0") == nil {
+		t.Fatal("expected db0 in leader snapshot")
+	}
+}
+
+func TestHandler_serveSnapshot_Wait(t *testing.T) {
+	s := newStore(NewConfig(), "http-node0", "node0")
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	h.store = s
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	get := func(query string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/?index=" + strconv.FormatUint(s.index(), 10) + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// A poll with a wait is answered without content once nothing changes
+	// within it.
+	if resp := get("&wait=10ms"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected status: %s", resp.Status)
+	}
+	for _, wait := range []string{"soon", "0s"} {
+		if resp := get("&wait=" + wait); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("unexpected status for wait %q: %s", wait, resp.Status)
+		}
+	}
+}
+
+func TestRemoteClient_MetaVersion(t *testing.T) {
+	s := newStore(NewConfig(), "http-node0", "node0")
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	h.store = s
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	restore := func(pb *internal.Data) {
+		t.Helper()
+		b, err := proto.Marshal(pb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := (*storeFSM)(s).Restore(ioutil.NopCloser(bytes.NewReader(b))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// version opens a client on url, which fetches a snapshot, and returns
+	// the version it reports.
+	version := func(url string) uint64 {
+		t.Helper()
+		c := NewRemoteClient()
+		c.SetMetaServers([]string{strings.TrimPrefix(url, "http://")})
+		if err := c.Open(); err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		v, err := c.MetaVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	// The version is carried through a snapshot.
+	restore(s.data.marshal())
+	if v := version(ts.URL); v != DataVersion {
+		t.Fatalf("unexpected version: got %d, exp %d", v, DataVersion)
+	}
+
+	// A metaservice from before versioning sends snapshots without one.
+	pb := s.data.marshal()
+	pb.Version = nil
+	pb.Index = proto.Uint64(1)
+	b, err := proto.Marshal(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("index") != "0" {
+			// Hold polls for updates until the client closes.
+			<-r.Context().Done()
+			return
+		}
+		w.Write(b)
+	}))
+	defer old.Close()
+	if v := version(old.URL); v != 0 {
+		t.Fatalf("unexpected version of unversioned snapshot: %d", v)
+	}
+}
+
+func TestHandler_serveVersion(t *testing.T) {
+	s := newStore(NewConfig(), "http-node0", "node0")
+	s.data.Version = DataVersion
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	h.store = s
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+	var v struct {
+		Version uint64 `json:"version"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&v); err != nil {
+		t.Fatal(err)
+	} else if v.Version != DataVersion {
+		t.Fatalf("unexpected version: got %d, exp %d", v.Version, DataVersion)
+	}
+}
+
+func TestHandler_serveReady(t *testing.T) {
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	ready := func() int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return w.Code
+	}
+
+	h.store = newStore(NewConfig(), "http-node0", "node0")
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status before open: %d", code)
+	}
+
+	configuration := raft.Configuration{Servers: []raft.Server{{ID: "node0", Address: "node0"}}}
+	s, _ := newTestRaftStore(t, "node0", &configuration)
+	defer s.raftState.raft.Shutdown()
+	h.store = s
+
+	timeout := time.After(5 * time.Second)
+	for !s.isLeader() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for leader")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// The node is not ready until it is in the meta node list, as it is
+	// once open returns.
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status before joining the meta nodes: %d", code)
+	}
+	if err := s.callSetMetaNode("http-node0", "node0"); err != nil {
+		t.Fatal(err)
+	}
+	if code := ready(); code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", code)
+	}
+}
+
+func TestRemoteClient_ShardIDsPage(t *testing.T) {
+	s := newStore(NewConfig(), "http-node0", "node0")
+	s.data.Databases = []DatabaseInfo{{Name: "db0", RetentionPolicies: []RetentionPolicyInfo{{Name: "rp0", ShardGroups: []ShardGroupInfo{
+		{ID: 1, Shards: []ShardInfo{{ID: 4}, {ID: 1}}},
+		{ID: 2, Shards: []ShardInfo{{ID: 3}, {ID: 8}, {ID: 6}}},
+	}}}}}
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	h.store = s
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+
+	var all []uint64
+	var after uint64
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("too many pages: %v", all)
+		}
+		ids, next, err := c.ShardIDsPage(after, 2)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(ids) > 2 {
+			t.Fatalf("page too large: %v", ids)
+		}
+		all = append(all, ids...)
+		if next == 0 {
+			break
+		}
+		after = next
+	}
+	if exp := []uint64{1, 3, 4, 6, 8}; !reflect.DeepEqual(all, exp) {
+		t.Fatalf("unexpected shard ids: got %v, exp %v", all, exp)
+	}
+
+	// A page past the end is empty.
+	if ids, next, err := c.ShardIDsPage(8, 2); err != nil {
+		t.Fatal(err)
+	} else if len(ids) != 0 || next != 0 {
+		t.Fatalf("unexpected page past the end: %v, %d", ids, next)
+	}
+
+	if _, _, err := c.ShardIDsPage(0, 0); err != ErrInvalidPageLimit {
+		t.Fatalf("unexpected error for zero limit: %v", err)
+	}
+}
+
+func TestHandler_serveForceSnapshot(t *testing.T) {
+	stores := newTestRaftCluster(t, "node0", "node1")
+	defer func() {
+		for _, s := range stores {
+			s.raftState.raft.Shutdown()
+		}
+	}()
+	leader, follower := waitForTestLeader(t, stores)
+
+	b, err := proto.Marshal(newTestCreateDatabaseCommand("db0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.apply(b); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(s *store) *httptest.ResponseRecorder {
+		h := NewHandler(NewServerConfig())
+		h.logger = zap.NewNop()
+		h.store = s
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/force-snapshot", nil))
+		return w
+	}
+
+	// The follower redirects to the leader.
+	w := serve(follower)
+	if w.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("unexpected status from follower: %d", w.Code)
+	} else if loc, exp := w.Header().Get("Location"), "http://"+leader.httpAddr+"/force-snapshot"; loc != exp {
+		t.Fatalf("unexpected redirect: got %s, exp %s", loc, exp)
+	}
+
+	w = serve(leader)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status from leader: %d: %s", w.Code, w.Body.String())
+	}
+	var report SnapshotReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	} else if report.PrevSnapshotIndex != 0 || report.SnapshotIndex == 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	// With nothing new to snapshot the index stays put.
+	w = serve(leader)
+	var again SnapshotReport
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status from leader: %d: %s", w.Code, w.Body.String())
+	} else if err := json.NewDecoder(w.Body).Decode(&again); err != nil {
+		t.Fatal(err)
+	} else if again.PrevSnapshotIndex != report.SnapshotIndex || again.SnapshotIndex != report.SnapshotIndex {
+		t.Fatalf("unexpected second report: %+v", again)
+	}
+}
+
+func TestHandler_serveMetaStats(t *testing.T) {
+	configuration := raft.Configuration{Servers: []raft.Server{{ID: "node0", Address: "node0"}}}
+	s, _ := newTestRaftStore(t, "node0", &configuration)
+	defer s.raftState.raft.Shutdown()
+	s.applyN, s.applyLatencies[0] = 1, 5*time.Millisecond
+
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	h.store = s
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/meta-stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats RaftStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	} else if stats.Applied != 1 || stats.LastApplyLatency != 5*time.Millisecond {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRemoteClient_retryUntilExec_Idempotent(t *testing.T) {
+	configuration := raft.Configuration{Servers: []raft.Server{{ID: "node0", Address: "node0"}}}
+	s, _ := newTestRaftStore(t, "node0", &configuration)
+	defer s.raftState.raft.Shutdown()
+	timeout := time.After(5 * time.Second)
+	for !s.isLeader() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for leader")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	h := NewHandler(NewServerConfig())
+	h.logger = zap.NewNop()
+	h.store = s
+
+	// The first attempt is applied, but the client never sees the response,
+	// as if it had timed out.
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts > 1 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	c.cacheData = &Data{Index: math.MaxUint64}
+
+	// Applying a strict create twice would fail with ErrDatabaseExists.
+	err := c.retryUntilExec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, &internal.CreateDatabaseCommand{
+		Name:   proto.String("db0"),
+		Strict: proto.Bool(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if attempts != 2 {
+		t.Fatalf("unexpected attempts: %d", attempts)
+	} else if s.database("db0") == nil {
+		t.Fatal("expected db0 to be created")
+	}
+
+	// A new operation gets a new key and is applied again.
+	err = c.retryUntilExec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, &internal.CreateDatabaseCommand{
+		Name:   proto.String("db0"),
+		Strict: proto.Bool(true),
+	})
+	if e, ok := err.(errCommand); !ok || e.msg != ErrDatabaseExists.Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// otherServersStore overrides the other meta servers a store reports.
+type otherServersStore struct {
+	*store
+	others []string
+}
+
+func (s *otherServersStore) otherMetaServersHTTP() []string { return s.others }
+
+func TestHandler_serveExecute_WriteConsistency(t *testing.T) {
+	stores := newTestRaftCluster(t, "node0", "node1")
+	defer func() {
+		for _, s := range stores {
+			s.raftState.raft.Shutdown()
+		}
+	}()
+	leader, follower := waitForTestLeader(t, stores)
+
+	fh := NewHandler(NewServerConfig())
+	fh.logger = zap.NewNop()
+	fh.store = follower
+	var mu sync.Mutex
+	var auth []string
+	fs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		fh.ServeHTTP(w, r)
+	}))
+	defer fs.Close()
+	reachable := strings.TrimPrefix(fs.URL, "http://")
+	unreachable := "127.0.0.1:0"
+
+	execute := func(s *store, others []string, query, name string) *httptest.ResponseRecorder {
+		h := NewHandler(NewServerConfig())
+		h.logger = zap.NewNop()
+		h.store = &otherServersStore{store: s, others: others}
+		b, err := proto.Marshal(newTestCreateDatabaseCommand(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/execute"+query, bytes.NewReader(b))
+		r.Header.Set("Authorization", "Bearer secret")
+		h.ServeHTTP(w, r)
+		return w
+	}
+	response := func(w *httptest.ResponseRecorder) *internal.Response {
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+		}
+		var resp internal.Response
+		if err := proto.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return &resp
+	}
+
+	// An unknown level is rejected.
+	if w := execute(leader, nil, "?consistency=most", "db0"); w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+
+	// The follower redirects to the leader, keeping the level.
+	w := execute(follower, nil, "?consistency=all", "db0")
+	if w.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("unexpected status from follower: %d", w.Code)
+	} else if loc, exp := w.Header().Get("Location"), "http://"+leader.httpAddr+"/execute?consistency=all"; loc != exp {
+		t.Fatalf("unexpected redirect: got %s, exp %s", loc, exp)
+	}
+
+	// Under all, the leader answers once the follower has applied the
+	// command, asking it with the request's credentials.
+	resp := response(execute(leader, []string{reachable}, "?consistency=all", "db0"))
+	if resp.GetError() != "" {
+		t.Fatalf("unexpected error: %s", resp.GetError())
+	} else if idx := follower.index(); idx < resp.GetIndex() {
+		t.Fatalf("follower at index %d, exp at least %d", idx, resp.GetIndex())
+	} else if follower.database("db0") == nil {
+		t.Fatal("expected db0 on the follower")
+	}
+	mu.Lock()
+	if len(auth) != 1 || auth[0] != "Bearer secret" {
+		t.Fatalf("unexpected forwarded authorization: %q", auth)
+	}
+	mu.Unlock()
+
+	// A meta server that can't be reached fails the command under all.
+	if resp := response(execute(leader, []string{reachable, unreachable}, "?consistency=all", "db1")); resp.GetError() != ErrWriteNotAcknowledged.Error() {
+		t.Fatalf("unexpected error under all: %q", resp.GetError())
+	}
+
+	// Under quorum, the leader and one of two followers are a majority of
+	// three, but the leader alone isn't a majority of two.
+	if resp := response(execute(leader, []string{reachable, unreachable}, "?consistency=quorum", "db2")); resp.GetError() != "" {
+		t.Fatalf("unexpected error under quorum: %s", resp.GetError())
+	}
+	if resp := response(execute(leader, []string{unreachable}, "?consistency=quorum", "db3")); resp.GetError() != ErrWriteNotAcknowledged.Error() {
+		t.Fatalf("unexpected error under quorum: %q", resp.GetError())
+	}
+
+	// Under leader, nothing is waited for.
+	if resp := response(execute(leader, []string{unreachable}, "?consistency=leader", "db4")); resp.GetError() != "" {
+		t.Fatalf("unexpected error under leader: %s", resp.GetError())
+	}
+}
@@ -23,35 +23,50 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 type Command_Type int32
 
 const (
-	Command_CreateNodeCommand                Command_Type = 1
-	Command_DeleteNodeCommand                Command_Type = 2
-	Command_CreateDatabaseCommand            Command_Type = 3
-	Command_DropDatabaseCommand              Command_Type = 4
-	Command_CreateRetentionPolicyCommand     Command_Type = 5
-	Command_DropRetentionPolicyCommand       Command_Type = 6
-	Command_SetDefaultRetentionPolicyCommand Command_Type = 7
-	Command_UpdateRetentionPolicyCommand     Command_Type = 8
-	Command_CreateShardGroupCommand          Command_Type = 9
-	Command_DeleteShardGroupCommand          Command_Type = 10
-	Command_CreateContinuousQueryCommand     Command_Type = 11
-	Command_DropContinuousQueryCommand       Command_Type = 12
-	Command_CreateUserCommand                Command_Type = 13
-	Command_DropUserCommand                  Command_Type = 14
-	Command_UpdateUserCommand                Command_Type = 15
-	Command_SetPrivilegeCommand              Command_Type = 16
-	Command_SetDataCommand                   Command_Type = 17
-	Command_SetAdminPrivilegeCommand         Command_Type = 18
-	Command_UpdateNodeCommand                Command_Type = 19
-	Command_CreateSubscriptionCommand        Command_Type = 21
-	Command_DropSubscriptionCommand          Command_Type = 22
-	Command_RemovePeerCommand                Command_Type = 23
-	Command_CreateMetaNodeCommand            Command_Type = 24
-	Command_CreateDataNodeCommand            Command_Type = 25
-	Command_UpdateDataNodeCommand            Command_Type = 26
-	Command_DeleteMetaNodeCommand            Command_Type = 27
-	Command_DeleteDataNodeCommand            Command_Type = 28
-	Command_SetMetaNodeCommand               Command_Type = 29
-	Command_DropShardCommand                 Command_Type = 30
+	Command_CreateNodeCommand                           Command_Type = 1
+	Command_DeleteNodeCommand                           Command_Type = 2
+	Command_CreateDatabaseCommand                       Command_Type = 3
+	Command_DropDatabaseCommand                         Command_Type = 4
+	Command_CreateRetentionPolicyCommand                Command_Type = 5
+	Command_DropRetentionPolicyCommand                  Command_Type = 6
+	Command_SetDefaultRetentionPolicyCommand            Command_Type = 7
+	Command_UpdateRetentionPolicyCommand                Command_Type = 8
+	Command_CreateShardGroupCommand                     Command_Type = 9
+	Command_DeleteShardGroupCommand                     Command_Type = 10
+	Command_CreateContinuousQueryCommand                Command_Type = 11
+	Command_DropContinuousQueryCommand                  Command_Type = 12
+	Command_CreateUserCommand                           Command_Type = 13
+	Command_DropUserCommand                             Command_Type = 14
+	Command_UpdateUserCommand                           Command_Type = 15
+	Command_SetPrivilegeCommand                         Command_Type = 16
+	Command_SetDataCommand                              Command_Type = 17
+	Command_SetAdminPrivilegeCommand                    Command_Type = 18
+	Command_UpdateNodeCommand                           Command_Type = 19
+	Command_CreateSubscriptionCommand                   Command_Type = 21
+	Command_DropSubscriptionCommand                     Command_Type = 22
+	Command_RemovePeerCommand                           Command_Type = 23
+	Command_CreateMetaNodeCommand                       Command_Type = 24
+	Command_CreateDataNodeCommand                       Command_Type = 25
+	Command_UpdateDataNodeCommand                       Command_Type = 26
+	Command_DeleteMetaNodeCommand                       Command_Type = 27
+	Command_DeleteDataNodeCommand                       Command_Type = 28
+	Command_SetMetaNodeCommand                          Command_Type = 29
+	Command_DropShardCommand                            Command_Type = 30
+	Command_MarkShardGroupDeletedCommand                Command_Type = 31
+	Command_ReplaceContinuousQueryCommand               Command_Type = 32
+	Command_SetMeasurementRetentionCommand              Command_Type = 33
+	Command_SetDatabaseQuotaCommand                     Command_Type = 34
+	Command_CreateShardGroupsCommand                    Command_Type = 35
+	Command_HeartbeatDataNodeCommand                    Command_Type = 36
+	Command_SetDataNodeDrainingCommand                  Command_Type = 37
+	Command_CopyRetentionPolicyCommand                  Command_Type = 38
+	Command_TransactionCommand                          Command_Type = 39
+	Command_RebalanceShardGroupCommand                  Command_Type = 40
+	Command_RepairDefaultsCommand                       Command_Type = 41
+	Command_ReserveShardIDsCommand                      Command_Type = 42
+	Command_SetMaintenanceModeCommand                   Command_Type = 43
+	Command_SetDatabaseDefaultShardGroupDurationCommand Command_Type = 44
+	Command_FreezeRetentionPolicyCommand                Command_Type = 45
 )
 
 var Command_Type_name = map[int32]string{
@@ -84,38 +99,68 @@ var Command_Type_name = map[int32]string{
 	28: "DeleteDataNodeCommand",
 	29: "SetMetaNodeCommand",
 	30: "DropShardCommand",
+	31: "MarkShardGroupDeletedCommand",
+	32: "ReplaceContinuousQueryCommand",
+	33: "SetMeasurementRetentionCommand",
+	34: "SetDatabaseQuotaCommand",
+	35: "CreateShardGroupsCommand",
+	36: "HeartbeatDataNodeCommand",
+	37: "SetDataNodeDrainingCommand",
+	38: "CopyRetentionPolicyCommand",
+	39: "TransactionCommand",
+	40: "RebalanceShardGroupCommand",
+	41: "RepairDefaultsCommand",
+	42: "ReserveShardIDsCommand",
+	43: "SetMaintenanceModeCommand",
+	44: "SetDatabaseDefaultShardGroupDurationCommand",
+	45: "FreezeRetentionPolicyCommand",
 }
 
 var Command_Type_value = map[string]int32{
-	"CreateNodeCommand":                1,
-	"DeleteNodeCommand":                2,
-	"CreateDatabaseCommand":            3,
-	"DropDatabaseCommand":              4,
-	"CreateRetentionPolicyCommand":     5,
-	"DropRetentionPolicyCommand":       6,
-	"SetDefaultRetentionPolicyCommand": 7,
-	"UpdateRetentionPolicyCommand":     8,
-	"CreateShardGroupCommand":          9,
-	"DeleteShardGroupCommand":          10,
-	"CreateContinuousQueryCommand":     11,
-	"DropContinuousQueryCommand":       12,
-	"CreateUserCommand":                13,
-	"DropUserCommand":                  14,
-	"UpdateUserCommand":                15,
-	"SetPrivilegeCommand":              16,
-	"SetDataCommand":                   17,
-	"SetAdminPrivilegeCommand":         18,
-	"UpdateNodeCommand":                19,
-	"CreateSubscriptionCommand":        21,
-	"DropSubscriptionCommand":          22,
-	"RemovePeerCommand":                23,
-	"CreateMetaNodeCommand":            24,
-	"CreateDataNodeCommand":            25,
-	"UpdateDataNodeCommand":            26,
-	"DeleteMetaNodeCommand":            27,
-	"DeleteDataNodeCommand":            28,
-	"SetMetaNodeCommand":               29,
-	"DropShardCommand":                 30,
+	"CreateNodeCommand":                           1,
+	"DeleteNodeCommand":                           2,
+	"CreateDatabaseCommand":                       3,
+	"DropDatabaseCommand":                         4,
+	"CreateRetentionPolicyCommand":                5,
+	"DropRetentionPolicyCommand":                  6,
+	"SetDefaultRetentionPolicyCommand":            7,
+	"UpdateRetentionPolicyCommand":                8,
+	"CreateShardGroupCommand":                     9,
+	"DeleteShardGroupCommand":                     10,
+	"CreateContinuousQueryCommand":                11,
+	"DropContinuousQueryCommand":                  12,
+	"CreateUserCommand":                           13,
+	"DropUserCommand":                             14,
+	"UpdateUserCommand":                           15,
+	"SetPrivilegeCommand":                         16,
+	"SetDataCommand":                              17,
+	"SetAdminPrivilegeCommand":                    18,
+	"UpdateNodeCommand":                           19,
+	"CreateSubscriptionCommand":                   21,
+	"DropSubscriptionCommand":                     22,
+	"RemovePeerCommand":                           23,
+	"CreateMetaNodeCommand":                       24,
+	"CreateDataNodeCommand":                       25,
+	"UpdateDataNodeCommand":                       26,
+	"DeleteMetaNodeCommand":                       27,
+	"DeleteDataNodeCommand":                       28,
+	"SetMetaNodeCommand":                          29,
+	"DropShardCommand":                            30,
+	"MarkShardGroupDeletedCommand":                31,
+	"ReplaceContinuousQueryCommand":               32,
+	"SetMeasurementRetentionCommand":              33,
+	"SetDatabaseQuotaCommand":                     34,
+	"CreateShardGroupsCommand":                    35,
+	"HeartbeatDataNodeCommand":                    36,
+	"SetDataNodeDrainingCommand":                  37,
+	"CopyRetentionPolicyCommand":                  38,
+	"TransactionCommand":                          39,
+	"RebalanceShardGroupCommand":                  40,
+	"RepairDefaultsCommand":                       41,
+	"ReserveShardIDsCommand":                      42,
+	"SetMaintenanceModeCommand":                   43,
+	"SetDatabaseDefaultShardGroupDurationCommand": 44,
+	"FreezeRetentionPolicyCommand":                45,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -138,7 +183,7 @@ func (x *Command_Type) UnmarshalJSON(data []byte) error {
 }
 
 func (Command_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{12, 0}
+	return fileDescriptor_59b0956366e72083, []int{17, 0}
 }
 
 type Data struct {
@@ -152,11 +197,18 @@ type Data struct {
 	MaxShardGroupID *uint64         `protobuf:"varint,8,req,name=MaxShardGroupID" json:"MaxShardGroupID,omitempty"`
 	MaxShardID      *uint64         `protobuf:"varint,9,req,name=MaxShardID" json:"MaxShardID,omitempty"`
 	// added for 0.10.0
-	DataNodes            []*NodeInfo `protobuf:"bytes,10,rep,name=DataNodes" json:"DataNodes,omitempty"`
-	MetaNodes            []*NodeInfo `protobuf:"bytes,11,rep,name=MetaNodes" json:"MetaNodes,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
-	XXX_unrecognized     []byte      `json:"-"`
-	XXX_sizecache        int32       `json:"-"`
+	DataNodes []*NodeInfo `protobuf:"bytes,10,rep,name=DataNodes" json:"DataNodes,omitempty"`
+	MetaNodes []*NodeInfo `protobuf:"bytes,11,rep,name=MetaNodes" json:"MetaNodes,omitempty"`
+	// Version is the meta data format version. Data written before it was
+	// added has no version.
+	Version         *uint64 `protobuf:"varint,12,opt,name=Version" json:"Version,omitempty"`
+	MaintenanceMode *bool   `protobuf:"varint,13,opt,name=MaintenanceMode" json:"MaintenanceMode,omitempty"`
+	// AppliedCommands are the results of the most recently applied commands
+	// by idempotency key, oldest first. Only raft snapshots set them.
+	AppliedCommands      []*AppliedCommand `protobuf:"bytes,14,rep,name=AppliedCommands" json:"AppliedCommands,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *Data) Reset()         { *m = Data{} }
@@ -260,10 +312,154 @@ func (m *Data) GetMetaNodes() []*NodeInfo {
 	return nil
 }
 
+func (m *Data) GetVersion() uint64 {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return 0
+}
+
+func (m *Data) GetMaintenanceMode() bool {
+	if m != nil && m.MaintenanceMode != nil {
+		return *m.MaintenanceMode
+	}
+	return false
+}
+
+func (m *Data) GetAppliedCommands() []*AppliedCommand {
+	if m != nil {
+		return m.AppliedCommands
+	}
+	return nil
+}
+
+type AppliedCommand struct {
+	Key                  *string  `protobuf:"bytes,1,req,name=Key" json:"Key,omitempty"`
+	Error                *string  `protobuf:"bytes,2,opt,name=Error" json:"Error,omitempty"`
+	Result               *uint64  `protobuf:"varint,3,opt,name=Result" json:"Result,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AppliedCommand) Reset()         { *m = AppliedCommand{} }
+func (m *AppliedCommand) String() string { return proto.CompactTextString(m) }
+func (*AppliedCommand) ProtoMessage()    {}
+func (*AppliedCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{1}
+}
+func (m *AppliedCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AppliedCommand.Unmarshal(m, b)
+}
+func (m *AppliedCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AppliedCommand.Marshal(b, m, deterministic)
+}
+func (m *AppliedCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AppliedCommand.Merge(m, src)
+}
+func (m *AppliedCommand) XXX_Size() int {
+	return xxx_messageInfo_AppliedCommand.Size(m)
+}
+func (m *AppliedCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_AppliedCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AppliedCommand proto.InternalMessageInfo
+
+func (m *AppliedCommand) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *AppliedCommand) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+func (m *AppliedCommand) GetResult() uint64 {
+	if m != nil && m.Result != nil {
+		return *m.Result
+	}
+	return 0
+}
+
+// DataDelta is the change from the data at BaseIndex to a later version. Data
+// holds the later version, except that only the databases and users added or
+// changed since BaseIndex are included.
+type DataDelta struct {
+	BaseIndex            *uint64  `protobuf:"varint,1,req,name=BaseIndex" json:"BaseIndex,omitempty"`
+	Data                 *Data    `protobuf:"bytes,2,req,name=Data" json:"Data,omitempty"`
+	DroppedDatabases     []string `protobuf:"bytes,3,rep,name=DroppedDatabases" json:"DroppedDatabases,omitempty"`
+	DroppedUsers         []string `protobuf:"bytes,4,rep,name=DroppedUsers" json:"DroppedUsers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DataDelta) Reset()         { *m = DataDelta{} }
+func (m *DataDelta) String() string { return proto.CompactTextString(m) }
+func (*DataDelta) ProtoMessage()    {}
+func (*DataDelta) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{2}
+}
+func (m *DataDelta) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DataDelta.Unmarshal(m, b)
+}
+func (m *DataDelta) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DataDelta.Marshal(b, m, deterministic)
+}
+func (m *DataDelta) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DataDelta.Merge(m, src)
+}
+func (m *DataDelta) XXX_Size() int {
+	return xxx_messageInfo_DataDelta.Size(m)
+}
+func (m *DataDelta) XXX_DiscardUnknown() {
+	xxx_messageInfo_DataDelta.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DataDelta proto.InternalMessageInfo
+
+func (m *DataDelta) GetBaseIndex() uint64 {
+	if m != nil && m.BaseIndex != nil {
+		return *m.BaseIndex
+	}
+	return 0
+}
+
+func (m *DataDelta) GetData() *Data {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *DataDelta) GetDroppedDatabases() []string {
+	if m != nil {
+		return m.DroppedDatabases
+	}
+	return nil
+}
+
+func (m *DataDelta) GetDroppedUsers() []string {
+	if m != nil {
+		return m.DroppedUsers
+	}
+	return nil
+}
+
 type NodeInfo struct {
 	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	Host                 *string  `protobuf:"bytes,2,req,name=Host" json:"Host,omitempty"`
 	TCPHost              *string  `protobuf:"bytes,3,opt,name=TCPHost" json:"TCPHost,omitempty"`
+	ShardCount           *uint32  `protobuf:"varint,4,opt,name=ShardCount" json:"ShardCount,omitempty"`
+	PendingShards        *uint32  `protobuf:"varint,5,opt,name=PendingShards" json:"PendingShards,omitempty"`
+	LastSeen             *int64   `protobuf:"varint,6,opt,name=LastSeen" json:"LastSeen,omitempty"`
+	Draining             *bool    `protobuf:"varint,7,opt,name=Draining" json:"Draining,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -273,7 +469,7 @@ func (m *NodeInfo) Reset()         { *m = NodeInfo{} }
 func (m *NodeInfo) String() string { return proto.CompactTextString(m) }
 func (*NodeInfo) ProtoMessage()    {}
 func (*NodeInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{1}
+	return fileDescriptor_59b0956366e72083, []int{3}
 }
 func (m *NodeInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_NodeInfo.Unmarshal(m, b)
@@ -314,21 +510,51 @@ func (m *NodeInfo) GetTCPHost() string {
 	return ""
 }
 
+func (m *NodeInfo) GetShardCount() uint32 {
+	if m != nil && m.ShardCount != nil {
+		return *m.ShardCount
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetPendingShards() uint32 {
+	if m != nil && m.PendingShards != nil {
+		return *m.PendingShards
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetLastSeen() int64 {
+	if m != nil && m.LastSeen != nil {
+		return *m.LastSeen
+	}
+	return 0
+}
+
+func (m *NodeInfo) GetDraining() bool {
+	if m != nil && m.Draining != nil {
+		return *m.Draining
+	}
+	return false
+}
+
 type DatabaseInfo struct {
-	Name                   *string                `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	DefaultRetentionPolicy *string                `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
-	RetentionPolicies      []*RetentionPolicyInfo `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
-	ContinuousQueries      []*ContinuousQueryInfo `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
-	XXX_NoUnkeyedLiteral   struct{}               `json:"-"`
-	XXX_unrecognized       []byte                 `json:"-"`
-	XXX_sizecache          int32                  `json:"-"`
+	Name                      *string                `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	DefaultRetentionPolicy    *string                `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
+	RetentionPolicies         []*RetentionPolicyInfo `protobuf:"bytes,3,rep,name=RetentionPolicies" json:"RetentionPolicies,omitempty"`
+	ContinuousQueries         []*ContinuousQueryInfo `protobuf:"bytes,4,rep,name=ContinuousQueries" json:"ContinuousQueries,omitempty"`
+	Quota                     *DatabaseQuota         `protobuf:"bytes,5,opt,name=Quota" json:"Quota,omitempty"`
+	DefaultShardGroupDuration *int64                 `protobuf:"varint,6,opt,name=DefaultShardGroupDuration" json:"DefaultShardGroupDuration,omitempty"`
+	XXX_NoUnkeyedLiteral      struct{}               `json:"-"`
+	XXX_unrecognized          []byte                 `json:"-"`
+	XXX_sizecache             int32                  `json:"-"`
 }
 
 func (m *DatabaseInfo) Reset()         { *m = DatabaseInfo{} }
 func (m *DatabaseInfo) String() string { return proto.CompactTextString(m) }
 func (*DatabaseInfo) ProtoMessage()    {}
 func (*DatabaseInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{2}
+	return fileDescriptor_59b0956366e72083, []int{4}
 }
 func (m *DatabaseInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DatabaseInfo.Unmarshal(m, b)
@@ -376,21 +602,84 @@ func (m *DatabaseInfo) GetContinuousQueries() []*ContinuousQueryInfo {
 	return nil
 }
 
-type RetentionPolicySpec struct {
-	Name                 *string  `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
-	Duration             *int64   `protobuf:"varint,2,opt,name=Duration" json:"Duration,omitempty"`
-	ShardGroupDuration   *int64   `protobuf:"varint,3,opt,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
-	ReplicaN             *uint32  `protobuf:"varint,4,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
+func (m *DatabaseInfo) GetQuota() *DatabaseQuota {
+	if m != nil {
+		return m.Quota
+	}
+	return nil
+}
+
+func (m *DatabaseInfo) GetDefaultShardGroupDuration() int64 {
+	if m != nil && m.DefaultShardGroupDuration != nil {
+		return *m.DefaultShardGroupDuration
+	}
+	return 0
+}
+
+type DatabaseQuota struct {
+	MaxRetentionPolicies *uint32  `protobuf:"varint,1,opt,name=MaxRetentionPolicies" json:"MaxRetentionPolicies,omitempty"`
+	MaxShardGroups       *uint32  `protobuf:"varint,2,opt,name=MaxShardGroups" json:"MaxShardGroups,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *DatabaseQuota) Reset()         { *m = DatabaseQuota{} }
+func (m *DatabaseQuota) String() string { return proto.CompactTextString(m) }
+func (*DatabaseQuota) ProtoMessage()    {}
+func (*DatabaseQuota) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{5}
+}
+func (m *DatabaseQuota) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DatabaseQuota.Unmarshal(m, b)
+}
+func (m *DatabaseQuota) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DatabaseQuota.Marshal(b, m, deterministic)
+}
+func (m *DatabaseQuota) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DatabaseQuota.Merge(m, src)
+}
+func (m *DatabaseQuota) XXX_Size() int {
+	return xxx_messageInfo_DatabaseQuota.Size(m)
+}
+func (m *DatabaseQuota) XXX_DiscardUnknown() {
+	xxx_messageInfo_DatabaseQuota.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DatabaseQuota proto.InternalMessageInfo
+
+func (m *DatabaseQuota) GetMaxRetentionPolicies() uint32 {
+	if m != nil && m.MaxRetentionPolicies != nil {
+		return *m.MaxRetentionPolicies
+	}
+	return 0
+}
+
+func (m *DatabaseQuota) GetMaxShardGroups() uint32 {
+	if m != nil && m.MaxShardGroups != nil {
+		return *m.MaxShardGroups
+	}
+	return 0
+}
+
+type RetentionPolicySpec struct {
+	Name                 *string        `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
+	Duration             *int64         `protobuf:"varint,2,opt,name=Duration" json:"Duration,omitempty"`
+	ShardGroupDuration   *int64         `protobuf:"varint,3,opt,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	ReplicaN             *uint32        `protobuf:"varint,4,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
+	ShardsPerGroup       *uint32        `protobuf:"varint,5,opt,name=ShardsPerGroup" json:"ShardsPerGroup,omitempty"`
+	ShardKeyTags         []string       `protobuf:"bytes,6,rep,name=ShardKeyTags" json:"ShardKeyTags,omitempty"`
+	TenantNodes          []*TenantNodes `protobuf:"bytes,7,rep,name=TenantNodes" json:"TenantNodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
 func (m *RetentionPolicySpec) Reset()         { *m = RetentionPolicySpec{} }
 func (m *RetentionPolicySpec) String() string { return proto.CompactTextString(m) }
 func (*RetentionPolicySpec) ProtoMessage()    {}
 func (*RetentionPolicySpec) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{3}
+	return fileDescriptor_59b0956366e72083, []int{6}
 }
 func (m *RetentionPolicySpec) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RetentionPolicySpec.Unmarshal(m, b)
@@ -438,23 +727,49 @@ func (m *RetentionPolicySpec) GetReplicaN() uint32 {
 	return 0
 }
 
+func (m *RetentionPolicySpec) GetShardsPerGroup() uint32 {
+	if m != nil && m.ShardsPerGroup != nil {
+		return *m.ShardsPerGroup
+	}
+	return 0
+}
+
+func (m *RetentionPolicySpec) GetShardKeyTags() []string {
+	if m != nil {
+		return m.ShardKeyTags
+	}
+	return nil
+}
+
+func (m *RetentionPolicySpec) GetTenantNodes() []*TenantNodes {
+	if m != nil {
+		return m.TenantNodes
+	}
+	return nil
+}
+
 type RetentionPolicyInfo struct {
-	Name                 *string             `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	Duration             *int64              `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
-	ShardGroupDuration   *int64              `protobuf:"varint,3,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
-	ReplicaN             *uint32             `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
-	ShardGroups          []*ShardGroupInfo   `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
-	Subscriptions        []*SubscriptionInfo `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
-	XXX_unrecognized     []byte              `json:"-"`
-	XXX_sizecache        int32               `json:"-"`
+	Name                 *string                 `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Duration             *int64                  `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
+	ShardGroupDuration   *int64                  `protobuf:"varint,3,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	ReplicaN             *uint32                 `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
+	ShardGroups          []*ShardGroupInfo       `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
+	Subscriptions        []*SubscriptionInfo     `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
+	MeasurementRetention []*MeasurementRetention `protobuf:"bytes,7,rep,name=MeasurementRetention" json:"MeasurementRetention,omitempty"`
+	ShardsPerGroup       *uint32                 `protobuf:"varint,8,opt,name=ShardsPerGroup" json:"ShardsPerGroup,omitempty"`
+	ShardKeyTags         []string                `protobuf:"bytes,9,rep,name=ShardKeyTags" json:"ShardKeyTags,omitempty"`
+	TenantNodes          []*TenantNodes          `protobuf:"bytes,10,rep,name=TenantNodes" json:"TenantNodes,omitempty"`
+	Frozen               *bool                   `protobuf:"varint,11,opt,name=Frozen" json:"Frozen,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
 }
 
 func (m *RetentionPolicyInfo) Reset()         { *m = RetentionPolicyInfo{} }
 func (m *RetentionPolicyInfo) String() string { return proto.CompactTextString(m) }
 func (*RetentionPolicyInfo) ProtoMessage()    {}
 func (*RetentionPolicyInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{4}
+	return fileDescriptor_59b0956366e72083, []int{7}
 }
 func (m *RetentionPolicyInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RetentionPolicyInfo.Unmarshal(m, b)
@@ -516,6 +831,133 @@ func (m *RetentionPolicyInfo) GetSubscriptions() []*SubscriptionInfo {
 	return nil
 }
 
+func (m *RetentionPolicyInfo) GetMeasurementRetention() []*MeasurementRetention {
+	if m != nil {
+		return m.MeasurementRetention
+	}
+	return nil
+}
+
+func (m *RetentionPolicyInfo) GetShardsPerGroup() uint32 {
+	if m != nil && m.ShardsPerGroup != nil {
+		return *m.ShardsPerGroup
+	}
+	return 0
+}
+
+func (m *RetentionPolicyInfo) GetShardKeyTags() []string {
+	if m != nil {
+		return m.ShardKeyTags
+	}
+	return nil
+}
+
+func (m *RetentionPolicyInfo) GetTenantNodes() []*TenantNodes {
+	if m != nil {
+		return m.TenantNodes
+	}
+	return nil
+}
+
+func (m *RetentionPolicyInfo) GetFrozen() bool {
+	if m != nil && m.Frozen != nil {
+		return *m.Frozen
+	}
+	return false
+}
+
+type MeasurementRetention struct {
+	Name                 *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Duration             *int64   `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MeasurementRetention) Reset()         { *m = MeasurementRetention{} }
+func (m *MeasurementRetention) String() string { return proto.CompactTextString(m) }
+func (*MeasurementRetention) ProtoMessage()    {}
+func (*MeasurementRetention) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{8}
+}
+func (m *MeasurementRetention) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MeasurementRetention.Unmarshal(m, b)
+}
+func (m *MeasurementRetention) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MeasurementRetention.Marshal(b, m, deterministic)
+}
+func (m *MeasurementRetention) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MeasurementRetention.Merge(m, src)
+}
+func (m *MeasurementRetention) XXX_Size() int {
+	return xxx_messageInfo_MeasurementRetention.Size(m)
+}
+func (m *MeasurementRetention) XXX_DiscardUnknown() {
+	xxx_messageInfo_MeasurementRetention.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MeasurementRetention proto.InternalMessageInfo
+
+func (m *MeasurementRetention) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *MeasurementRetention) GetDuration() int64 {
+	if m != nil && m.Duration != nil {
+		return *m.Duration
+	}
+	return 0
+}
+
+type TenantNodes struct {
+	Tenant               *string  `protobuf:"bytes,1,req,name=Tenant" json:"Tenant,omitempty"`
+	NodeIDs              []uint64 `protobuf:"varint,2,rep,name=NodeIDs" json:"NodeIDs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TenantNodes) Reset()         { *m = TenantNodes{} }
+func (m *TenantNodes) String() string { return proto.CompactTextString(m) }
+func (*TenantNodes) ProtoMessage()    {}
+func (*TenantNodes) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{9}
+}
+func (m *TenantNodes) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TenantNodes.Unmarshal(m, b)
+}
+func (m *TenantNodes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TenantNodes.Marshal(b, m, deterministic)
+}
+func (m *TenantNodes) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TenantNodes.Merge(m, src)
+}
+func (m *TenantNodes) XXX_Size() int {
+	return xxx_messageInfo_TenantNodes.Size(m)
+}
+func (m *TenantNodes) XXX_DiscardUnknown() {
+	xxx_messageInfo_TenantNodes.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TenantNodes proto.InternalMessageInfo
+
+func (m *TenantNodes) GetTenant() string {
+	if m != nil && m.Tenant != nil {
+		return *m.Tenant
+	}
+	return ""
+}
+
+func (m *TenantNodes) GetNodeIDs() []uint64 {
+	if m != nil {
+		return m.NodeIDs
+	}
+	return nil
+}
+
 type ShardGroupInfo struct {
 	ID                   *uint64      `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	StartTime            *int64       `protobuf:"varint,2,req,name=StartTime" json:"StartTime,omitempty"`
@@ -532,7 +974,7 @@ func (m *ShardGroupInfo) Reset()         { *m = ShardGroupInfo{} }
 func (m *ShardGroupInfo) String() string { return proto.CompactTextString(m) }
 func (*ShardGroupInfo) ProtoMessage()    {}
 func (*ShardGroupInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{5}
+	return fileDescriptor_59b0956366e72083, []int{10}
 }
 func (m *ShardGroupInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardGroupInfo.Unmarshal(m, b)
@@ -598,6 +1040,7 @@ type ShardInfo struct {
 	ID                   *uint64       `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	OwnerIDs             []uint64      `protobuf:"varint,2,rep,name=OwnerIDs" json:"OwnerIDs,omitempty"` // Deprecated: Do not use.
 	Owners               []*ShardOwner `protobuf:"bytes,3,rep,name=Owners" json:"Owners,omitempty"`
+	Tenant               *string       `protobuf:"bytes,4,opt,name=Tenant" json:"Tenant,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
 	XXX_unrecognized     []byte        `json:"-"`
 	XXX_sizecache        int32         `json:"-"`
@@ -607,7 +1050,7 @@ func (m *ShardInfo) Reset()         { *m = ShardInfo{} }
 func (m *ShardInfo) String() string { return proto.CompactTextString(m) }
 func (*ShardInfo) ProtoMessage()    {}
 func (*ShardInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{6}
+	return fileDescriptor_59b0956366e72083, []int{11}
 }
 func (m *ShardInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardInfo.Unmarshal(m, b)
@@ -649,6 +1092,13 @@ func (m *ShardInfo) GetOwners() []*ShardOwner {
 	return nil
 }
 
+func (m *ShardInfo) GetTenant() string {
+	if m != nil && m.Tenant != nil {
+		return *m.Tenant
+	}
+	return ""
+}
+
 type SubscriptionInfo struct {
 	Name                 *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	Mode                 *string  `protobuf:"bytes,2,req,name=Mode" json:"Mode,omitempty"`
@@ -662,7 +1112,7 @@ func (m *SubscriptionInfo) Reset()         { *m = SubscriptionInfo{} }
 func (m *SubscriptionInfo) String() string { return proto.CompactTextString(m) }
 func (*SubscriptionInfo) ProtoMessage()    {}
 func (*SubscriptionInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{7}
+	return fileDescriptor_59b0956366e72083, []int{12}
 }
 func (m *SubscriptionInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SubscriptionInfo.Unmarshal(m, b)
@@ -714,7 +1164,7 @@ func (m *ShardOwner) Reset()         { *m = ShardOwner{} }
 func (m *ShardOwner) String() string { return proto.CompactTextString(m) }
 func (*ShardOwner) ProtoMessage()    {}
 func (*ShardOwner) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{8}
+	return fileDescriptor_59b0956366e72083, []int{13}
 }
 func (m *ShardOwner) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardOwner.Unmarshal(m, b)
@@ -753,7 +1203,7 @@ func (m *ContinuousQueryInfo) Reset()         { *m = ContinuousQueryInfo{} }
 func (m *ContinuousQueryInfo) String() string { return proto.CompactTextString(m) }
 func (*ContinuousQueryInfo) ProtoMessage()    {}
 func (*ContinuousQueryInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{9}
+	return fileDescriptor_59b0956366e72083, []int{14}
 }
 func (m *ContinuousQueryInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ContinuousQueryInfo.Unmarshal(m, b)
@@ -801,7 +1251,7 @@ func (m *UserInfo) Reset()         { *m = UserInfo{} }
 func (m *UserInfo) String() string { return proto.CompactTextString(m) }
 func (*UserInfo) ProtoMessage()    {}
 func (*UserInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{10}
+	return fileDescriptor_59b0956366e72083, []int{15}
 }
 func (m *UserInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UserInfo.Unmarshal(m, b)
@@ -861,7 +1311,7 @@ func (m *UserPrivilege) Reset()         { *m = UserPrivilege{} }
 func (m *UserPrivilege) String() string { return proto.CompactTextString(m) }
 func (*UserPrivilege) ProtoMessage()    {}
 func (*UserPrivilege) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{11}
+	return fileDescriptor_59b0956366e72083, []int{16}
 }
 func (m *UserPrivilege) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UserPrivilege.Unmarshal(m, b)
@@ -896,8 +1346,11 @@ func (m *UserPrivilege) GetPrivilege() int32 {
 }
 
 type Command struct {
-	Type                         *Command_Type `protobuf:"varint,1,req,name=type,enum=meta.Command_Type" json:"type,omitempty"`
-	XXX_NoUnkeyedLiteral         struct{}      `json:"-"`
+	Type *Command_Type `protobuf:"varint,1,req,name=type,enum=meta.Command_Type" json:"type,omitempty"`
+	// IdempotencyKey is the same for every attempt to send a command, so
+	// that a command is applied once even if it is resent.
+	IdempotencyKey               *string  `protobuf:"bytes,2,opt,name=IdempotencyKey" json:"IdempotencyKey,omitempty"`
+	XXX_NoUnkeyedLiteral         struct{} `json:"-"`
 	proto.XXX_InternalExtensions `json:"-"`
 	XXX_unrecognized             []byte `json:"-"`
 	XXX_sizecache                int32  `json:"-"`
@@ -907,7 +1360,7 @@ func (m *Command) Reset()         { *m = Command{} }
 func (m *Command) String() string { return proto.CompactTextString(m) }
 func (*Command) ProtoMessage()    {}
 func (*Command) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{12}
+	return fileDescriptor_59b0956366e72083, []int{17}
 }
 
 var extRange_Command = []proto.ExtensionRange{
@@ -943,6 +1396,13 @@ func (m *Command) GetType() Command_Type {
 	return Command_CreateNodeCommand
 }
 
+func (m *Command) GetIdempotencyKey() string {
+	if m != nil && m.IdempotencyKey != nil {
+		return *m.IdempotencyKey
+	}
+	return ""
+}
+
 // This isn't used in >= 0.10.0. Kept around for upgrade purposes. Instead
 // look at CreateDataNodeCommand and CreateMetaNodeCommand
 type CreateNodeCommand struct {
@@ -957,7 +1417,7 @@ func (m *CreateNodeCommand) Reset()         { *m = CreateNodeCommand{} }
 func (m *CreateNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateNodeCommand) ProtoMessage()    {}
 func (*CreateNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{13}
+	return fileDescriptor_59b0956366e72083, []int{18}
 }
 func (m *CreateNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateNodeCommand.Unmarshal(m, b)
@@ -1012,7 +1472,7 @@ func (m *DeleteNodeCommand) Reset()         { *m = DeleteNodeCommand{} }
 func (m *DeleteNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteNodeCommand) ProtoMessage()    {}
 func (*DeleteNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{14}
+	return fileDescriptor_59b0956366e72083, []int{19}
 }
 func (m *DeleteNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteNodeCommand.Unmarshal(m, b)
@@ -1058,6 +1518,7 @@ var E_DeleteNodeCommand_Command = &proto.ExtensionDesc{
 type CreateDatabaseCommand struct {
 	Name                 *string              `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	RetentionPolicy      *RetentionPolicyInfo `protobuf:"bytes,2,opt,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Strict               *bool                `protobuf:"varint,3,opt,name=Strict" json:"Strict,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
 	XXX_sizecache        int32                `json:"-"`
@@ -1067,7 +1528,7 @@ func (m *CreateDatabaseCommand) Reset()         { *m = CreateDatabaseCommand{} }
 func (m *CreateDatabaseCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateDatabaseCommand) ProtoMessage()    {}
 func (*CreateDatabaseCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{15}
+	return fileDescriptor_59b0956366e72083, []int{20}
 }
 func (m *CreateDatabaseCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateDatabaseCommand.Unmarshal(m, b)
@@ -1101,6 +1562,13 @@ func (m *CreateDatabaseCommand) GetRetentionPolicy() *RetentionPolicyInfo {
 	return nil
 }
 
+func (m *CreateDatabaseCommand) GetStrict() bool {
+	if m != nil && m.Strict != nil {
+		return *m.Strict
+	}
+	return false
+}
+
 var E_CreateDatabaseCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*CreateDatabaseCommand)(nil),
@@ -1112,6 +1580,7 @@ var E_CreateDatabaseCommand_Command = &proto.ExtensionDesc{
 
 type DropDatabaseCommand struct {
 	Name                 *string  `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Force                *bool    `protobuf:"varint,2,opt,name=Force" json:"Force,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1121,7 +1590,7 @@ func (m *DropDatabaseCommand) Reset()         { *m = DropDatabaseCommand{} }
 func (m *DropDatabaseCommand) String() string { return proto.CompactTextString(m) }
 func (*DropDatabaseCommand) ProtoMessage()    {}
 func (*DropDatabaseCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{16}
+	return fileDescriptor_59b0956366e72083, []int{21}
 }
 func (m *DropDatabaseCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropDatabaseCommand.Unmarshal(m, b)
@@ -1148,9 +1617,16 @@ func (m *DropDatabaseCommand) GetName() string {
 	return ""
 }
 
-var E_DropDatabaseCommand_Command = &proto.ExtensionDesc{
-	ExtendedType:  (*Command)(nil),
-	ExtensionType: (*DropDatabaseCommand)(nil),
+func (m *DropDatabaseCommand) GetForce() bool {
+	if m != nil && m.Force != nil {
+		return *m.Force
+	}
+	return false
+}
+
+var E_DropDatabaseCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DropDatabaseCommand)(nil),
 	Field:         104,
 	Name:          "meta.DropDatabaseCommand.command",
 	Tag:           "bytes,104,opt,name=command",
@@ -1170,7 +1646,7 @@ func (m *CreateRetentionPolicyCommand) Reset()         { *m = CreateRetentionPol
 func (m *CreateRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateRetentionPolicyCommand) ProtoMessage()    {}
 func (*CreateRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{17}
+	return fileDescriptor_59b0956366e72083, []int{22}
 }
 func (m *CreateRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateRetentionPolicyCommand.Unmarshal(m, b)
@@ -1232,7 +1708,7 @@ func (m *DropRetentionPolicyCommand) Reset()         { *m = DropRetentionPolicyC
 func (m *DropRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*DropRetentionPolicyCommand) ProtoMessage()    {}
 func (*DropRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{18}
+	return fileDescriptor_59b0956366e72083, []int{23}
 }
 func (m *DropRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropRetentionPolicyCommand.Unmarshal(m, b)
@@ -1287,7 +1763,7 @@ func (m *SetDefaultRetentionPolicyCommand) Reset()         { *m = SetDefaultRete
 func (m *SetDefaultRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDefaultRetentionPolicyCommand) ProtoMessage()    {}
 func (*SetDefaultRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{19}
+	return fileDescriptor_59b0956366e72083, []int{24}
 }
 func (m *SetDefaultRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetDefaultRetentionPolicyCommand.Unmarshal(m, b)
@@ -1337,6 +1813,7 @@ type UpdateRetentionPolicyCommand struct {
 	Duration             *int64   `protobuf:"varint,4,opt,name=Duration" json:"Duration,omitempty"`
 	ReplicaN             *uint32  `protobuf:"varint,5,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
 	Default              *bool    `protobuf:"varint,6,req,name=Default" json:"Default,omitempty"`
+	ShardsPerGroup       *uint32  `protobuf:"varint,7,opt,name=ShardsPerGroup" json:"ShardsPerGroup,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1346,7 +1823,7 @@ func (m *UpdateRetentionPolicyCommand) Reset()         { *m = UpdateRetentionPol
 func (m *UpdateRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateRetentionPolicyCommand) ProtoMessage()    {}
 func (*UpdateRetentionPolicyCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{20}
+	return fileDescriptor_59b0956366e72083, []int{25}
 }
 func (m *UpdateRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateRetentionPolicyCommand.Unmarshal(m, b)
@@ -1408,6 +1885,13 @@ func (m *UpdateRetentionPolicyCommand) GetDefault() bool {
 	return false
 }
 
+func (m *UpdateRetentionPolicyCommand) GetShardsPerGroup() uint32 {
+	if m != nil && m.ShardsPerGroup != nil {
+		return *m.ShardsPerGroup
+	}
+	return 0
+}
+
 var E_UpdateRetentionPolicyCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*UpdateRetentionPolicyCommand)(nil),
@@ -1430,7 +1914,7 @@ func (m *CreateShardGroupCommand) Reset()         { *m = CreateShardGroupCommand
 func (m *CreateShardGroupCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateShardGroupCommand) ProtoMessage()    {}
 func (*CreateShardGroupCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{21}
+	return fileDescriptor_59b0956366e72083, []int{26}
 }
 func (m *CreateShardGroupCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateShardGroupCommand.Unmarshal(m, b)
@@ -1493,7 +1977,7 @@ func (m *DeleteShardGroupCommand) Reset()         { *m = DeleteShardGroupCommand
 func (m *DeleteShardGroupCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteShardGroupCommand) ProtoMessage()    {}
 func (*DeleteShardGroupCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{22}
+	return fileDescriptor_59b0956366e72083, []int{27}
 }
 func (m *DeleteShardGroupCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteShardGroupCommand.Unmarshal(m, b)
@@ -1556,7 +2040,7 @@ func (m *CreateContinuousQueryCommand) Reset()         { *m = CreateContinuousQu
 func (m *CreateContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateContinuousQueryCommand) ProtoMessage()    {}
 func (*CreateContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{23}
+	return fileDescriptor_59b0956366e72083, []int{28}
 }
 func (m *CreateContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateContinuousQueryCommand.Unmarshal(m, b)
@@ -1618,7 +2102,7 @@ func (m *DropContinuousQueryCommand) Reset()         { *m = DropContinuousQueryC
 func (m *DropContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*DropContinuousQueryCommand) ProtoMessage()    {}
 func (*DropContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{24}
+	return fileDescriptor_59b0956366e72083, []int{29}
 }
 func (m *DropContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropContinuousQueryCommand.Unmarshal(m, b)
@@ -1674,7 +2158,7 @@ func (m *CreateUserCommand) Reset()         { *m = CreateUserCommand{} }
 func (m *CreateUserCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateUserCommand) ProtoMessage()    {}
 func (*CreateUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{25}
+	return fileDescriptor_59b0956366e72083, []int{30}
 }
 func (m *CreateUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateUserCommand.Unmarshal(m, b)
@@ -1735,7 +2219,7 @@ func (m *DropUserCommand) Reset()         { *m = DropUserCommand{} }
 func (m *DropUserCommand) String() string { return proto.CompactTextString(m) }
 func (*DropUserCommand) ProtoMessage()    {}
 func (*DropUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{26}
+	return fileDescriptor_59b0956366e72083, []int{31}
 }
 func (m *DropUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropUserCommand.Unmarshal(m, b)
@@ -1783,7 +2267,7 @@ func (m *UpdateUserCommand) Reset()         { *m = UpdateUserCommand{} }
 func (m *UpdateUserCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateUserCommand) ProtoMessage()    {}
 func (*UpdateUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{27}
+	return fileDescriptor_59b0956366e72083, []int{32}
 }
 func (m *UpdateUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateUserCommand.Unmarshal(m, b)
@@ -1839,7 +2323,7 @@ func (m *SetPrivilegeCommand) Reset()         { *m = SetPrivilegeCommand{} }
 func (m *SetPrivilegeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetPrivilegeCommand) ProtoMessage()    {}
 func (*SetPrivilegeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{28}
+	return fileDescriptor_59b0956366e72083, []int{33}
 }
 func (m *SetPrivilegeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetPrivilegeCommand.Unmarshal(m, b)
@@ -1891,6 +2375,7 @@ var E_SetPrivilegeCommand_Command = &proto.ExtensionDesc{
 
 type SetDataCommand struct {
 	Data                 *Data    `protobuf:"bytes,1,req,name=Data" json:"Data,omitempty"`
+	PreserveNodes        *bool    `protobuf:"varint,2,opt,name=PreserveNodes" json:"PreserveNodes,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1900,7 +2385,7 @@ func (m *SetDataCommand) Reset()         { *m = SetDataCommand{} }
 func (m *SetDataCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDataCommand) ProtoMessage()    {}
 func (*SetDataCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{29}
+	return fileDescriptor_59b0956366e72083, []int{34}
 }
 func (m *SetDataCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetDataCommand.Unmarshal(m, b)
@@ -1927,6 +2412,13 @@ func (m *SetDataCommand) GetData() *Data {
 	return nil
 }
 
+func (m *SetDataCommand) GetPreserveNodes() bool {
+	if m != nil && m.PreserveNodes != nil {
+		return *m.PreserveNodes
+	}
+	return false
+}
+
 var E_SetDataCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*SetDataCommand)(nil),
@@ -1939,6 +2431,7 @@ var E_SetDataCommand_Command = &proto.ExtensionDesc{
 type SetAdminPrivilegeCommand struct {
 	Username             *string  `protobuf:"bytes,1,req,name=Username" json:"Username,omitempty"`
 	Admin                *bool    `protobuf:"varint,2,req,name=Admin" json:"Admin,omitempty"`
+	ClearPrivileges      *bool    `protobuf:"varint,3,opt,name=ClearPrivileges" json:"ClearPrivileges,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -1948,7 +2441,7 @@ func (m *SetAdminPrivilegeCommand) Reset()         { *m = SetAdminPrivilegeComma
 func (m *SetAdminPrivilegeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetAdminPrivilegeCommand) ProtoMessage()    {}
 func (*SetAdminPrivilegeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{30}
+	return fileDescriptor_59b0956366e72083, []int{35}
 }
 func (m *SetAdminPrivilegeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetAdminPrivilegeCommand.Unmarshal(m, b)
@@ -1982,6 +2475,13 @@ func (m *SetAdminPrivilegeCommand) GetAdmin() bool {
 	return false
 }
 
+func (m *SetAdminPrivilegeCommand) GetClearPrivileges() bool {
+	if m != nil && m.ClearPrivileges != nil {
+		return *m.ClearPrivileges
+	}
+	return false
+}
+
 var E_SetAdminPrivilegeCommand_Command = &proto.ExtensionDesc{
 	ExtendedType:  (*Command)(nil),
 	ExtensionType: (*SetAdminPrivilegeCommand)(nil),
@@ -2003,7 +2503,7 @@ func (m *UpdateNodeCommand) Reset()         { *m = UpdateNodeCommand{} }
 func (m *UpdateNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateNodeCommand) ProtoMessage()    {}
 func (*UpdateNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{31}
+	return fileDescriptor_59b0956366e72083, []int{36}
 }
 func (m *UpdateNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateNodeCommand.Unmarshal(m, b)
@@ -2061,7 +2561,7 @@ func (m *CreateSubscriptionCommand) Reset()         { *m = CreateSubscriptionCom
 func (m *CreateSubscriptionCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateSubscriptionCommand) ProtoMessage()    {}
 func (*CreateSubscriptionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{32}
+	return fileDescriptor_59b0956366e72083, []int{37}
 }
 func (m *CreateSubscriptionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateSubscriptionCommand.Unmarshal(m, b)
@@ -2138,7 +2638,7 @@ func (m *DropSubscriptionCommand) Reset()         { *m = DropSubscriptionCommand
 func (m *DropSubscriptionCommand) String() string { return proto.CompactTextString(m) }
 func (*DropSubscriptionCommand) ProtoMessage()    {}
 func (*DropSubscriptionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{33}
+	return fileDescriptor_59b0956366e72083, []int{38}
 }
 func (m *DropSubscriptionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropSubscriptionCommand.Unmarshal(m, b)
@@ -2200,7 +2700,7 @@ func (m *RemovePeerCommand) Reset()         { *m = RemovePeerCommand{} }
 func (m *RemovePeerCommand) String() string { return proto.CompactTextString(m) }
 func (*RemovePeerCommand) ProtoMessage()    {}
 func (*RemovePeerCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{34}
+	return fileDescriptor_59b0956366e72083, []int{39}
 }
 func (m *RemovePeerCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RemovePeerCommand.Unmarshal(m, b)
@@ -2256,7 +2756,7 @@ func (m *CreateMetaNodeCommand) Reset()         { *m = CreateMetaNodeCommand{} }
 func (m *CreateMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateMetaNodeCommand) ProtoMessage()    {}
 func (*CreateMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{35}
+	return fileDescriptor_59b0956366e72083, []int{40}
 }
 func (m *CreateMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateMetaNodeCommand.Unmarshal(m, b)
@@ -2318,7 +2818,7 @@ func (m *CreateDataNodeCommand) Reset()         { *m = CreateDataNodeCommand{} }
 func (m *CreateDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateDataNodeCommand) ProtoMessage()    {}
 func (*CreateDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{36}
+	return fileDescriptor_59b0956366e72083, []int{41}
 }
 func (m *CreateDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateDataNodeCommand.Unmarshal(m, b)
@@ -2374,7 +2874,7 @@ func (m *UpdateDataNodeCommand) Reset()         { *m = UpdateDataNodeCommand{} }
 func (m *UpdateDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateDataNodeCommand) ProtoMessage()    {}
 func (*UpdateDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{37}
+	return fileDescriptor_59b0956366e72083, []int{42}
 }
 func (m *UpdateDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateDataNodeCommand.Unmarshal(m, b)
@@ -2435,7 +2935,7 @@ func (m *DeleteMetaNodeCommand) Reset()         { *m = DeleteMetaNodeCommand{} }
 func (m *DeleteMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteMetaNodeCommand) ProtoMessage()    {}
 func (*DeleteMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{38}
+	return fileDescriptor_59b0956366e72083, []int{43}
 }
 func (m *DeleteMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteMetaNodeCommand.Unmarshal(m, b)
@@ -2482,7 +2982,7 @@ func (m *DeleteDataNodeCommand) Reset()         { *m = DeleteDataNodeCommand{} }
 func (m *DeleteDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteDataNodeCommand) ProtoMessage()    {}
 func (*DeleteDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{39}
+	return fileDescriptor_59b0956366e72083, []int{44}
 }
 func (m *DeleteDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteDataNodeCommand.Unmarshal(m, b)
@@ -2522,6 +3022,7 @@ type Response struct {
 	OK                   *bool    `protobuf:"varint,1,req,name=OK" json:"OK,omitempty"`
 	Error                *string  `protobuf:"bytes,2,opt,name=Error" json:"Error,omitempty"`
 	Index                *uint64  `protobuf:"varint,3,opt,name=Index" json:"Index,omitempty"`
+	Result               *uint64  `protobuf:"varint,4,opt,name=Result" json:"Result,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -2531,7 +3032,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{40}
+	return fileDescriptor_59b0956366e72083, []int{45}
 }
 func (m *Response) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Response.Unmarshal(m, b)
@@ -2572,6 +3073,13 @@ func (m *Response) GetIndex() uint64 {
 	return 0
 }
 
+func (m *Response) GetResult() uint64 {
+	if m != nil && m.Result != nil {
+		return *m.Result
+	}
+	return 0
+}
+
 // SetMetaNodeCommand is for the initial metanode in a cluster or
 // if the single host restarts and its hostname changes, this will update it
 type SetMetaNodeCommand struct {
@@ -2587,7 +3095,7 @@ func (m *SetMetaNodeCommand) Reset()         { *m = SetMetaNodeCommand{} }
 func (m *SetMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetMetaNodeCommand) ProtoMessage()    {}
 func (*SetMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{41}
+	return fileDescriptor_59b0956366e72083, []int{46}
 }
 func (m *SetMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetMetaNodeCommand.Unmarshal(m, b)
@@ -2648,7 +3156,7 @@ func (m *DropShardCommand) Reset()         { *m = DropShardCommand{} }
 func (m *DropShardCommand) String() string { return proto.CompactTextString(m) }
 func (*DropShardCommand) ProtoMessage()    {}
 func (*DropShardCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_59b0956366e72083, []int{42}
+	return fileDescriptor_59b0956366e72083, []int{47}
 }
 func (m *DropShardCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropShardCommand.Unmarshal(m, b)
@@ -2684,13 +3192,877 @@ var E_DropShardCommand_Command = &proto.ExtensionDesc{
 	Filename:      "internal/meta.proto",
 }
 
+type MarkShardGroupDeletedCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy      *string  `protobuf:"bytes,2,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	ShardGroupID         *uint64  `protobuf:"varint,3,req,name=ShardGroupID" json:"ShardGroupID,omitempty"`
+	DeletedAt            *int64   `protobuf:"varint,4,req,name=DeletedAt" json:"DeletedAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MarkShardGroupDeletedCommand) Reset()         { *m = MarkShardGroupDeletedCommand{} }
+func (m *MarkShardGroupDeletedCommand) String() string { return proto.CompactTextString(m) }
+func (*MarkShardGroupDeletedCommand) ProtoMessage()    {}
+func (*MarkShardGroupDeletedCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{48}
+}
+func (m *MarkShardGroupDeletedCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MarkShardGroupDeletedCommand.Unmarshal(m, b)
+}
+func (m *MarkShardGroupDeletedCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MarkShardGroupDeletedCommand.Marshal(b, m, deterministic)
+}
+func (m *MarkShardGroupDeletedCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MarkShardGroupDeletedCommand.Merge(m, src)
+}
+func (m *MarkShardGroupDeletedCommand) XXX_Size() int {
+	return xxx_messageInfo_MarkShardGroupDeletedCommand.Size(m)
+}
+func (m *MarkShardGroupDeletedCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_MarkShardGroupDeletedCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MarkShardGroupDeletedCommand proto.InternalMessageInfo
+
+func (m *MarkShardGroupDeletedCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *MarkShardGroupDeletedCommand) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *MarkShardGroupDeletedCommand) GetShardGroupID() uint64 {
+	if m != nil && m.ShardGroupID != nil {
+		return *m.ShardGroupID
+	}
+	return 0
+}
+
+func (m *MarkShardGroupDeletedCommand) GetDeletedAt() int64 {
+	if m != nil && m.DeletedAt != nil {
+		return *m.DeletedAt
+	}
+	return 0
+}
+
+var E_MarkShardGroupDeletedCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*MarkShardGroupDeletedCommand)(nil),
+	Field:         131,
+	Name:          "meta.MarkShardGroupDeletedCommand.command",
+	Tag:           "bytes,131,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type ReplaceContinuousQueryCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Name                 *string  `protobuf:"bytes,2,req,name=Name" json:"Name,omitempty"`
+	Query                *string  `protobuf:"bytes,3,req,name=Query" json:"Query,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplaceContinuousQueryCommand) Reset()         { *m = ReplaceContinuousQueryCommand{} }
+func (m *ReplaceContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
+func (*ReplaceContinuousQueryCommand) ProtoMessage()    {}
+func (*ReplaceContinuousQueryCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{49}
+}
+func (m *ReplaceContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplaceContinuousQueryCommand.Unmarshal(m, b)
+}
+func (m *ReplaceContinuousQueryCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplaceContinuousQueryCommand.Marshal(b, m, deterministic)
+}
+func (m *ReplaceContinuousQueryCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplaceContinuousQueryCommand.Merge(m, src)
+}
+func (m *ReplaceContinuousQueryCommand) XXX_Size() int {
+	return xxx_messageInfo_ReplaceContinuousQueryCommand.Size(m)
+}
+func (m *ReplaceContinuousQueryCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplaceContinuousQueryCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplaceContinuousQueryCommand proto.InternalMessageInfo
+
+func (m *ReplaceContinuousQueryCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *ReplaceContinuousQueryCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *ReplaceContinuousQueryCommand) GetQuery() string {
+	if m != nil && m.Query != nil {
+		return *m.Query
+	}
+	return ""
+}
+
+var E_ReplaceContinuousQueryCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*ReplaceContinuousQueryCommand)(nil),
+	Field:         132,
+	Name:          "meta.ReplaceContinuousQueryCommand.command",
+	Tag:           "bytes,132,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetMeasurementRetentionCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy      *string  `protobuf:"bytes,2,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Measurement          *string  `protobuf:"bytes,3,req,name=Measurement" json:"Measurement,omitempty"`
+	Duration             *int64   `protobuf:"varint,4,req,name=Duration" json:"Duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetMeasurementRetentionCommand) Reset()         { *m = SetMeasurementRetentionCommand{} }
+func (m *SetMeasurementRetentionCommand) String() string { return proto.CompactTextString(m) }
+func (*SetMeasurementRetentionCommand) ProtoMessage()    {}
+func (*SetMeasurementRetentionCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{50}
+}
+func (m *SetMeasurementRetentionCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetMeasurementRetentionCommand.Unmarshal(m, b)
+}
+func (m *SetMeasurementRetentionCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetMeasurementRetentionCommand.Marshal(b, m, deterministic)
+}
+func (m *SetMeasurementRetentionCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetMeasurementRetentionCommand.Merge(m, src)
+}
+func (m *SetMeasurementRetentionCommand) XXX_Size() int {
+	return xxx_messageInfo_SetMeasurementRetentionCommand.Size(m)
+}
+func (m *SetMeasurementRetentionCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetMeasurementRetentionCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetMeasurementRetentionCommand proto.InternalMessageInfo
+
+func (m *SetMeasurementRetentionCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetMeasurementRetentionCommand) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *SetMeasurementRetentionCommand) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *SetMeasurementRetentionCommand) GetDuration() int64 {
+	if m != nil && m.Duration != nil {
+		return *m.Duration
+	}
+	return 0
+}
+
+var E_SetMeasurementRetentionCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetMeasurementRetentionCommand)(nil),
+	Field:         133,
+	Name:          "meta.SetMeasurementRetentionCommand.command",
+	Tag:           "bytes,133,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDatabaseQuotaCommand struct {
+	Database             *string        `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Quota                *DatabaseQuota `protobuf:"bytes,2,req,name=Quota" json:"Quota,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *SetDatabaseQuotaCommand) Reset()         { *m = SetDatabaseQuotaCommand{} }
+func (m *SetDatabaseQuotaCommand) String() string { return proto.CompactTextString(m) }
+func (*SetDatabaseQuotaCommand) ProtoMessage()    {}
+func (*SetDatabaseQuotaCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{51}
+}
+func (m *SetDatabaseQuotaCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetDatabaseQuotaCommand.Unmarshal(m, b)
+}
+func (m *SetDatabaseQuotaCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetDatabaseQuotaCommand.Marshal(b, m, deterministic)
+}
+func (m *SetDatabaseQuotaCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetDatabaseQuotaCommand.Merge(m, src)
+}
+func (m *SetDatabaseQuotaCommand) XXX_Size() int {
+	return xxx_messageInfo_SetDatabaseQuotaCommand.Size(m)
+}
+func (m *SetDatabaseQuotaCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetDatabaseQuotaCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetDatabaseQuotaCommand proto.InternalMessageInfo
+
+func (m *SetDatabaseQuotaCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetDatabaseQuotaCommand) GetQuota() *DatabaseQuota {
+	if m != nil {
+		return m.Quota
+	}
+	return nil
+}
+
+var E_SetDatabaseQuotaCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDatabaseQuotaCommand)(nil),
+	Field:         134,
+	Name:          "meta.SetDatabaseQuotaCommand.command",
+	Tag:           "bytes,134,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type CreateShardGroupsCommand struct {
+	Groups               []*CreateShardGroupCommand `protobuf:"bytes,1,rep,name=Groups" json:"Groups,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *CreateShardGroupsCommand) Reset()         { *m = CreateShardGroupsCommand{} }
+func (m *CreateShardGroupsCommand) String() string { return proto.CompactTextString(m) }
+func (*CreateShardGroupsCommand) ProtoMessage()    {}
+func (*CreateShardGroupsCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{52}
+}
+func (m *CreateShardGroupsCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateShardGroupsCommand.Unmarshal(m, b)
+}
+func (m *CreateShardGroupsCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateShardGroupsCommand.Marshal(b, m, deterministic)
+}
+func (m *CreateShardGroupsCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateShardGroupsCommand.Merge(m, src)
+}
+func (m *CreateShardGroupsCommand) XXX_Size() int {
+	return xxx_messageInfo_CreateShardGroupsCommand.Size(m)
+}
+func (m *CreateShardGroupsCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateShardGroupsCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateShardGroupsCommand proto.InternalMessageInfo
+
+func (m *CreateShardGroupsCommand) GetGroups() []*CreateShardGroupCommand {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+var E_CreateShardGroupsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CreateShardGroupsCommand)(nil),
+	Field:         135,
+	Name:          "meta.CreateShardGroupsCommand.command",
+	Tag:           "bytes,135,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type HeartbeatDataNodeCommand struct {
+	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Time                 *int64   `protobuf:"varint,2,req,name=Time" json:"Time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HeartbeatDataNodeCommand) Reset()         { *m = HeartbeatDataNodeCommand{} }
+func (m *HeartbeatDataNodeCommand) String() string { return proto.CompactTextString(m) }
+func (*HeartbeatDataNodeCommand) ProtoMessage()    {}
+func (*HeartbeatDataNodeCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{53}
+}
+func (m *HeartbeatDataNodeCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HeartbeatDataNodeCommand.Unmarshal(m, b)
+}
+func (m *HeartbeatDataNodeCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HeartbeatDataNodeCommand.Marshal(b, m, deterministic)
+}
+func (m *HeartbeatDataNodeCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HeartbeatDataNodeCommand.Merge(m, src)
+}
+func (m *HeartbeatDataNodeCommand) XXX_Size() int {
+	return xxx_messageInfo_HeartbeatDataNodeCommand.Size(m)
+}
+func (m *HeartbeatDataNodeCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_HeartbeatDataNodeCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HeartbeatDataNodeCommand proto.InternalMessageInfo
+
+func (m *HeartbeatDataNodeCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *HeartbeatDataNodeCommand) GetTime() int64 {
+	if m != nil && m.Time != nil {
+		return *m.Time
+	}
+	return 0
+}
+
+var E_HeartbeatDataNodeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*HeartbeatDataNodeCommand)(nil),
+	Field:         136,
+	Name:          "meta.HeartbeatDataNodeCommand.command",
+	Tag:           "bytes,136,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDataNodeDrainingCommand struct {
+	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Draining             *bool    `protobuf:"varint,2,req,name=Draining" json:"Draining,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetDataNodeDrainingCommand) Reset()         { *m = SetDataNodeDrainingCommand{} }
+func (m *SetDataNodeDrainingCommand) String() string { return proto.CompactTextString(m) }
+func (*SetDataNodeDrainingCommand) ProtoMessage()    {}
+func (*SetDataNodeDrainingCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{54}
+}
+func (m *SetDataNodeDrainingCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetDataNodeDrainingCommand.Unmarshal(m, b)
+}
+func (m *SetDataNodeDrainingCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetDataNodeDrainingCommand.Marshal(b, m, deterministic)
+}
+func (m *SetDataNodeDrainingCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetDataNodeDrainingCommand.Merge(m, src)
+}
+func (m *SetDataNodeDrainingCommand) XXX_Size() int {
+	return xxx_messageInfo_SetDataNodeDrainingCommand.Size(m)
+}
+func (m *SetDataNodeDrainingCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetDataNodeDrainingCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetDataNodeDrainingCommand proto.InternalMessageInfo
+
+func (m *SetDataNodeDrainingCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetDataNodeDrainingCommand) GetDraining() bool {
+	if m != nil && m.Draining != nil {
+		return *m.Draining
+	}
+	return false
+}
+
+var E_SetDataNodeDrainingCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDataNodeDrainingCommand)(nil),
+	Field:         137,
+	Name:          "meta.SetDataNodeDrainingCommand.command",
+	Tag:           "bytes,137,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type CopyRetentionPolicyCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Source               *string  `protobuf:"bytes,2,req,name=Source" json:"Source,omitempty"`
+	Destination          *string  `protobuf:"bytes,3,req,name=Destination" json:"Destination,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CopyRetentionPolicyCommand) Reset()         { *m = CopyRetentionPolicyCommand{} }
+func (m *CopyRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
+func (*CopyRetentionPolicyCommand) ProtoMessage()    {}
+func (*CopyRetentionPolicyCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{55}
+}
+func (m *CopyRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CopyRetentionPolicyCommand.Unmarshal(m, b)
+}
+func (m *CopyRetentionPolicyCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CopyRetentionPolicyCommand.Marshal(b, m, deterministic)
+}
+func (m *CopyRetentionPolicyCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CopyRetentionPolicyCommand.Merge(m, src)
+}
+func (m *CopyRetentionPolicyCommand) XXX_Size() int {
+	return xxx_messageInfo_CopyRetentionPolicyCommand.Size(m)
+}
+func (m *CopyRetentionPolicyCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_CopyRetentionPolicyCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CopyRetentionPolicyCommand proto.InternalMessageInfo
+
+func (m *CopyRetentionPolicyCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *CopyRetentionPolicyCommand) GetSource() string {
+	if m != nil && m.Source != nil {
+		return *m.Source
+	}
+	return ""
+}
+
+func (m *CopyRetentionPolicyCommand) GetDestination() string {
+	if m != nil && m.Destination != nil {
+		return *m.Destination
+	}
+	return ""
+}
+
+var E_CopyRetentionPolicyCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*CopyRetentionPolicyCommand)(nil),
+	Field:         138,
+	Name:          "meta.CopyRetentionPolicyCommand.command",
+	Tag:           "bytes,138,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+// TransactionCommand applies several commands as one: either all of them
+// take effect or none do.
+type TransactionCommand struct {
+	// Commands are marshaled Commands, applied in order.
+	Commands             [][]byte `protobuf:"bytes,1,rep,name=Commands" json:"Commands,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransactionCommand) Reset()         { *m = TransactionCommand{} }
+func (m *TransactionCommand) String() string { return proto.CompactTextString(m) }
+func (*TransactionCommand) ProtoMessage()    {}
+func (*TransactionCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{56}
+}
+func (m *TransactionCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransactionCommand.Unmarshal(m, b)
+}
+func (m *TransactionCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransactionCommand.Marshal(b, m, deterministic)
+}
+func (m *TransactionCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransactionCommand.Merge(m, src)
+}
+func (m *TransactionCommand) XXX_Size() int {
+	return xxx_messageInfo_TransactionCommand.Size(m)
+}
+func (m *TransactionCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransactionCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransactionCommand proto.InternalMessageInfo
+
+func (m *TransactionCommand) GetCommands() [][]byte {
+	if m != nil {
+		return m.Commands
+	}
+	return nil
+}
+
+var E_TransactionCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*TransactionCommand)(nil),
+	Field:         139,
+	Name:          "meta.TransactionCommand.command",
+	Tag:           "bytes,139,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type RebalanceShardGroupCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy      *string  `protobuf:"bytes,2,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	ShardGroupID         *uint64  `protobuf:"varint,3,req,name=ShardGroupID" json:"ShardGroupID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RebalanceShardGroupCommand) Reset()         { *m = RebalanceShardGroupCommand{} }
+func (m *RebalanceShardGroupCommand) String() string { return proto.CompactTextString(m) }
+func (*RebalanceShardGroupCommand) ProtoMessage()    {}
+func (*RebalanceShardGroupCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{57}
+}
+func (m *RebalanceShardGroupCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RebalanceShardGroupCommand.Unmarshal(m, b)
+}
+func (m *RebalanceShardGroupCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RebalanceShardGroupCommand.Marshal(b, m, deterministic)
+}
+func (m *RebalanceShardGroupCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RebalanceShardGroupCommand.Merge(m, src)
+}
+func (m *RebalanceShardGroupCommand) XXX_Size() int {
+	return xxx_messageInfo_RebalanceShardGroupCommand.Size(m)
+}
+func (m *RebalanceShardGroupCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_RebalanceShardGroupCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RebalanceShardGroupCommand proto.InternalMessageInfo
+
+func (m *RebalanceShardGroupCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *RebalanceShardGroupCommand) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *RebalanceShardGroupCommand) GetShardGroupID() uint64 {
+	if m != nil && m.ShardGroupID != nil {
+		return *m.ShardGroupID
+	}
+	return 0
+}
+
+var E_RebalanceShardGroupCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RebalanceShardGroupCommand)(nil),
+	Field:         140,
+	Name:          "meta.RebalanceShardGroupCommand.command",
+	Tag:           "bytes,140,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type RepairDefaultsCommand struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RepairDefaultsCommand) Reset()         { *m = RepairDefaultsCommand{} }
+func (m *RepairDefaultsCommand) String() string { return proto.CompactTextString(m) }
+func (*RepairDefaultsCommand) ProtoMessage()    {}
+func (*RepairDefaultsCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{58}
+}
+func (m *RepairDefaultsCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RepairDefaultsCommand.Unmarshal(m, b)
+}
+func (m *RepairDefaultsCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RepairDefaultsCommand.Marshal(b, m, deterministic)
+}
+func (m *RepairDefaultsCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RepairDefaultsCommand.Merge(m, src)
+}
+func (m *RepairDefaultsCommand) XXX_Size() int {
+	return xxx_messageInfo_RepairDefaultsCommand.Size(m)
+}
+func (m *RepairDefaultsCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_RepairDefaultsCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RepairDefaultsCommand proto.InternalMessageInfo
+
+var E_RepairDefaultsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RepairDefaultsCommand)(nil),
+	Field:         141,
+	Name:          "meta.RepairDefaultsCommand.command",
+	Tag:           "bytes,141,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type ReserveShardIDsCommand struct {
+	// Start, if set, is the first ID to reserve and the command fails if it
+	// isn't the next unused ID. Otherwise the next unused IDs are reserved and
+	// the first of them is returned in the response's Result.
+	Start                *uint64  `protobuf:"varint,1,opt,name=Start" json:"Start,omitempty"`
+	N                    *uint64  `protobuf:"varint,2,req,name=N" json:"N,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReserveShardIDsCommand) Reset()         { *m = ReserveShardIDsCommand{} }
+func (m *ReserveShardIDsCommand) String() string { return proto.CompactTextString(m) }
+func (*ReserveShardIDsCommand) ProtoMessage()    {}
+func (*ReserveShardIDsCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{59}
+}
+func (m *ReserveShardIDsCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReserveShardIDsCommand.Unmarshal(m, b)
+}
+func (m *ReserveShardIDsCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReserveShardIDsCommand.Marshal(b, m, deterministic)
+}
+func (m *ReserveShardIDsCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReserveShardIDsCommand.Merge(m, src)
+}
+func (m *ReserveShardIDsCommand) XXX_Size() int {
+	return xxx_messageInfo_ReserveShardIDsCommand.Size(m)
+}
+func (m *ReserveShardIDsCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReserveShardIDsCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReserveShardIDsCommand proto.InternalMessageInfo
+
+func (m *ReserveShardIDsCommand) GetStart() uint64 {
+	if m != nil && m.Start != nil {
+		return *m.Start
+	}
+	return 0
+}
+
+func (m *ReserveShardIDsCommand) GetN() uint64 {
+	if m != nil && m.N != nil {
+		return *m.N
+	}
+	return 0
+}
+
+var E_ReserveShardIDsCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*ReserveShardIDsCommand)(nil),
+	Field:         142,
+	Name:          "meta.ReserveShardIDsCommand.command",
+	Tag:           "bytes,142,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetMaintenanceModeCommand struct {
+	On                   *bool    `protobuf:"varint,1,req,name=On" json:"On,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetMaintenanceModeCommand) Reset()         { *m = SetMaintenanceModeCommand{} }
+func (m *SetMaintenanceModeCommand) String() string { return proto.CompactTextString(m) }
+func (*SetMaintenanceModeCommand) ProtoMessage()    {}
+func (*SetMaintenanceModeCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{60}
+}
+func (m *SetMaintenanceModeCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetMaintenanceModeCommand.Unmarshal(m, b)
+}
+func (m *SetMaintenanceModeCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetMaintenanceModeCommand.Marshal(b, m, deterministic)
+}
+func (m *SetMaintenanceModeCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetMaintenanceModeCommand.Merge(m, src)
+}
+func (m *SetMaintenanceModeCommand) XXX_Size() int {
+	return xxx_messageInfo_SetMaintenanceModeCommand.Size(m)
+}
+func (m *SetMaintenanceModeCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetMaintenanceModeCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetMaintenanceModeCommand proto.InternalMessageInfo
+
+func (m *SetMaintenanceModeCommand) GetOn() bool {
+	if m != nil && m.On != nil {
+		return *m.On
+	}
+	return false
+}
+
+var E_SetMaintenanceModeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetMaintenanceModeCommand)(nil),
+	Field:         143,
+	Name:          "meta.SetMaintenanceModeCommand.command",
+	Tag:           "bytes,143,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDatabaseDefaultShardGroupDurationCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Duration             *int64   `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetDatabaseDefaultShardGroupDurationCommand) Reset() {
+	*m = SetDatabaseDefaultShardGroupDurationCommand{}
+}
+func (m *SetDatabaseDefaultShardGroupDurationCommand) String() string {
+	return proto.CompactTextString(m)
+}
+func (*SetDatabaseDefaultShardGroupDurationCommand) ProtoMessage() {}
+func (*SetDatabaseDefaultShardGroupDurationCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{61}
+}
+func (m *SetDatabaseDefaultShardGroupDurationCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetDatabaseDefaultShardGroupDurationCommand.Unmarshal(m, b)
+}
+func (m *SetDatabaseDefaultShardGroupDurationCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetDatabaseDefaultShardGroupDurationCommand.Marshal(b, m, deterministic)
+}
+func (m *SetDatabaseDefaultShardGroupDurationCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetDatabaseDefaultShardGroupDurationCommand.Merge(m, src)
+}
+func (m *SetDatabaseDefaultShardGroupDurationCommand) XXX_Size() int {
+	return xxx_messageInfo_SetDatabaseDefaultShardGroupDurationCommand.Size(m)
+}
+func (m *SetDatabaseDefaultShardGroupDurationCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetDatabaseDefaultShardGroupDurationCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetDatabaseDefaultShardGroupDurationCommand proto.InternalMessageInfo
+
+func (m *SetDatabaseDefaultShardGroupDurationCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetDatabaseDefaultShardGroupDurationCommand) GetDuration() int64 {
+	if m != nil && m.Duration != nil {
+		return *m.Duration
+	}
+	return 0
+}
+
+var E_SetDatabaseDefaultShardGroupDurationCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDatabaseDefaultShardGroupDurationCommand)(nil),
+	Field:         144,
+	Name:          "meta.SetDatabaseDefaultShardGroupDurationCommand.command",
+	Tag:           "bytes,144,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type FreezeRetentionPolicyCommand struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	RetentionPolicy      *string  `protobuf:"bytes,2,req,name=RetentionPolicy" json:"RetentionPolicy,omitempty"`
+	Frozen               *bool    `protobuf:"varint,3,req,name=Frozen" json:"Frozen,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FreezeRetentionPolicyCommand) Reset()         { *m = FreezeRetentionPolicyCommand{} }
+func (m *FreezeRetentionPolicyCommand) String() string { return proto.CompactTextString(m) }
+func (*FreezeRetentionPolicyCommand) ProtoMessage()    {}
+func (*FreezeRetentionPolicyCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{62}
+}
+func (m *FreezeRetentionPolicyCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FreezeRetentionPolicyCommand.Unmarshal(m, b)
+}
+func (m *FreezeRetentionPolicyCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FreezeRetentionPolicyCommand.Marshal(b, m, deterministic)
+}
+func (m *FreezeRetentionPolicyCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FreezeRetentionPolicyCommand.Merge(m, src)
+}
+func (m *FreezeRetentionPolicyCommand) XXX_Size() int {
+	return xxx_messageInfo_FreezeRetentionPolicyCommand.Size(m)
+}
+func (m *FreezeRetentionPolicyCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_FreezeRetentionPolicyCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FreezeRetentionPolicyCommand proto.InternalMessageInfo
+
+func (m *FreezeRetentionPolicyCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *FreezeRetentionPolicyCommand) GetRetentionPolicy() string {
+	if m != nil && m.RetentionPolicy != nil {
+		return *m.RetentionPolicy
+	}
+	return ""
+}
+
+func (m *FreezeRetentionPolicyCommand) GetFrozen() bool {
+	if m != nil && m.Frozen != nil {
+		return *m.Frozen
+	}
+	return false
+}
+
+var E_FreezeRetentionPolicyCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*FreezeRetentionPolicyCommand)(nil),
+	Field:         145,
+	Name:          "meta.FreezeRetentionPolicyCommand.command",
+	Tag:           "bytes,145,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
 func init() {
 	proto.RegisterEnum("meta.Command_Type", Command_Type_name, Command_Type_value)
 	proto.RegisterType((*Data)(nil), "meta.Data")
+	proto.RegisterType((*AppliedCommand)(nil), "meta.AppliedCommand")
+	proto.RegisterType((*DataDelta)(nil), "meta.DataDelta")
 	proto.RegisterType((*NodeInfo)(nil), "meta.NodeInfo")
 	proto.RegisterType((*DatabaseInfo)(nil), "meta.DatabaseInfo")
+	proto.RegisterType((*DatabaseQuota)(nil), "meta.DatabaseQuota")
 	proto.RegisterType((*RetentionPolicySpec)(nil), "meta.RetentionPolicySpec")
 	proto.RegisterType((*RetentionPolicyInfo)(nil), "meta.RetentionPolicyInfo")
+	proto.RegisterType((*MeasurementRetention)(nil), "meta.MeasurementRetention")
+	proto.RegisterType((*TenantNodes)(nil), "meta.TenantNodes")
 	proto.RegisterType((*ShardGroupInfo)(nil), "meta.ShardGroupInfo")
 	proto.RegisterType((*ShardInfo)(nil), "meta.ShardInfo")
 	proto.RegisterType((*SubscriptionInfo)(nil), "meta.SubscriptionInfo")
@@ -2758,124 +4130,218 @@ func init() {
 	proto.RegisterType((*SetMetaNodeCommand)(nil), "meta.SetMetaNodeCommand")
 	proto.RegisterExtension(E_DropShardCommand_Command)
 	proto.RegisterType((*DropShardCommand)(nil), "meta.DropShardCommand")
+	proto.RegisterExtension(E_MarkShardGroupDeletedCommand_Command)
+	proto.RegisterType((*MarkShardGroupDeletedCommand)(nil), "meta.MarkShardGroupDeletedCommand")
+	proto.RegisterExtension(E_ReplaceContinuousQueryCommand_Command)
+	proto.RegisterType((*ReplaceContinuousQueryCommand)(nil), "meta.ReplaceContinuousQueryCommand")
+	proto.RegisterExtension(E_SetMeasurementRetentionCommand_Command)
+	proto.RegisterType((*SetMeasurementRetentionCommand)(nil), "meta.SetMeasurementRetentionCommand")
+	proto.RegisterExtension(E_SetDatabaseQuotaCommand_Command)
+	proto.RegisterType((*SetDatabaseQuotaCommand)(nil), "meta.SetDatabaseQuotaCommand")
+	proto.RegisterExtension(E_CreateShardGroupsCommand_Command)
+	proto.RegisterType((*CreateShardGroupsCommand)(nil), "meta.CreateShardGroupsCommand")
+	proto.RegisterExtension(E_HeartbeatDataNodeCommand_Command)
+	proto.RegisterType((*HeartbeatDataNodeCommand)(nil), "meta.HeartbeatDataNodeCommand")
+	proto.RegisterExtension(E_SetDataNodeDrainingCommand_Command)
+	proto.RegisterType((*SetDataNodeDrainingCommand)(nil), "meta.SetDataNodeDrainingCommand")
+	proto.RegisterExtension(E_CopyRetentionPolicyCommand_Command)
+	proto.RegisterType((*CopyRetentionPolicyCommand)(nil), "meta.CopyRetentionPolicyCommand")
+	proto.RegisterExtension(E_TransactionCommand_Command)
+	proto.RegisterType((*TransactionCommand)(nil), "meta.TransactionCommand")
+	proto.RegisterExtension(E_RebalanceShardGroupCommand_Command)
+	proto.RegisterType((*RebalanceShardGroupCommand)(nil), "meta.RebalanceShardGroupCommand")
+	proto.RegisterExtension(E_RepairDefaultsCommand_Command)
+	proto.RegisterType((*RepairDefaultsCommand)(nil), "meta.RepairDefaultsCommand")
+	proto.RegisterExtension(E_ReserveShardIDsCommand_Command)
+	proto.RegisterType((*ReserveShardIDsCommand)(nil), "meta.ReserveShardIDsCommand")
+	proto.RegisterExtension(E_SetMaintenanceModeCommand_Command)
+	proto.RegisterType((*SetMaintenanceModeCommand)(nil), "meta.SetMaintenanceModeCommand")
+	proto.RegisterExtension(E_SetDatabaseDefaultShardGroupDurationCommand_Command)
+	proto.RegisterType((*SetDatabaseDefaultShardGroupDurationCommand)(nil), "meta.SetDatabaseDefaultShardGroupDurationCommand")
+	proto.RegisterExtension(E_FreezeRetentionPolicyCommand_Command)
+	proto.RegisterType((*FreezeRetentionPolicyCommand)(nil), "meta.FreezeRetentionPolicyCommand")
 }
 
 func init() { proto.RegisterFile("internal/meta.proto", fileDescriptor_59b0956366e72083) }
 
 var fileDescriptor_59b0956366e72083 = []byte{
-	// 1819 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0xcb, 0x73, 0xdc, 0x44,
-	0x13, 0x2f, 0x49, 0xbb, 0xeb, 0xdd, 0xf6, 0x33, 0xe3, 0x97, 0x9c, 0x38, 0xfe, 0xb6, 0x54, 0xa9,
-	0x7c, 0x5b, 0x5f, 0x7d, 0x65, 0xa8, 0xa5, 0x2a, 0x27, 0x5e, 0x89, 0x37, 0x89, 0xb7, 0x52, 0x7e,
-	0xa0, 0x75, 0xae, 0x54, 0x29, 0xde, 0x49, 0xbc, 0xb0, 0x2b, 0x2d, 0x92, 0x36, 0x89, 0x09, 0x06,
-	0xc3, 0x25, 0x57, 0x28, 0x8a, 0xe2, 0x90, 0x1b, 0x1c, 0x38, 0x52, 0x5c, 0xb8, 0x70, 0xe2, 0xc0,
-	0x89, 0xbf, 0x80, 0x7f, 0x80, 0x13, 0x77, 0xae, 0xd4, 0xcc, 0x68, 0x34, 0x23, 0x69, 0x46, 0xb6,
-	0x21, 0xdc, 0x34, 0xdd, 0x3d, 0xd3, 0xbf, 0xee, 0xe9, 0xe9, 0xe9, 0x1e, 0xc1, 0xe2, 0xc0, 0x8f,
-	0x71, 0xe8, 0x7b, 0xc3, 0x57, 0x46, 0x38, 0xf6, 0x36, 0xc7, 0x61, 0x10, 0x07, 0xa8, 0x42, 0xbe,
-	0x9d, 0xcf, 0x2d, 0xa8, 0x74, 0xbc, 0xd8, 0x43, 0x08, 0x2a, 0x07, 0x38, 0x1c, 0xd9, 0x46, 0xd3,
-	0x6c, 0x55, 0x5c, 0xfa, 0x8d, 0x96, 0xa0, 0xda, 0xf5, 0xfb, 0xf8, 0xa9, 0x6d, 0x52, 0x22, 0x1b,
-	0xa0, 0x75, 0x68, 0x6c, 0x0d, 0x27, 0x51, 0x8c, 0xc3, 0x6e, 0xc7, 0xb6, 0x28, 0x47, 0x10, 0xd0,
-	0x35, 0xa8, 0xee, 0x06, 0x7d, 0x1c, 0xd9, 0x95, 0xa6, 0xd5, 0x9a, 0x6e, 0xcf, 0x6d, 0x52, 0x95,
-	0x84, 0xd4, 0xf5, 0x1f, 0x06, 0x2e, 0x63, 0xa2, 0x57, 0xa1, 0x41, 0xb4, 0x3e, 0xf0, 0x22, 0x1c,
-	0xd9, 0x55, 0x2a, 0x89, 0x98, 0x24, 0x27, 0x53, 0x69, 0x21, 0x44, 0xd6, 0xbd, 0x1f, 0xe1, 0x30,
-	0xb2, 0x6b, 0xf2, 0xba, 0x84, 0xc4, 0xd6, 0xa5, 0x4c, 0x82, 0x6d, 0xc7, 0x7b, 0x4a, 0xb5, 0x75,
-	0xec, 0x29, 0x86, 0x2d, 0x25, 0xa0, 0x16, 0xcc, 0xef, 0x78, 0x4f, 0x7b, 0x47, 0x5e, 0xd8, 0xbf,
-	0x1b, 0x06, 0x93, 0x71, 0xb7, 0x63, 0xd7, 0xa9, 0x4c, 0x9e, 0x8c, 0x36, 0x00, 0x38, 0xa9, 0xdb,
-	0xb1, 0x1b, 0x54, 0x48, 0xa2, 0xa0, 0xff, 0x33, 0xfc, 0xcc, 0x52, 0x50, 0x5a, 0x2a, 0x04, 0x88,
-	0xf4, 0x0e, 0xe6, 0xd2, 0xd3, 0x6a, 0xe9, 0x54, 0xc0, 0xd9, 0x86, 0x3a, 0x27, 0xa3, 0x39, 0x30,
-	0xbb, 0x9d, 0x64, 0x4f, 0xcc, 0x6e, 0x87, 0xec, 0xd2, 0x76, 0x10, 0xc5, 0x74, 0x43, 0x1a, 0x2e,
-	0xfd, 0x46, 0x36, 0x4c, 0x1d, 0x6c, 0xed, 0x53, 0xb2, 0xd5, 0x34, 0x5a, 0x0d, 0x97, 0x0f, 0x9d,
-	0x3f, 0x0c, 0x98, 0x91, 0xfd, 0x49, 0xa6, 0xef, 0x7a, 0x23, 0x4c, 0x17, 0x6c, 0xb8, 0xf4, 0x1b,
-	0xdd, 0x80, 0x95, 0x0e, 0x7e, 0xe8, 0x4d, 0x86, 0xb1, 0x8b, 0x63, 0xec, 0xc7, 0x83, 0xc0, 0xdf,
-	0x0f, 0x86, 0x83, 0xc3, 0xe3, 0x44, 0x89, 0x86, 0x8b, 0xee, 0xc2, 0xa5, 0x2c, 0x69, 0x80, 0x23,
-	0xdb, 0xa2, 0xc6, 0xad, 0x31, 0xe3, 0x72, 0x33, 0xa8, 0x9d, 0xc5, 0x39, 0x64, 0xa1, 0xad, 0xc0,
-	0x8f, 0x07, 0xfe, 0x24, 0x98, 0x44, 0xef, 0x4c, 0x70, 0x38, 0x48, 0xa3, 0x27, 0x59, 0x28, 0xcb,
-	0x4e, 0x16, 0x2a, 0xcc, 0x71, 0xbe, 0x30, 0x60, 0x31, 0xa7, 0xb3, 0x37, 0xc6, 0x87, 0x92, 0xd5,
-	0x46, 0x6a, 0xf5, 0x65, 0xa8, 0x77, 0x26, 0xa1, 0x47, 0x24, 0x6d, 0xb3, 0x69, 0xb4, 0x2c, 0x37,
-	0x1d, 0xa3, 0x4d, 0x40, 0x22, 0x18, 0x52, 0x29, 0x8b, 0x4a, 0x29, 0x38, 0x64, 0x2d, 0x17, 0x8f,
-	0x87, 0x83, 0x43, 0x6f, 0xd7, 0xae, 0x34, 0x8d, 0xd6, 0xac, 0x9b, 0x8e, 0x9d, 0xe7, 0x66, 0x01,
-	0x93, 0x76, 0x27, 0xb2, 0x98, 0xcc, 0x73, 0x61, 0x32, 0xcf, 0x85, 0xc9, 0x94, 0x31, 0xa1, 0x1b,
-	0x30, 0x2d, 0x66, 0xf0, 0xe3, 0xb7, 0xc4, 0x5c, 0x2d, 0x9d, 0x02, 0xe2, 0x65, 0x59, 0x10, 0xbd,
-	0x0e, 0xb3, 0xbd, 0xc9, 0x83, 0xe8, 0x30, 0x1c, 0x8c, 0x89, 0x0e, 0x7e, 0x14, 0x57, 0x92, 0x99,
-	0x12, 0x8b, 0xce, 0xcd, 0x0a, 0x3b, 0x3f, 0x1b, 0x30, 0x97, 0x5d, 0xbd, 0x10, 0xdd, 0xeb, 0xd0,
-	0xe8, 0xc5, 0x5e, 0x18, 0x1f, 0x0c, 0x46, 0x38, 0xf1, 0x80, 0x20, 0x90, 0x38, 0xbf, 0xed, 0xf7,
-	0x29, 0x8f, 0xd9, 0xcd, 0x87, 0x64, 0x5e, 0x07, 0x0f, 0x71, 0x8c, 0xfb, 0x37, 0x63, 0x6a, 0xad,
-	0xe5, 0x0a, 0x02, 0xfa, 0x2f, 0xd4, 0xa8, 0x5e, 0x6e, 0xe9, 0xbc, 0x64, 0x29, 0x05, 0x9a, 0xb0,
-	0x51, 0x13, 0xa6, 0x0f, 0xc2, 0x89, 0x7f, 0xe8, 0xb1, 0x85, 0x6a, 0x74, 0xc3, 0x65, 0x92, 0x83,
-	0xa1, 0x91, 0x4e, 0x2b, 0xa0, 0xdf, 0x80, 0xfa, 0xde, 0x13, 0x9f, 0x24, 0xc1, 0xc8, 0x36, 0x9b,
-	0x56, 0xab, 0x72, 0xcb, 0xb4, 0x0d, 0x37, 0xa5, 0xa1, 0x16, 0xd4, 0xe8, 0x37, 0x3f, 0x25, 0x0b,
-	0x12, 0x0e, 0xca, 0x70, 0x13, 0xbe, 0xf3, 0x2e, 0x2c, 0xe4, 0xbd, 0xa9, 0x0c, 0x18, 0x04, 0x95,
-	0x9d, 0xa0, 0x8f, 0x79, 0x36, 0x20, 0xdf, 0xc8, 0x81, 0x99, 0x0e, 0x8e, 0xe2, 0x81, 0xef, 0xb1,
-	0x3d, 0x22, 0xba, 0x1a, 0x6e, 0x86, 0xe6, 0x5c, 0x03, 0x10, 0x5a, 0xd1, 0x0a, 0xd4, 0x92, 0x84,
-	0xc9, 0x6c, 0x49, 0x46, 0xce, 0x5b, 0xb0, 0xa8, 0x38, 0x78, 0x4a, 0x20, 0x4b, 0x50, 0xa5, 0x02,
-	0x09, 0x12, 0x36, 0x70, 0x4e, 0xa0, 0xce, 0xf3, 0xb3, 0x0e, 0xfe, 0xb6, 0x17, 0x1d, 0xa5, 0xc9,
-	0xcc, 0x8b, 0x8e, 0xc8, 0x4a, 0x37, 0xfb, 0xa3, 0x01, 0x0b, 0xed, 0xba, 0xcb, 0x06, 0xe8, 0x35,
-	0x80, 0xfd, 0x70, 0xf0, 0x78, 0x30, 0xc4, 0x8f, 0xd2, 0xdc, 0xb0, 0x28, 0x6e, 0x80, 0x94, 0xe7,
-	0x4a, 0x62, 0x4e, 0x17, 0x66, 0x33, 0x4c, 0x7a, 0xbe, 0x92, 0x6c, 0x98, 0xe0, 0x48, 0xc7, 0x24,
-	0x84, 0x52, 0x41, 0x0a, 0xa8, 0xea, 0x0a, 0x82, 0xf3, 0x5b, 0x0d, 0xa6, 0xb6, 0x82, 0xd1, 0xc8,
-	0xf3, 0xfb, 0xe8, 0x3a, 0x54, 0xe2, 0xe3, 0x31, 0x5b, 0x61, 0x8e, 0xdf, 0x5a, 0x09, 0x73, 0xf3,
-	0xe0, 0x78, 0x8c, 0x5d, 0xca, 0x77, 0x5e, 0xd4, 0xa0, 0x42, 0x86, 0x68, 0x19, 0x2e, 0x6d, 0x85,
-	0xd8, 0x8b, 0x31, 0xf1, 0x6b, 0x22, 0xb8, 0x60, 0x10, 0x32, 0x8b, 0x51, 0x99, 0x6c, 0xa2, 0x35,
-	0x58, 0x66, 0xd2, 0x1c, 0x1a, 0x67, 0x59, 0x68, 0x15, 0x16, 0x3b, 0x61, 0x30, 0xce, 0x33, 0x2a,
-	0xa8, 0x09, 0xeb, 0x6c, 0x4e, 0x2e, 0xd3, 0x70, 0x89, 0x2a, 0xda, 0x80, 0xcb, 0x64, 0xaa, 0x86,
-	0x5f, 0x43, 0xd7, 0xa0, 0xd9, 0xc3, 0xb1, 0x3a, 0xd3, 0x73, 0xa9, 0x29, 0xa2, 0xe7, 0xfe, 0xb8,
-	0xaf, 0xd7, 0x53, 0x47, 0x57, 0x60, 0x95, 0x21, 0x11, 0x27, 0x9d, 0x33, 0x1b, 0x84, 0xc9, 0x2c,
-	0x2e, 0x32, 0x41, 0xd8, 0x90, 0x8b, 0x39, 0x2e, 0x31, 0xcd, 0x6d, 0xd0, 0xf0, 0x67, 0x84, 0x9f,
-	0xc9, 0xae, 0x73, 0xf2, 0x2c, 0x5a, 0x84, 0x79, 0x32, 0x4d, 0x26, 0xce, 0x11, 0x59, 0x66, 0x89,
-	0x4c, 0x9e, 0x27, 0x1e, 0xee, 0xe1, 0x38, 0xdd, 0x77, 0xce, 0x58, 0x40, 0x08, 0xe6, 0x88, 0x7f,
-	0xbc, 0xd8, 0xe3, 0xb4, 0x4b, 0x68, 0x1d, 0xec, 0x1e, 0x8e, 0x69, 0x80, 0x16, 0x66, 0x20, 0xa1,
-	0x41, 0xde, 0xde, 0x45, 0x74, 0x15, 0xd6, 0x12, 0x07, 0x49, 0x07, 0x9c, 0xb3, 0x97, 0xa9, 0x8b,
-	0xc2, 0x60, 0xac, 0x62, 0xae, 0x90, 0x25, 0x5d, 0x3c, 0x0a, 0x1e, 0xe3, 0x7d, 0x2c, 0x40, 0xaf,
-	0x8a, 0x88, 0xe1, 0x25, 0x04, 0x67, 0xd9, 0xd9, 0x60, 0x92, 0x59, 0x6b, 0x84, 0xc5, 0xf0, 0xe5,
-	0x59, 0x97, 0x09, 0x8b, 0xed, 0x53, 0x7e, 0xc1, 0x2b, 0x82, 0x95, 0x9f, 0xb5, 0x8e, 0x56, 0x00,
-	0xf5, 0x70, 0x9c, 0x9f, 0x72, 0x15, 0x2d, 0xc1, 0x02, 0x35, 0x89, 0xec, 0x39, 0xa7, 0x6e, 0xfc,
-	0xaf, 0x5e, 0xef, 0x2f, 0x9c, 0x9e, 0x9e, 0x9e, 0x9a, 0xce, 0x89, 0xe2, 0x78, 0xa4, 0x75, 0x8e,
-	0x21, 0xd5, 0x39, 0x08, 0x2a, 0xae, 0xe7, 0xf7, 0x93, 0x62, 0x94, 0x7e, 0xb7, 0xdf, 0x86, 0xa9,
-	0xc3, 0x64, 0xca, 0x6c, 0xe6, 0x24, 0xda, 0xb8, 0x69, 0xb4, 0xa6, 0xdb, 0xab, 0x09, 0x31, 0xaf,
-	0xc0, 0xe5, 0xd3, 0x9c, 0x67, 0x8a, 0x63, 0x58, 0x48, 0xed, 0x4b, 0x50, 0xbd, 0x13, 0x84, 0x87,
-	0x2c, 0x33, 0xd4, 0x5d, 0x36, 0x28, 0x51, 0xfe, 0x50, 0x56, 0x5e, 0x58, 0x5e, 0x28, 0xff, 0xd1,
-	0xd0, 0x9c, 0x76, 0x65, 0xbe, 0xdc, 0x82, 0xf9, 0x62, 0x89, 0x66, 0x94, 0xd7, 0x5b, 0xf9, 0x19,
-	0xed, 0x8e, 0x16, 0xf4, 0x23, 0xba, 0xd6, 0x15, 0xd9, 0x63, 0x39, 0x54, 0x02, 0xf8, 0x48, 0x99,
-	0x8a, 0x54, 0xa8, 0xdb, 0xb7, 0xb4, 0x0a, 0x8f, 0x64, 0xf0, 0x8a, 0xe5, 0x84, 0xba, 0xdf, 0x8d,
-	0xf2, 0x0c, 0x57, 0x9a, 0xda, 0x95, 0x6e, 0x33, 0x2f, 0xe6, 0x36, 0x52, 0x7c, 0x24, 0xd9, 0x31,
-	0xb9, 0x99, 0xf8, 0xb0, 0x7d, 0x4f, 0x6b, 0xdf, 0x80, 0xda, 0xe7, 0xc8, 0x0e, 0x55, 0xc3, 0x17,
-	0x86, 0x7e, 0x6d, 0x94, 0x25, 0xea, 0x52, 0x33, 0xb9, 0xef, 0x4d, 0xc9, 0xf7, 0x5d, 0x2d, 0xb6,
-	0xf7, 0x28, 0xb6, 0xa6, 0xf0, 0xfd, 0x59, 0xc8, 0xbe, 0x35, 0xce, 0xbe, 0x22, 0x2e, 0x8c, 0x6f,
-	0x4f, 0x8b, 0xef, 0x7d, 0x8a, 0xef, 0x7a, 0x52, 0x22, 0x9d, 0xa1, 0x57, 0xa0, 0x7c, 0x6e, 0x96,
-	0x5f, 0x51, 0x17, 0x45, 0x48, 0xf6, 0x7d, 0x17, 0x3f, 0xa1, 0xe4, 0xa4, 0xb9, 0x4a, 0x86, 0x99,
-	0x6a, 0xbd, 0x92, 0xeb, 0x20, 0xe4, 0xea, 0xbb, 0x9a, 0xed, 0x08, 0xe4, 0x48, 0xaa, 0x9d, 0x37,
-	0x92, 0x86, 0x72, 0x24, 0x95, 0xd9, 0x27, 0x3c, 0xf1, 0x8b, 0xa1, 0xbd, 0x8a, 0x4b, 0x9d, 0xd0,
-	0x52, 0x9f, 0x96, 0x46, 0xf1, 0x48, 0xac, 0x43, 0x83, 0x54, 0xdf, 0x51, 0xec, 0x8d, 0xc6, 0x49,
-	0x45, 0x2e, 0x08, 0xed, 0x3b, 0x5a, 0x63, 0x46, 0xd4, 0x98, 0xab, 0xf2, 0xb1, 0x28, 0x40, 0x14,
-	0x76, 0xfc, 0x6a, 0x68, 0xab, 0x86, 0x97, 0x64, 0x87, 0x03, 0x33, 0x99, 0x27, 0x01, 0xf6, 0xa4,
-	0x91, 0xa1, 0x95, 0x58, 0xe3, 0xcb, 0xd6, 0x68, 0x80, 0x0a, 0x6b, 0x7e, 0x30, 0xca, 0xcb, 0x9c,
-	0x0b, 0xc7, 0x67, 0x5a, 0x79, 0x5b, 0x52, 0xe5, 0x5d, 0x12, 0x49, 0x41, 0x31, 0x27, 0xa9, 0x91,
-	0x14, 0x73, 0xd2, 0xcb, 0x41, 0x5c, 0x92, 0x93, 0xc6, 0xf9, 0x9c, 0x74, 0x16, 0xb2, 0x2f, 0x0d,
-	0x45, 0xc9, 0xf7, 0xcf, 0x5a, 0x8d, 0x92, 0x4b, 0xfd, 0x83, 0x62, 0x45, 0x21, 0xa9, 0x15, 0xa8,
-	0x70, 0xa1, 0xe0, 0x54, 0xde, 0x8b, 0x6f, 0x6a, 0x15, 0x85, 0x54, 0xd1, 0xb2, 0xf0, 0x83, 0x52,
-	0xcd, 0x89, 0xa2, 0x84, 0x3d, 0xaf, 0xed, 0x25, 0x56, 0x46, 0xb2, 0x95, 0x05, 0x05, 0x42, 0xfd,
-	0xf7, 0x86, 0xb2, 0x56, 0x26, 0xe1, 0x40, 0xe4, 0x7d, 0x81, 0x22, 0x1d, 0x67, 0x42, 0xc5, 0x2c,
-	0x6b, 0xc0, 0xac, 0x5c, 0x03, 0x56, 0x52, 0x44, 0xc4, 0x72, 0x11, 0xa1, 0x00, 0x24, 0x10, 0x07,
-	0xf9, 0x1a, 0x1e, 0x6d, 0xb0, 0xb7, 0x4f, 0x8a, 0x73, 0xba, 0x0d, 0xe2, 0x01, 0xd2, 0xa5, 0xf4,
-	0xf6, 0x1b, 0x5a, 0xad, 0x13, 0xaa, 0x75, 0x49, 0x5c, 0x4f, 0x62, 0x55, 0xa1, 0xf0, 0x2b, 0x43,
-	0xdf, 0x21, 0x94, 0xfa, 0x29, 0x8d, 0x4c, 0x53, 0x8e, 0xcc, 0xbb, 0x5a, 0x34, 0x8f, 0x29, 0x9a,
-	0x8d, 0x14, 0x8d, 0x52, 0xa3, 0xc0, 0x75, 0xac, 0x68, 0x4d, 0xce, 0xf3, 0xd2, 0x58, 0x12, 0x35,
-	0x4f, 0x8a, 0x51, 0xa3, 0x2c, 0x78, 0xff, 0x34, 0x4a, 0xfa, 0x1f, 0xed, 0xa3, 0x98, 0x2e, 0x66,
-	0x14, 0x39, 0xde, 0x52, 0xe7, 0x78, 0xfe, 0x52, 0x52, 0x29, 0x79, 0x29, 0xa9, 0x16, 0x5f, 0x4a,
-	0xda, 0xdb, 0x5a, 0x8b, 0x8f, 0xa9, 0xc5, 0xff, 0xc9, 0xdc, 0x62, 0x45, 0x93, 0x84, 0xe5, 0x3f,
-	0x19, 0xda, 0xd6, 0xee, 0xdf, 0xb3, 0xbb, 0xe4, 0xde, 0xfa, 0x30, 0x73, 0x6f, 0xa9, 0x81, 0x65,
-	0x42, 0xa6, 0xd0, 0x7a, 0xa6, 0x21, 0x63, 0x88, 0x90, 0xb9, 0xd9, 0xef, 0x87, 0x3c, 0x64, 0xc8,
-	0x77, 0x49, 0xc8, 0x3c, 0x93, 0x43, 0xa6, 0xb0, 0xb8, 0x50, 0xfd, 0x9d, 0xa1, 0xe9, 0x6f, 0x89,
-	0x8b, 0xb6, 0x0f, 0x0e, 0xf6, 0xa9, 0xce, 0xe4, 0x08, 0xf1, 0x71, 0xf2, 0x28, 0x2e, 0xc1, 0xe1,
-	0xc3, 0xb4, 0x8d, 0xb4, 0xa4, 0x36, 0x52, 0xdf, 0x14, 0x7d, 0x54, 0x6c, 0x8a, 0x72, 0x30, 0x32,
-	0xd7, 0x91, 0xba, 0xdd, 0xfe, 0x7b, 0x48, 0x4b, 0x50, 0x9d, 0xa8, 0x5b, 0x35, 0x25, 0xaa, 0x17,
-	0x86, 0xa6, 0xd3, 0xbf, 0xf8, 0xcf, 0x05, 0x53, 0xfa, 0xb9, 0x50, 0x82, 0xee, 0x63, 0x19, 0x9d,
-	0x52, 0xb5, 0xdc, 0x48, 0xaa, 0xdf, 0x1a, 0xf2, 0xe0, 0x4a, 0xd4, 0x7d, 0x22, 0xab, 0x53, 0x2e,
-	0x26, 0xd4, 0xf9, 0x9a, 0xf7, 0x8b, 0x82, 0xba, 0xdb, 0x5a, 0x75, 0xa7, 0x46, 0x51, 0x9f, 0xd6,
-	0xbc, 0x3b, 0xa4, 0x11, 0x88, 0xc6, 0x81, 0x1f, 0x61, 0xa2, 0x62, 0xef, 0x1e, 0x55, 0x51, 0x77,
-	0xcd, 0xbd, 0x7b, 0x24, 0xcb, 0xdf, 0x0e, 0xc3, 0x20, 0xa4, 0x4d, 0x7c, 0xc3, 0x65, 0x03, 0xf1,
-	0xcf, 0xcd, 0xa2, 0xe7, 0x8a, 0x0d, 0x9c, 0x6f, 0x0c, 0xd5, 0xeb, 0xca, 0x4b, 0x3c, 0x01, 0xfa,
-	0x0b, 0xf6, 0x53, 0x66, 0xaf, 0x9d, 0xde, 0x2e, 0x5a, 0xe7, 0xf6, 0x8b, 0x2f, 0x3d, 0x05, 0xbf,
-	0xea, 0xf3, 0xc1, 0x67, 0x4c, 0xcf, 0x8a, 0x94, 0x91, 0xa4, 0x85, 0x52, 0x2d, 0x7f, 0x05, 0x00,
-	0x00, 0xff, 0xff, 0x0e, 0x26, 0x9f, 0x1d, 0xcd, 0x1c, 0x00, 0x00,
+	// 2848 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5a, 0xcd, 0x8f, 0x1c, 0x47,
+	0x15, 0x57, 0xf5, 0xcc, 0xee, 0xce, 0xd4, 0x7e, 0xba, 0x76, 0xbd, 0x6e, 0x3b, 0xeb, 0xcd, 0xa4,
+	0xb3, 0x38, 0x13, 0x27, 0x38, 0x30, 0x11, 0x39, 0x85, 0x04, 0x7b, 0xc7, 0x1f, 0x8b, 0xb3, 0xeb,
+	0x4d, 0xcf, 0x86, 0x0b, 0x52, 0xa4, 0xf6, 0x4c, 0xd9, 0x6e, 0x3c, 0xd3, 0x3d, 0x74, 0xf7, 0xd8,
+	0x5e, 0x07, 0x83, 0x21, 0x01, 0xc2, 0xf7, 0x57, 0x10, 0x42, 0xdc, 0xe0, 0x80, 0x38, 0x21, 0x24,
+	0x6e, 0x48, 0x20, 0x90, 0xe0, 0x00, 0x12, 0x07, 0x24, 0xfe, 0x03, 0xc4, 0x99, 0x03, 0x12, 0x37,
+	0x84, 0xea, 0xab, 0xab, 0xba, 0xbb, 0xaa, 0x76, 0x17, 0x96, 0xdc, 0xba, 0xde, 0xab, 0xaa, 0xf7,
+	0x7b, 0xaf, 0x5e, 0xd5, 0x7b, 0xaf, 0xaa, 0xe1, 0x72, 0x18, 0x65, 0x38, 0x89, 0x82, 0xe1, 0x0b,
+	0x23, 0x9c, 0x05, 0x17, 0xc6, 0x49, 0x9c, 0xc5, 0xa8, 0x4e, 0xbe, 0xbd, 0xf7, 0xea, 0xb0, 0xde,
+	0x0d, 0xb2, 0x00, 0x21, 0x58, 0xdf, 0xc3, 0xc9, 0xc8, 0x05, 0x2d, 0xa7, 0x5d, 0xf7, 0xe9, 0x37,
+	0x5a, 0x81, 0x53, 0x5b, 0xd1, 0x00, 0x3f, 0x70, 0x1d, 0x4a, 0x64, 0x0d, 0xb4, 0x06, 0x9b, 0x9b,
+	0xc3, 0x49, 0x9a, 0xe1, 0x64, 0xab, 0xeb, 0xd6, 0x28, 0x47, 0x12, 0xd0, 0x06, 0x9c, 0xda, 0x89,
+	0x07, 0x38, 0x75, 0xeb, 0xad, 0x5a, 0x7b, 0xb6, 0xb3, 0x70, 0x81, 0x8a, 0x24, 0xa4, 0xad, 0xe8,
+	0x56, 0xec, 0x33, 0x26, 0xfa, 0x10, 0x6c, 0x12, 0xa9, 0x37, 0x83, 0x14, 0xa7, 0xee, 0x14, 0xed,
+	0x89, 0x58, 0x4f, 0x41, 0xa6, 0xbd, 0x65, 0x27, 0x32, 0xef, 0x1b, 0x29, 0x4e, 0x52, 0x77, 0x5a,
+	0x9d, 0x97, 0x90, 0xd8, 0xbc, 0x94, 0x49, 0xb0, 0x6d, 0x07, 0x0f, 0xa8, 0xb4, 0xae, 0x3b, 0xc3,
+	0xb0, 0xe5, 0x04, 0xd4, 0x86, 0x8b, 0xdb, 0xc1, 0x83, 0xde, 0x9d, 0x20, 0x19, 0x5c, 0x4d, 0xe2,
+	0xc9, 0x78, 0xab, 0xeb, 0x36, 0x68, 0x9f, 0x32, 0x19, 0xad, 0x43, 0x28, 0x48, 0x5b, 0x5d, 0xb7,
+	0x49, 0x3b, 0x29, 0x14, 0xf4, 0x3c, 0xc3, 0xcf, 0x34, 0x85, 0x5a, 0x4d, 0x65, 0x07, 0xd2, 0x7b,
+	0x1b, 0x8b, 0xde, 0xb3, 0xfa, 0xde, 0x79, 0x07, 0xe4, 0xc2, 0x99, 0x4f, 0xe0, 0x24, 0x0d, 0xe3,
+	0xc8, 0x9d, 0x6b, 0x81, 0x76, 0xdd, 0x17, 0x4d, 0x86, 0x9f, 0xac, 0x65, 0x14, 0x44, 0x7d, 0xbc,
+	0x1d, 0x0f, 0xb0, 0x3b, 0xdf, 0x02, 0xed, 0x86, 0x5f, 0x26, 0xa3, 0x57, 0xe0, 0xe2, 0xc5, 0xf1,
+	0x78, 0x18, 0xe2, 0xc1, 0x66, 0x3c, 0x1a, 0x05, 0xd1, 0x20, 0x75, 0x17, 0xa8, 0xdc, 0x15, 0x26,
+	0xb7, 0xc8, 0xf4, 0xcb, 0x9d, 0xbd, 0x5d, 0xb8, 0x50, 0x24, 0xa1, 0x25, 0x58, 0xbb, 0x8e, 0xf7,
+	0xa9, 0x7b, 0x34, 0x7d, 0xf2, 0x49, 0xbc, 0xe3, 0x72, 0x92, 0xc4, 0x89, 0xeb, 0xb4, 0x40, 0xbb,
+	0xe9, 0xb3, 0x06, 0x5a, 0x85, 0xd3, 0x3e, 0x4e, 0x27, 0xc3, 0xcc, 0xad, 0x51, 0xf0, 0xbc, 0xe5,
+	0xfd, 0x10, 0x30, 0x93, 0x75, 0xf1, 0x30, 0x0b, 0xc8, 0x3a, 0x5d, 0xa2, 0x8b, 0x4c, 0xbc, 0x8b,
+	0xb9, 0x9c, 0x24, 0xa0, 0x75, 0xe6, 0x93, 0xd4, 0xed, 0x66, 0x3b, 0x50, 0x3a, 0x86, 0x4f, 0xe9,
+	0xe8, 0x3c, 0x5c, 0xea, 0x26, 0xf1, 0x78, 0x8c, 0x07, 0xd2, 0x89, 0x6a, 0xad, 0x5a, 0xbb, 0xe9,
+	0x57, 0xe8, 0xc8, 0x83, 0x73, 0x9c, 0xc6, 0xdc, 0xa7, 0x4e, 0xfb, 0x15, 0x68, 0xde, 0x1f, 0x00,
+	0x6c, 0x88, 0x95, 0x40, 0x0b, 0xd0, 0xd9, 0xea, 0x72, 0x4c, 0xce, 0x56, 0x97, 0x6c, 0x8c, 0x6b,
+	0x71, 0x9a, 0x51, 0x30, 0x4d, 0x9f, 0x7e, 0x93, 0x25, 0xda, 0xdb, 0xdc, 0xa5, 0xe4, 0x1a, 0x55,
+	0x5e, 0x34, 0x89, 0xe3, 0x50, 0x1f, 0xd9, 0x8c, 0x27, 0x51, 0xe6, 0xd6, 0x5b, 0xa0, 0x3d, 0xef,
+	0x2b, 0x14, 0xb4, 0x01, 0xe7, 0x77, 0x71, 0x34, 0x08, 0xa3, 0xdb, 0x94, 0x48, 0x9c, 0x9f, 0x74,
+	0x29, 0x12, 0xd1, 0x19, 0xd8, 0x78, 0x2d, 0x48, 0xb3, 0x1e, 0xc6, 0x91, 0x3b, 0xdd, 0x02, 0xed,
+	0x9a, 0x9f, 0xb7, 0x09, 0xaf, 0x9b, 0x04, 0x61, 0x14, 0x46, 0xb7, 0xdd, 0x19, 0xba, 0xfa, 0x79,
+	0xdb, 0xfb, 0xab, 0x03, 0xe7, 0xd4, 0x0d, 0x44, 0xc0, 0xef, 0x04, 0x23, 0xcc, 0x97, 0x8d, 0x7e,
+	0xa3, 0x97, 0xe0, 0x6a, 0x17, 0xdf, 0x0a, 0x26, 0xc3, 0xcc, 0xc7, 0x19, 0x8e, 0xb2, 0x30, 0x8e,
+	0x76, 0xe3, 0x61, 0xd8, 0xdf, 0xe7, 0x2a, 0x1a, 0xb8, 0xe8, 0x2a, 0x3c, 0x51, 0x24, 0x85, 0xdc,
+	0xec, 0xb3, 0x9d, 0xd3, 0x6c, 0x89, 0x4a, 0x23, 0xa8, 0x63, 0x57, 0xc7, 0x90, 0x89, 0x36, 0xe3,
+	0x28, 0x0b, 0xa3, 0x49, 0x3c, 0x49, 0x5f, 0x9f, 0xe0, 0x24, 0xcc, 0x8f, 0x0b, 0x3e, 0x51, 0x91,
+	0xcd, 0x27, 0xaa, 0x8c, 0x41, 0xcf, 0xc2, 0xa9, 0xd7, 0x27, 0x71, 0x16, 0x50, 0x23, 0xce, 0x76,
+	0x96, 0x8b, 0x27, 0x08, 0x65, 0xf9, 0xac, 0x07, 0x7a, 0x19, 0x9e, 0xe6, 0x6a, 0xc9, 0x7d, 0xde,
+	0x9d, 0x24, 0x01, 0x01, 0xc6, 0x4d, 0x6c, 0xee, 0xe0, 0xdd, 0x85, 0xf3, 0x85, 0x59, 0x51, 0x07,
+	0xae, 0x6c, 0x07, 0x0f, 0xaa, 0xe6, 0x00, 0x74, 0x35, 0xb5, 0x3c, 0x74, 0x0e, 0x2e, 0x14, 0x8e,
+	0x99, 0x94, 0x6e, 0x9c, 0x79, 0xbf, 0x44, 0xf5, 0xbe, 0xeb, 0xc0, 0xe5, 0x92, 0x25, 0x7b, 0x63,
+	0xdc, 0x57, 0xd6, 0x12, 0xe4, 0x6b, 0x49, 0x9c, 0x41, 0x68, 0xe1, 0x30, 0x47, 0x11, 0x6d, 0x74,
+	0x01, 0x22, 0x8d, 0xae, 0x35, 0xda, 0x4b, 0xc3, 0x21, 0x73, 0xf9, 0x78, 0x3c, 0x0c, 0xfb, 0xc1,
+	0x0e, 0x77, 0xdc, 0xbc, 0x4d, 0xb0, 0x33, 0xd7, 0xdc, 0xc5, 0x09, 0x1d, 0xc5, 0xfd, 0xb6, 0x44,
+	0x25, 0xbb, 0x8d, 0x52, 0xae, 0xe3, 0xfd, 0xbd, 0xe0, 0x36, 0x3b, 0xac, 0x9b, 0x7e, 0x81, 0x86,
+	0x5e, 0x84, 0xb3, 0x7b, 0x38, 0x0a, 0xa2, 0x8c, 0x9d, 0x87, 0x33, 0x74, 0xe1, 0x4f, 0xb0, 0xb5,
+	0x53, 0x18, 0xbe, 0xda, 0xcb, 0xfb, 0x47, 0xad, 0x62, 0x14, 0xa3, 0x83, 0x17, 0x8d, 0xe2, 0x1c,
+	0xca, 0x28, 0xce, 0xa1, 0x8c, 0xe2, 0x14, 0x8c, 0xf2, 0x12, 0x9c, 0x55, 0x57, 0x73, 0x4a, 0x3d,
+	0x60, 0x25, 0x83, 0x3a, 0xaf, 0xda, 0x11, 0xbd, 0x0c, 0xe7, 0x7b, 0x93, 0x9b, 0x69, 0x3f, 0x09,
+	0xc7, 0x44, 0x86, 0x08, 0x69, 0xab, 0x7c, 0xa4, 0xc2, 0xa2, 0x63, 0x8b, 0x9d, 0xd1, 0x0e, 0x5c,
+	0xd9, 0xc6, 0x41, 0x3a, 0x49, 0xf0, 0x08, 0x47, 0x72, 0x93, 0x72, 0x3b, 0x9e, 0x61, 0x93, 0xe8,
+	0x7a, 0xf8, 0xda, 0x71, 0x9a, 0xa5, 0x6d, 0x1c, 0x6a, 0x69, 0x9b, 0x07, 0x2f, 0x2d, 0x3c, 0xcc,
+	0xd2, 0x92, 0x88, 0x71, 0x25, 0x89, 0x1f, 0xe2, 0xc8, 0x9d, 0xa5, 0xc7, 0x19, 0x6f, 0x79, 0x57,
+	0xf4, 0x8a, 0x1e, 0x75, 0xc9, 0xbd, 0x57, 0x61, 0x59, 0x1c, 0x6b, 0xf2, 0x09, 0x78, 0x8b, 0x9c,
+	0xe9, 0x2c, 0x4d, 0x20, 0xfb, 0xb2, 0x46, 0xc2, 0x2e, 0x6f, 0x7a, 0xbf, 0x05, 0xdc, 0x44, 0xf9,
+	0x7a, 0x56, 0x82, 0xc4, 0x1a, 0x6c, 0xf6, 0xb2, 0x20, 0xc9, 0xf6, 0xc2, 0x11, 0xe6, 0x00, 0x24,
+	0x81, 0x4c, 0x7d, 0x39, 0x1a, 0x50, 0x1e, 0xf3, 0x34, 0xd1, 0x24, 0xe3, 0xba, 0x78, 0x88, 0x33,
+	0x3c, 0xb8, 0x98, 0x51, 0xff, 0xaa, 0xf9, 0x92, 0x80, 0x9e, 0x81, 0xd3, 0x79, 0x94, 0x20, 0x96,
+	0x5c, 0x54, 0x7c, 0x8b, 0xba, 0x06, 0x67, 0xa3, 0x16, 0x9c, 0xdd, 0x4b, 0x26, 0x51, 0x3f, 0x60,
+	0x13, 0xb1, 0xf3, 0x4c, 0x25, 0x79, 0x8f, 0x60, 0x33, 0x1f, 0x56, 0x41, 0xbf, 0x0e, 0x1b, 0x37,
+	0xee, 0x47, 0x38, 0xc9, 0x75, 0xbf, 0xe4, 0xb8, 0xc0, 0xcf, 0x69, 0xa8, 0x0d, 0xa7, 0xe9, 0xb7,
+	0x38, 0xee, 0x97, 0x14, 0x1c, 0x94, 0xe1, 0x73, 0xbe, 0x62, 0xdc, 0x3a, 0x3d, 0xa5, 0x78, 0xcb,
+	0x7b, 0x13, 0x2e, 0x95, 0xfd, 0x5a, 0xbb, 0x8e, 0x08, 0xd6, 0x69, 0x5a, 0xc3, 0x83, 0x2d, 0xf9,
+	0xa6, 0x11, 0x1c, 0xa7, 0x59, 0x18, 0x05, 0x6c, 0xb7, 0xd4, 0x78, 0x04, 0x57, 0x68, 0xde, 0x06,
+	0x0f, 0xbb, 0x14, 0x06, 0x41, 0xc1, 0x53, 0x40, 0xa6, 0x23, 0x6f, 0x79, 0xaf, 0xc2, 0x65, 0x4d,
+	0x64, 0xd1, 0x02, 0x59, 0x21, 0xa1, 0x05, 0x27, 0x22, 0x26, 0xb2, 0x86, 0xf7, 0x08, 0x36, 0x44,
+	0xc6, 0x69, 0x82, 0x7f, 0x2d, 0x48, 0xef, 0xe4, 0xb9, 0x42, 0x90, 0xde, 0x21, 0x33, 0x5d, 0x1c,
+	0x8c, 0x42, 0x76, 0xc8, 0x34, 0x7c, 0xd6, 0x40, 0x2f, 0x42, 0xb8, 0x9b, 0x84, 0xf7, 0xc2, 0x21,
+	0xbe, 0x9d, 0x07, 0xbf, 0x65, 0x99, 0xd3, 0xe6, 0x3c, 0x5f, 0xe9, 0xe6, 0x6d, 0xc1, 0xf9, 0x02,
+	0x93, 0xba, 0x3d, 0x8f, 0x4b, 0x1c, 0x47, 0xde, 0x26, 0xae, 0x95, 0x77, 0xa4, 0x80, 0xa6, 0x7c,
+	0x49, 0xf0, 0xfe, 0x06, 0xe1, 0x8c, 0x48, 0xed, 0xce, 0xc1, 0x7a, 0xb6, 0x3f, 0x66, 0x33, 0x2c,
+	0x88, 0x3c, 0x9c, 0x33, 0x2f, 0xec, 0xed, 0x8f, 0xb1, 0x4f, 0xf9, 0xe4, 0xa4, 0xd8, 0x1a, 0xe0,
+	0xd1, 0x38, 0xce, 0x70, 0xd4, 0xdf, 0x27, 0xd9, 0x20, 0xcb, 0xfc, 0x4a, 0x54, 0xef, 0xdf, 0x4d,
+	0x58, 0x27, 0xc3, 0xd0, 0x49, 0x78, 0x62, 0x33, 0xc1, 0x41, 0x86, 0x89, 0xfd, 0xf9, 0x84, 0x4b,
+	0x80, 0x90, 0x99, 0x8f, 0xab, 0x64, 0x07, 0x9d, 0x86, 0x27, 0x59, 0x6f, 0xa1, 0x82, 0x60, 0xd5,
+	0xd0, 0x29, 0xb8, 0x4c, 0x12, 0xb6, 0x32, 0xa3, 0x8e, 0x5a, 0x70, 0x8d, 0x8d, 0x29, 0xc5, 0x06,
+	0xd1, 0x63, 0x0a, 0xad, 0xc3, 0x33, 0x64, 0xa8, 0x81, 0x3f, 0x8d, 0x36, 0x60, 0xab, 0x87, 0x33,
+	0x7d, 0xca, 0x23, 0x7a, 0xcd, 0x10, 0x39, 0x6f, 0x8c, 0x07, 0x66, 0x39, 0x0d, 0xf4, 0x04, 0x3c,
+	0xc5, 0x90, 0xc8, 0x93, 0x42, 0x30, 0x9b, 0x84, 0xc9, 0x34, 0xae, 0x32, 0xa1, 0xd4, 0xa1, 0xe4,
+	0x9b, 0xa2, 0xc7, 0xac, 0xd0, 0xc1, 0xc0, 0x9f, 0x93, 0x76, 0x26, 0xde, 0x21, 0xc8, 0xf3, 0x68,
+	0x19, 0x2e, 0x92, 0x61, 0x2a, 0x71, 0x81, 0xf4, 0x65, 0x9a, 0xa8, 0xe4, 0x45, 0x62, 0xe1, 0x1e,
+	0xce, 0x72, 0xff, 0x10, 0x8c, 0x25, 0x84, 0xe0, 0x02, 0xb1, 0x4f, 0x90, 0x05, 0x82, 0x76, 0x02,
+	0xad, 0x41, 0xb7, 0x87, 0x33, 0xea, 0xc8, 0x95, 0x11, 0x48, 0x4a, 0x50, 0x97, 0x77, 0x19, 0x9d,
+	0x85, 0xa7, 0xb9, 0x81, 0x94, 0x83, 0x40, 0xb0, 0x4f, 0x52, 0x13, 0x25, 0xf1, 0x58, 0xc7, 0x5c,
+	0x25, 0x53, 0xfa, 0x78, 0x14, 0xdf, 0xc3, 0xbb, 0x58, 0x82, 0x3e, 0x25, 0x3d, 0x46, 0x14, 0x4f,
+	0x82, 0xe5, 0x16, 0x9d, 0x49, 0x65, 0x9d, 0x26, 0x2c, 0x86, 0xaf, 0xcc, 0x3a, 0x43, 0x58, 0x6c,
+	0x9d, 0xca, 0x13, 0x3e, 0x21, 0x59, 0xe5, 0x51, 0x6b, 0x68, 0x15, 0xa2, 0x1e, 0xce, 0xca, 0x43,
+	0xce, 0xa2, 0x15, 0x56, 0xa6, 0xf0, 0xec, 0x9f, 0x51, 0xd7, 0xc9, 0x72, 0x6f, 0x07, 0xc9, 0x5d,
+	0x25, 0xd7, 0x60, 0xe7, 0xbd, 0xe8, 0xf1, 0x24, 0x7a, 0x0a, 0x9e, 0x25, 0x39, 0x46, 0xd0, 0x37,
+	0x79, 0x44, 0x0b, 0x79, 0x70, 0x9d, 0x8a, 0xac, 0x86, 0x47, 0xd1, 0xe7, 0x29, 0x62, 0x51, 0xbe,
+	0x72, 0x79, 0xde, 0x2a, 0x98, 0x1e, 0x59, 0xc2, 0xb2, 0xbb, 0xa6, 0x82, 0xfb, 0x34, 0xe1, 0x5e,
+	0xc3, 0x41, 0x92, 0xdd, 0xc4, 0x41, 0x56, 0xd6, 0x77, 0x83, 0xb8, 0x63, 0x0f, 0xe7, 0x74, 0x51,
+	0x7c, 0x08, 0xfe, 0x07, 0x08, 0x7f, 0x33, 0x1e, 0xef, 0x1b, 0xb6, 0xca, 0x39, 0x62, 0xaf, 0xbd,
+	0x24, 0x88, 0xd2, 0xa0, 0xaf, 0x02, 0x7e, 0x86, 0x8c, 0xf3, 0xf1, 0xcd, 0x60, 0x48, 0xaa, 0xd8,
+	0xea, 0x46, 0x69, 0x93, 0x25, 0xf0, 0xf1, 0x38, 0x08, 0x13, 0xbe, 0x5b, 0x73, 0xc0, 0xcf, 0xa2,
+	0x33, 0x70, 0xd5, 0xc7, 0x29, 0x4e, 0xee, 0x61, 0x5e, 0xa1, 0xe7, 0xbc, 0xf3, 0xc4, 0xf1, 0x88,
+	0xad, 0x8a, 0x15, 0xb2, 0x60, 0x3f, 0x87, 0x5e, 0x80, 0xcf, 0x29, 0x66, 0x32, 0xd6, 0x00, 0x62,
+	0xc0, 0xf3, 0x64, 0x01, 0xaf, 0x24, 0x18, 0x3f, 0x34, 0x9d, 0x05, 0x1f, 0x3c, 0xdf, 0x68, 0x0c,
+	0x96, 0x1e, 0x3f, 0x7e, 0xfc, 0xd8, 0xf1, 0x1e, 0x69, 0x4e, 0xc0, 0xbc, 0xa2, 0x04, 0x4a, 0x45,
+	0x89, 0x60, 0xdd, 0x0f, 0xa2, 0x01, 0xbf, 0x69, 0xa1, 0xdf, 0x9d, 0x8f, 0xc1, 0x99, 0x3e, 0x1f,
+	0x32, 0x5f, 0x38, 0x94, 0x5d, 0x4c, 0xeb, 0x9d, 0x53, 0x9c, 0x58, 0x16, 0xe0, 0x8b, 0x61, 0xde,
+	0x5b, 0x9a, 0x93, 0xb6, 0x12, 0xfd, 0x57, 0xe0, 0xd4, 0x95, 0x38, 0xe9, 0xb3, 0x20, 0xd1, 0xf0,
+	0x59, 0xc3, 0x22, 0xfc, 0x96, 0x2a, 0xbc, 0x32, 0xbd, 0x14, 0xfe, 0x67, 0x60, 0x38, 0xd0, 0xb5,
+	0xa1, 0x73, 0x13, 0x2e, 0x56, 0xcb, 0x51, 0x60, 0xaf, 0x2d, 0xcb, 0x23, 0x48, 0xe0, 0xef, 0x65,
+	0x49, 0xd8, 0x67, 0x65, 0x79, 0xc3, 0xe7, 0xad, 0x4e, 0xd7, 0xa8, 0xcc, 0x6d, 0x2a, 0xe3, 0x09,
+	0xd5, 0x92, 0x25, 0xb4, 0x52, 0xa1, 0xb7, 0x81, 0x36, 0x0c, 0x99, 0xf2, 0x07, 0x61, 0x54, 0x20,
+	0x8d, 0x7a, 0xc9, 0x88, 0xe3, 0x8e, 0xaa, 0xab, 0x46, 0x88, 0x44, 0xf1, 0x77, 0x60, 0x8f, 0x79,
+	0xd6, 0xa4, 0x40, 0x6b, 0x65, 0xe7, 0x88, 0x56, 0x76, 0xe1, 0x0c, 0xdf, 0x26, 0x3c, 0xa7, 0x11,
+	0xcd, 0xce, 0x75, 0xa3, 0x7e, 0x21, 0xd5, 0xcf, 0x53, 0xed, 0xac, 0x87, 0x2f, 0x15, 0xfd, 0x01,
+	0xb0, 0x85, 0x6e, 0xab, 0x9a, 0x62, 0x45, 0x1c, 0xb9, 0x22, 0x9d, 0x2d, 0x23, 0xb6, 0x4f, 0x51,
+	0x6c, 0x2d, 0x69, 0xfb, 0x83, 0x90, 0xfd, 0x04, 0x1c, 0x9c, 0x34, 0x1c, 0x19, 0xdf, 0x0d, 0x23,
+	0xbe, 0xbb, 0x14, 0xdf, 0x39, 0x46, 0x3c, 0x48, 0xae, 0x44, 0xf9, 0x73, 0xc7, 0x9e, 0xb4, 0x1c,
+	0x15, 0x21, 0xad, 0x90, 0xf0, 0x7d, 0x4a, 0xe6, 0xb7, 0x5e, 0xbc, 0x59, 0x28, 0xbf, 0xea, 0xa5,
+	0x6b, 0x08, 0xb5, 0x82, 0x9e, 0x2a, 0x5d, 0x2b, 0x28, 0x9e, 0x34, 0x5d, 0xf0, 0x24, 0x4d, 0x55,
+	0x3a, 0xa3, 0xab, 0x4a, 0x2d, 0x1e, 0x37, 0x54, 0x3d, 0xce, 0x66, 0x07, 0x69, 0xb1, 0xdf, 0x03,
+	0x63, 0x12, 0x67, 0x35, 0x56, 0x5b, 0xbf, 0xab, 0x9a, 0xd5, 0xad, 0xb3, 0x06, 0x9b, 0xa4, 0xee,
+	0x4b, 0xb3, 0x60, 0x34, 0xe6, 0xb5, 0xa0, 0x24, 0x74, 0xae, 0x18, 0x95, 0x19, 0x51, 0x65, 0xce,
+	0xaa, 0xdb, 0xa7, 0x02, 0x51, 0xea, 0xf1, 0x27, 0x60, 0xcc, 0x37, 0x8f, 0x49, 0x0f, 0x71, 0x19,
+	0x20, 0xae, 0xd1, 0xd9, 0x33, 0x40, 0x81, 0x66, 0xd1, 0x26, 0x52, 0xb5, 0x31, 0x00, 0x95, 0xda,
+	0xfc, 0x02, 0xd8, 0x13, 0xe4, 0x23, 0xfb, 0x71, 0x5e, 0xdb, 0xd5, 0x94, 0xda, 0xce, 0xe2, 0x49,
+	0x71, 0xf5, 0xec, 0xd2, 0x23, 0xa9, 0x9e, 0x5d, 0xc7, 0x83, 0xd8, 0x72, 0x76, 0x8d, 0xcb, 0x67,
+	0xd7, 0x41, 0xc8, 0xbe, 0x07, 0x34, 0xc5, 0xc2, 0xff, 0x56, 0xcc, 0x5a, 0x72, 0x85, 0x4f, 0x57,
+	0x13, 0x15, 0x45, 0xac, 0x44, 0x85, 0x2b, 0xa5, 0x8a, 0x0e, 0x52, 0xe7, 0x15, 0xa3, 0xa0, 0x84,
+	0x0a, 0x3a, 0x29, 0xed, 0xa0, 0x15, 0xf3, 0x48, 0x53, 0xfc, 0x1c, 0x56, 0x77, 0x8b, 0x96, 0xa9,
+	0xaa, 0x65, 0x45, 0x80, 0x72, 0x22, 0x03, 0x6d, 0x95, 0x45, 0xdc, 0x81, 0xf4, 0x8f, 0x24, 0x8a,
+	0xbc, 0x5d, 0x70, 0x15, 0xc7, 0x56, 0xe2, 0xd7, 0x4a, 0x25, 0xbe, 0x25, 0xd9, 0xc8, 0xd4, 0x64,
+	0x43, 0x03, 0x48, 0x22, 0xfe, 0x3e, 0x28, 0x97, 0x7f, 0xf9, 0xe3, 0x0c, 0x30, 0x3c, 0xce, 0x90,
+	0x17, 0x8e, 0x84, 0xe5, 0xe2, 0xec, 0x16, 0x90, 0x65, 0x40, 0x45, 0x62, 0xe7, 0xa3, 0x46, 0x70,
+	0x93, 0x16, 0x50, 0xae, 0x51, 0x0b, 0xb2, 0x25, 0xae, 0xdf, 0x00, 0x73, 0x09, 0x6a, 0x35, 0x67,
+	0xee, 0xc0, 0x8e, 0x7a, 0x1b, 0xd3, 0x86, 0x8b, 0x9b, 0x43, 0x1c, 0x24, 0xca, 0x95, 0x0c, 0x4b,
+	0x20, 0xcb, 0xe4, 0xce, 0x55, 0x23, 0xee, 0x7b, 0x14, 0xf7, 0x7a, 0x8e, 0x5b, 0x8b, 0x4d, 0x6a,
+	0xb0, 0xaf, 0xa9, 0x92, 0x0f, 0xf3, 0xf6, 0x64, 0x71, 0xc3, 0xfb, 0x55, 0x37, 0xd4, 0x26, 0xe6,
+	0xff, 0x02, 0x96, 0x52, 0xdc, 0x78, 0xbd, 0x6a, 0x72, 0x42, 0x4d, 0xd0, 0xa8, 0xe9, 0x83, 0x86,
+	0xb8, 0xdc, 0xab, 0x5b, 0x2e, 0xf7, 0xa6, 0xaa, 0x97, 0x7b, 0x9d, 0x6b, 0x46, 0x8d, 0xf7, 0xa9,
+	0xc6, 0x4f, 0x16, 0xc2, 0x62, 0x55, 0x25, 0xa9, 0xf9, 0xaf, 0x80, 0xf1, 0x96, 0xe1, 0xff, 0xa7,
+	0xb7, 0x25, 0x10, 0x3e, 0x2c, 0x04, 0x42, 0x3d, 0xb0, 0x82, 0xcb, 0x54, 0x6e, 0x41, 0x72, 0x97,
+	0x01, 0xd2, 0x65, 0x2e, 0x0e, 0x06, 0x89, 0x70, 0x19, 0xf2, 0x6d, 0x71, 0x99, 0xb7, 0x54, 0x97,
+	0xa9, 0x4c, 0x2e, 0x45, 0xff, 0x14, 0x18, 0xae, 0x5a, 0x88, 0x89, 0xae, 0xed, 0xed, 0xed, 0x52,
+	0x99, 0x7c, 0xb3, 0x89, 0x36, 0x7f, 0x26, 0x55, 0xe0, 0x88, 0x66, 0x5e, 0xee, 0xd6, 0x94, 0x72,
+	0xd7, 0x5c, 0xa4, 0x7d, 0xa6, 0x5a, 0xa4, 0x95, 0x60, 0x14, 0xe2, 0x9b, 0xfe, 0xe6, 0xe7, 0xbf,
+	0x43, 0x6a, 0x41, 0xf5, 0x48, 0x5f, 0x3a, 0x6a, 0x51, 0xfd, 0x08, 0x18, 0x2e, 0x9d, 0x8e, 0xfe,
+	0xdc, 0xec, 0x28, 0xcf, 0xcd, 0x16, 0x74, 0x9f, 0x55, 0xd1, 0x69, 0x45, 0x4b, 0x74, 0x23, 0xc3,
+	0xb5, 0x57, 0x19, 0x9c, 0x45, 0xdc, 0xe7, 0x54, 0x71, 0xda, 0xc9, 0xa4, 0xb8, 0xc8, 0x70, 0x95,
+	0x56, 0x11, 0x77, 0xd9, 0x28, 0xee, 0x31, 0xa8, 0xca, 0x33, 0xaa, 0xf7, 0x26, 0xa9, 0x40, 0xd2,
+	0x71, 0x1c, 0xa5, 0x98, 0x88, 0xb8, 0x71, 0x9d, 0x8a, 0x68, 0xf8, 0xce, 0x8d, 0xeb, 0x86, 0x9f,
+	0x18, 0xf2, 0x1f, 0x5f, 0xd8, 0x3f, 0x0c, 0xac, 0xa1, 0xfc, 0xda, 0x50, 0x2f, 0xfc, 0xda, 0xf0,
+	0x63, 0xa0, 0xbb, 0x00, 0x3c, 0xc6, 0x9d, 0x61, 0x8e, 0xe4, 0x9f, 0x67, 0x76, 0x70, 0xf3, 0xa8,
+	0x63, 0x34, 0xfa, 0xa0, 0x7a, 0x19, 0x59, 0xb1, 0xb7, 0xf9, 0x9c, 0xf8, 0x02, 0x93, 0xb3, 0xaa,
+	0x9c, 0x54, 0xca, 0x44, 0x52, 0xca, 0x3f, 0x81, 0xfd, 0x76, 0xf3, 0xfd, 0x2b, 0x3f, 0xec, 0x4f,
+	0x6b, 0x9d, 0xd7, 0x8c, 0xaa, 0xbe, 0x0d, 0xd4, 0x74, 0xdf, 0xa6, 0x8c, 0x54, 0xfb, 0x97, 0xe0,
+	0x80, 0x2b, 0xdb, 0x63, 0xaa, 0x51, 0xb6, 0x8d, 0xa8, 0xdf, 0x61, 0xa8, 0x9f, 0x16, 0x27, 0xb9,
+	0x05, 0x4b, 0x61, 0xb5, 0x0e, 0xb8, 0x46, 0x3e, 0xa6, 0xf5, 0x6a, 0xc1, 0x59, 0x45, 0x08, 0xd7,
+	0x49, 0x25, 0x95, 0x6e, 0x10, 0x0a, 0x0f, 0xb8, 0x9d, 0x1d, 0xa3, 0xd6, 0x5f, 0x64, 0x5a, 0x6f,
+	0x28, 0xee, 0x6f, 0x54, 0x45, 0xaa, 0xfd, 0x33, 0x60, 0xbc, 0x19, 0xb7, 0xea, 0x9b, 0xff, 0x6e,
+	0xc2, 0xae, 0xcc, 0x2c, 0xbf, 0x9b, 0x58, 0xd2, 0xc4, 0x2f, 0x01, 0x35, 0xe6, 0x1b, 0x60, 0x14,
+	0x02, 0x87, 0xf1, 0xa2, 0x1e, 0x7d, 0x04, 0x4e, 0x33, 0x82, 0x0b, 0x5a, 0x35, 0x39, 0xa9, 0xe9,
+	0x7e, 0x80, 0x77, 0xb6, 0xe4, 0x53, 0x5f, 0x06, 0x6a, 0x12, 0x6b, 0x92, 0x2b, 0xd1, 0xbd, 0x0b,
+	0xcc, 0x0f, 0x05, 0xba, 0xc8, 0xa6, 0x3c, 0x8f, 0xd3, 0x6f, 0x0b, 0x94, 0x77, 0x0b, 0x50, 0x4c,
+	0x42, 0x24, 0x94, 0xf7, 0x80, 0xed, 0x55, 0xa2, 0x02, 0x46, 0xfd, 0x8b, 0x8a, 0x95, 0x02, 0x79,
+	0xbb, 0xf3, 0x71, 0x23, 0xa8, 0xaf, 0x00, 0xb5, 0xdc, 0x36, 0x8b, 0x93, 0xb0, 0x7e, 0x0d, 0x6c,
+	0x8f, 0x21, 0x56, 0x77, 0x23, 0x97, 0xd9, 0xf1, 0x44, 0x5c, 0xcc, 0x37, 0x7d, 0xde, 0x22, 0x9b,
+	0x49, 0x49, 0x8f, 0xc5, 0x66, 0x52, 0x48, 0x16, 0x05, 0xbe, 0x5a, 0x50, 0xc0, 0x0c, 0x4c, 0x2a,
+	0x90, 0xe9, 0x1e, 0x6b, 0x08, 0xee, 0xfc, 0xc7, 0x42, 0xe2, 0x7b, 0x73, 0x7e, 0xde, 0xb6, 0x44,
+	0xab, 0xaf, 0x15, 0xa2, 0x55, 0x75, 0x5a, 0x29, 0xf5, 0x2f, 0xc0, 0xf6, 0x16, 0xf4, 0x3e, 0x5e,
+	0x62, 0x99, 0x4d, 0xf9, 0xf5, 0x82, 0x29, 0xcd, 0x60, 0xd5, 0x3c, 0x44, 0xff, 0x7e, 0x65, 0xc9,
+	0x73, 0xbe, 0x51, 0xc8, 0x73, 0xb4, 0xa3, 0xe5, 0xfc, 0xef, 0x00, 0xd3, 0x2b, 0x18, 0x09, 0x27,
+	0xf4, 0x77, 0x14, 0x5e, 0x28, 0xb0, 0x06, 0x9a, 0x83, 0x60, 0x87, 0xbf, 0x38, 0x81, 0x1d, 0x4b,
+	0x99, 0xf2, 0x4d, 0x86, 0x62, 0x4d, 0xa0, 0xd0, 0x89, 0x90, 0x30, 0xee, 0x59, 0xde, 0xdb, 0x68,
+	0xfe, 0x15, 0xe5, 0xf9, 0x57, 0x64, 0xb9, 0xd9, 0xfa, 0x16, 0x50, 0x8b, 0x3b, 0xe3, 0x8c, 0x52,
+	0xee, 0xef, 0xc0, 0x91, 0x5e, 0xf2, 0xac, 0x4e, 0x64, 0xf9, 0x9f, 0xa8, 0xf3, 0x49, 0x23, 0xe4,
+	0x6f, 0x33, 0xc8, 0x1f, 0xae, 0x9c, 0xed, 0x07, 0x61, 0x91, 0x4a, 0xfc, 0x11, 0xd8, 0x5f, 0x17,
+	0x8f, 0xc9, 0xf5, 0xe5, 0x3f, 0x57, 0xec, 0x22, 0x8f, 0xb7, 0x2c, 0x69, 0xd1, 0x77, 0x0a, 0x69,
+	0x91, 0x0d, 0x62, 0xae, 0xcc, 0x7f, 0x06, 0x00, 0x0f, 0xe0, 0x80, 0xa8, 0x78, 0x2e, 0x00, 0x00,
 }
@@ -0,0 +1,179 @@
+package meta
+
+import "reflect"
+
+// MergeStrategy controls how MergeFrom resolves an object that exists on both
+// sides with different settings.
+type MergeStrategy int
+
+const (
+	// MergeSkip keeps the existing object.
+	MergeSkip MergeStrategy = iota
+	// MergeOverwrite replaces the existing object with the merged one.
+	MergeOverwrite
+	// MergeFail aborts the merge without changing anything.
+	MergeFail
+)
+
+// MergeConflict identifies an object that differs between the two sides of
+// a merge. Name is qualified with the database for retention policies and
+// continuous queries, as in "db0.rp0".
+type MergeConflict struct {
+	Kind string
+	Name string
+}
+
+// MergeResult reports what MergeFrom added and which objects conflicted.
+type MergeResult struct {
+	Databases         []string
+	RetentionPolicies []string
+	ContinuousQueries []string
+	Users             []string
+	Conflicts         []MergeConflict
+}
+
+// MergeFrom copies the databases, retention policies, continuous queries and
+// users that exist only in other into data, and resolves those that exist in
+// both with different settings according to strategy.
+//
+// Shard groups are never merged: their IDs are allocated independently on
+// each side and may refer to different shards. Retention policies taken
+// from other are added without shard groups, and overwriting a retention
+// policy only replaces its settings.
+func (data *Data) MergeFrom(other *Data, strategy MergeStrategy) (MergeResult, error) {
+	var result MergeResult
+	merged := data.Clone()
+
+	for _, odi := range other.Databases {
+		di := merged.Database(odi.Name)
+		if di == nil {
+			ndi := odi.clone()
+			for i := range ndi.RetentionPolicies {
+				ndi.RetentionPolicies[i].ShardGroups = nil
+			}
+			merged.Databases = append(merged.Databases, ndi)
+			result.Databases = append(result.Databases, odi.Name)
+			continue
+		}
+
+		if di.DefaultRetentionPolicy != odi.DefaultRetentionPolicy {
+			result.Conflicts = append(result.Conflicts, MergeConflict{Kind: "database", Name: di.Name})
+		}
+		defaultRP := odi.DefaultRetentionPolicy
+
+		for _, orpi := range odi.RetentionPolicies {
+			name := di.Name + "." + orpi.Name
+			rpi := di.RetentionPolicy(orpi.Name)
+			if rpi == nil {
+				nrpi := orpi.clone()
+				nrpi.ShardGroups = nil
+				di.RetentionPolicies = append(di.RetentionPolicies, nrpi)
+				result.RetentionPolicies = append(result.RetentionPolicies, name)
+				continue
+			}
+
+			if !sameRetentionPolicySettings(rpi, &orpi) {
+				result.Conflicts = append(result.Conflicts, MergeConflict{Kind: "retention policy", Name: name})
+				if strategy == MergeOverwrite {
+					mergeRetentionPolicySettings(rpi, &orpi)
+				}
+			}
+		}
+
+		// Only switch the default once the policy is known to exist.
+		if strategy == MergeOverwrite && di.DefaultRetentionPolicy != defaultRP &&
+			(defaultRP == "" || di.RetentionPolicy(defaultRP) != nil) {
+			di.DefaultRetentionPolicy = defaultRP
+		}
+
+		for _, ocqi := range odi.ContinuousQueries {
+			name := di.Name + "." + ocqi.Name
+			i := continuousQueryIndex(di, ocqi.Name)
+			if i < 0 {
+				di.ContinuousQueries = append(di.ContinuousQueries, ocqi.clone())
+				result.ContinuousQueries = append(result.ContinuousQueries, name)
+				continue
+			}
+
+			if di.ContinuousQueries[i].Query != ocqi.Query {
+				result.Conflicts = append(result.Conflicts, MergeConflict{Kind: "continuous query", Name: name})
+				if strategy == MergeOverwrite {
+					di.ContinuousQueries[i] = ocqi.clone()
+				}
+			}
+		}
+	}
+
+	for _, oui := range other.Users {
+		ui := merged.user(oui.Name)
+		if ui == nil {
+			merged.Users = append(merged.Users, oui.clone())
+			result.Users = append(result.Users, oui.Name)
+			continue
+		}
+
+		if !sameUser(ui, &oui) {
+			result.Conflicts = append(result.Conflicts, MergeConflict{Kind: "user", Name: ui.Name})
+			if strategy == MergeOverwrite {
+				*ui = oui.clone()
+			}
+		}
+	}
+	merged.adminUserExists = merged.hasAdminUser()
+
+	if strategy == MergeFail && len(result.Conflicts) > 0 {
+		return result, ErrMergeConflict
+	}
+
+	*data = *merged
+	return result, nil
+}
+
+// sameRetentionPolicySettings returns true if a and b differ in no setting.
+// Shard groups and subscriptions are not compared.
+func sameRetentionPolicySettings(a, b *RetentionPolicyInfo) bool {
+	return a.Duration == b.Duration &&
+		a.ShardGroupDuration == b.ShardGroupDuration &&
+		a.ReplicaN == b.ReplicaN &&
+		a.shardsPerGroup() == b.shardsPerGroup() &&
+		sameShardKeyTags(a.ShardKeyTags, b.ShardKeyTags) &&
+		sameTenantNodes(a.TenantNodes, b.TenantNodes) &&
+		reflect.DeepEqual(a.MeasurementRetention, b.MeasurementRetention)
+}
+
+// mergeRetentionPolicySettings copies the settings of src to dst.
+func mergeRetentionPolicySettings(dst, src *RetentionPolicyInfo) {
+	dst.Duration = src.Duration
+	dst.ShardGroupDuration = src.ShardGroupDuration
+	dst.ReplicaN = src.ReplicaN
+	dst.ShardsPerGroup = src.ShardsPerGroup
+	clone := src.clone()
+	dst.ShardKeyTags = clone.ShardKeyTags
+	dst.TenantNodes = clone.TenantNodes
+	dst.MeasurementRetention = clone.MeasurementRetention
+}
+
+// continuousQueryIndex returns the index of the named continuous query in di,
+// or -1 if there is none.
+func continuousQueryIndex(di *DatabaseInfo, name string) int {
+	for i := range di.ContinuousQueries {
+		if di.ContinuousQueries[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameUser returns true if a and b have the same password, admin status and
+// privileges.
+func sameUser(a, b *UserInfo) bool {
+	if a.Hash != b.Hash || a.Admin != b.Admin || len(a.Privileges) != len(b.Privileges) {
+		return false
+	}
+	for db, p := range a.Privileges {
+		if op, ok := b.Privileges[db]; !ok || op != p {
+			return false
+		}
+	}
+	return true
+}
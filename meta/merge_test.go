@@ -0,0 +1,174 @@
+package meta_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb/meta"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+)
+
+// newMergeTestData returns data with database db, its default retention
+// policy rp0 of the given duration, and a user.
+func newMergeTestData(t *testing.T, db string, d time.Duration, user, hash string) *meta.Data {
+	t.Helper()
+
+	data := &meta.Data{}
+	if err := data.CreateDatabase(db); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: d, ShardGroupDuration: time.Hour}
+	if err := data.CreateRetentionPolicy(db, rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateUser(user, hash, false); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestData_MergeFrom_Disjoint(t *testing.T) {
+	data := newMergeTestData(t, "db0", 0, "u0", "h0")
+	other := newMergeTestData(t, "db1", 0, "u1", "h1")
+	if err := other.CreateShardGroup("db1", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A matching db0 with an extra retention policy is merged into db0.
+	if err := other.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp1", ReplicaN: 1, ShardGroupDuration: time.Hour}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := data.MergeFrom(other, meta.MergeFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := meta.MergeResult{
+		Databases:         []string{"db1"},
+		RetentionPolicies: []string{"db0.rp1"},
+		Users:             []string{"u1"},
+	}
+	if !reflect.DeepEqual(result, exp) {
+		t.Fatalf("unexpected result: got %+v, exp %+v", result, exp)
+	}
+
+	if data.Database("db1") == nil {
+		t.Fatal("expected merged database")
+	} else if rpi, _ := data.RetentionPolicy("db0", "rp1"); rpi == nil {
+		t.Fatal("expected merged retention policy")
+	}
+	if rpi, _ := data.RetentionPolicy("db1", "rp0"); len(rpi.ShardGroups) != 0 {
+		t.Fatalf("unexpected shard groups merged: %v", rpi.ShardGroups)
+	}
+	if data.User("u0") == nil || data.User("u1") == nil {
+		t.Fatal("expected both users")
+	}
+}
+
+func TestData_MergeFrom_TenancyConflict(t *testing.T) {
+	data := newMergeTestData(t, "db0", 0, "u0", "h0")
+	other := newMergeTestData(t, "db0", 0, "u0", "h0")
+	rpi, _ := other.RetentionPolicy("db0", "rp0")
+	rpi.ShardKeyTags = []string{"tenant"}
+	rpi.TenantNodes = map[string][]uint64{"acme": {1}}
+
+	exp := []meta.MergeConflict{{Kind: "retention policy", Name: "db0.rp0"}}
+	result, err := data.MergeFrom(other, meta.MergeOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(result.Conflicts, exp) {
+		t.Fatalf("unexpected conflicts: %+v", result.Conflicts)
+	}
+	if rpi, _ := data.RetentionPolicy("db0", "rp0"); !reflect.DeepEqual(rpi.ShardKeyTags, []string{"tenant"}) ||
+		!reflect.DeepEqual(rpi.TenantNodes, map[string][]uint64{"acme": {1}}) {
+		t.Fatalf("unexpected tenancy: %v %v", rpi.ShardKeyTags, rpi.TenantNodes)
+	}
+}
+
+func TestData_MergeFrom_Conflicts(t *testing.T) {
+	newOther := func() *meta.Data {
+		other := newMergeTestData(t, "db0", 24*time.Hour, "u0", "h1")
+		if err := other.SetPrivilege("u0", "db0", cnosql.ReadPrivilege); err != nil {
+			t.Fatal(err)
+		}
+		if err := other.CreateUser("u1", "h1", false); err != nil {
+			t.Fatal(err)
+		}
+		return other
+	}
+	expConflicts := []meta.MergeConflict{
+		{Kind: "retention policy", Name: "db0.rp0"},
+		{Kind: "user", Name: "u0"},
+	}
+
+	t.Run("Skip", func(t *testing.T) {
+		data := newMergeTestData(t, "db0", 0, "u0", "h0")
+		result, err := data.MergeFrom(newOther(), meta.MergeSkip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result.Conflicts, expConflicts) {
+			t.Fatalf("unexpected conflicts: %+v", result.Conflicts)
+		}
+		if rpi, _ := data.RetentionPolicy("db0", "rp0"); rpi.Duration != 0 {
+			t.Fatalf("unexpected duration: %s", rpi.Duration)
+		}
+		if u := data.User("u0").(*meta.UserInfo); u.Hash != "h0" {
+			t.Fatalf("unexpected hash: %s", u.Hash)
+		}
+		if data.User("u1") == nil {
+			t.Fatal("expected non-conflicting user to be merged")
+		}
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		data := newMergeTestData(t, "db0", 0, "u0", "h0")
+		if err := data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		prev, _ := data.RetentionPolicy("db0", "rp0")
+		sgID := prev.ShardGroups[0].ID
+
+		result, err := data.MergeFrom(newOther(), meta.MergeOverwrite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result.Conflicts, expConflicts) {
+			t.Fatalf("unexpected conflicts: %+v", result.Conflicts)
+		}
+		rpi, _ := data.RetentionPolicy("db0", "rp0")
+		if rpi.Duration != 24*time.Hour {
+			t.Fatalf("unexpected duration: %s", rpi.Duration)
+		} else if len(rpi.ShardGroups) != 1 || rpi.ShardGroups[0].ID != sgID {
+			t.Fatalf("expected shard groups to be kept: %v", rpi.ShardGroups)
+		}
+		u := data.User("u0").(*meta.UserInfo)
+		if u.Hash != "h1" || u.Privileges["db0"] != cnosql.ReadPrivilege {
+			t.Fatalf("unexpected user: %+v", u)
+		}
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		data := newMergeTestData(t, "db0", 0, "u0", "h0")
+		result, err := data.MergeFrom(newOther(), meta.MergeFail)
+		if err != meta.ErrMergeConflict {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Conflicts, expConflicts) {
+			t.Fatalf("unexpected conflicts: %+v", result.Conflicts)
+		}
+		if data.User("u1") != nil {
+			t.Fatal("expected data to be unchanged")
+		}
+		if rpi, _ := data.RetentionPolicy("db0", "rp0"); rpi.Duration != 0 {
+			t.Fatalf("unexpected duration: %s", rpi.Duration)
+		}
+	})
+}
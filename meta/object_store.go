@@ -0,0 +1,124 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// ErrNoObjectStore is returned when backing up to or restoring from an
+// object store before one has been set.
+var ErrNoObjectStore = errors.New("no object store configured")
+
+// ObjectStore is an S3-style store of objects addressed by bucket and key,
+// used for off-host backups of the meta data.
+type ObjectStore interface {
+	// Put writes the object read from r at key in bucket, replacing any
+	// object already there.
+	Put(ctx context.Context, bucket, key string, r io.Reader) error
+
+	// Get returns a reader for the object at key in bucket. The caller
+	// closes it.
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// parseObjectURL splits an object store URL such as s3://bucket/some/key
+// into its bucket and key. The key may be empty.
+func parseObjectURL(rawurl string) (bucket, key string, err error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", "", err
+	} else if u.Scheme == "" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid object store url %q: expected scheme://bucket/key", rawurl)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// metaBackupKey returns the key of the backup of data under prefix, named
+// after its cluster ID and index.
+func metaBackupKey(prefix string, data *Data) string {
+	return path.Join(prefix, fmt.Sprintf("meta-%d-%d.db", data.ClusterID, data.Index))
+}
+
+// backupMetaTo uploads the current data to the object store, under the key
+// prefix given by rawurl.
+func (s *store) backupMetaTo(ctx context.Context, rawurl string) error {
+	if s.objectStore == nil {
+		return ErrNoObjectStore
+	}
+	bucket, prefix, err := parseObjectURL(rawurl)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+	b, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return s.objectStore.Put(ctx, bucket, metaBackupKey(prefix, data), bytes.NewReader(b))
+}
+
+// restoreMetaFrom replaces the databases, retention policies and users with
+// those of the backup at rawurl, which names the object written by
+// backupMetaTo. The cluster ID and nodes of the running cluster are kept.
+func (s *store) restoreMetaFrom(ctx context.Context, rawurl string) error {
+	if s.objectStore == nil {
+		return ErrNoObjectStore
+	}
+	bucket, key, err := parseObjectURL(rawurl)
+	if err != nil {
+		return err
+	} else if key == "" {
+		return fmt.Errorf("invalid object store url %q: no key", rawurl)
+	}
+
+	rc, err := s.objectStore.Get(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	var data Data
+	if err := data.UnmarshalBinary(b); err != nil {
+		return fmt.Errorf("unmarshal %q: %v", rawurl, err)
+	}
+
+	// Don't start replacing the data once the caller has given up.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t := internal.Command_SetDataCommand
+	cmd := &internal.Command{Type: &t}
+	if err := proto.SetExtension(cmd, internal.E_SetDataCommand_Command, &internal.SetDataCommand{
+		Data:          data.marshal(),
+		PreserveNodes: proto.Bool(true),
+	}); err != nil {
+		panic(err)
+	}
+
+	buf, err := proto.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	return s.apply(buf)
+}
@@ -0,0 +1,126 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/raft"
+)
+
+func TestStore_BackupMetaTo(t *testing.T) {
+	configuration := raft.Configuration{Servers: []raft.Server{{ID: "node0", Address: "node0"}}}
+	s, _ := newTestRaftStore(t, "node0", &configuration)
+	defer s.raftState.raft.Shutdown()
+	timeout := time.After(5 * time.Second)
+	for !s.isLeader() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for leader")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	ctx := context.Background()
+	if err := s.backupMetaTo(ctx, "s3://backups/meta"); err != ErrNoObjectStore {
+		t.Fatalf("unexpected error without object store: %v", err)
+	}
+	objects := &memObjectStore{}
+	s.objectStore = objects
+
+	b, err := proto.Marshal(newTestCreateDatabaseCommand("db0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.apply(b); err != nil {
+		t.Fatal(err)
+	}
+	s.data.ClusterID = 100
+
+	if err := s.backupMetaTo(ctx, "s3://backups/meta"); err != nil {
+		t.Fatal(err)
+	}
+	key := fmt.Sprintf("meta/meta-100-%d.db", s.data.Index)
+	if _, ok := objects.objects["backups/"+key]; !ok {
+		t.Fatalf("backup not found at %s: %v", key, objects.keys())
+	}
+
+	// Restoring brings back the dropped database.
+	typ := internal.Command_DropDatabaseCommand
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, internal.E_DropDatabaseCommand_Command, &internal.DropDatabaseCommand{
+		Name: proto.String("db0"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	b, err = proto.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.apply(b); err != nil {
+		t.Fatal(err)
+	} else if s.database("db0") != nil {
+		t.Fatal("expected db0 to be dropped")
+	}
+
+	if err := s.restoreMetaFrom(ctx, "s3://backups/meta/meta-0-0.db"); err == nil {
+		t.Fatal("expected error restoring a missing backup")
+	}
+	if err := s.restoreMetaFrom(ctx, "s3://backups/"+key); err != nil {
+		t.Fatal(err)
+	} else if s.database("db0") == nil {
+		t.Fatal("expected db0 to be restored")
+	}
+
+	if err := s.backupMetaTo(ctx, "backups/meta"); err == nil {
+		t.Fatal("expected error for url without a scheme")
+	}
+}
+
+// memObjectStore is an ObjectStore that keeps objects in memory.
+type memObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memObjectStore) Put(ctx context.Context, bucket, key string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.objects == nil {
+		m.objects = make(map[string][]byte)
+	}
+	m.objects[bucket+"/"+key] = b
+	return nil
+}
+
+func (m *memObjectStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.objects[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("object %s/%s not found", bucket, key)
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (m *memObjectStore) keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.objects {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -193,24 +193,43 @@ func (r *raftState) close() error {
 }
 
 // apply applies a serialized command to the raft log.
-func (r *raftState) apply(b []byte) error {
+func (r *raftState) apply(b []byte) (uint64, error) {
 	// Apply to raft log.
 	f := r.raft.Apply(b, 0)
 	if err := f.Error(); err != nil {
-		return err
+		if err == raft.ErrNotLeader {
+			return 0, err
+		}
+		return 0, &applyError{err: err, state: r.raft.State(), leader: r.leader()}
 	}
 
-	// Return response if it's an error.
+	// Return response if it's an error or a command's result.
 	// No other non-nil objects should be returned.
-	resp := f.Response()
-	if err, ok := resp.(error); ok {
-		return err
-	}
-	if resp != nil {
+	switch resp := f.Response().(type) {
+	case nil:
+		return 0, nil
+	case error:
+		return 0, resp
+	case commandResult:
+		return uint64(resp), nil
+	default:
 		panic(fmt.Sprintf("unexpected response: %#v", resp))
 	}
+}
 
-	return nil
+// barrier blocks until every preceding log entry has been applied. It fails
+// with raft.ErrNotLeader if this node is not the leader.
+func (r *raftState) barrier() error {
+	return r.raft.Barrier(0).Error()
+}
+
+// applied returns true if every committed log entry has been applied to the FSM.
+func (r *raftState) applied() bool {
+	commit, err := strconv.ParseUint(r.raft.Stats()["commit_index"], 10, 64)
+	if err != nil {
+		return false
+	}
+	return r.raft.AppliedIndex() >= commit
 }
 
 func (r *raftState) lastIndex() uint64 {
@@ -222,6 +241,35 @@ func (r *raftState) snapshot() error {
 	return future.Error()
 }
 
+// uncommittedEntries returns the number of log entries that have been
+// appended but not committed.
+func (r *raftState) uncommittedEntries() uint64 {
+	stats := r.raft.Stats()
+	last, _ := strconv.ParseUint(stats["last_log_index"], 10, 64)
+	commit, _ := strconv.ParseUint(stats["commit_index"], 10, 64)
+	if last < commit {
+		return 0
+	}
+	return last - commit
+}
+
+// lastSnapshotIndex returns the index of the last log entry in the latest
+// snapshot, or zero if there is none.
+func (r *raftState) lastSnapshotIndex() uint64 {
+	n, _ := strconv.ParseUint(r.raft.Stats()["last_snapshot_index"], 10, 64)
+	return n
+}
+
+// firstLogIndex returns the index of the oldest entry in the raft log, or zero
+// if it is unknown.
+func (r *raftState) firstLogIndex() uint64 {
+	if r.raftStore == nil {
+		return 0
+	}
+	n, _ := r.raftStore.FirstIndex()
+	return n
+}
+
 // addVoter instead of addPeer, adds addr to the list of peers in the cluster.
 func (r *raftState) addVoter(addr string) error {
 	serverAddr := raft.ServerAddress(addr)
@@ -246,6 +294,27 @@ func (r *raftState) addVoter(addr string) error {
 	return nil
 }
 
+// waitForVoter blocks until a configuration that includes addr has been
+// committed, or until timeout elapses.
+func (r *raftState) waitForVoter(addr string, timeout time.Duration) error {
+	// The barrier is committed after every earlier entry, including the
+	// configuration change that added addr.
+	if err := r.raft.Barrier(timeout).Error(); err != nil {
+		return err
+	}
+
+	peers, err := r.peers()
+	if err != nil {
+		return err
+	}
+	for _, p := range peers {
+		if p == addr {
+			return nil
+		}
+	}
+	return fmt.Errorf("raft configuration does not include %s", addr)
+}
+
 // removeVoter instead of removePeer removes addr from the list of peers in the cluster.
 func (r *raftState) removeVoter(addr string) error {
 	// Only do this on the leader
@@ -311,6 +380,20 @@ func (r *raftState) isLeader() bool {
 	return r.raft.State() == raft.Leader
 }
 
+// applyError is returned when a command could not be committed to the raft log.
+// It records the raft state at the time of the failure.
+type applyError struct {
+	err    error
+	state  raft.RaftState
+	leader string
+}
+
+func (e *applyError) Error() string {
+	return fmt.Sprintf("raft apply failed (state %s, leader %q): %s", e.state, e.leader, e.err)
+}
+
+func (e *applyError) Unwrap() error { return e.err }
+
 // raftLayer wraps the connection so it can be re-used for forwarding.
 type raftLayer struct {
 	addr   *raftLayerAddr
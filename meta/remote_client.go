@@ -2,8 +2,12 @@ package meta
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	cRand "crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,14 +15,19 @@ import (
 	"io/ioutil"
 	"math"
 	"math/rand"
+	"mime"
 	"net/http"
+	"net/url"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cnosdb/cnosdb"
 	internal "github.com/cnosdb/cnosdb/meta/internal"
-	"github.com/cnosdb/cnosdb/pkg/logger"
+	"github.com/cnosdb/cnosdb/pkg/uuid"
 	"github.com/cnosdb/cnosdb/vend/cnosql"
 	"github.com/gogo/protobuf/proto"
 	"go.uber.org/zap"
@@ -30,34 +39,198 @@ const (
 	// before making another pass
 	errSleep = time.Second
 
-	// maxRetries is the maximum number of attemps to make before returning
-	// a failure to the caller
-	maxRetries = 10
+	// maxRetryAfter caps how long a Retry-After header from a metaserver can
+	// delay the next attempt
+	maxRetryAfter = 30 * time.Second
+
+	// DefaultMaxRetries is the default number of times a command is retried
+	// before returning a failure to the caller.
+	DefaultMaxRetries = 10
+
+	// DefaultRetryInterval is the default wait after a failed attempt to
+	// send a command.
+	DefaultRetryInterval = time.Second
+
+	// corruptSnapshotCooldown is how long a metaserver that returned a corrupt
+	// snapshot is skipped when polling for updates
+	corruptSnapshotCooldown = 30 * time.Second
+
+	// DefaultMaxSnapshotBytes is the default limit on the size of a snapshot
+	// read from a metaserver.
+	DefaultMaxSnapshotBytes = 256 << 20
+
+	// DefaultLeaseMaxBackoff is the default cap on the wait between attempts
+	// to acquire a lease.
+	DefaultLeaseMaxBackoff = 2 * time.Second
+
+	// DefaultLeaseJitter is the default fraction of each wait between lease
+	// attempts that is randomized.
+	DefaultLeaseJitter = 0.5
 )
 
+// Write consistency levels, which set how many metaservers must apply a
+// command before it is acknowledged.
+const (
+	// WriteConsistencyLeader acknowledges a command once the leader has
+	// committed it.
+	WriteConsistencyLeader = "leader"
+
+	// WriteConsistencyQuorum acknowledges a command once a majority of
+	// metaservers, counting the leader, has applied it.
+	WriteConsistencyQuorum = "quorum"
+
+	// WriteConsistencyAll acknowledges a command once every metaserver has
+	// applied it.
+	WriteConsistencyAll = "all"
+)
+
+// validWriteConsistency returns true if level is a known write consistency
+// level.
+func validWriteConsistency(level string) bool {
+	switch level {
+	case WriteConsistencyLeader, WriteConsistencyQuorum, WriteConsistencyAll:
+		return true
+	}
+	return false
+}
+
 var _ MetaClient = &RemoteClient{}
 
+// errClientClosed is returned by requests started after the client is closed.
+var errClientClosed = errors.New("meta client closed")
+
+// errExecTimeout is returned by an attempt to send a command that ran out of
+// time. The command is retried like one that failed.
+var errExecTimeout = errors.New("meta command timed out")
+
 type RemoteClient struct {
 	tls    bool
 	logger *zap.Logger
 	nodeID uint64
+	clock  clock
 
 	mu          sync.RWMutex
 	metaServers []string
+	authToken   string
 	changed     chan struct{}
+	refreshed   chan struct{}
 	closing     chan struct{}
 	cacheData   *Data
 
-	// Authentication cache.
-	authCache map[string]authUser
+	// tlsCertFile, tlsKeyFile and tlsCAFile are the files ReloadTLS reads.
+	// httpClient sends requests with the TLS config loaded from them, or is
+	// nil to send them with http.DefaultClient.
+	tlsCertFile, tlsKeyFile, tlsCAFile string
+	httpClient                         *http.Client
+
+	// tlsReloadInterval is how often the TLS files are read again; zero
+	// disables reloading. tlsReloadReset is closed when it changes.
+	tlsReloadInterval time.Duration
+	tlsReloadReset    chan struct{}
+
+	// corrupt holds the time until which a metaserver that returned a
+	// corrupt snapshot is skipped.
+	corrupt map[string]time.Time
+
+	// snapshots is the number of snapshots acquired and not yet released.
+	snapshots int64
+
+	watchers shardGroupWatchers
+
+	// preferred is the metaserver last known to be the leader. Commands are
+	// sent there first to avoid being redirected.
+	preferred string
+
+	// execSem bounds the number of concurrent exec calls. It is nil if
+	// there is no limit.
+	execSem chan struct{}
+
+	// linearizable makes reads fetch the data from the leader instead of
+	// using the cache.
+	linearizable bool
+
+	// writeConsistency is sent with every command, if set, as the level of
+	// acknowledgment the metaserver must get before answering.
+	writeConsistency string
+
+	// maxSnapshotBytes is the largest snapshot read from a metaserver. Zero
+	// means no limit.
+	maxSnapshotBytes int64
+
+	// leaseMaxBackoff and leaseJitter shape the waits between attempts to
+	// acquire a lease.
+	leaseMaxBackoff time.Duration
+	leaseJitter     float64
+
+	// minPollInterval is the least time between the starts of two snapshot
+	// requests made while polling for updates. Zero means no limit.
+	minPollInterval time.Duration
+
+	// running tracks the poll goroutine and the requests in flight so that
+	// Close can wait for them. Requests only start while the client is open.
+	running sync.WaitGroup
+
+	// closeTimeout bounds how long Close waits for running. Zero means no
+	// limit.
+	closeTimeout time.Duration
+
+	// maxStaleness, if set, is how long the cache may go without an update
+	// before mutators are rejected with ErrMetaStale. lastUpdate is when a
+	// poll last got the current data, or zero before the client is opened.
+	maxStaleness time.Duration
+	lastUpdate   time.Time
+
+	// caseInsensitiveNames must match the setting of the metaservers.
+	caseInsensitiveNames bool
+
+	// next rotates the metaserver that requests start from so that load is
+	// spread across the servers instead of always landing on the first one.
+	next uint32
+
+	// Authentication cache. It is left empty while authCacheDisabled is set.
+	authCache         map[string]authUser
+	authCacheDisabled bool
+	authCacheLimits   authCacheLimits
+
+	// execTimeout bounds each attempt to send a command; zero means no
+	// limit. A failed command is retried up to maxRetries times, waiting
+	// retryInterval after each failure.
+	execTimeout   time.Duration
+	maxRetries    int
+	retryInterval time.Duration
+
+	// readOnly rejects every command with ErrMetaReadOnly.
+	readOnly bool
+
+	// config is a copy of the last config applied with ApplyConfig, if any.
+	config *Config
+
+	// authDisabled skips password checks and grants every user all
+	// privileges. It is only set by SetAuthEnabled(false).
+	authDisabled bool
+
+	// clearPrivilegesOnAdmin makes SetAdminPrivilege remove the database
+	// privileges of a user it makes an admin.
+	clearPrivilegesOnAdmin bool
 }
 
 // NewRemoteClient returns a new *Remote
 func NewRemoteClient() *RemoteClient {
 	return &RemoteClient{
-		cacheData: &Data{},
-		logger:    zap.NewNop(),
-		authCache: make(map[string]authUser, 0),
+		cacheData:      &Data{},
+		logger:         zap.NewNop(),
+		clock:          realClock{},
+		refreshed:      make(chan struct{}),
+		tlsReloadReset: make(chan struct{}),
+		corrupt:        make(map[string]time.Time),
+		authCache:      make(map[string]authUser, 0),
+		next:           rand.Uint32(),
+
+		maxSnapshotBytes: DefaultMaxSnapshotBytes,
+		leaseMaxBackoff:  DefaultLeaseMaxBackoff,
+		leaseJitter:      DefaultLeaseJitter,
+		maxRetries:       DefaultMaxRetries,
+		retryInterval:    DefaultRetryInterval,
 	}
 }
 
@@ -65,30 +238,104 @@ func NewRemoteClient() *RemoteClient {
 func (c *RemoteClient) Open() error {
 	c.changed = make(chan struct{})
 	c.closing = make(chan struct{})
-	c.cacheData = c.retryUntilSnapshot(0)
+	data, err := c.retryUntilSnapshot(0)
+	if err != nil {
+		return err
+	} else if data != nil {
+		c.cacheData = data
+	}
 
-	go c.pollForUpdates()
+	c.running.Add(2)
+	go func() {
+		defer c.running.Done()
+		c.pollForUpdates()
+	}()
+	go func() {
+		defer c.running.Done()
+		c.reloadTLSPeriodically()
+	}()
 
 	return nil
 }
 
-// Close the meta service cluster connection.
+// Close the meta service cluster connection. It waits for the poll for
+// updates to stop and for requests in flight to finish, for at most the close
+// timeout, and returns ErrCloseTimeout if they don't. Closing a closed client
+// does nothing and returns nil.
 func (c *RemoteClient) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+
+	select {
+	case <-c.closing:
+		c.mu.Unlock()
+		return nil
+	default:
+		close(c.closing)
+	}
+	c.watchers.close()
 
 	if t, ok := http.DefaultTransport.(*http.Transport); ok {
 		t.CloseIdleConnections()
 	}
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+
+	if n := atomic.LoadInt64(&c.snapshots); n > 0 {
+		c.logger.Warn("Closing with unreleased meta snapshots", zap.Int64("snapshots", n))
+	}
+	timeout := c.closeTimeout
 
+	// The poll for updates takes the lock, so it must be released before
+	// waiting.
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+
+	if timeout == 0 {
+		<-done
+		return nil
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
 	select {
-	case <-c.closing:
+	case <-done:
 		return nil
-	default:
-		close(c.closing)
+	case <-t.C:
+		return ErrCloseTimeout
 	}
+}
 
-	return nil
+// SetCloseTimeout sets the longest time Close waits for the poll for updates
+// and requests in flight to finish. Zero, the default, waits for as long as
+// they take.
+func (c *RemoteClient) SetCloseTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	c.closeTimeout = d
+}
+
+// startRequest registers a request to the meta service that Close waits for.
+// It returns false, and registers nothing, once the client is closed.
+func (c *RemoteClient) startRequest() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.closing:
+		return false
+	default:
+	}
+	c.running.Add(1)
+	return true
 }
 
 // NodeID returns the client's node ID.
@@ -102,6 +349,13 @@ func (c *RemoteClient) ClusterID() uint64 {
 	return c.cacheData.ClusterID
 }
 
+// MetaVersion returns the format version of the meta data in the last
+// snapshot fetched from the metaservice. Data from a metaservice that
+// predates versioning is reported as version 0.
+func (c *RemoteClient) MetaVersion() (uint64, error) {
+	return c.cache().Version, nil
+}
+
 // Ping will hit the ping endpoint for the metaservice and return nil if
 // it returns 200. If checkAllMetaServers is set to true, it will hit the
 // ping endpoint and tell it to verify the health of all meta-servers in the
@@ -115,7 +369,7 @@ func (c *RemoteClient) Ping(checkAllMetaServers bool) error {
 		url = url + "?all=true"
 	}
 
-	resp, err := http.Get(url)
+	resp, err := c.do(http.MethodGet, url, "", nil)
 	if err != nil {
 		return err
 	}
@@ -123,25 +377,35 @@ func (c *RemoteClient) Ping(checkAllMetaServers bool) error {
 
 	if resp.StatusCode == http.StatusOK {
 		return nil
+	} else if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
 	}
 
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	return fmt.Errorf(string(b))
-} // AcquireLease attempts to acquire the specified lease.
+	return errors.New(responseError(resp))
+}
+
+// AcquireLease attempts to acquire the specified lease.
 // A lease is a logical concept that can be used by anything that needs to limit
 // execution to a single node.  E.g., the CQ service on all nodes may ask for
 // the "ContinuousQuery" lease. Only the node that acquires it will run CQs.
 // NOTE: Leases are not managed through the CP system and are not fully
 // consistent.  Any actions taken after acquiring a lease must be idempotent.
-func (c *RemoteClient) AcquireLease(name string) (l *Lease, err error) {
+func (c *RemoteClient) AcquireLease(name string) (*Lease, error) {
+	return c.AcquireLeaseContext(context.Background(), name)
+}
+
+// AcquireLeaseContext is like AcquireLease, but stops retrying and returns
+// ctx.Err() once ctx is done.
+func (c *RemoteClient) AcquireLeaseContext(ctx context.Context, name string) (l *Lease, err error) {
+	c.mu.RLock()
+	max, jitter := c.leaseMaxBackoff, c.leaseJitter
+	c.mu.RUnlock()
+
 	for n := 1; n < 11; n++ {
-		if l, err = c.acquireLease(name); err == ErrServiceUnavailable || err == ErrService {
-			// exponential backoff
-			d := time.Duration(math.Pow(10, float64(n))) * time.Millisecond
-			time.Sleep(d)
+		if l, err = c.acquireLease(ctx, name); err == ErrServiceUnavailable || err == ErrService {
+			if err := sleepContext(ctx, leaseBackoff(n, max, jitter, rand.Float64)); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		break
@@ -149,34 +413,45 @@ func (c *RemoteClient) AcquireLease(name string) (l *Lease, err error) {
 	return
 }
 
-func (c *RemoteClient) acquireLease(name string) (*Lease, error) {
+// leaseBackoff returns the wait before lease attempt n+1: 10^n milliseconds,
+// capped at max if max is positive, less up to jitter of it as chosen by rnd.
+func leaseBackoff(n int, max time.Duration, jitter float64, rnd func() float64) time.Duration {
+	d := time.Duration(math.Pow(10, float64(n))) * time.Millisecond
+	if max > 0 && d > max {
+		d = max
+	}
+	return d - time.Duration(jitter*rnd()*float64(d))
+}
+
+func (c *RemoteClient) acquireLease(ctx context.Context, name string) (*Lease, error) {
 	c.mu.RLock()
 	server := c.metaServers[0]
 	c.mu.RUnlock()
 	url := fmt.Sprintf("%s/lease?name=%s&nodeid=%d", c.url(server), name, c.nodeID)
 
-	resp, err := http.Get(url)
+	resp, err := c.doContext(ctx, http.MethodGet, url, "", nil)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	switch resp.StatusCode {
 	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
 	case http.StatusConflict:
 		err = errors.New("another node owns the lease")
 	case http.StatusServiceUnavailable:
 		return nil, ErrServiceUnavailable
 	case http.StatusBadRequest:
-		b, e := ioutil.ReadAll(resp.Body)
-		if e != nil {
-			return nil, e
-		}
-		return nil, fmt.Errorf("meta service: %s", string(b))
+		return nil, fmt.Errorf("meta service: %s", responseError(resp))
 	case http.StatusInternalServerError:
-		return nil, errors.New("meta service internal error")
+		return nil, fmt.Errorf("meta service internal error: %s", responseError(resp))
 	default:
-		return nil, errors.New("unrecognized meta service error")
+		return nil, fmt.Errorf("unrecognized meta service error: %s: %s", resp.Status, responseError(resp))
 	}
 
 	// Read lease JSON from response body.
@@ -193,6 +468,41 @@ func (c *RemoteClient) acquireLease(name string) (*Lease, error) {
 	return l, err
 }
 
+// Leases returns the leases held on the meta service leader, sorted by name.
+// Expired leases are included.
+func (c *RemoteClient) Leases() ([]LeaseInfo, error) {
+	c.mu.RLock()
+	if len(c.metaServers) == 0 {
+		c.mu.RUnlock()
+		return nil, ErrServiceUnavailable
+	}
+	server := c.metaServers[0]
+	c.mu.RUnlock()
+
+	// Requests to a follower are redirected to the leader.
+	resp, err := c.do(http.MethodGet, c.url(server)+"/leases", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	case http.StatusServiceUnavailable:
+		return nil, ErrServiceUnavailable
+	default:
+		return nil, fmt.Errorf("meta service returned %s: %s", resp.Status, responseError(resp))
+	}
+
+	var a []LeaseInfo
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
 // SetMetaServers updates the meta-servers on the
 func (c *RemoteClient) SetMetaServers(a []string) {
 	c.mu.Lock()
@@ -200,10 +510,461 @@ func (c *RemoteClient) SetMetaServers(a []string) {
 	c.metaServers = a
 }
 
+// RefreshMetaServers replaces the meta-servers and makes the poll for updates
+// abandon its current request and start again from the first of the new servers.
+func (c *RemoteClient) RefreshMetaServers(a []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metaServers = a
+	c.preferred = ""
+	close(c.refreshed)
+	c.refreshed = make(chan struct{})
+}
+
+// SetMaxConcurrentExec limits the number of commands sent to the meta service at
+// once; further commands wait for one in flight to finish. A value of zero or
+// less removes the limit.
+func (c *RemoteClient) SetMaxConcurrentExec(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n <= 0 {
+		c.execSem = nil
+		return
+	}
+	c.execSem = make(chan struct{}, n)
+}
+
+// SetLinearizableReads sets whether reads are served by the leader rather than
+// the local cache, which may lag behind it. Leader reads see every committed
+// change at the cost of a request per read. If the leader can't be reached,
+// reads that return an error return that one, and the others fall back to the
+// cache; ConsistentData never does.
+func (c *RemoteClient) SetLinearizableReads(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.linearizable = enabled
+}
+
+// SetWriteConsistency sets how many metaservers must apply a command before
+// it is acknowledged: WriteConsistencyLeader, WriteConsistencyQuorum or
+// WriteConsistencyAll. Commands that the leader commits but not enough
+// metaservers apply in time fail with ErrWriteNotAcknowledged.
+func (c *RemoteClient) SetWriteConsistency(level string) error {
+	if !validWriteConsistency(level) {
+		return ErrInvalidWriteConsistency
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeConsistency = level
+	return nil
+}
+
+// RejectWritesWhenStale makes commands to the meta service, such as creating
+// a shard group for a write, fail with ErrMetaStale once the last successful
+// poll for updates is more than maxStaleness ago. A node cut off from the
+// meta cluster, whether its polls fail or hang, then stops acting on cached
+// data that may conflict with changes made meanwhile. Reads still use the
+// cache. Zero, the default, never rejects.
+//
+// While it is set, polls ask the metaservers to answer within half of
+// maxStaleness even if nothing changed, so an idle cluster doesn't make the
+// cache look stale.
+func (c *RemoteClient) RejectWritesWhenStale(maxStaleness time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if maxStaleness < 0 {
+		maxStaleness = 0
+	}
+	c.maxStaleness = maxStaleness
+}
+
+// checkStale returns ErrMetaStale if writes are rejected when stale and the
+// last successful poll is longer ago than the maximum staleness.
+func (c *RemoteClient) checkStale() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.maxStaleness == 0 || c.lastUpdate.IsZero() {
+		return nil
+	}
+	if c.clock.Now().Sub(c.lastUpdate) > c.maxStaleness {
+		return ErrMetaStale
+	}
+	return nil
+}
+
+// setUpdated records that a poll just got the current data.
+func (c *RemoteClient) setUpdated() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastUpdate = c.clock.Now()
+}
+
+// SetMaxSnapshotBytes limits the size of snapshots read from the metaservers.
+// A server that sends a larger snapshot is skipped as if the snapshot were
+// corrupt. A value of zero or less removes the limit.
+func (c *RemoteClient) SetMaxSnapshotBytes(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	c.maxSnapshotBytes = n
+}
+
+// SetLeaseBackoff sets the cap on the wait between attempts to acquire a
+// lease, and the fraction of each wait, between 0 and 1, that is randomized
+// so that nodes contending for a lease don't retry in step.
+func (c *RemoteClient) SetLeaseBackoff(max time.Duration, jitter float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if jitter < 0 {
+		jitter = 0
+	} else if jitter > 1 {
+		jitter = 1
+	}
+	c.leaseMaxBackoff = max
+	c.leaseJitter = jitter
+}
+
+// SetMinPollInterval sets the least time between snapshot requests made while
+// polling for updates. A metaserver that long-polls holds each request until
+// the data changes, so no interval is needed; one that answers at once would
+// otherwise be asked again in a tight loop. Zero, the default, removes the
+// limit.
+func (c *RemoteClient) SetMinPollInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	c.minPollInterval = d
+}
+
+// ApplyConfig applies the settings of config that a remote client uses:
+// LeaseMaxBackoff, LeaseJitter, MinPollInterval, the AuthCache settings,
+// Timeout, MaxRetries, RetryInterval and ReadOnly. They take effect with the
+// next lease attempt, poll, login or command. The settings of a
+// local meta store that only apply when the client is opened are taken from
+// the first config applied; a later config that changes Dir or WALPath, or
+// that changes CaseInsensitiveNames from the client's setting, or has an
+// invalid setting, is rejected and nothing is applied.
+func (c *RemoteClient) ApplyConfig(config *Config) error {
+	if err := config.validateSettings(); err != nil {
+		return err
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" || config.TLSCAFile != "" {
+		var err error
+		if tlsConfig, err = loadTLSConfig(config.TLSCertFile, config.TLSKeyFile, config.TLSCAFile, c.clock.Now()); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config != nil {
+		switch {
+		case config.Dir != c.config.Dir:
+			return ErrConfigImmutable("dir")
+		case config.WALPath != c.config.WALPath:
+			return ErrConfigImmutable("wal-path")
+		}
+	}
+	if config.CaseInsensitiveNames != c.caseInsensitiveNames {
+		return ErrConfigImmutable("case-insensitive-names")
+	}
+
+	var prev Config
+	if c.config != nil {
+		prev = *c.config
+	}
+	applied := *config
+	c.config = &applied
+	c.leaseMaxBackoff = time.Duration(config.LeaseMaxBackoff)
+	c.leaseJitter = config.LeaseJitter
+	c.minPollInterval = time.Duration(config.MinPollInterval)
+	if config.AuthCacheDisabled && !c.authCacheDisabled {
+		c.authCache = make(map[string]authUser)
+	}
+	c.authCacheDisabled = config.AuthCacheDisabled
+	c.authCacheLimits = authCacheLimits{ttl: time.Duration(config.AuthCacheTTL), size: config.AuthCacheSize}
+	c.execTimeout = time.Duration(config.Timeout)
+	c.maxRetries = config.MaxRetries
+	c.retryInterval = time.Duration(config.RetryInterval)
+	c.readOnly = config.ReadOnly
+
+	if tlsConfig != nil {
+		c.tls = true
+		c.tlsCertFile, c.tlsKeyFile, c.tlsCAFile = config.TLSCertFile, config.TLSKeyFile, config.TLSCAFile
+		c.setTLSConfig(tlsConfig)
+	} else if c.tlsCertFile == prev.TLSCertFile && c.tlsKeyFile == prev.TLSKeyFile && c.tlsCAFile == prev.TLSCAFile &&
+		(prev.TLSCertFile != "" || prev.TLSKeyFile != "" || prev.TLSCAFile != "") {
+		// The files were removed from the config: go back to plain HTTP
+		// and the default HTTP client.
+		c.tls = false
+		c.tlsCertFile, c.tlsKeyFile, c.tlsCAFile = "", "", ""
+		c.httpClient.CloseIdleConnections()
+		c.httpClient = nil
+	}
+	if d := time.Duration(config.TLSReloadInterval); d != c.tlsReloadInterval {
+		c.tlsReloadInterval = d
+		close(c.tlsReloadReset)
+		c.tlsReloadReset = make(chan struct{})
+	}
+	return nil
+}
+
+// SetCaseInsensitiveNames makes database lookups ignore case. It must match
+// the CaseInsensitiveNames setting of the metaservers, and only takes effect
+// with the next snapshot.
+func (c *RemoteClient) SetCaseInsensitiveNames(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.caseInsensitiveNames = enabled
+}
+
+// SetPreferredServer sets the metaserver that commands are sent to first,
+// typically the current leader. The hint is replaced when another server
+// redirects the client, and dropped if the server cannot be reached.
+func (c *RemoteClient) SetPreferredServer(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.preferred = addr
+}
+
+// startServer returns the index of the metaserver a request should try first.
+// It must be called with c.mu held.
+func (c *RemoteClient) startServer() int {
+	if len(c.metaServers) == 0 {
+		return 0
+	}
+	return int(atomic.AddUint32(&c.next, 1) % uint32(len(c.metaServers)))
+}
+
+// setClock replaces the clock used for server cooldowns.
+func (c *RemoteClient) setClock(clk clock) { c.clock = clk }
+
 // SetTLS sets whether the client should use TLS when connecting.
 // This function is not safe for concurrent use.
 func (c *RemoteClient) SetTLS(v bool) { c.tls = v }
 
+// SetTLSFiles loads the client certificate and key, and the CA certificates
+// that metaservers are verified with, from PEM files and uses them for every
+// request after. Either the certificate and key or the CA file may be empty.
+// TLS itself is enabled with SetTLS.
+func (c *RemoteClient) SetTLSFiles(certFile, keyFile, caFile string) error {
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return ErrTLSNotConfigured
+	}
+	config, err := loadTLSConfig(certFile, keyFile, caFile, c.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tlsCertFile, c.tlsKeyFile, c.tlsCAFile = certFile, keyFile, caFile
+	c.setTLSConfig(config)
+	return nil
+}
+
+// ReloadTLS reads the files given to SetTLSFiles again, so that a rotated
+// certificate or CA is used without restarting. The new files are checked
+// before they replace the old ones; if they are invalid an error is returned
+// and the client keeps using the old ones. Requests in flight finish with the
+// old config.
+func (c *RemoteClient) ReloadTLS() error {
+	c.mu.RLock()
+	certFile, keyFile, caFile := c.tlsCertFile, c.tlsKeyFile, c.tlsCAFile
+	c.mu.RUnlock()
+
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return ErrTLSNotConfigured
+	}
+	config, err := loadTLSConfig(certFile, keyFile, caFile, c.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setTLSConfig(config)
+	c.logger.Info("Reloaded meta client TLS files",
+		zap.String("cert", certFile),
+		zap.String("ca", caFile))
+	return nil
+}
+
+// reloadTLSPeriodically calls ReloadTLS every TLS reload interval until the
+// client is closed. A failed reload is logged and the old files are kept.
+func (c *RemoteClient) reloadTLSPeriodically() {
+	for {
+		c.mu.RLock()
+		interval, reset, closing := c.tlsReloadInterval, c.tlsReloadReset, c.closing
+		c.mu.RUnlock()
+
+		var t *time.Timer
+		var tick <-chan time.Time
+		if interval > 0 {
+			t = time.NewTimer(interval)
+			tick = t.C
+		}
+
+		select {
+		case <-closing:
+			if t != nil {
+				t.Stop()
+			}
+			return
+		case <-reset:
+			if t != nil {
+				t.Stop()
+			}
+		case <-tick:
+			if err := c.ReloadTLS(); err != nil && err != ErrTLSNotConfigured {
+				c.logger.Warn("Failed to reload meta client TLS files", zap.Error(err))
+			}
+		}
+	}
+}
+
+// setTLSConfig replaces the HTTP client with one using config. Connections
+// made with the old config are closed once idle. It must be called with c.mu
+// held.
+func (c *RemoteClient) setTLSConfig(config *tls.Config) {
+	var tr *http.Transport
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		tr = t.Clone()
+	} else {
+		tr = &http.Transport{}
+	}
+	tr.TLSClientConfig = config
+
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+	c.httpClient = &http.Client{Transport: tr}
+}
+
+// loadTLSConfig returns a TLS config with the certificate and key, and the
+// CA certificates, read from PEM files. It returns an error if a file can't
+// be read, the certificate doesn't match the key or has expired by now, or the
+// CA file has no certificates.
+func loadTLSConfig(certFile, keyFile, caFile string, now time.Time) (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS certificate: %s", err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("parse TLS certificate: %s", err)
+		} else if now.After(leaf.NotAfter) {
+			return nil, fmt.Errorf("TLS certificate %s expired at %s", certFile, leaf.NotAfter)
+		}
+		cert.Leaf = leaf
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		b, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read TLS CA file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// SetAuthToken sets the bearer token sent to the meta service on every request.
+// An empty token disables the Authorization header.
+func (c *RemoteClient) SetAuthToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authToken = token
+}
+
+// do sends an HTTP request to a metaserver, attaching the auth token if one is set.
+func (c *RemoteClient) do(method, url, contentType string, body io.Reader) (*http.Response, error) {
+	return c.doContext(context.Background(), method, url, contentType, body)
+}
+
+// doContext is like do, but the request is aborted when ctx is done.
+func (c *RemoteClient) doContext(ctx context.Context, method, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.send(req)
+}
+
+// getCompressed is like doContext for a GET of url, but asks the server to
+// gzip the response and returns it with the body decompressed.
+func (c *RemoteClient) getCompressed(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Setting the header ourselves stops the transport from decompressing,
+	// so the body is decoded below.
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, errCorruptSnapshot{err: err}
+		}
+		resp.Body = gzipReadCloser{Reader: gz, body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+	}
+	return resp, nil
+}
+
+// send adds the auth token to req and sends it.
+func (c *RemoteClient) send(req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	token := c.authToken
+	client := c.httpClient
+	c.mu.RUnlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// gzipReadCloser reads a decompressed response body and closes the
+// underlying body.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (r gzipReadCloser) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
+
 // joinMetaServer will add the passed in tcpAddr to the raft peers and add a MetaNode to
 // the metastore
 func (c *RemoteClient) joinMetaServer(httpAddr, tcpAddr string) (*NodeInfo, error) {
@@ -239,7 +1000,7 @@ func (c *RemoteClient) joinMetaServer(httpAddr, tcpAddr string) (*NodeInfo, erro
 			url = c.url(server) + "/add-meta"
 		}
 
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(b))
+		resp, err := c.do(http.MethodPost, url, "application/json", bytes.NewBuffer(b))
 		if err != nil {
 			//  TODO print error
 			currentServer++
@@ -256,6 +1017,10 @@ func (c *RemoteClient) joinMetaServer(httpAddr, tcpAddr string) (*NodeInfo, erro
 		}
 		resp.Body.Close()
 
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrUnauthorized
+		}
+
 		// We tried to join a meta node that was not the leader, rety at the node
 		// they think is the leader.
 		if resp.StatusCode == http.StatusTemporaryRedirect {
@@ -272,7 +1037,11 @@ func (c *RemoteClient) joinMetaServer(httpAddr, tcpAddr string) (*NodeInfo, erro
 
 // DataNode returns a node by id.
 func (c *RemoteClient) DataNode(id uint64) (*NodeInfo, error) {
-	for _, n := range c.data().DataNodes {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range data.DataNodes {
 		if n.ID == id {
 			return &n, nil
 		}
@@ -282,7 +1051,16 @@ func (c *RemoteClient) DataNode(id uint64) (*NodeInfo, error) {
 
 // DataNodes returns the data nodes' info.
 func (c *RemoteClient) DataNodes() ([]NodeInfo, error) {
-	return c.data().DataNodes, nil
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.DataNodes, nil
+}
+
+// DataNodeLoad returns the number of live shards owned by each data node.
+func (c *RemoteClient) DataNodeLoad() map[uint64]int {
+	return c.data().DataNodeLoad()
 }
 
 // CreateDataNode will create a new data node in the metastore
@@ -308,7 +1086,10 @@ func (c *RemoteClient) CreateDataNode(httpAddr, tcpAddr string) (*NodeInfo, erro
 
 // DataNodeByHTTPHost returns the data node with the give http bind address
 func (c *RemoteClient) DataNodeByHTTPHost(httpAddr string) (*NodeInfo, error) {
-	nodes, _ := c.DataNodes()
+	nodes, err := c.DataNodes()
+	if err != nil {
+		return nil, err
+	}
 	for _, n := range nodes {
 		if n.Host == httpAddr {
 			return &n, nil
@@ -320,7 +1101,10 @@ func (c *RemoteClient) DataNodeByHTTPHost(httpAddr string) (*NodeInfo, error) {
 
 // DataNodeByTCPHost returns the data node with the give http bind address
 func (c *RemoteClient) DataNodeByTCPHost(tcpAddr string) (*NodeInfo, error) {
-	nodes, _ := c.DataNodes()
+	nodes, err := c.DataNodes()
+	if err != nil {
+		return nil, err
+	}
 	for _, n := range nodes {
 		if n.TCPHost == tcpAddr {
 			return &n, nil
@@ -339,9 +1123,46 @@ func (c *RemoteClient) DeleteDataNode(id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteDataNodeCommand, internal.E_DeleteDataNodeCommand_Command, cmd)
 }
 
+// HeartbeatDataNode records that the data node is alive as of now.
+func (c *RemoteClient) HeartbeatDataNode(id uint64) error {
+	cmd := &internal.HeartbeatDataNodeCommand{
+		ID:   proto.Uint64(id),
+		Time: proto.Int64(c.clock.Now().UnixNano()),
+	}
+
+	return c.retryUntilExec(internal.Command_HeartbeatDataNodeCommand, internal.E_HeartbeatDataNodeCommand_Command, cmd)
+}
+
+// DeadDataNodes returns the data nodes that have not sent a heartbeat within
+// threshold, including those that never have.
+func (c *RemoteClient) DeadDataNodes(threshold time.Duration) []NodeInfo {
+	return c.data().DeadDataNodes(c.clock.Now(), threshold)
+}
+
+// SetDataNodeDraining sets whether the data node is draining. New shard groups
+// place no shards on a draining node, so its shards can be moved off before
+// it is deleted.
+func (c *RemoteClient) SetDataNodeDraining(id uint64, draining bool) error {
+	cmd := &internal.SetDataNodeDrainingCommand{
+		ID:       proto.Uint64(id),
+		Draining: proto.Bool(draining),
+	}
+
+	return c.retryUntilExec(internal.Command_SetDataNodeDrainingCommand, internal.E_SetDataNodeDrainingCommand_Command, cmd)
+}
+
+// DrainingDataNodes returns the data nodes that are draining.
+func (c *RemoteClient) DrainingDataNodes() []NodeInfo {
+	return c.data().DrainingDataNodes()
+}
+
 // MetaNodes returns the meta nodes' info.
 func (c *RemoteClient) MetaNodes() ([]NodeInfo, error) {
-	return c.data().MetaNodes, nil
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.MetaNodes, nil
 }
 
 // MetaNodeByAddr returns the meta node's info.
@@ -383,16 +1204,55 @@ func (c *RemoteClient) DeleteMetaNode(id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteMetaNodeCommand, internal.E_DeleteMetaNodeCommand_Command, cmd)
 }
 
-func (c *RemoteClient) data() *Data {
+// cache returns the cached data. Commands check and look up what they
+// changed in it, not in the leader's data, as the cache has caught up with a
+// command by the time it returns.
+func (c *RemoteClient) cache() *Data {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.cacheData
 }
 
+// readData returns the data that reads are served from: the leader's if
+// linearizable reads are enabled, or else the cache.
+func (c *RemoteClient) readData() (*Data, error) {
+	c.mu.RLock()
+	data, linearizable := c.cacheData, c.linearizable
+	c.mu.RUnlock()
+	if !linearizable {
+		return data, nil
+	}
+	return c.leaderData()
+}
+
+// data is readData for the reads that can't return an error. If the leader
+// can't be reached they are served from the cache.
+func (c *RemoteClient) data() *Data {
+	data, err := c.readData()
+	if err != nil {
+		c.logger.Warn("Failed to read from the meta leader, using cached data", zap.Error(err))
+		return c.cache()
+	}
+	return data
+}
+
+// ConsistentData returns the leader's data, which holds every committed
+// change, whether or not linearizable reads are enabled. Unlike reads that
+// can't return an error, it fails if the leader can't be reached rather than
+// returning cached data.
+func (c *RemoteClient) ConsistentData() (*Data, error) {
+	return c.leaderData()
+}
+
 // Database returns info for the requested database.
 func (c *RemoteClient) Database(name string) *DatabaseInfo {
-	for _, d := range c.data().Databases {
-		if d.Name == name {
+	return databaseInfo(c.data(), name)
+}
+
+// databaseInfo returns a copy of the info of the named database in data.
+func databaseInfo(data *Data, name string) *DatabaseInfo {
+	for _, d := range data.Databases {
+		if data.sameDatabaseName(d.Name, name) {
 			return &d
 		}
 	}
@@ -411,9 +1271,14 @@ func (c *RemoteClient) Databases() []DatabaseInfo {
 	return dbs
 }
 
+// AllRetentionPolicies returns the retention policies of all databases.
+func (c *RemoteClient) AllRetentionPolicies() []RetentionPolicyRef {
+	return c.data().AllRetentionPolicies()
+}
+
 // CreateDatabase creates a database or returns it if it already exists
 func (c *RemoteClient) CreateDatabase(name string) (*DatabaseInfo, error) {
-	if db := c.Database(name); db != nil {
+	if db := databaseInfo(c.cache(), name); db != nil {
 		return db, nil
 	}
 
@@ -426,7 +1291,34 @@ func (c *RemoteClient) CreateDatabase(name string) (*DatabaseInfo, error) {
 		return nil, err
 	}
 
-	if db := c.Database(name); db == nil {
+	if db := databaseInfo(c.cache(), name); db == nil {
+		return nil, ErrDatabaseNotExists
+	} else {
+		return db, nil
+	}
+}
+
+// CreateDatabaseStrict creates a database, returning ErrDatabaseExists if it
+// already exists. The metaservice checks again when applying the command, so
+// only one of several callers creating the same database succeeds.
+func (c *RemoteClient) CreateDatabaseStrict(name string) (*DatabaseInfo, error) {
+	if databaseInfo(c.cache(), name) != nil {
+		return nil, ErrDatabaseExists
+	}
+
+	cmd := &internal.CreateDatabaseCommand{
+		Name:   proto.String(name),
+		Strict: proto.Bool(true),
+	}
+
+	err := c.retryUntilExec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, cmd)
+	if e, ok := err.(errCommand); ok && e.msg == ErrDatabaseExists.Error() {
+		return nil, ErrDatabaseExists
+	} else if err != nil {
+		return nil, err
+	}
+
+	if db := databaseInfo(c.cache(), name); db == nil {
 		return nil, ErrDatabaseNotExists
 	} else {
 		return db, nil
@@ -443,11 +1335,11 @@ func (c *RemoteClient) CreateDatabaseWithRetentionPolicy(name string, spec *Rete
 		return nil, ErrRetentionPolicyDurationTooLow
 	}
 
-	if db := c.Database(name); db != nil {
+	if db := databaseInfo(c.cache(), name); db != nil {
 		// Check if the retention policy already exists. If it does and matches
 		// the desired retention policy, exit with no error.
 		if rp := db.RetentionPolicy(spec.Name); rp != nil {
-			if rp.ReplicaN != *spec.ReplicaN || rp.Duration != *spec.Duration {
+			if rp.ReplicaN != *spec.ReplicaN || rp.Duration != *spec.Duration || !spec.matchesTenancy(rp) {
 				return nil, ErrRetentionPolicyConflict
 			}
 			return db, nil
@@ -456,7 +1348,7 @@ func (c *RemoteClient) CreateDatabaseWithRetentionPolicy(name string, spec *Rete
 
 	cmd := &internal.CreateDatabaseCommand{
 		Name:            proto.String(name),
-		RetentionPolicy: spec.NewRetentionPolicyInfo().marshal(),
+		RetentionPolicy: spec.commandRetentionPolicyInfo().marshal(),
 	}
 
 	err := c.retryUntilExec(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, cmd)
@@ -464,25 +1356,79 @@ func (c *RemoteClient) CreateDatabaseWithRetentionPolicy(name string, spec *Rete
 		return nil, err
 	}
 
-	if db := c.Database(name); db == nil {
+	if db := databaseInfo(c.cache(), name); db == nil {
 		return nil, ErrDatabaseNotExists
 	} else {
 		return db, nil
 	}
 }
 
-// DropDatabase deletes a database.
+// DropDatabase deletes a database. It returns ErrDatabaseHasSubscriptions if
+// the database has subscriptions, so that they aren't removed unnoticed.
 func (c *RemoteClient) DropDatabase(name string) error {
+	return c.dropDatabase(name, false)
+}
+
+// DropDatabaseForce deletes a database along with its subscriptions.
+func (c *RemoteClient) DropDatabaseForce(name string) error {
+	return c.dropDatabase(name, true)
+}
+
+func (c *RemoteClient) dropDatabase(name string, force bool) error {
 	cmd := &internal.DropDatabaseCommand{
-		Name: proto.String(name),
+		Name:  proto.String(name),
+		Force: proto.Bool(force),
+	}
+
+	err := c.retryUntilExec(internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command, cmd)
+	if _, ok := err.(errCommand); ok && !force {
+		// Only the message survives the trip from the server, so rebuild
+		// the guard's error from the leader's data if it is the one.
+		if data, derr := c.leaderData(); derr == nil {
+			if di := databaseInfo(data, name); di != nil {
+				if e, ok := di.CheckDrop().(ErrDatabaseHasSubscriptions); ok && e.Error() == err.Error() {
+					return e
+				}
+			}
+		}
+	}
+	return err
+}
+
+// DropDatabaseWithReport deletes a database and returns what was removed with it.
+// The report is taken from the leader's data right before the command is sent,
+// so that it includes what was created since the cache was last updated. Like
+// DropDatabase, it returns ErrDatabaseHasSubscriptions if the database has
+// subscriptions.
+func (c *RemoteClient) DropDatabaseWithReport(name string) (DropReport, error) {
+	return c.dropDatabaseWithReport(name, false)
+}
+
+// DropDatabaseWithReportForce is like DropDatabaseWithReport but drops the
+// database along with its subscriptions, which the report lists.
+func (c *RemoteClient) DropDatabaseWithReportForce(name string) (DropReport, error) {
+	return c.dropDatabaseWithReport(name, true)
+}
+
+func (c *RemoteClient) dropDatabaseWithReport(name string, force bool) (DropReport, error) {
+	data, err := c.leaderData()
+	if err != nil {
+		return DropReport{}, err
+	}
+	report := DropReport{Database: name}
+	if di := databaseInfo(data, name); di != nil {
+		report = di.dropReport()
 	}
 
-	return c.retryUntilExec(internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command, cmd)
+	if err := c.dropDatabase(name, force); err != nil {
+		return DropReport{}, err
+	}
+	return report, nil
 }
 
 // CreateRetentionPolicy creates a retention policy on the specified database.
 func (c *RemoteClient) CreateRetentionPolicy(database string, spec *RetentionPolicySpec, makeDefault bool) (*RetentionPolicyInfo, error) {
-	if rp, _ := c.RetentionPolicy(database, spec.Name); rp != nil {
+	if rp, _ := retentionPolicyInfo(c.cache(), database, spec.Name); rp != nil {
 		return rp, nil
 	}
 
@@ -492,7 +1438,7 @@ func (c *RemoteClient) CreateRetentionPolicy(database string, spec *RetentionPol
 
 	cmd := &internal.CreateRetentionPolicyCommand{
 		Database:        proto.String(database),
-		RetentionPolicy: spec.NewRetentionPolicyInfo().marshal(),
+		RetentionPolicy: spec.commandRetentionPolicyInfo().marshal(),
 		Default:         proto.Bool(makeDefault),
 	}
 
@@ -500,17 +1446,50 @@ func (c *RemoteClient) CreateRetentionPolicy(database string, spec *RetentionPol
 		return nil, err
 	}
 
-	return c.RetentionPolicy(database, spec.Name)
+	return retentionPolicyInfo(c.cache(), database, spec.Name)
 }
 
 // RetentionPolicy returns the requested retention policy info.
 func (c *RemoteClient) RetentionPolicy(database, name string) (rpi *RetentionPolicyInfo, err error) {
-	db := c.Database(database)
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return retentionPolicyInfo(data, database, name)
+}
+
+// retentionPolicyInfo returns the named retention policy of a database in
+// data, or nil if the database has none by that name.
+func retentionPolicyInfo(data *Data, database, name string) (*RetentionPolicyInfo, error) {
+	db := databaseInfo(data, database)
 	if db == nil {
 		return nil, cnosdb.ErrDatabaseNotFound(database)
 	}
 
-	return db.RetentionPolicy(name), nil
+	return db.RetentionPolicy(name), nil
+}
+
+// DefaultRetentionPolicy returns the name of the default retention policy for a database.
+func (c *RemoteClient) DefaultRetentionPolicy(database string) (string, error) {
+	data, err := c.readData()
+	if err != nil {
+		return "", err
+	}
+	db := data.Database(database)
+	if db == nil {
+		return "", cnosdb.ErrDatabaseNotFound(database)
+	}
+
+	return db.DefaultRetentionPolicy, nil
+}
+
+// RetentionPolicyUsage returns shard group and shard counts and the time span of a retention policy.
+func (c *RemoteClient) RetentionPolicyUsage(database, rp string) (RPUsage, error) {
+	data, err := c.readData()
+	if err != nil {
+		return RPUsage{}, err
+	}
+	return data.RetentionPolicyUsage(database, rp)
 }
 
 // DropRetentionPolicy drops a retention policy from a database.
@@ -523,6 +1502,17 @@ func (c *RemoteClient) DropRetentionPolicy(database, name string) error {
 	return c.retryUntilExec(internal.Command_DropRetentionPolicyCommand, internal.E_DropRetentionPolicyCommand_Command, cmd)
 }
 
+// DropRetentionPolicyWithReport returns the shard groups, shards and
+// subscriptions that dropping a retention policy would remove, and the time
+// span of the data they hold. It does not drop anything.
+func (c *RemoteClient) DropRetentionPolicyWithReport(database, name string) (DropReport, error) {
+	data, err := c.readData()
+	if err != nil {
+		return DropReport{}, err
+	}
+	return data.dropRetentionPolicyReport(database, name)
+}
+
 // SetDefaultRetentionPolicy sets a database's default retention policy.
 func (c *RemoteClient) SetDefaultRetentionPolicy(database, name string) error {
 	cmd := &internal.SetDefaultRetentionPolicyCommand{
@@ -533,8 +1523,82 @@ func (c *RemoteClient) SetDefaultRetentionPolicy(database, name string) error {
 	return c.retryUntilExec(internal.Command_SetDefaultRetentionPolicyCommand, internal.E_SetDefaultRetentionPolicyCommand_Command, cmd)
 }
 
+// RepairDefaults replaces every default retention policy that names a policy
+// which no longer exists. See Data.RepairDefaultRetentionPolicy.
+func (c *RemoteClient) RepairDefaults() error {
+	return c.retryUntilExec(internal.Command_RepairDefaultsCommand, internal.E_RepairDefaultsCommand_Command, &internal.RepairDefaultsCommand{})
+}
+
+// FreezeRetentionPolicy sets whether new shard groups can be created in a
+// retention policy. Creating one in a frozen policy fails with
+// ErrRetentionPolicyFrozen, and none are precreated for it.
+func (c *RemoteClient) FreezeRetentionPolicy(database, rp string, frozen bool) error {
+	cmd := &internal.FreezeRetentionPolicyCommand{
+		Database:        proto.String(database),
+		RetentionPolicy: proto.String(rp),
+		Frozen:          proto.Bool(frozen),
+	}
+
+	return c.retryUntilExec(internal.Command_FreezeRetentionPolicyCommand, internal.E_FreezeRetentionPolicyCommand_Command, cmd)
+}
+
+// SetMeasurementRetention overrides the retention duration of a measurement
+// within a retention policy. A zero duration clears the override.
+func (c *RemoteClient) SetMeasurementRetention(database, rp, measurement string, d time.Duration) error {
+	cmd := &internal.SetMeasurementRetentionCommand{
+		Database:        proto.String(database),
+		RetentionPolicy: proto.String(rp),
+		Measurement:     proto.String(measurement),
+		Duration:        proto.Int64(int64(d)),
+	}
+
+	return c.retryUntilExec(internal.Command_SetMeasurementRetentionCommand, internal.E_SetMeasurementRetentionCommand_Command, cmd)
+}
+
+// SetDatabaseQuota sets the limits on the retention policies and shard groups
+// of a database. A zero limit means unlimited.
+func (c *RemoteClient) SetDatabaseQuota(database string, q DatabaseQuota) error {
+	if q.MaxRetentionPolicies < 0 || q.MaxShardGroups < 0 {
+		return ErrInvalidQuota
+	}
+
+	cmd := &internal.SetDatabaseQuotaCommand{
+		Database: proto.String(database),
+		Quota:    q.marshal(),
+	}
+
+	return c.retryUntilExec(internal.Command_SetDatabaseQuotaCommand, internal.E_SetDatabaseQuotaCommand_Command, cmd)
+}
+
+// SetDatabaseDefaultShardGroupDuration sets the shard group duration that
+// retention policies created on a database without one get. A zero duration
+// removes the default.
+func (c *RemoteClient) SetDatabaseDefaultShardGroupDuration(database string, d time.Duration) error {
+	if d < 0 {
+		return ErrShardGroupDurationInvalid
+	}
+
+	cmd := &internal.SetDatabaseDefaultShardGroupDurationCommand{
+		Database: proto.String(database),
+		Duration: proto.Int64(int64(d)),
+	}
+
+	return c.retryUntilExec(internal.Command_SetDatabaseDefaultShardGroupDurationCommand, internal.E_SetDatabaseDefaultShardGroupDurationCommand_Command, cmd)
+}
+
 // UpdateRetentionPolicy updates a retention policy.
 func (c *RemoteClient) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error {
+	cmd, err := newUpdateRetentionPolicyCommand(database, name, rpu, makeDefault)
+	if err != nil {
+		return err
+	}
+
+	return c.retryUntilExec(internal.Command_UpdateRetentionPolicyCommand, internal.E_UpdateRetentionPolicyCommand_Command, cmd)
+}
+
+// newUpdateRetentionPolicyCommand returns the command that applies rpu to
+// the retention policy name.
+func newUpdateRetentionPolicyCommand(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) (*internal.UpdateRetentionPolicyCommand, error) {
 	var newName *string
 	if rpu.Name != nil {
 		newName = rpu.Name
@@ -552,16 +1616,43 @@ func (c *RemoteClient) UpdateRetentionPolicy(database, name string, rpu *Retenti
 		replicaN = &value
 	}
 
-	cmd := &internal.UpdateRetentionPolicyCommand{
-		Database: proto.String(database),
-		Name:     proto.String(name),
-		NewName:  newName,
-		Duration: duration,
-		ReplicaN: replicaN,
-		Default:  proto.Bool(makeDefault),
+	var shardsPerGroup *uint32
+	if rpu.ShardsPerGroup != nil {
+		if *rpu.ShardsPerGroup < 1 {
+			return nil, ErrInvalidShardsPerGroup
+		}
+		value := uint32(*rpu.ShardsPerGroup)
+		shardsPerGroup = &value
+	}
+
+	return &internal.UpdateRetentionPolicyCommand{
+		Database:       proto.String(database),
+		Name:           proto.String(name),
+		NewName:        newName,
+		Duration:       duration,
+		ReplicaN:       replicaN,
+		Default:        proto.Bool(makeDefault),
+		ShardsPerGroup: shardsPerGroup,
+	}, nil
+}
+
+// CopyRetentionPolicy creates a retention policy named dstName with the
+// settings of srcName, without its shard groups or subscriptions.
+func (c *RemoteClient) CopyRetentionPolicy(database, srcName, dstName string) (*RetentionPolicyInfo, error) {
+	cmd := &internal.CopyRetentionPolicyCommand{
+		Database:    proto.String(database),
+		Source:      proto.String(srcName),
+		Destination: proto.String(dstName),
+	}
+
+	err := c.retryUntilExec(internal.Command_CopyRetentionPolicyCommand, internal.E_CopyRetentionPolicyCommand_Command, cmd)
+	if e, ok := err.(errCommand); ok && e.msg == ErrRetentionPolicyNameExists.Error() {
+		return nil, ErrRetentionPolicyNameExists
+	} else if err != nil {
+		return nil, err
 	}
 
-	return c.retryUntilExec(internal.Command_UpdateRetentionPolicyCommand, internal.E_UpdateRetentionPolicyCommand_Command, cmd)
+	return retentionPolicyInfo(c.cache(), database, dstName)
 }
 
 func (c *RemoteClient) Users() []UserInfo {
@@ -573,12 +1664,34 @@ func (c *RemoteClient) Users() []UserInfo {
 	return users
 }
 
+// ForEachUser calls fn with the name and admin flag of each user, in order,
+// until fn returns false. Unlike Users it copies nothing and never exposes
+// password hashes. The users are read from one version of the meta data,
+// which later updates don't change.
+func (c *RemoteClient) ForEachUser(fn func(name string, admin bool) bool) {
+	users := c.data().Users
+	for i := range users {
+		if !fn(users[i].Name, users[i].Admin) {
+			return
+		}
+	}
+}
+
 func (c *RemoteClient) UserCount() int {
 	return len(c.data().Users)
 }
 
 func (c *RemoteClient) User(name string) (User, error) {
-	for _, u := range c.data().Users {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return userInfo(data, name)
+}
+
+// userInfo returns a copy of the named user in data.
+func userInfo(data *Data, name string) (User, error) {
+	for _, u := range data.Users {
 		if u.Name == name {
 			return &u, nil
 		}
@@ -631,7 +1744,33 @@ func (c *RemoteClient) CreateUser(name, password string, admin bool) (User, erro
 	); err != nil {
 		return nil, err
 	}
-	return c.User(name)
+	return userInfo(c.cache(), name)
+}
+
+// CreateUserWithHash adds a user with an already bcrypt-hashed password.
+func (c *RemoteClient) CreateUserWithHash(name, hash string, admin bool) (User, error) {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return nil, ErrInvalidPasswordHash
+	}
+
+	// See if the user already exists.
+	if u := c.cache().user(name); u != nil {
+		if u.Hash != hash || u.Admin != admin {
+			return nil, ErrUserExists
+		}
+		return u, nil
+	}
+
+	if err := c.retryUntilExec(internal.Command_CreateUserCommand, internal.E_CreateUserCommand_Command,
+		&internal.CreateUserCommand{
+			Name:  proto.String(name),
+			Hash:  proto.String(hash),
+			Admin: proto.Bool(admin),
+		},
+	); err != nil {
+		return nil, err
+	}
+	return userInfo(c.cache(), name)
 }
 
 func (c *RemoteClient) UpdateUser(name, password string) error {
@@ -668,30 +1807,92 @@ func (c *RemoteClient) SetPrivilege(username, database string, p cnosql.Privileg
 }
 
 func (c *RemoteClient) SetAdminPrivilege(username string, admin bool) error {
-	return c.retryUntilExec(internal.Command_SetAdminPrivilegeCommand, internal.E_SetAdminPrivilegeCommand_Command,
-		&internal.SetAdminPrivilegeCommand{
-			Username: proto.String(username),
-			Admin:    proto.Bool(admin),
-		},
-	)
+	c.mu.RLock()
+	clear := admin && c.clearPrivilegesOnAdmin
+	c.mu.RUnlock()
+
+	cmd := &internal.SetAdminPrivilegeCommand{
+		Username: proto.String(username),
+		Admin:    proto.Bool(admin),
+	}
+	if clear {
+		// The metaservice drops the grants in the same log entry, so the
+		// user is never seen as an admin that still has them.
+		cmd.ClearPrivileges = proto.Bool(true)
+	}
+	return c.retryUntilExec(internal.Command_SetAdminPrivilegeCommand, internal.E_SetAdminPrivilegeCommand_Command, cmd)
 }
 
 func (c *RemoteClient) UserPrivileges(username string) (map[string]cnosql.Privilege, error) {
-	p, err := c.data().UserPrivileges(username)
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	p, err := data.UserPrivileges(username)
 	if err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
+// UserAuthz returns whether the user is an admin and its privileges in one
+// read, for authorization decisions. Every user is an admin when
+// authentication is disabled.
+func (c *RemoteClient) UserAuthz(username string) (UserAuthz, error) {
+	c.mu.RLock()
+	disabled := c.authDisabled
+	c.mu.RUnlock()
+	if disabled {
+		return UserAuthz{Admin: true}, nil
+	}
+
+	data, err := c.readData()
+	if err != nil {
+		return UserAuthz{}, err
+	}
+	return data.UserAuthz(username)
+}
+
 func (c *RemoteClient) UserPrivilege(username, database string) (*cnosql.Privilege, error) {
-	p, err := c.data().UserPrivilege(username, database)
+	c.mu.RLock()
+	disabled := c.authDisabled
+	c.mu.RUnlock()
+	if disabled {
+		p := cnosql.AllPrivileges
+		return &p, nil
+	}
+
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	p, err := data.UserPrivilege(username, database)
 	if err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
+// EffectivePrivilege returns the privilege the user has on the given database,
+// which is AllPrivileges for an admin user.
+func (c *RemoteClient) EffectivePrivilege(username, database string) (cnosql.Privilege, error) {
+	data, err := c.readData()
+	if err != nil {
+		return 0, err
+	}
+	return data.EffectivePrivilege(username, database)
+}
+
+// AccessibleDatabases returns the sorted names of the databases the user has
+// any privilege on, which is every database for an admin user.
+func (c *RemoteClient) AccessibleDatabases(username string) ([]string, error) {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.AccessibleDatabases(username)
+}
+
 func (c *RemoteClient) AdminUserExists() bool {
 	for _, u := range c.data().Users {
 		if u.Admin {
@@ -705,16 +1906,29 @@ func (c *RemoteClient) Authenticate(username, password string) (User, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.authDisabled {
+		return authDisabledUser(username), nil
+	}
+
 	// Find user.
 	userInfo := c.cacheData.user(username)
 	if userInfo == nil {
 		return nil, ErrUserNotFound
 	}
 
+	// Without the cache, every password is checked against its hash.
+	if c.authCacheDisabled {
+		if err := compareHashAndPassword([]byte(userInfo.Hash), []byte(password)); err != nil {
+			return nil, ErrAuthenticate
+		}
+		return userInfo, nil
+	}
+
 	// Check the local auth cache first.
-	if au, ok := c.authCache[username]; ok {
-		// verify the password using the cached salt and hash
-		if bytes.Equal(c.hashWithSalt(au.salt, password), au.hash) {
+	if au, ok := c.authCacheLimits.lookup(c.authCache, username, c.clock.Now()); ok {
+		// verify the password using the cached salt and hash, unless the
+		// password has changed since the entry was cached
+		if au.bhash == userInfo.Hash && bytes.Equal(c.hashWithSalt(au.salt, password), au.hash) {
 			return userInfo, nil
 		}
 
@@ -722,7 +1936,7 @@ func (c *RemoteClient) Authenticate(username, password string) (User, error) {
 	}
 
 	// Compare password with user hash.
-	if err := bcrypt.CompareHashAndPassword([]byte(userInfo.Hash), []byte(password)); err != nil {
+	if err := compareHashAndPassword([]byte(userInfo.Hash), []byte(password)); err != nil {
 		return nil, ErrAuthenticate
 	}
 
@@ -731,11 +1945,36 @@ func (c *RemoteClient) Authenticate(username, password string) (User, error) {
 	if err != nil {
 		return nil, err
 	}
-	c.authCache[username] = authUser{salt: salt, hash: hashed, bhash: userInfo.Hash}
+	c.authCacheLimits.add(c.authCache, username, authUser{salt: salt, hash: hashed, bhash: userInfo.Hash, cached: c.clock.Now()})
 
 	return userInfo, nil
 }
 
+// AuthenticateBatch authenticates each of creds as Authenticate does,
+// returning the results in the same order. Repeated credentials are checked
+// once, so a burst of logins by the same user costs a single bcrypt compare.
+func (c *RemoteClient) AuthenticateBatch(creds []Credential) []AuthResult {
+	return authenticateBatch(creds, c.Authenticate)
+}
+
+// InvalidateAuthCache drops the cached credentials of username, so the next
+// Authenticate for that user checks the password against its bcrypt hash.
+// Use it to apply a change made on another node before the next poll.
+func (c *RemoteClient) InvalidateAuthCache(username string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.authCache, username)
+}
+
+// InvalidateAllAuthCache drops the cached credentials of every user.
+func (c *RemoteClient) InvalidateAllAuthCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.authCache = make(map[string]authUser)
+}
+
 // ShardIDs returns a list of all shard ids.
 func (c *RemoteClient) ShardIDs() []uint64 {
 	var a []uint64
@@ -752,11 +1991,109 @@ func (c *RemoteClient) ShardIDs() []uint64 {
 	return a
 }
 
+// ShardIDsPage returns up to limit shard ids greater than afterID in ascending
+// order, and the cursor for the next page, which is zero on the last page.
+// The page is fetched from the meta service; if it doesn't support paging,
+// the page is taken from the cached data.
+func (c *RemoteClient) ShardIDsPage(afterID uint64, limit int) ([]uint64, uint64, error) {
+	if limit <= 0 {
+		return nil, 0, ErrInvalidPageLimit
+	}
+
+	c.mu.RLock()
+	if len(c.metaServers) == 0 {
+		c.mu.RUnlock()
+		return nil, 0, ErrServiceUnavailable
+	}
+	server := c.metaServers[c.startServer()]
+	c.mu.RUnlock()
+
+	u := fmt.Sprintf("%s/shard-ids?after=%d&limit=%d", c.url(server), afterID, limit)
+	resp, err := c.do(http.MethodGet, u, "", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, 0, ErrUnauthorized
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		data, err := c.readData()
+		if err != nil {
+			return nil, 0, err
+		}
+		return data.ShardIDsPage(afterID, limit)
+	default:
+		return nil, 0, fmt.Errorf("meta service returned %s: %s", resp.Status, responseError(resp))
+	}
+
+	var page shardIDsPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, 0, err
+	}
+	return page.IDs, page.Next, nil
+}
+
+// ShardGroupForTimestamp returns a copy of the shard group that contains t,
+// or ErrShardGroupNotFound if the group has not been created yet.
+func (c *RemoteClient) ShardGroupForTimestamp(database, rp string, t time.Time) (*ShardGroupInfo, error) {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.shardGroupForTimestamp(database, rp, t)
+}
+
+// ShardGroupBoundaries returns the time boundaries of every shard group on a
+// database and retention policy, sorted by start time. Deleted groups are
+// included and flagged.
+func (c *RemoteClient) ShardGroupBoundaries(database, rp string) ([]ShardGroupBoundary, error) {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.ShardGroupBoundaries(database, rp)
+}
+
+// ShardGroupTimeline returns the creation and deletion times of every shard
+// group on a database and retention policy, sorted by creation time.
+func (c *RemoteClient) ShardGroupTimeline(database, rp string) []ShardGroupTimelineEntry {
+	return c.data().ShardGroupTimeline(database, rp)
+}
+
+// ShardGroupBoundaryFor returns the time range of the shard group that would
+// be created for t, without creating it.
+func (c *RemoteClient) ShardGroupBoundaryFor(database, rp string, t time.Time) (start, end time.Time, err error) {
+	data, err := c.readData()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return data.ShardGroupBoundaryFor(database, rp, t)
+}
+
+// AllShardGroupsByTimeRange returns the live shard groups of every database
+// and retention policy that may contain data for the time range, sorted by
+// start time.
+func (c *RemoteClient) AllShardGroupsByTimeRange(min, max time.Time) ([]ShardGroupRef, error) {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.AllShardGroupsByTimeRange(min, max)
+}
+
 // ShardGroupsByTimeRange returns a list of all shard groups on a database and retention policy that may contain data
 // for the specified time range. ShardGroups are sorted by start time.
 func (c *RemoteClient) ShardGroupsByTimeRange(database, rp string, min, max time.Time) (a []ShardGroupInfo, err error) {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find retention policy.
-	rpi, err := c.data().RetentionPolicy(database, rp)
+	rpi, err := data.RetentionPolicy(database, rp)
 	if err != nil {
 		return nil, err
 	} else if rpi == nil {
@@ -772,6 +2109,17 @@ func (c *RemoteClient) ShardGroupsByTimeRange(database, rp string, min, max time
 	return groups, nil
 }
 
+// HealthyShardGroupsByTimeRange returns the shard groups on a database and
+// retention policy that may contain data for the time range, leaving out
+// groups whose owners have all missed heartbeats for longer than threshold.
+func (c *RemoteClient) HealthyShardGroupsByTimeRange(database, rp string, min, max time.Time, threshold time.Duration) ([]ShardGroupInfo, error) {
+	data, err := c.readData()
+	if err != nil {
+		return nil, err
+	}
+	return data.HealthyShardGroupsByTimeRange(database, rp, min, max, c.clock.Now(), threshold)
+}
+
 // ShardsByTimeRange returns a slice of shards that may contain data in the time range.
 func (c *RemoteClient) ShardsByTimeRange(sources cnosql.Sources, tmin, tmax time.Time) (a []ShardInfo, err error) {
 	m := make(map[*ShardInfo]struct{})
@@ -821,7 +2169,7 @@ func (c *RemoteClient) PruneShardGroups() error {
 
 // CreateShardGroup creates a shard group on a database and retention policy for a given timestamp.
 func (c *RemoteClient) CreateShardGroup(database, rp string, timestamp time.Time) (*ShardGroupInfo, error) {
-	if sg, _ := c.data().ShardGroupByTimestamp(database, rp, timestamp); sg != nil {
+	if sg, _ := c.cache().ShardGroupByTimestamp(database, rp, timestamp); sg != nil {
 		return sg, nil
 	}
 
@@ -831,18 +2179,25 @@ func (c *RemoteClient) CreateShardGroup(database, rp string, timestamp time.Time
 		Timestamp:       proto.Int64(timestamp.UnixNano()),
 	}
 
-	if err := c.retryUntilExec(internal.Command_CreateShardGroupCommand, internal.E_CreateShardGroupCommand_Command, cmd); err != nil {
+	// The result is the ID of the new group, or zero if the group was
+	// already there, created by another node since the cache was updated.
+	created, err := c.retryUntilExecResult(context.Background(), internal.Command_CreateShardGroupCommand, internal.E_CreateShardGroupCommand_Command, cmd)
+	if err != nil && err != errClientClosed {
 		return nil, err
 	}
 
-	rpi, err := c.RetentionPolicy(database, rp)
+	rpi, err := retentionPolicyInfo(c.cache(), database, rp)
 	if err != nil {
 		return nil, err
 	} else if rpi == nil {
 		return nil, errors.New("retention policy deleted after shard group created")
 	}
 
-	return rpi.ShardGroupByTimestamp(timestamp), nil
+	sgi := rpi.ShardGroupByTimestamp(timestamp)
+	if sgi != nil && sgi.ID == created {
+		logShardGroupCreated(c.logger, database, rp, sgi)
+	}
+	return sgi, nil
 }
 
 // DeleteShardGroup removes a shard group from a database and retention policy by id.
@@ -856,42 +2211,107 @@ func (c *RemoteClient) DeleteShardGroup(database, rp string, id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteShardGroupCommand, internal.E_DeleteShardGroupCommand_Command, cmd)
 }
 
+// ReserveShardIDs reserves n consecutive shard IDs and returns the first of
+// them. The leader allocates the IDs when it applies the command, so
+// concurrent callers never get overlapping ranges.
+func (c *RemoteClient) ReserveShardIDs(n int) (uint64, error) {
+	if n <= 0 {
+		return 0, ErrInvalidShardIDCount
+	}
+
+	cmd := &internal.ReserveShardIDsCommand{
+		N: proto.Uint64(uint64(n)),
+	}
+
+	start, err := c.retryUntilExecResult(context.Background(), internal.Command_ReserveShardIDsCommand, internal.E_ReserveShardIDsCommand_Command, cmd)
+	if e, ok := err.(errCommand); ok && e.msg == ErrInvalidShardIDCount.Error() {
+		return 0, ErrInvalidShardIDCount
+	} else if err != nil {
+		return 0, err
+	}
+	return start, nil
+}
+
+// RebalanceShardGroup reassigns the owners of a shard group's shards over the
+// current data nodes. The shards' data is not moved.
+func (c *RemoteClient) RebalanceShardGroup(database, rp string, id uint64) error {
+	cmd := &internal.RebalanceShardGroupCommand{
+		Database:        proto.String(database),
+		RetentionPolicy: proto.String(rp),
+		ShardGroupID:    proto.Uint64(id),
+	}
+
+	err := c.retryUntilExec(internal.Command_RebalanceShardGroupCommand, internal.E_RebalanceShardGroupCommand_Command, cmd)
+	if e, ok := err.(errCommand); ok {
+		switch e.msg {
+		case ErrShardGroupNotFound.Error():
+			return ErrShardGroupNotFound
+		case ErrNodesRequired.Error():
+			return ErrNodesRequired
+		}
+	}
+	return err
+}
+
+// MarkShardGroupDeleted marks a shard group as deleted as of the given time without
+// removing it. PruneShardGroups removes it once ShardGroupDeletedExpiration has passed.
+func (c *RemoteClient) MarkShardGroupDeleted(database, rp string, id uint64, at time.Time) error {
+	// The zero time has no UnixNano, so it can't be sent.
+	if at.IsZero() {
+		return ErrShardGroupDeletedAtRequired
+	}
+
+	cmd := &internal.MarkShardGroupDeletedCommand{
+		Database:        proto.String(database),
+		RetentionPolicy: proto.String(rp),
+		ShardGroupID:    proto.Uint64(id),
+		DeletedAt:       proto.Int64(at.UnixNano()),
+	}
+
+	return c.retryUntilExec(internal.Command_MarkShardGroupDeletedCommand, internal.E_MarkShardGroupDeletedCommand_Command, cmd)
+}
+
 // PrecreateShardGroups creates shard groups whose endtime is before the 'to' time passed in, but
 // is yet to expire before 'from'. This is to avoid the need for these shards to be created when data
 // for the corresponding time range arrives. Shard creation involves Raft consensus, and precreation
-// avoids taking the hit at write-time.
+// avoids taking the hit at write-time. Every group is sent in a single command, which fails
+// with ErrShardGroupsNotCreated if none of the groups could be created.
 func (c *RemoteClient) PrecreateShardGroups(from, to time.Time) error {
-	for _, di := range c.data().Databases {
-		for _, rp := range di.RetentionPolicies {
-			if len(rp.ShardGroups) == 0 {
-				// No data was ever written to this shard group, or all groups have been deleted.
-				continue
-			}
-			rg := rp.ShardGroups[len(rp.ShardGroups)-1] // Get the last shard group in time.
-			if !rg.Deleted() && rg.EndTime.Before(to) && rg.EndTime.After(from) {
-				// ShardGroup is not deleted, will end before the future time, but is still yet to expire.
-				// This last check is important, so the system doesn't create shards groups wholly
-				// in the past.
-
-				// Create successive shard group.
-				nextShardGroupTime := rg.EndTime.Add(1 * time.Nanosecond)
-				if newRg, err := c.CreateShardGroup(di.Name, rp.Name, nextShardGroupTime); err != nil {
-					c.logger.Error("Failed to precreate successive shard group",
-						zap.Uint64("shard_group_id", rg.ID),
-						zap.Error(err))
-				} else {
-					c.logger.Info("New shard group successfully precreated",
-						logger.ShardGroup(newRg.ID),
-						logger.Database(di.Name),
-						logger.RetentionPolicy(rp.Name))
-				}
-			}
-		}
+	reqs := planShardGroupPrecreation(c.cache().Clone(), from, to, c.logger)
+	if len(reqs) == 0 {
 		return nil
 	}
+
+	cmd := &internal.CreateShardGroupsCommand{
+		Groups: make([]*internal.CreateShardGroupCommand, len(reqs)),
+	}
+	for i, req := range reqs {
+		cmd.Groups[i] = &internal.CreateShardGroupCommand{
+			Database:        proto.String(req.database),
+			RetentionPolicy: proto.String(req.rp),
+			Timestamp:       proto.Int64(req.timestamp.UnixNano()),
+		}
+	}
+
+	if err := c.retryUntilExec(internal.Command_CreateShardGroupsCommand, internal.E_CreateShardGroupsCommand_Command, cmd); err != nil {
+		c.logger.Error("Failed to precreate shard groups", zap.Int("groups", len(reqs)), zap.Error(err))
+		return err
+	}
+	c.logger.Info("New shard groups successfully precreated", zap.Int("groups", len(reqs)))
 	return nil
 }
 
+// UnderReplicatedShards returns the live shards that have fewer owners than
+// their retention policy's replica factor.
+func (c *RemoteClient) UnderReplicatedShards() []UnderReplicatedShard {
+	return c.data().UnderReplicatedShards()
+}
+
+// ShardsOwnedBy returns the live shards owned by the given data node.
+func (c *RemoteClient) ShardsOwnedBy(nodeID uint64) []NodeShard {
+	return c.data().ShardsOwnedBy(nodeID)
+}
+
 // ShardOwner returns the owning shard group info for a specific shard.
 func (c *RemoteClient) ShardOwner(shardID uint64) (database, rp string, sgi *ShardGroupInfo) {
 	for _, dbi := range c.data().Databases {
@@ -925,6 +2345,25 @@ func (c *RemoteClient) CreateContinuousQuery(database, name, query string) error
 	)
 }
 
+// CreateOrReplaceContinuousQuery saves a continuous query with the given name for the
+// given database, replacing the query if one with that name already exists.
+func (c *RemoteClient) CreateOrReplaceContinuousQuery(database, name, query string) error {
+	c.mu.RLock()
+	exists := c.cacheData.hasContinuousQuery(database, name, query)
+	c.mu.RUnlock()
+	if exists {
+		return nil
+	}
+
+	return c.retryUntilExec(internal.Command_ReplaceContinuousQueryCommand, internal.E_ReplaceContinuousQueryCommand_Command,
+		&internal.ReplaceContinuousQueryCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+			Query:    proto.String(query),
+		},
+	)
+}
+
 func (c *RemoteClient) DropContinuousQuery(database, name string) error {
 	return c.retryUntilExec(internal.Command_DropContinuousQueryCommand, internal.E_DropContinuousQueryCommand_Command,
 		&internal.DropContinuousQueryCommand{
@@ -934,6 +2373,36 @@ func (c *RemoteClient) DropContinuousQuery(database, name string) error {
 	)
 }
 
+// ExportContinuousQueriesDDL writes a CREATE CONTINUOUS QUERY statement for
+// every continuous query to w.
+func (c *RemoteClient) ExportContinuousQueriesDDL(w io.Writer) error {
+	data, err := c.readData()
+	if err != nil {
+		return err
+	}
+	return data.ExportContinuousQueriesDDL(w)
+}
+
+// ExportRetentionPoliciesDDL writes the statements that recreate every
+// database and retention policy to w.
+func (c *RemoteClient) ExportRetentionPoliciesDDL(w io.Writer) error {
+	data, err := c.readData()
+	if err != nil {
+		return err
+	}
+	return data.ExportRetentionPoliciesDDL(w)
+}
+
+// ExportUsersDDL writes the statements that recreate every user and their
+// privileges to w, with placeholder passwords.
+func (c *RemoteClient) ExportUsersDDL(w io.Writer) error {
+	data, err := c.readData()
+	if err != nil {
+		return err
+	}
+	return data.ExportUsersDDL(w)
+}
+
 func (c *RemoteClient) CreateSubscription(database, rp, name, mode string, destinations []string) error {
 	return c.retryUntilExec(internal.Command_CreateSubscriptionCommand, internal.E_CreateSubscriptionCommand_Command,
 		&internal.CreateSubscriptionCommand{
@@ -957,9 +2426,28 @@ func (c *RemoteClient) DropSubscription(database, rp, name string) error {
 }
 
 func (c *RemoteClient) SetData(data *Data) error {
+	return c.RestoreData(data, false)
+}
+
+// RestoreData overwrites the underlying data in the meta store. If
+// preserveNodes is set, the meta service keeps its cluster ID and nodes and
+// only the databases, retention policies and users are restored.
+func (c *RemoteClient) RestoreData(data *Data, preserveNodes bool) error {
 	return c.retryUntilExec(internal.Command_SetDataCommand, internal.E_SetDataCommand_Command,
 		&internal.SetDataCommand{
-			Data: data.marshal(),
+			Data:          data.marshal(),
+			PreserveNodes: proto.Bool(preserveNodes),
+		},
+	)
+}
+
+// SetMaintenanceMode turns maintenance mode on or off for the cluster. While
+// it is on, the meta service rejects every other change with
+// ErrMaintenanceMode.
+func (c *RemoteClient) SetMaintenanceMode(on bool) error {
+	return c.retryUntilExec(internal.Command_SetMaintenanceModeCommand, internal.E_SetMaintenanceModeCommand_Command,
+		&internal.SetMaintenanceModeCommand{
+			On: proto.Bool(on),
 		},
 	)
 }
@@ -972,6 +2460,20 @@ func (c *RemoteClient) Data() Data {
 	return *d
 }
 
+// AcquireSnapshot returns the current meta data without copying it, along with
+// a func to release it when done. Updates polled from the meta service replace
+// the cached data rather than modify it, so the returned data stays unchanged;
+// it must not be modified by the caller either.
+//
+// A snapshot keeps its version of the meta data alive until it is released and
+// no longer referenced, so holding many snapshots across updates retains memory.
+func (c *RemoteClient) AcquireSnapshot() (*Data, func()) {
+	c.mu.RLock()
+	data := c.cacheData
+	c.mu.RUnlock()
+	return data, acquireSnapshot(&c.snapshots)
+}
+
 // WaitForDataChanged will return a channel that will get closed when
 // the metastore data has changed
 func (c *RemoteClient) WaitForDataChanged() chan struct{} {
@@ -999,6 +2501,27 @@ func (c *RemoteClient) WithLogger(log *zap.Logger) {
 	c.logger = log.With(zap.String("service", "remote-meta-client"))
 }
 
+// SetAuthEnabled sets whether Authenticate checks passwords. When disabled,
+// Authenticate returns an admin user for any credentials and UserPrivilege
+// returns AllPrivileges. Authentication is enabled by default.
+func (c *RemoteClient) SetAuthEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !enabled {
+		c.logger.Warn("Authentication is disabled, every user is treated as an admin")
+	}
+	c.authDisabled = !enabled
+}
+
+// SetClearPrivilegesOnAdmin sets whether SetAdminPrivilege asks the
+// metaservice to remove the database privileges of a user it makes an admin.
+// Grants are kept by default.
+func (c *RemoteClient) SetClearPrivilegesOnAdmin(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clearPrivilegesOnAdmin = enabled
+}
+
 type errRedirect struct {
 	host string
 }
@@ -1015,6 +2538,25 @@ func (e errCommand) Error() string {
 	return e.msg
 }
 
+// errRetryAfter is returned by exec when the metaserver is unavailable and
+// asked the client to wait before retrying.
+type errRetryAfter struct {
+	err  error
+	wait time.Duration
+}
+
+func (e errRetryAfter) Error() string {
+	return e.err.Error()
+}
+
+type errCorruptSnapshot struct {
+	err error
+}
+
+func (e errCorruptSnapshot) Error() string {
+	return fmt.Sprintf("corrupt snapshot: %s", e.err)
+}
+
 func (c *RemoteClient) index() uint64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -1024,56 +2566,115 @@ func (c *RemoteClient) index() uint64 {
 // retryUntilExec will attempt the command on each of the metaservers until it either succeeds or
 // hits the max number of tries
 func (c *RemoteClient) retryUntilExec(typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
+	return c.retryUntilExecContext(context.Background(), typ, desc, value)
+}
+
+// retryUntilExecContext is like retryUntilExec but gives up when ctx is done,
+// returning ctx.Err() without trying another server.
+func (c *RemoteClient) retryUntilExecContext(ctx context.Context, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
+	if _, err := c.retryUntilExecResult(ctx, typ, desc, value); err != errClientClosed {
+		return err
+	}
+	return nil
+}
+
+// retryUntilExecResult is like retryUntilExecContext but also returns the
+// value the command produced, such as the first of the shard IDs it reserved.
+// It returns errClientClosed if the client is closed before the command is
+// applied.
+func (c *RemoteClient) retryUntilExecResult(ctx context.Context, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) (uint64, error) {
 	var err error
-	var index uint64
+	var res *internal.Response
 	tries := 0
-	currentServer := 0
 	var redirectServer string
 
+	// Every attempt carries the same key, so the server applies the command
+	// once even if an attempt that timed out went through.
+	key := uuid.TimeUUID().String()
+
+	if err := c.checkStale(); err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	if c.readOnly {
+		c.mu.RUnlock()
+		return 0, ErrMetaReadOnly
+	}
+	currentServer := c.startServer()
+	var query string
+	if c.writeConsistency != "" {
+		query = "?consistency=" + c.writeConsistency
+	}
+	timeout, maxRetries, retryInterval := c.execTimeout, c.maxRetries, c.retryInterval
+	c.mu.RUnlock()
+
 	for {
 		c.mu.RLock()
 		// exit if we're closed
 		select {
 		case <-c.closing:
 			c.mu.RUnlock()
-			return nil
+			return 0, errClientClosed
 		default:
 			// we're still open, continue on
 		}
 		c.mu.RUnlock()
 
-		// build the url to hit the redirect server or the next metaserver
-		var url string
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		// build the url to hit the redirect server, the preferred server or
+		// the next metaserver
+		var url, server string
 		if redirectServer != "" {
 			url = redirectServer
 			redirectServer = ""
 		} else {
 			c.mu.RLock()
-			if currentServer >= len(c.metaServers) {
-				currentServer = 0
+			server = c.preferred
+			if server == "" {
+				if currentServer >= len(c.metaServers) {
+					currentServer = 0
+				}
+				server = c.metaServers[currentServer]
+				currentServer++
 			}
-			server := c.metaServers[currentServer]
 			c.mu.RUnlock()
 
-			url = fmt.Sprintf("://%s/execute", server)
-			if c.tls {
-				url = "https" + url
-			} else {
-				url = "http" + url
-			}
+			url = c.url(server) + "/execute" + query
 		}
 
-		index, err = c.exec(url, typ, desc, value)
+		res, err = c.execAttempt(ctx, timeout, url, key, typ, desc, value)
 		tries++
-		currentServer++
 
 		if err == nil {
-			c.waitForIndex(index)
-			return nil
+			c.waitForIndex(res.GetIndex())
+			return res.GetResult(), nil
+		} else if isContextDone(err) {
+			// The caller gave up; the server is not at fault.
+			return 0, err
+		}
+
+		if e, ok := err.(errRedirect); ok {
+			// The server we were redirected to is the leader; go there first next time.
+			c.mu.Lock()
+			c.preferred = hostFromURL(e.host)
+			c.mu.Unlock()
+		} else if _, ok := err.(errRetryAfter); ok {
+			// The server is up but busy; keep preferring it.
+		} else if _, ok := err.(errCommand); !ok && err != ErrUnauthorized {
+			// The server could not be reached or failed; stop preferring it.
+			c.mu.Lock()
+			if c.preferred == server {
+				c.preferred = ""
+			}
+			c.mu.Unlock()
 		}
 
 		if tries > maxRetries {
-			return err
+			return 0, err
 		}
 
 		if e, ok := err.(errRedirect); ok {
@@ -1081,55 +2682,193 @@ func (c *RemoteClient) retryUntilExec(typ internal.Command_Type, desc *proto.Ext
 			continue
 		}
 
-		if _, ok := err.(errCommand); ok {
-			return err
+		if e, ok := err.(errCommand); ok {
+			switch e.msg {
+			case ErrMaintenanceMode.Error():
+				return 0, ErrMaintenanceMode
+			case ErrWriteNotAcknowledged.Error():
+				return 0, ErrWriteNotAcknowledged
+			case ErrShardGroupsNotCreated.Error():
+				return 0, ErrShardGroupsNotCreated
+			}
+			return 0, err
+		} else if err == ErrUnauthorized {
+			return 0, err
 		}
 
-		time.Sleep(errSleep)
+		wait := retryInterval
+		if e, ok := err.(errRetryAfter); ok {
+			wait = e.wait
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// execAttempt is like exec but gives up on the attempt after timeout, if it
+// is positive, returning errExecTimeout unless ctx itself is done.
+func (c *RemoteClient) execAttempt(ctx context.Context, timeout time.Duration, url, key string, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) (*internal.Response, error) {
+	if timeout <= 0 {
+		return c.exec(ctx, url, key, typ, desc, value)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := c.exec(attemptCtx, url, key, typ, desc, value)
+	if err != nil && attemptCtx.Err() != nil && ctx.Err() == nil {
+		return nil, errExecTimeout
+	}
+	return res, err
+}
+
+// isContextDone returns true if err is the error of a cancelled or expired
+// context.
+func isContextDone(err error) bool {
+	return err == context.Canceled || err == context.DeadlineExceeded
+}
+
+// sleepContext waits for d or until ctx is done, in which case it returns
+// ctx.Err().
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
-func (c *RemoteClient) exec(url string, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) (index uint64, err error) {
+// exec sends a command to url. If ctx is done before a response arrives, it
+// returns ctx.Err() rather than the transport's error, so that callers can
+// tell a cancelled command from a failed server.
+func (c *RemoteClient) exec(ctx context.Context, url, key string, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) (*internal.Response, error) {
+	if !c.startRequest() {
+		return nil, errClientClosed
+	}
+	defer c.running.Done()
+
+	c.mu.RLock()
+	sem := c.execSem
+	c.mu.RUnlock()
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.closing:
+			return nil, errClientClosed
+		}
+		defer func() { <-sem }()
+	}
+
 	// Create command.
 	cmd := &internal.Command{Type: &typ}
+	if key != "" {
+		cmd.IdempotencyKey = proto.String(key)
+	}
 	if err := proto.SetExtension(cmd, desc, value); err != nil {
 		panic(err)
 	}
 
 	b, err := proto.Marshal(cmd)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	resp, err := http.Post(url, "application/octet-stream", bytes.NewBuffer(b))
+	resp, err := c.doContext(ctx, http.MethodPost, url, "application/octet-stream", bytes.NewBuffer(b))
 	if err != nil {
-		return 0, err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// read the response
 	if resp.StatusCode == http.StatusTemporaryRedirect {
-		return 0, errRedirect{host: resp.Header.Get("Location")}
+		return nil, errRedirect{host: resp.Header.Get("Location")}
+	} else if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
 	} else if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("meta service returned %s", resp.Status)
+		err := fmt.Errorf("meta service returned %s: %s", resp.Status, responseError(resp))
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now()); ok {
+				return nil, errRetryAfter{err: err, wait: wait}
+			}
+		}
+		return nil, err
 	}
 
 	res := &internal.Response{}
 
 	b, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
 	}
 
 	if err := proto.Unmarshal(b, res); err != nil {
-		return 0, err
+		return nil, err
 	}
 	es := res.GetError()
 	if es != "" {
-		return 0, errCommand{msg: es}
+		return nil, errCommand{msg: es}
+	}
+
+	return res, nil
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header, given
+// either as a number of seconds or as an HTTP date, clamped to maxRetryAfter.
+func parseRetryAfter(h string, now time.Time) (time.Duration, bool) {
+	h = strings.TrimSpace(h)
+	if h == "" {
+		return 0, false
 	}
 
-	return res.GetIndex(), nil
+	var wait time.Duration
+	if secs, err := strconv.ParseInt(h, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		} else if secs > int64(maxRetryAfter/time.Second) {
+			return maxRetryAfter, true
+		}
+		wait = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(h); err == nil {
+		wait = t.Sub(now)
+	} else {
+		return 0, false
+	}
+
+	if wait < 0 {
+		wait = 0
+	} else if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait, true
+}
+
+// responseError returns the error message of a failed meta service response.
+// The message is taken from the JSON error body, or is the raw body if the
+// response is not JSON.
+func responseError(resp *http.Response) string {
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err.Error()
+	}
+
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == "application/json" {
+		var e errorResponse
+		if err := json.Unmarshal(b, &e); err == nil && e.Error != "" {
+			return e.Error
+		}
+	}
+	return strings.TrimSpace(string(b))
 }
 
 func (c *RemoteClient) waitForIndex(idx uint64) {
@@ -1139,9 +2878,13 @@ func (c *RemoteClient) waitForIndex(idx uint64) {
 			c.mu.RUnlock()
 			return
 		}
-		ch := c.changed
+		ch, closing := c.changed, c.closing
 		c.mu.RUnlock()
-		<-ch
+		select {
+		case <-ch:
+		case <-closing:
+			return
+		}
 	}
 }
 
@@ -1161,9 +2904,19 @@ func (c *RemoteClient) updateAuthCache() {
 }
 
 func (c *RemoteClient) pollForUpdates() {
+	var last time.Time
 	for {
-		data := c.retryUntilSnapshot(c.index())
-		if data == nil {
+		if !c.waitPollInterval(last) {
+			return
+		}
+		last = c.clock.Now()
+
+		data, err := c.retryUntilSnapshot(c.index())
+		if err != nil {
+			c.logger.Error("failure polling for updates", zap.Error(err))
+			time.Sleep(errSleep)
+			continue
+		} else if data == nil {
 			// this will only be nil if the client has been closed,
 			// so we can exit out
 			return
@@ -1172,6 +2925,7 @@ func (c *RemoteClient) pollForUpdates() {
 		// update the data and notify of the change
 		c.mu.Lock()
 		idx := c.cacheData.Index
+		c.watchers.publish(c.cacheData, data, c.clock.Now())
 		c.cacheData = data
 		c.updateAuthCache()
 		if idx < data.Index {
@@ -1182,6 +2936,48 @@ func (c *RemoteClient) pollForUpdates() {
 	}
 }
 
+// waitPollInterval waits until the minimum poll interval has passed since the
+// snapshot request started at last. It returns false if the client is closed
+// while waiting.
+func (c *RemoteClient) waitPollInterval(last time.Time) bool {
+	c.mu.RLock()
+	wait := c.minPollInterval - c.clock.Now().Sub(last)
+	closing := c.closing
+	c.mu.RUnlock()
+	if wait <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-closing:
+		return false
+	}
+}
+
+// WatchShardGroups returns a channel of shard group creations, deletions and
+// expirations, found by comparing each snapshot polled from the meta service
+// with the one before it. The channel is closed when ctx is done or the
+// client is closed.
+func (c *RemoteClient) WatchShardGroups(ctx context.Context) <-chan ShardGroupEvent {
+	return c.watchers.watch(ctx)
+}
+
+// hostFromURL returns the host portion of a metaserver URL, such as the
+// location of a redirect.
+func hostFromURL(s string) string {
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+len("://"):]
+	}
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
 func (c *RemoteClient) url(server string) string {
 	url := fmt.Sprintf("://%s", server)
 
@@ -1194,31 +2990,254 @@ func (c *RemoteClient) url(server string) string {
 	return url
 }
 
-func (c *RemoteClient) getSnapshot(server string, index uint64) (*Data, error) {
-	resp, err := http.Get(c.url(server) + fmt.Sprintf("?index=%d", index))
+// FetchDatabase returns the named database from the meta service without
+// fetching a full snapshot. If the meta service doesn't support fetching a
+// single database, a snapshot is fetched and the database looked up in it.
+func (c *RemoteClient) FetchDatabase(name string) (*DatabaseInfo, error) {
+	c.mu.RLock()
+	if len(c.metaServers) == 0 {
+		c.mu.RUnlock()
+		return nil, ErrServiceUnavailable
+	}
+	server := c.metaServers[c.startServer()]
+	c.mu.RUnlock()
+
+	resp, err := c.do(http.MethodGet, c.url(server)+"/database?name="+url.QueryEscape(name), "", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("meta server returned non-200: %s", resp.Status)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		// A JSON error means the database doesn't exist; anything else means
+		// the endpoint doesn't.
+		if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == "application/json" {
+			return nil, cnosdb.ErrDatabaseNotFound(name)
+		}
+		data, err := c.getSnapshot(server, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		di := data.Database(name)
+		if di == nil {
+			return nil, cnosdb.ErrDatabaseNotFound(name)
+		}
+		return di, nil
+	default:
+		return nil, fmt.Errorf("meta service returned %s: %s", resp.Status, responseError(resp))
 	}
 
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	data := &Data{}
-	if err := data.UnmarshalBinary(b); err != nil {
+	var pb internal.DatabaseInfo
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return nil, err
+	}
+	di := &DatabaseInfo{}
+	di.unmarshal(&pb)
+	return di, nil
+}
+
+// getSnapshot requests a snapshot newer than index from server. The request is
+// abandoned if refreshed is closed or the client is closed.
+func (c *RemoteClient) getSnapshot(server string, index uint64, refreshed <-chan struct{}) (*Data, error) {
+	if !c.startRequest() {
+		return nil, errClientClosed
+	}
+	defer c.running.Done()
+
+	c.mu.RLock()
+	closing := c.closing
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-refreshed:
+			cancel()
+		case <-closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// A client that already has data asks for only what changed. If the
+	// cache changed while the request waited, the delta doesn't apply and
+	// the full data is fetched instead.
+	data, err := c.requestSnapshot(ctx, server, index, index > 0)
+	if err == errDeltaBase {
+		return c.requestSnapshot(ctx, server, index, false)
+	}
+	return data, err
+}
+
+// requestSnapshot requests the data newer than index from server, as a delta
+// from the cached data if delta is set and the server supports it.
+func (c *RemoteClient) requestSnapshot(ctx context.Context, server string, index uint64, delta bool) (*Data, error) {
+	url := c.url(server) + fmt.Sprintf("?index=%d", index)
+	if delta {
+		url += "&delta=true"
+	}
+	c.mu.RLock()
+	wait := c.maxStaleness / 2
+	c.mu.RUnlock()
+	if wait > 0 {
+		url += "&wait=" + wait.String()
+	}
+
+	resp, err := c.getCompressed(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	} else if resp.StatusCode == http.StatusNoContent {
+		// Nothing changed during the wait; the cache is current.
+		return c.cache(), nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("meta server returned non-200: %s: %s", resp.Status, responseError(resp))
+	}
+
+	if resp.Header.Get(metaDeltaHeader) != "" {
+		return c.readDelta(resp.Body)
+	}
+	return c.readSnapshot(resp.Body)
+}
+
+// readDelta decodes a delta from r and applies it to the cached data,
+// reading no more than the maximum snapshot size.
+func (c *RemoteClient) readDelta(r io.Reader) (*Data, error) {
+	b, err := c.readSnapshotBytes(r)
+	if err != nil {
 		return nil, err
 	}
+	var pb internal.DataDelta
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return nil, errCorruptSnapshot{err: err}
+	}
+
+	c.mu.RLock()
+	base, caseInsensitiveNames := c.cacheData, c.caseInsensitiveNames
+	c.mu.RUnlock()
 
+	data, err := applyDataDelta(base, &pb)
+	if err != nil {
+		return nil, err
+	}
+	data.caseInsensitiveNames = caseInsensitiveNames
 	return data, nil
 }
 
-func (c *RemoteClient) retryUntilSnapshot(idx uint64) *Data {
-	currentServer := 0
+// readSnapshotBytes reads r, failing if it is larger than the maximum
+// snapshot size.
+func (c *RemoteClient) readSnapshotBytes(r io.Reader) ([]byte, error) {
+	c.mu.RLock()
+	max := c.maxSnapshotBytes
+	c.mu.RUnlock()
+
+	if max > 0 {
+		// Read one byte past the limit to tell a snapshot of exactly max
+		// bytes from a larger one.
+		r = io.LimitReader(r, max+1)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	} else if max > 0 && int64(len(b)) > max {
+		return nil, ErrSnapshotTooLarge
+	}
+	return b, nil
+}
+
+// readSnapshot decodes a snapshot from r, reading no more than the maximum
+// snapshot size.
+func (c *RemoteClient) readSnapshot(r io.Reader) (*Data, error) {
+	b, err := c.readSnapshotBytes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	caseInsensitiveNames := c.caseInsensitiveNames
+	c.mu.RUnlock()
+	data := &Data{caseInsensitiveNames: caseInsensitiveNames}
+	if err := data.UnmarshalBinary(b); err != nil {
+		return nil, errCorruptSnapshot{err: err}
+	}
+
+	return data, nil
+}
+
+// leaderData fetches the current data from the leader, trying the preferred
+// server first. Followers redirect the request to the leader.
+func (c *RemoteClient) leaderData() (*Data, error) {
+	c.mu.RLock()
+	servers := make([]string, 0, len(c.metaServers)+1)
+	if c.preferred != "" {
+		servers = append(servers, c.preferred)
+	}
+	if n := len(c.metaServers); n > 0 {
+		start := c.startServer()
+		for i := 0; i < n; i++ {
+			servers = append(servers, c.metaServers[(start+i)%n])
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(servers) == 0 {
+		return nil, ErrServiceUnavailable
+	}
+
+	var err error
+	for _, server := range servers {
+		var resp *http.Response
+		resp, err = c.getCompressed(context.Background(), c.url(server)+"?index=0&consistency=leader")
+		if err != nil {
+			continue
+		}
+
+		var data *Data
+		switch resp.StatusCode {
+		case http.StatusOK:
+			data, err = c.readSnapshot(resp.Body)
+		case http.StatusUnauthorized:
+			err = ErrUnauthorized
+		default:
+			err = fmt.Errorf("meta server returned non-200: %s: %s", resp.Status, responseError(resp))
+		}
+		resp.Body.Close()
+
+		if err == nil {
+			// The request may have been redirected, so remember the server
+			// that answered as the leader.
+			c.mu.Lock()
+			c.preferred = resp.Request.URL.Host
+			c.mu.Unlock()
+			return data, nil
+		} else if err == ErrUnauthorized {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// retryUntilSnapshot polls the metaservers for a snapshot newer than idx. Servers
+// that recently returned a corrupt snapshot are skipped; if every server is in
+// that state ErrSnapshotCorrupt is returned.
+func (c *RemoteClient) retryUntilSnapshot(idx uint64) (*Data, error) {
+	c.mu.RLock()
+	currentServer := c.startServer()
+	refreshed := c.refreshed
+	c.mu.RUnlock()
 	for {
 		// get the index to look from and the server to poll
 		c.mu.RLock()
@@ -1227,28 +3246,73 @@ func (c *RemoteClient) retryUntilSnapshot(idx uint64) *Data {
 		select {
 		case <-c.closing:
 			c.mu.RUnlock()
-			return nil
+			return nil, nil
 		default:
 			// we're still open, continue on
 		}
 
-		if currentServer >= len(c.metaServers) {
-			currentServer = 0
+		if len(c.metaServers) == 0 {
+			c.mu.RUnlock()
+			return nil, ErrServiceUnavailable
 		}
-		server := c.metaServers[currentServer]
+		server, ok := c.nextSnapshotServer(&currentServer)
 		c.mu.RUnlock()
+		if !ok {
+			return nil, ErrSnapshotCorrupt
+		}
 
-		data, err := c.getSnapshot(server, idx)
-
+		data, err := c.getSnapshot(server, idx, refreshed)
 		if err == nil {
-			return data
+			c.setUpdated()
+			return data, nil
+		} else if err == ErrUnauthorized {
+			return nil, err
+		}
+
+		select {
+		case <-refreshed:
+			// The meta-servers were replaced; start over with the new list.
+			c.mu.RLock()
+			currentServer = 0
+			refreshed = c.refreshed
+			c.mu.RUnlock()
+			continue
+		default:
+		}
+
+		currentServer++
+
+		if _, ok := err.(errCorruptSnapshot); ok || err == ErrSnapshotTooLarge {
+			c.logger.Error("bad snapshot, skipping meta server",
+				zap.String("server", server),
+				zap.Duration("cooldown", corruptSnapshotCooldown),
+				zap.Error(err))
+			c.mu.Lock()
+			c.corrupt[server] = c.clock.Now().Add(corruptSnapshotCooldown)
+			c.mu.Unlock()
+			continue
 		}
 
 		c.logger.Error("failure getting snapshot,",
 			zap.String("server", server),
 			zap.Error(err))
 		time.Sleep(errSleep)
+	}
+}
 
-		currentServer++
+// nextSnapshotServer returns the first metaserver at or after *i that is not
+// cooling down after a corrupt snapshot. It must be called with c.mu held.
+func (c *RemoteClient) nextSnapshotServer(i *int) (string, bool) {
+	now := c.clock.Now()
+	for n := 0; n < len(c.metaServers); n++ {
+		if *i >= len(c.metaServers) {
+			*i = 0
+		}
+		server := c.metaServers[*i]
+		if until, ok := c.corrupt[server]; !ok || now.After(until) {
+			return server, true
+		}
+		*i++
 	}
+	return "", false
 }
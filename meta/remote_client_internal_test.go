@@ -0,0 +1,1218 @@
+package meta
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cRand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/cnosdb/cnosdb/vend/common/pkg/toml"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRemoteClient_getSnapshot_TooLarge(t *testing.T) {
+	b, err := (&Data{Index: 2, ClusterID: 100}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(b)
+	}))
+	defer ts.Close()
+	server := strings.TrimPrefix(ts.URL, "http://")
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{server})
+
+	// A snapshot of exactly the limit is accepted.
+	c.SetMaxSnapshotBytes(int64(len(b)))
+	if data, err := c.getSnapshot(server, 0, nil); err != nil {
+		t.Fatal(err)
+	} else if data.ClusterID != 100 {
+		t.Fatalf("unexpected cluster id: %d", data.ClusterID)
+	}
+
+	c.SetMaxSnapshotBytes(int64(len(b) - 1))
+	if _, err := c.getSnapshot(server, 0, nil); err != ErrSnapshotTooLarge {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrSnapshotTooLarge)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{header: "3", wait: 3 * time.Second, ok: true},
+		{header: "0", wait: 0, ok: true},
+		{header: "3600", wait: maxRetryAfter, ok: true},
+		{header: now.Add(5 * time.Second).Format(http.TimeFormat), wait: 5 * time.Second, ok: true},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), wait: 0, ok: true},
+		{header: "", ok: false},
+		{header: "-1", ok: false},
+		{header: "soon", ok: false},
+	} {
+		wait, ok := parseRetryAfter(tt.header, now)
+		if ok != tt.ok || wait != tt.wait {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; exp %s, %v", tt.header, wait, ok, tt.wait, tt.ok)
+		}
+	}
+}
+
+func TestLeaseBackoff(t *testing.T) {
+	max := 2 * time.Second
+	for n := 1; n < 11; n++ {
+		exp := time.Duration(math.Pow(10, float64(n))) * time.Millisecond
+		if exp > max {
+			exp = max
+		}
+
+		// Without jitter the wait grows to the cap and stays there.
+		if d := leaseBackoff(n, max, 0.5, func() float64 { return 0 }); d != exp {
+			t.Errorf("attempt %d: unexpected backoff without jitter: got %s, exp %s", n, d, exp)
+		}
+		// Jitter takes up to half of the wait off.
+		if d, min := leaseBackoff(n, max, 0.5, func() float64 { return 0.999 }), exp/2; d < min || d >= exp {
+			t.Errorf("attempt %d: unexpected jittered backoff: got %s, exp [%s, %s)", n, d, min, exp)
+		}
+	}
+
+	// Random waits for the same attempt differ.
+	seen := make(map[time.Duration]struct{})
+	for i := 0; i < 10; i++ {
+		seen[leaseBackoff(5, max, 0.5, rand.Float64)] = struct{}{}
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected jittered backoffs to differ: %v", seen)
+	}
+}
+
+func TestRemoteClient_getSnapshot_Gzip(t *testing.T) {
+	b, err := (&Data{Index: 2, ClusterID: 100}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write(b)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(b)
+		gz.Close()
+	}))
+	defer ts.Close()
+	server := strings.TrimPrefix(ts.URL, "http://")
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{server})
+
+	// The size limit applies to the decompressed snapshot.
+	c.SetMaxSnapshotBytes(int64(len(b)))
+	if data, err := c.getSnapshot(server, 0, nil); err != nil {
+		t.Fatal(err)
+	} else if data.Index != 2 || data.ClusterID != 100 {
+		t.Fatalf("unexpected snapshot: index %d, cluster id %d", data.Index, data.ClusterID)
+	}
+}
+
+func TestRemoteClient_Authenticate_StaleCache(t *testing.T) {
+	hash := func(password string) string {
+		b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	c := NewRemoteClient()
+	c.cacheData = &Data{Users: []UserInfo{{Name: "u0", Hash: hash("old")}}}
+	if _, err := c.Authenticate("u0", "old"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := c.authCache["u0"]; !ok {
+		t.Fatal("expected authenticated user to be cached")
+	}
+
+	// Replace the hash without pruning the cache, as happens when the
+	// password changes on another node between polls.
+	c.cacheData.Users[0].Hash = hash("new")
+	if _, err := c.Authenticate("u0", "old"); err != ErrAuthenticate {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrAuthenticate)
+	}
+	if _, err := c.Authenticate("u0", "new"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_ApplyConfig(t *testing.T) {
+	b, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewRemoteClient()
+	c.cacheData = &Data{Users: []UserInfo{{Name: "u0", Hash: string(b)}}}
+	if _, err := c.Authenticate("u0", "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewConfig()
+	config.Dir = "/var/lib/cnosdb/meta"
+	config.LeaseMaxBackoff = toml.Duration(time.Second)
+	config.LeaseJitter = 0.25
+	config.MinPollInterval = toml.Duration(100 * time.Millisecond)
+	config.AuthCacheDisabled = true
+	if err := c.ApplyConfig(config); err != nil {
+		t.Fatal(err)
+	} else if c.leaseMaxBackoff != time.Second || c.leaseJitter != 0.25 || c.minPollInterval != 100*time.Millisecond {
+		t.Fatalf("unexpected settings: %s, %v, %s", c.leaseMaxBackoff, c.leaseJitter, c.minPollInterval)
+	} else if len(c.authCache) != 0 {
+		t.Fatalf("unexpected auth cache: %v", c.authCache)
+	}
+	if _, err := c.Authenticate("u0", "pass"); err != nil {
+		t.Fatal(err)
+	} else if len(c.authCache) != 0 {
+		t.Fatal("credentials cached with the auth cache disabled")
+	}
+
+	// An immutable or invalid setting rejects the whole config.
+	other := *config
+	other.Dir = "/var/lib/cnosdb/other"
+	other.LeaseJitter = 0.5
+	if err := c.ApplyConfig(&other); err == nil || err.Error() != ErrConfigImmutable("dir").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other = *config
+	other.CaseInsensitiveNames = true
+	if err := c.ApplyConfig(&other); err == nil || err.Error() != ErrConfigImmutable("case-insensitive-names").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other = *config
+	other.LeaseJitter = 2
+	if err := c.ApplyConfig(&other); err == nil {
+		t.Fatal("expected error for invalid lease jitter")
+	}
+	if c.leaseJitter != 0.25 {
+		t.Fatalf("rejected config was applied: jitter %v", c.leaseJitter)
+	}
+}
+
+func TestRemoteClient_ApplyConfig_Exec(t *testing.T) {
+	t.Parallel()
+
+	// Every command fails, or hangs until the test ends.
+	var attempts, hang int32
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&hang) == 1 {
+			<-done
+			return
+		}
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	c.cacheData = &Data{}
+	drop := func() error {
+		return c.retryUntilExecContext(context.Background(), internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command,
+			&internal.DropDatabaseCommand{Name: proto.String("db0")})
+	}
+
+	config := NewConfig()
+	config.Dir = "/var/lib/cnosdb/meta"
+	config.MaxRetries = 2
+	config.RetryInterval = toml.Duration(time.Millisecond)
+	if err := c.ApplyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed command is retried MaxRetries times, RetryInterval apart.
+	start := time.Now()
+	if err := drop(); err == nil {
+		t.Fatal("expected error")
+	} else if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("unexpected attempts: got %d, exp 3", n)
+	} else if d := time.Since(start); d >= DefaultRetryInterval {
+		t.Fatalf("retries took %s", d)
+	}
+
+	// An attempt that outlives Timeout is abandoned and retried.
+	atomic.StoreInt32(&attempts, 0)
+	atomic.StoreInt32(&hang, 1)
+	other := *config
+	other.Timeout = toml.Duration(10 * time.Millisecond)
+	other.MaxRetries = 1
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	}
+	if err := drop(); err != errExecTimeout {
+		t.Fatalf("unexpected error: got %v, exp %v", err, errExecTimeout)
+	} else if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Fatalf("unexpected attempts: got %d, exp 2", n)
+	}
+
+	// A read-only client doesn't send the command at all.
+	atomic.StoreInt32(&attempts, 0)
+	other.ReadOnly = true
+	if err := c.ApplyConfig(&other); err != nil {
+		t.Fatal(err)
+	}
+	if err := drop(); err != ErrMetaReadOnly {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrMetaReadOnly)
+	} else if n := atomic.LoadInt32(&attempts); n != 0 {
+		t.Fatalf("unexpected attempts: got %d, exp 0", n)
+	}
+}
+
+func TestRemoteClient_ApplyConfig_AuthCache(t *testing.T) {
+	b, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewRemoteClient()
+	clk := &testClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.setClock(clk)
+	c.cacheData = &Data{Users: []UserInfo{{Name: "u0", Hash: string(b)}, {Name: "u1", Hash: string(b)}}}
+
+	config := NewConfig()
+	config.Dir = "/var/lib/cnosdb/meta"
+	config.AuthCacheTTL = toml.Duration(time.Minute)
+	config.AuthCacheSize = 1
+	if err := c.ApplyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	n, restore := countCompares()
+	defer restore()
+	authenticate := func(name string, compares int64) {
+		t.Helper()
+		if _, err := c.Authenticate(name, "pass"); err != nil {
+			t.Fatal(err)
+		} else if got := atomic.LoadInt64(n); got != compares {
+			t.Fatalf("unexpected compares after %s: got %d, exp %d", name, got, compares)
+		}
+	}
+
+	// Within the TTL the cached credentials are used; past it they are
+	// checked again.
+	authenticate("u0", 1)
+	clk.add(time.Minute)
+	authenticate("u0", 1)
+	clk.add(time.Second)
+	authenticate("u0", 2)
+
+	// Caching u1 drops u0, the oldest entry.
+	clk.add(time.Second)
+	authenticate("u1", 3)
+	authenticate("u0", 4)
+	if len(c.authCache) != 1 {
+		t.Fatalf("unexpected auth cache size: %d", len(c.authCache))
+	}
+}
+
+func TestRemoteClient_InvalidateAuthCache(t *testing.T) {
+	c := NewRemoteClient()
+	c.cacheData = &Data{}
+	testInvalidateAuthCache(t, c, c.cacheData, func() map[string]authUser { return c.authCache }, c.hashWithSalt)
+}
+
+func TestClient_InvalidateAuthCache(t *testing.T) {
+	c, _ := newTestClockClient(t)
+	defer os.RemoveAll(c.path)
+	defer c.Close()
+	testInvalidateAuthCache(t, c, c.cacheData, func() map[string]authUser { return c.authCache }, c.hashWithSalt)
+}
+
+// testInvalidateAuthCache checks that invalidating the auth cache of c makes
+// Authenticate fall back to bcrypt. It plants a cache entry that accepts a
+// wrong password, which only a bcrypt comparison rejects.
+func testInvalidateAuthCache(t *testing.T, c interface {
+	Authenticate(username, password string) (User, error)
+	InvalidateAuthCache(username string)
+	InvalidateAllAuthCache()
+}, data *Data, cache func() map[string]authUser, hashWithSalt func([]byte, string) []byte) {
+	t.Helper()
+
+	for _, name := range []string{"u0", "u1"} {
+		hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data.Users = append(data.Users, UserInfo{Name: name, Hash: string(hash)})
+	}
+
+	plant := func(name string) {
+		salt := []byte("salt")
+		cache()[name] = authUser{salt: salt, hash: hashWithSalt(salt, "wrong"), bhash: data.user(name).Hash}
+		if _, err := c.Authenticate(name, "wrong"); err != nil {
+			t.Fatalf("expected cached credentials for %s to be used: %v", name, err)
+		}
+	}
+
+	plant("u0")
+	plant("u1")
+	c.InvalidateAuthCache("u0")
+	if _, err := c.Authenticate("u0", "wrong"); err != ErrAuthenticate {
+		t.Fatalf("unexpected error after invalidating u0: %v", err)
+	}
+	if _, err := c.Authenticate("u1", "wrong"); err != nil {
+		t.Fatalf("unexpected error for u1: %v", err)
+	}
+
+	plant("u0")
+	c.InvalidateAllAuthCache()
+	for _, name := range []string{"u0", "u1"} {
+		if _, err := c.Authenticate(name, "wrong"); err != ErrAuthenticate {
+			t.Fatalf("unexpected error after invalidating all for %s: %v", name, err)
+		}
+		if _, err := c.Authenticate(name, "pass"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRemoteClient_Close_WaitsForPoll(t *testing.T) {
+	t.Parallel()
+
+	b, err := (&Data{Index: 1, ClusterID: 100}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server answers Open and fails every poll, so the poll goroutine
+	// sleeps between attempts.
+	var polls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("index") == "0" {
+			w.Write(b)
+			return
+		}
+		atomic.AddInt32(&polls, 1)
+		http.Error(w, "poll failed", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	for atomic.LoadInt32(&polls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close returned, so the poll goroutine must already have exited.
+	done := make(chan struct{})
+	go func() {
+		c.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("poll goroutine still running after Close")
+	}
+}
+
+func TestRemoteClient_retryUntilExecContext_Canceled(t *testing.T) {
+	t.Parallel()
+
+	// Every command fails, so the client keeps retrying until cancelled.
+	var attempts int32
+	failed := make(chan struct{}, DefaultMaxRetries+1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+		failed <- struct{}{}
+	}))
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-failed
+		cancel()
+	}()
+
+	start := time.Now()
+	err := c.retryUntilExecContext(ctx, internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command,
+		&internal.DropDatabaseCommand{Name: proto.String("db0")})
+	if err != context.Canceled {
+		t.Fatalf("unexpected error: got %v, exp %v", err, context.Canceled)
+	}
+	// Cancelling interrupts the sleep before the next attempt.
+	if d := time.Since(start); d >= errSleep {
+		t.Fatalf("cancelled command returned after %s", d)
+	} else if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Fatalf("unexpected attempts: got %d, exp 1", n)
+	}
+
+	// A command that is in flight when the context is cancelled returns
+	// the context's error rather than the transport's.
+	done := make(chan struct{})
+	block := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer block.Close()
+	defer close(done)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.exec(ctx, block.URL+"/execute", "", internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command,
+		&internal.DropDatabaseCommand{Name: proto.String("db0")}); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: got %v, exp %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRemoteClient_RejectWritesWhenStale(t *testing.T) {
+	t.Parallel()
+
+	data := &Data{Index: 1, ClusterID: 100}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRetentionPolicy("db0", &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	}
+	b, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server answers Open, then the poller is cut off from it.
+	var polls, executed int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/execute" {
+			atomic.AddInt32(&executed, 1)
+		} else if r.URL.Query().Get("index") == "0" {
+			w.Write(b)
+			return
+		} else {
+			atomic.AddInt32(&polls, 1)
+		}
+		http.Error(w, "partitioned", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	clk := &testClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.setClock(clk)
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	c.RejectWritesWhenStale(time.Minute)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for atomic.LoadInt32(&polls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clk.add(2 * time.Minute)
+	if _, err := c.CreateShardGroup("db0", "rp0", clk.Now()); err != ErrMetaStale {
+		t.Fatalf("unexpected error creating shard group: got %v, exp %v", err, ErrMetaStale)
+	} else if _, err := c.CreateDatabase("db1"); err != ErrMetaStale {
+		t.Fatalf("unexpected error creating database: got %v, exp %v", err, ErrMetaStale)
+	} else if err := c.DropRetentionPolicy("db0", "rp0"); err != ErrMetaStale {
+		t.Fatalf("unexpected error dropping retention policy: got %v, exp %v", err, ErrMetaStale)
+	} else if n := atomic.LoadInt32(&executed); n != 0 {
+		t.Fatalf("unexpected commands sent to the meta service: %d", n)
+	}
+
+	// Reads are still served from the cache.
+	if c.Database("db0") == nil {
+		t.Fatal("expected db0 in the cache")
+	}
+
+	// A successful poll clears the staleness.
+	c.setUpdated()
+	if err := c.checkStale(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_RejectWritesWhenStale_HungPoll(t *testing.T) {
+	t.Parallel()
+
+	b, err := (&Data{Index: 1, ClusterID: 100}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server answers Open, then holds polls without answering until it
+	// is told to honour their wait.
+	var honour int32
+	waits := make(chan string, 100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("index") == "0" {
+			w.Write(b)
+			return
+		}
+		select {
+		case waits <- r.URL.Query().Get("wait"):
+		default:
+		}
+		if atomic.LoadInt32(&honour) == 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	clk := &testClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.setClock(clk)
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	c.SetMinPollInterval(10 * time.Millisecond)
+	c.RejectWritesWhenStale(time.Minute)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if wait := <-waits; wait != "30s" {
+		t.Fatalf("unexpected poll wait: %q", wait)
+	}
+
+	// A poll that hangs without failing doesn't keep the cache fresh.
+	clk.add(2 * time.Minute)
+	if _, err := c.CreateDatabase("db0"); err != ErrMetaStale {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrMetaStale)
+	}
+
+	// A poll that ends without a change shows the cache is current.
+	atomic.StoreInt32(&honour, 1)
+	c.RefreshMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	timeout := time.After(5 * time.Second)
+	for c.checkStale() != nil {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for a poll")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if c.Data().Index != 1 {
+		t.Fatalf("unexpected index: %d", c.Data().Index)
+	}
+}
+
+func TestRemoteClient_ReserveShardIDs_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	fsm := newTestStoreFSM()
+	fsm.data.Index = 1
+	fsm.data.ClusterID = 100
+	fsm.data.MaxShardID = 10
+	done := make(chan struct{})
+	ts := newFSMServer(t, fsm, done)
+	defer ts.Close()
+
+	var clients []*RemoteClient
+	defer func() {
+		close(done)
+		for _, c := range clients {
+			c.Close()
+		}
+	}()
+	newClient := func() *RemoteClient {
+		c := NewRemoteClient()
+		c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+		if err := c.Open(); err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, c)
+		return c
+	}
+
+	// The clients' reservations race in the FSM, which hands out the IDs.
+	const n, reservations = 3, 10
+	const clientN = 4
+	starts := make(chan uint64, clientN*reservations)
+	errs := make(chan error, clientN)
+	for i := 0; i < clientN; i++ {
+		c := newClient()
+		go func() {
+			for j := 0; j < reservations; j++ {
+				start, err := c.ReserveShardIDs(n)
+				if err != nil {
+					errs <- err
+					return
+				}
+				starts <- start
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < clientN; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(starts)
+
+	reserved := make(map[uint64]bool)
+	for start := range starts {
+		for id := start; id < start+n; id++ {
+			if reserved[id] {
+				t.Fatalf("shard id %d reserved twice", id)
+			}
+			reserved[id] = true
+		}
+	}
+	for id := uint64(11); id <= 10+clientN*reservations*n; id++ {
+		if !reserved[id] {
+			t.Fatalf("shard id %d not reserved", id)
+		}
+	}
+	if exp := uint64(10 + clientN*reservations*n); fsm.data.MaxShardID != exp {
+		t.Fatalf("unexpected max shard id: got %d, exp %d", fsm.data.MaxShardID, exp)
+	}
+
+	if _, err := clients[0].ReserveShardIDs(0); err != ErrInvalidShardIDCount {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRemoteClient_MaintenanceMode(t *testing.T) {
+	t.Parallel()
+
+	fsm := newTestStoreFSM()
+	fsm.data.Index = 1
+	fsm.data.ClusterID = 100
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	ts := newFSMServer(t, fsm, done)
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	defer close(done)
+
+	if err := c.SetMaintenanceMode(true); err != nil {
+		t.Fatal(err)
+	} else if !c.Data().MaintenanceMode {
+		t.Fatal("expected maintenance mode in the cached data")
+	}
+	if err := c.DropDatabase("db0"); err != ErrMaintenanceMode {
+		t.Fatalf("unexpected error: %v", err)
+	} else if c.Database("db0") == nil {
+		t.Fatal("expected db0 not to be dropped")
+	}
+
+	if err := c.SetMaintenanceMode(false); err != nil {
+		t.Fatal(err)
+	} else if err := c.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if c.Database("db0") != nil {
+		t.Fatal("expected db0 to be dropped")
+	}
+}
+
+func TestRemoteClient_DropDatabase_Subscriptions(t *testing.T) {
+	t.Parallel()
+
+	fsm := newTestStoreFSM()
+	fsm.data.Index = 1
+	fsm.data.ClusterID = 100
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := fsm.data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sub0", "sub1"} {
+		if err := fsm.data.CreateSubscription("db0", "rp0", name, "ALL", []string{"udp://example.com:9090"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan struct{})
+	ts := newFSMServer(t, fsm, done)
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	defer close(done)
+
+	// The guard's error comes back typed, as it does from a local client.
+	exp := ErrDatabaseHasSubscriptions{Database: "db0", Subscriptions: []string{"sub0", "sub1"}, RetentionPolicies: []string{"rp0", "rp0"}}
+	if err := c.DropDatabase("db0"); !reflect.DeepEqual(err, exp) {
+		t.Fatalf("unexpected error: got %#v, exp %#v", err, exp)
+	} else if _, err := c.DropDatabaseWithReport("db0"); !reflect.DeepEqual(err, exp) {
+		t.Fatalf("unexpected error with report: got %#v, exp %#v", err, exp)
+	} else if c.Database("db0") == nil {
+		t.Fatal("expected db0 not to be dropped")
+	}
+
+	if err := c.DropDatabaseForce("db0"); err != nil {
+		t.Fatal(err)
+	} else if c.Database("db0") != nil {
+		t.Fatal("expected db0 to be dropped")
+	}
+}
+
+// newFSMServer serves the meta API from fsm, applying commands to it as the
+// leader would. Polls that are up to date are held open until the data
+// changes or done is closed.
+func newFSMServer(t *testing.T, fsm *storeFSM, done chan struct{}) *httptest.Server {
+	return httptest.NewServer(newFSMHandler(t, fsm, done))
+}
+
+// newFSMHandler returns the handler of a server made by newFSMServer.
+func newFSMHandler(t *testing.T, fsm *storeFSM, done chan struct{}) http.Handler {
+	var mu sync.Mutex
+	snapshot := func() (uint64, []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		b, err := fsm.data.MarshalBinary()
+		if err != nil {
+			t.Error(err)
+		}
+		return fsm.data.Index, b
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			idx, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+			for {
+				index, b := snapshot()
+				if idx < index || r.URL.Query().Get("consistency") == "leader" {
+					w.Write(b)
+					return
+				}
+				select {
+				case <-done:
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				case <-r.Context().Done():
+					return
+				case <-time.After(time.Millisecond):
+				}
+			}
+		case "/execute":
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			res := fsm.Apply(&raft.Log{Index: fsm.data.Index + 1, Data: b})
+			index := fsm.data.Index
+			mu.Unlock()
+
+			resp := &internal.Response{Index: proto.Uint64(index)}
+			switch res := res.(type) {
+			case error:
+				resp.Error = proto.String(res.Error())
+			case commandResult:
+				resp.Result = proto.Uint64(uint64(res))
+			}
+			resp.OK = proto.Bool(resp.Error == nil)
+			b, err = proto.Marshal(resp)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			w.Write(b)
+		case "/ping":
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestRemoteClient_SetWriteConsistency(t *testing.T) {
+	fsm := newTestStoreFSM()
+	fsm.data.Index = 1
+	fsm.data.ClusterID = 100
+	done := make(chan struct{})
+
+	// Record the consistency level each command is sent with.
+	var mu sync.Mutex
+	var levels []string
+	h := newFSMHandler(t, fsm, done)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/execute" {
+			mu.Lock()
+			levels = append(levels, r.URL.Query().Get("consistency"))
+			mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		close(done)
+		c.Close()
+	}()
+
+	if err := c.SetWriteConsistency("most"); err != ErrInvalidWriteConsistency {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetWriteConsistency(WriteConsistencyAll); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	got := levels
+	mu.Unlock()
+	if exp := []string{"", WriteConsistencyAll}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected consistency levels: got %q, exp %q", got, exp)
+	}
+
+	// The cache caught up with the index of the command before it returned.
+	if c.Database("db1") == nil {
+		t.Fatal("expected db1 in the cache")
+	} else if idx, exp := c.Data().Index, fsm.data.Index; idx < exp {
+		t.Fatalf("unexpected cache index: got %d, exp at least %d", idx, exp)
+	}
+}
+
+func TestRemoteClient_CreateShardGroup_Log(t *testing.T) {
+	fsm := newTestStoreFSM()
+	fsm.data.Index = 1
+	fsm.data.ClusterID = 100
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateRetentionPolicy("db0", &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	ts := newFSMServer(t, fsm, done)
+	defer ts.Close()
+
+	c := NewRemoteClient()
+	core, logs := observer.New(zap.InfoLevel)
+	c.WithLogger(zap.New(core))
+	c.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var other *RemoteClient
+	defer func() {
+		close(done)
+		c.Close()
+		if other != nil {
+			other.Close()
+		}
+	}()
+
+	now := time.Now().Truncate(time.Hour)
+	sgi, err := c.CreateShardGroup("db0", "rp0", now)
+	if err != nil {
+		t.Fatal(err)
+	} else if sgi == nil {
+		t.Fatal("expected a shard group")
+	}
+	created := logs.FilterMessage("New shard group created")
+	if n := created.Len(); n != 1 {
+		t.Fatalf("unexpected creation logs: %d", n)
+	} else if id := created.All()[0].ContextMap()["db_shard_group"]; id != sgi.ID {
+		t.Fatalf("unexpected shard group in log: got %v, exp %d", id, sgi.ID)
+	}
+
+	// Returning the existing group logs nothing.
+	if _, err := c.CreateShardGroup("db0", "rp0", now); err != nil {
+		t.Fatal(err)
+	}
+	if n := logs.FilterMessage("New shard group created").Len(); n != 1 {
+		t.Fatalf("unexpected creation logs after returning an existing group: %d", n)
+	}
+
+	// Nor does returning a group that another node created before this
+	// client's cache caught up.
+	other = NewRemoteClient()
+	other.SetMetaServers([]string{strings.TrimPrefix(ts.URL, "http://")})
+	if err := other.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.CreateShardGroup("db0", "rp0", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if sgi, err := c.CreateShardGroup("db0", "rp0", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if sgi == nil {
+		t.Fatal("expected a shard group")
+	}
+	if n := logs.FilterMessage("New shard group created").Len(); n != 1 {
+		t.Fatalf("unexpected creation logs after returning another node's group: %d", n)
+	}
+}
+
+func TestRemoteClient_ReloadTLS(t *testing.T) {
+	caA, _ := newTestCA(t, "ca-a")
+	caB, signB := newTestCA(t, "ca-b")
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{signB(t)}}
+	ts.StartTLS()
+	defer ts.Close()
+	server := strings.TrimPrefix(ts.URL, "https://")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, caA, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewRemoteClient()
+	c.SetTLS(true)
+	if err := c.ReloadTLS(); err != ErrTLSNotConfigured {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := c.SetTLSFiles("", "", caFile); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() error {
+		resp, err := c.do(http.MethodGet, c.url(server)+"/ping", "", nil)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// The server's certificate isn't signed by the configured CA.
+	if err := get(); err == nil {
+		t.Fatal("expected certificate error")
+	}
+
+	// Invalid files are rejected and the old config kept.
+	if err := ioutil.WriteFile(caFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	} else if err := c.ReloadTLS(); err == nil {
+		t.Fatal("expected error for invalid CA file")
+	} else if err := get(); err == nil {
+		t.Fatal("expected certificate error")
+	}
+
+	// Requests after rotating to the server's CA use it.
+	if err := ioutil.WriteFile(caFile, caB, 0600); err != nil {
+		t.Fatal(err)
+	} else if err := c.ReloadTLS(); err != nil {
+		t.Fatal(err)
+	} else if err := get(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_ApplyConfig_TLS(t *testing.T) {
+	caA, _ := newTestCA(t, "ca-a")
+	caB, signB := newTestCA(t, "ca-b")
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{signB(t)}}
+	ts.StartTLS()
+	defer ts.Close()
+	server := strings.TrimPrefix(ts.URL, "https://")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, caA, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewRemoteClient()
+	config := NewConfig()
+	config.TLSCAFile = filepath.Join(t.TempDir(), "missing.pem")
+	if err := c.ApplyConfig(config); err == nil {
+		t.Fatal("expected error for missing CA file")
+	}
+	config.TLSCAFile = caFile
+	config.TLSReloadInterval = toml.Duration(10 * time.Millisecond)
+	if err := c.ApplyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() error {
+		resp, err := c.do(http.MethodGet, c.url(server)+"/ping", "", nil)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// The server's certificate isn't signed by the configured CA.
+	if err := get(); err == nil {
+		t.Fatal("expected certificate error")
+	}
+
+	// The rotated CA file is picked up by the periodic reload.
+	c.closing = make(chan struct{})
+	defer close(c.closing)
+	go c.reloadTLSPeriodically()
+	if err := ioutil.WriteFile(caFile, caB, 0600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for get() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the TLS files to be reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Removing the files from the config drops the TLS config and goes
+	// back to plain HTTP.
+	config.TLSCAFile = ""
+	if err := c.ApplyConfig(config); err != nil {
+		t.Fatal(err)
+	} else if err := c.ReloadTLS(); err != ErrTLSNotConfigured {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer plain.Close()
+	server = strings.TrimPrefix(plain.URL, "http://")
+	if u := c.url(server); u != plain.URL {
+		t.Fatalf("unexpected url: got %s, exp %s", u, plain.URL)
+	} else if err := get(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_Clock(t *testing.T) {
+	_, sign := newTestCA(t, "ca")
+	cert := sign(t)
+	key, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600); err != nil {
+		t.Fatal(err)
+	} else if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewRemoteClient()
+	clk := &testClock{now: time.Now()}
+	c.setClock(clk)
+	if err := c.SetTLSFiles(certFile, keyFile, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The certificate has expired by the client's clock.
+	clk.add(2 * time.Hour)
+	if err := c.ReloadTLS(); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The poll interval is measured by the client's clock, so a poll that
+	// started an interval ago by it doesn't wait.
+	c.closing = make(chan struct{})
+	c.SetMinPollInterval(time.Hour)
+	waited := make(chan bool)
+	go func() { waited <- c.waitPollInterval(clk.Now().Add(-time.Hour)) }()
+	select {
+	case ok := <-waited:
+		if !ok {
+			t.Fatal("expected wait to finish")
+		}
+	case <-time.After(5 * time.Second):
+		close(c.closing)
+		t.Fatal("timed out waiting for poll interval")
+	}
+}
+
+// newTestCA returns the PEM certificate of a new CA and a func that issues a
+// certificate for 127.0.0.1 signed by it.
+func newTestCA(t *testing.T, name string) ([]byte, func(t *testing.T) tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), cRand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(cRand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(t *testing.T) tls.Certificate {
+		leafKey, err := ecdsa.GenerateKey(elliptic.P256(), cRand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "127.0.0.1"},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		leafDER, err := x509.CreateCertificate(cRand.Reader, leaf, tmpl, &leafKey.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tls.Certificate{Certificate: [][]byte{leafDER}, PrivateKey: leafKey}
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), issue
+}
@@ -0,0 +1,937 @@
+package meta_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cnosdb/cnosdb"
+	"github.com/cnosdb/cnosdb/meta"
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/gogo/protobuf/proto"
+)
+
+func TestRemoteClient_Open_SkipsCorruptSnapshot(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	corrupt := httptest.NewServer(corruptSnapshotHandler())
+	defer corrupt.Close()
+	good := httptest.NewServer(snapshotHandler(t, &meta.Data{Index: 2, ClusterID: 100}, done))
+	defer good.Close()
+	defer close(done)
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(corrupt), serverAddr(good)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if id := c.ClusterID(); id != 100 {
+		t.Fatalf("unexpected cluster id: got %d, exp 100", id)
+	}
+}
+
+func TestRemoteClient_Open_AllSnapshotsCorrupt(t *testing.T) {
+	t.Parallel()
+
+	s0 := httptest.NewServer(corruptSnapshotHandler())
+	defer s0.Close()
+	s1 := httptest.NewServer(corruptSnapshotHandler())
+	defer s1.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s0), serverAddr(s1)})
+	if err := c.Open(); err != meta.ErrSnapshotCorrupt {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrSnapshotCorrupt)
+	}
+}
+
+func TestRemoteClient_AuthToken(t *testing.T) {
+	t.Parallel()
+
+	s := newTestMetaServer(t, &meta.Data{Index: 2})
+	s.token = "secret"
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s.Server)})
+	if err := c.Open(); err != meta.ErrUnauthorized {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUnauthorized)
+	}
+
+	c = meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s.Server)})
+	c.SetAuthToken("secret")
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.Ping(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.AcquireLease("cq"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A rejected token must not be retried.
+	c.SetAuthToken("wrong")
+	rejected := s.unauthorized()
+	if err := c.DropDatabase("db0"); err != meta.ErrUnauthorized {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUnauthorized)
+	}
+	if n := s.unauthorized() - rejected; n != 1 {
+		t.Fatalf("unexpected number of rejected requests: got %d, exp 1", n)
+	}
+	if err := c.Ping(false); err != meta.ErrUnauthorized {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUnauthorized)
+	}
+	if _, err := c.AcquireLease("cq"); err != meta.ErrUnauthorized {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrUnauthorized)
+	}
+}
+
+func TestRemoteClient_SpreadsRequests(t *testing.T) {
+	t.Parallel()
+
+	var servers []*testMetaServer
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		s := newTestMetaServer(t, &meta.Data{Index: 2})
+		defer s.Close()
+		servers = append(servers, s)
+		addrs = append(addrs, serverAddr(s.Server))
+	}
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers(addrs)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 30; i++ {
+		if err := c.DropDatabase("db0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Snapshot requests share the rotation, so the split is only roughly even.
+	for i, s := range servers {
+		if n := s.executions(); n < 8 || n > 12 {
+			t.Errorf("unexpected executions on server %d: got %d, exp about 10", i, n)
+		}
+	}
+}
+
+func TestRemoteClient_PreferredServer(t *testing.T) {
+	t.Parallel()
+
+	var servers []*testMetaServer
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		s := newTestMetaServer(t, &meta.Data{Index: 2})
+		defer s.Close()
+		servers = append(servers, s)
+		addrs = append(addrs, serverAddr(s.Server))
+	}
+	// Followers redirect to the leader.
+	servers[0].leader = addrs[2]
+	servers[1].leader = addrs[2]
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers(addrs)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Once redirected, commands go straight to the leader.
+	for i := 0; i < 10; i++ {
+		if err := c.DropDatabase("db0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := servers[2].executions(); n != 10 {
+		t.Fatalf("unexpected executions on leader: got %d, exp 10", n)
+	}
+
+	// A pinned server is used without consulting the others.
+	servers[0].leader = ""
+	c.SetPreferredServer(addrs[0])
+	for i := 0; i < 10; i++ {
+		if err := c.DropDatabase("db0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := servers[0].executions(); n != 10 {
+		t.Fatalf("unexpected executions on preferred server: got %d, exp 10", n)
+	}
+}
+
+func TestRemoteClient_RefreshMetaServers(t *testing.T) {
+	t.Parallel()
+
+	s0 := newTestMetaServer(t, &meta.Data{Index: 2, ClusterID: 100})
+	defer s0.Close()
+	s1 := newTestMetaServer(t, &meta.Data{Index: 3, ClusterID: 101})
+	defer s1.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s0.Server)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// The poll is now held open by s0, which has nothing newer to return.
+	c.RefreshMetaServers([]string{serverAddr(s1.Server)})
+
+	timeout := time.After(5 * time.Second)
+	for c.ClusterID() != 101 {
+		select {
+		case <-timeout:
+			t.Fatalf("snapshot not taken from new meta server, cluster id %d", c.ClusterID())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestRemoteClient_PrecreateShardGroups_SingleCommand(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Hour)
+	data := &meta.Data{Index: 2}
+	for i := 0; i < 20; i++ {
+		db := fmt.Sprintf("db%d", i)
+		if err := data.CreateDatabase(db); err != nil {
+			t.Fatal(err)
+		}
+		rpi := &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+		if err := data.CreateRetentionPolicy(db, rpi, true); err != nil {
+			t.Fatal(err)
+		} else if err := data.CreateShardGroup(db, "rp0", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := newTestMetaServer(t, data)
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s.Server)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.PrecreateShardGroups(now, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.executions(); n != 1 {
+		t.Fatalf("unexpected commands: got %d, exp 1", n)
+	}
+}
+
+func TestRemoteClient_MaxConcurrentExec(t *testing.T) {
+	t.Parallel()
+
+	s := newTestMetaServer(t, &meta.Data{Index: 2})
+	s.execDelay = 20 * time.Millisecond
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s.Server)})
+	c.SetMaxConcurrentExec(3)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.DropDatabase("db0"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.executed != 20 {
+		t.Fatalf("unexpected executions: got %d, exp 20", s.executed)
+	} else if s.maxInflight > 3 {
+		t.Fatalf("too many concurrent commands: got %d, exp at most 3", s.maxInflight)
+	}
+}
+
+func TestRemoteClient_MaxConcurrentExec_Close(t *testing.T) {
+	t.Parallel()
+
+	s := newTestMetaServer(t, &meta.Data{Index: 2})
+	held, release := make(chan struct{}), make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/execute" {
+			held <- struct{}{}
+			<-release
+		}
+		s.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(slow)})
+	c.SetMaxConcurrentExec(1)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first command holds the only slot until the server answers, and
+	// the second waits for it.
+	first := make(chan error, 1)
+	go func() { first <- c.DropDatabase("db0") }()
+	<-held
+	second := make(chan error, 1)
+	go func() { second <- c.DropDatabase("db1") }()
+	// Give the second command time to start waiting for the slot.
+	time.Sleep(100 * time.Millisecond)
+
+	// Closing the client releases the waiting command even though the slot
+	// is still taken.
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+	select {
+	case <-second:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("command waiting for a slot not released by Close")
+	}
+
+	close(release)
+	<-first
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_Exec_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	s := newTestMetaServer(t, &meta.Data{Index: 2})
+
+	var mu sync.Mutex
+	var attempts []time.Time
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/execute" {
+			s.ServeHTTP(w, r)
+			return
+		}
+
+		mu.Lock()
+		attempts = append(attempts, time.Now())
+		n := len(attempts)
+		mu.Unlock()
+		if n == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.ServeHTTP(w, r)
+	}))
+	defer busy.Close()
+	// Closing s first releases the snapshot poll held open through busy.
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(busy)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.DropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 2 {
+		t.Fatalf("unexpected attempts: got %d, exp 2", len(attempts))
+	}
+	// The flat retry sleep is one second, so a shorter wait means the
+	// header was ignored.
+	if wait := attempts[1].Sub(attempts[0]); wait < 1900*time.Millisecond || wait > 5*time.Second {
+		t.Fatalf("unexpected wait between attempts: %s", wait)
+	}
+}
+
+func TestRemoteClient_FetchDatabase(t *testing.T) {
+	t.Parallel()
+
+	var snapshots int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/database":
+			if name := r.URL.Query().Get("name"); name != "db0" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":"database not found: ` + name + `"}`))
+				return
+			}
+			b, err := proto.Marshal(&internal.DatabaseInfo{
+				Name:                   proto.String("db0"),
+				DefaultRetentionPolicy: proto.String("rp0"),
+				RetentionPolicies: []*internal.RetentionPolicyInfo{{
+					Name:               proto.String("rp0"),
+					Duration:           proto.Int64(0),
+					ShardGroupDuration: proto.Int64(int64(time.Hour)),
+					ReplicaN:           proto.Uint32(1),
+				}},
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			w.Write(b)
+		default:
+			snapshots++
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s)})
+
+	di, err := c.FetchDatabase("db0")
+	if err != nil {
+		t.Fatal(err)
+	} else if di.Name != "db0" || di.DefaultRetentionPolicy != "rp0" {
+		t.Fatalf("unexpected database: %+v", di)
+	} else if rpi := di.RetentionPolicy("rp0"); rpi == nil || rpi.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected retention policy: %+v", rpi)
+	}
+
+	if _, err := c.FetchDatabase("db1"); err == nil || err.Error() != cnosdb.ErrDatabaseNotFound("db1").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshots != 0 {
+		t.Fatalf("unexpected snapshot requests: %d", snapshots)
+	}
+}
+
+func TestRemoteClient_FetchDatabase_Fallback(t *testing.T) {
+	t.Parallel()
+
+	data := &meta.Data{Index: 2}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestMetaServer(t, data)
+	defer s.Close()
+
+	// The test server has no /database endpoint, so a snapshot is used.
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s.Server)})
+	if di, err := c.FetchDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if di.Name != "db0" {
+		t.Fatalf("unexpected database: %+v", di)
+	}
+	if _, err := c.FetchDatabase("db1"); err == nil || err.Error() != cnosdb.ErrDatabaseNotFound("db1").Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRemoteClient_DropDatabaseWithReport(t *testing.T) {
+	t.Parallel()
+
+	leaderData := &meta.Data{Index: 3}
+	if err := leaderData.CreateDataNode("host0", "tcp0"); err != nil {
+		t.Fatal(err)
+	} else if err := leaderData.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := leaderData.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1}, true); err != nil {
+		t.Fatal(err)
+	} else if err := leaderData.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	leader := newTestMetaServer(t, leaderData)
+	defer leader.Close()
+
+	// The follower's snapshot hasn't caught up with the shard group. Both
+	// report the same index so that the client doesn't wait for the drop to
+	// reach its cache.
+	followerData := &meta.Data{Index: 3}
+	if err := followerData.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	follower := newTestMetaServer(t, followerData)
+	follower.leader = serverAddr(leader.Server)
+	defer follower.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(follower.Server)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// The report lists what the leader drops, not what the cache holds.
+	report, err := c.DropDatabaseWithReport("db0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg := leaderData.Databases[0].RetentionPolicies[0].ShardGroups[0]
+	if exp := []string{"rp0"}; !reflect.DeepEqual(report.RetentionPolicies, exp) {
+		t.Errorf("unexpected retention policies: got %v, exp %v", report.RetentionPolicies, exp)
+	}
+	if exp := []uint64{sg.ID}; !reflect.DeepEqual(report.ShardGroupIDs, exp) {
+		t.Errorf("unexpected shard groups: got %v, exp %v", report.ShardGroupIDs, exp)
+	}
+	if exp := []uint64{sg.Shards[0].ID}; !reflect.DeepEqual(report.ShardIDs, exp) {
+		t.Errorf("unexpected shards: got %v, exp %v", report.ShardIDs, exp)
+	}
+	if n := leader.executions(); n != 1 {
+		t.Fatalf("unexpected executions: got %d, exp 1", n)
+	}
+}
+
+// testMetaServer is a minimal stand-in for the meta service HTTP API.
+func TestRemoteClient_LinearizableReads(t *testing.T) {
+	t.Parallel()
+
+	leaderData := &meta.Data{Index: 3}
+	for _, name := range []string{"db0", "db1"} {
+		if err := leaderData.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	leader := newTestMetaServer(t, leaderData)
+	defer leader.Close()
+
+	// The follower hasn't caught up with the creation of db1.
+	followerData := &meta.Data{Index: 2}
+	if err := followerData.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	follower := newTestMetaServer(t, followerData)
+	follower.leader = serverAddr(leader.Server)
+	defer follower.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(follower.Server)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if c.Database("db1") != nil {
+		t.Fatal("expected cached read to miss db1")
+	}
+
+	c.SetLinearizableReads(true)
+	if c.Database("db1") == nil {
+		t.Fatal("expected leader read to find db1")
+	}
+	if dbs := c.Databases(); len(dbs) != 2 {
+		t.Fatalf("unexpected databases: %+v", dbs)
+	}
+	leader.mu.Lock()
+	reads := leader.leaderReads
+	leader.mu.Unlock()
+	if reads != 2 {
+		t.Fatalf("unexpected leader reads: got %d, exp 2", reads)
+	}
+
+	// Commands check the cache rather than reading from the leader.
+	if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	leader.mu.Lock()
+	reads = leader.leaderReads
+	leader.mu.Unlock()
+	if reads != 2 {
+		t.Fatalf("unexpected leader reads after command: got %d, exp 2", reads)
+	}
+
+	c.SetLinearizableReads(false)
+	if c.Database("db1") != nil {
+		t.Fatal("expected cached read to miss db1")
+	}
+	if data, err := c.ConsistentData(); err != nil {
+		t.Fatal(err)
+	} else if data.Database("db1") == nil {
+		t.Fatal("expected consistent read to find db1")
+	}
+
+	// Reads that can fail return the error when the leader is unreachable
+	// instead of the cached data.
+	leader.Server.Close()
+	c.SetLinearizableReads(true)
+	if _, err := c.RetentionPolicy("db0", "autogen"); err == nil {
+		t.Fatal("expected error reading from unreachable leader")
+	}
+	if _, err := c.ConsistentData(); err == nil {
+		t.Fatal("expected error reading from unreachable leader")
+	}
+}
+
+type testMetaServer struct {
+	*httptest.Server
+	t     *testing.T
+	token string
+	data  *meta.Data
+	done  chan struct{}
+
+	// leader, if set, is the address that commands are redirected to.
+	leader string
+	// execDelay is how long each command takes to execute.
+	execDelay time.Duration
+
+	mu          sync.Mutex
+	rejected    int
+	executed    int
+	leaderReads int
+	inflight    int
+	maxInflight int
+}
+
+func newTestMetaServer(t *testing.T, data *meta.Data) *testMetaServer {
+	s := &testMetaServer{t: t, data: data, done: make(chan struct{})}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *testMetaServer) Close() {
+	close(s.done)
+	s.Server.Close()
+}
+
+func (s *testMetaServer) unauthorized() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rejected
+}
+
+func (s *testMetaServer) executions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.executed
+}
+
+func (s *testMetaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.Header.Get("Authorization") != "Bearer "+s.token {
+		s.mu.Lock()
+		s.rejected++
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/":
+		if r.URL.Query().Get("consistency") != "leader" {
+			snapshotHandler(s.t, s.data, s.done)(w, r)
+			return
+		} else if s.leader != "" {
+			http.Redirect(w, r, "http://"+s.leader+"/?"+r.URL.RawQuery, http.StatusTemporaryRedirect)
+			return
+		}
+		s.mu.Lock()
+		s.leaderReads++
+		s.mu.Unlock()
+
+		b, err := s.data.MarshalBinary()
+		if err != nil {
+			s.t.Error(err)
+			return
+		}
+		w.Write(b)
+	case "/ping":
+	case "/lease":
+		json.NewEncoder(w).Encode(&meta.Lease{Name: r.URL.Query().Get("name"), Expiration: time.Now().Add(time.Minute)})
+	case "/execute":
+		if s.leader != "" {
+			http.Redirect(w, r, "http://"+s.leader+"/execute", http.StatusTemporaryRedirect)
+			return
+		}
+		s.mu.Lock()
+		s.executed++
+		s.inflight++
+		if s.inflight > s.maxInflight {
+			s.maxInflight = s.inflight
+		}
+		s.mu.Unlock()
+
+		time.Sleep(s.execDelay)
+
+		s.mu.Lock()
+		s.inflight--
+		s.mu.Unlock()
+
+		b, err := proto.Marshal(&internal.Response{OK: proto.Bool(true), Index: proto.Uint64(s.data.Index)})
+		if err != nil {
+			s.t.Error(err)
+			return
+		}
+		w.Write(b)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// snapshotHandler serves data to requests for an older index and holds any
+// poll that is already up to date open until done is closed.
+func snapshotHandler(t *testing.T, data *meta.Data, done chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if idx, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); idx >= data.Index {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		b, err := data.MarshalBinary()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(b)
+	}
+}
+
+func corruptSnapshotHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not a snapshot"))
+	}
+}
+
+func serverAddr(s *httptest.Server) string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func TestRemoteClient_Open_SkipsOversizedSnapshot(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 4096))
+	}))
+	defer large.Close()
+	good := httptest.NewServer(snapshotHandler(t, &meta.Data{Index: 2, ClusterID: 100}, done))
+	defer good.Close()
+	defer close(done)
+
+	c := meta.NewRemoteClient()
+	c.SetMaxSnapshotBytes(1024)
+	c.SetMetaServers([]string{serverAddr(large), serverAddr(good)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if id := c.ClusterID(); id != 100 {
+		t.Fatalf("unexpected cluster id: got %d, exp 100", id)
+	}
+}
+
+func TestRemoteClient_Leases(t *testing.T) {
+	t.Parallel()
+
+	exp := []meta.LeaseInfo{
+		{Name: "ContinuousQuery", Owner: 2, Expiration: time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC)},
+	}
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/leases" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(exp)
+	}))
+	defer leader.Close()
+	follower := httptest.NewServer(http.RedirectHandler(leader.URL+"/leases", http.StatusTemporaryRedirect))
+	defer follower.Close()
+
+	for _, s := range []*httptest.Server{leader, follower} {
+		c := meta.NewRemoteClient()
+		c.SetMetaServers([]string{serverAddr(s)})
+		if leases, err := c.Leases(); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(leases, exp) {
+			t.Fatalf("unexpected leases: got %+v, exp %+v", leases, exp)
+		}
+	}
+
+	c := meta.NewRemoteClient()
+	if _, err := c.Leases(); err != meta.ErrServiceUnavailable {
+		t.Fatalf("unexpected error without meta servers: %v", err)
+	}
+}
+
+func TestRemoteClient_AcquireLeaseContext(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(ts)})
+
+	// Without a deadline the backoff would grow to seconds between attempts.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.AcquireLeaseContext(ctx, "cq"); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: got %v, exp %v", err, context.DeadlineExceeded)
+	} else if d := time.Since(start); d > time.Second {
+		t.Fatalf("lease returned after %s", d)
+	} else if n := atomic.LoadInt32(&attempts); n < 2 || n >= 10 {
+		t.Fatalf("unexpected attempts: %d", n)
+	}
+
+	// A context that is already done makes no attempt.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	before := atomic.LoadInt32(&attempts)
+	if _, err := c.AcquireLeaseContext(ctx, "cq"); err != context.Canceled {
+		t.Fatalf("unexpected error: got %v, exp %v", err, context.Canceled)
+	} else if n := atomic.LoadInt32(&attempts) - before; n != 0 {
+		t.Fatalf("unexpected attempts with a cancelled context: %d", n)
+	}
+}
+
+func TestRemoteClient_Close_WaitsForExec(t *testing.T) {
+	t.Parallel()
+
+	s := newTestMetaServer(t, &meta.Data{Index: 2})
+
+	// The server holds each command until it is released.
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/execute" {
+			started <- struct{}{}
+			<-release
+		}
+		s.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(slow)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- c.DropDatabase("db0") }()
+	<-started
+
+	// Close gives up on the command after the timeout.
+	c.SetCloseTimeout(50 * time.Millisecond)
+	if err := c.Close(); err != meta.ErrCloseTimeout {
+		t.Fatalf("unexpected error: got %v, exp %v", err, meta.ErrCloseTimeout)
+	}
+
+	close(release)
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not return after Close")
+	}
+}
+
+func TestRemoteClient_Close_Twice(t *testing.T) {
+	t.Parallel()
+
+	s := newTestMetaServer(t, &meta.Data{Index: 2})
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s.Server)})
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- c.Close() }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteClient_SetMinPollInterval(t *testing.T) {
+	t.Parallel()
+
+	// The server answers every request at once with newer data, as a server
+	// that doesn't long-poll would.
+	var mu sync.Mutex
+	var requests int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		data := &meta.Data{Index: uint64(requests), ClusterID: 100}
+		mu.Unlock()
+
+		b, err := data.MarshalBinary()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(b)
+	}))
+	defer s.Close()
+
+	c := meta.NewRemoteClient()
+	c.SetMetaServers([]string{serverAddr(s)})
+	c.SetMinPollInterval(50 * time.Millisecond)
+	if err := c.Open(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	c.Close()
+
+	// Open makes one request and polling at most one per interval.
+	mu.Lock()
+	n := requests
+	mu.Unlock()
+	if n < 3 || n > 9 {
+		t.Fatalf("unexpected request count in 300ms: %d", n)
+	}
+}
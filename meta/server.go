@@ -1,6 +1,7 @@
 package meta
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -34,7 +35,8 @@ type Server struct {
 	Node    *cnosdb.Node
 	NewNode bool
 
-	store *store
+	store       *store
+	objectStore ObjectStore
 
 	services []interface {
 		WithLogger(log *zap.Logger)
@@ -82,6 +84,37 @@ func (s *Server) Close() {
 	s.mux.Close()
 }
 
+// LastApplyError returns the last error this server hit committing a command
+// to raft, for debugging. It returns nil if no command has failed.
+func (s *Server) LastApplyError() error {
+	return s.store.lastApplyError()
+}
+
+// SetObjectStore sets the object store used by BackupMetaTo and
+// RestoreMetaFrom. It must be called before Open.
+func (s *Server) SetObjectStore(objects ObjectStore) {
+	s.objectStore = objects
+}
+
+// BackupMetaTo uploads the current meta data to the object store under the
+// key prefix in rawurl, as in s3://bucket/prefix. The object is named after
+// the cluster ID and index of the data.
+func (s *Server) BackupMetaTo(ctx context.Context, rawurl string) error {
+	return s.store.backupMetaTo(ctx, rawurl)
+}
+
+// RestoreMetaFrom restores the databases, retention policies and users from
+// the backup object at rawurl. Only the leader can restore.
+func (s *Server) RestoreMetaFrom(ctx context.Context, rawurl string) error {
+	return s.store.restoreMetaFrom(ctx, rawurl)
+}
+
+// RaftStats returns how long recent commands took to commit to raft and how
+// many are still waiting.
+func (s *Server) RaftStats() RaftStats {
+	return s.store.raftStats()
+}
+
 func (s *Server) initFileSystem() error {
 	if err := os.MkdirAll(s.Config.Dir, 0777); err != nil {
 		return fmt.Errorf("mkdir all: %s", err)
@@ -104,6 +137,7 @@ func (s *Server) initMetaStore() {
 	s.store = newStore(s.Config, httpAddr, tcpAddr)
 	s.store.withLogger(s.logger)
 	s.store.node = s.Node
+	s.store.objectStore = s.objectStore
 }
 
 func (s *Server) initNetwork(ln net.Listener) error {
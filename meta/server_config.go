@@ -32,6 +32,10 @@ const (
 
 	// DefaultLeaseDuration is the default duration for leases.
 	DefaultLeaseDuration = 60 * time.Second
+
+	// DefaultShardGroupSkewWarning is how far ahead of the leader's clock a
+	// new shard group may start before a warning is logged.
+	DefaultShardGroupSkewWarning = time.Hour
 )
 
 type ServerConfig struct {
@@ -54,6 +58,11 @@ type ServerConfig struct {
 	ClusterTracing     bool          `toml:"cluster-tracing"`
 	LeaseDuration      toml.Duration `toml:"lease-duration"`
 
+	// ShardGroupSkewWarning is how far ahead of the leader's clock a new
+	// shard group may start before the store warns that the node asking
+	// for it may have a skewed clock. Zero disables the warning.
+	ShardGroupSkewWarning toml.Duration `toml:"shard-group-skew-warning"`
+
 	TLS *tls.Config `toml:"-"`
 }
 
@@ -67,6 +76,8 @@ func NewServerConfig() *ServerConfig {
 		LeaderLeaseTimeout: toml.Duration(DefaultLeaderLeaseTimeout),
 		CommitTimeout:      toml.Duration(DefaultCommitTimeout),
 		LeaseDuration:      toml.Duration(DefaultLeaseDuration),
+
+		ShardGroupSkewWarning: toml.Duration(DefaultShardGroupSkewWarning),
 	}
 
 	return sc
@@ -104,6 +115,8 @@ func (c ServerConfig) Diagnostics() (*diagnostics.Diagnostics, error) {
 		"leader-lease-timeout": c.LeaderLeaseTimeout,
 		"commit-timeout":       c.CommitTimeout,
 		"cluster-tracing":      c.ClusterTracing,
+
+		"shard-group-skew-warning": c.ShardGroupSkewWarning,
 	}), nil
 }
 
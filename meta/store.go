@@ -30,6 +30,10 @@ const (
 // Raft configuration.
 const (
 	raftListenerStartupTimeout = time.Second
+
+	// raftConfigCommitTimeout bounds how long adding a meta node waits for
+	// the new raft configuration to commit.
+	raftConfigCommitTimeout = 10 * time.Second
 )
 
 type store struct {
@@ -47,20 +51,71 @@ type store struct {
 	raftAddr string
 	httpAddr string
 
-	node *cnosdb.Node
+	node  *cnosdb.Node
+	clock clock
+
+	// objectStore holds off-host backups of the meta data, if set.
+	objectStore ObjectStore
+
+	// applied holds the results of recent commands by idempotency key. It
+	// is guarded by mu.
+	applied appliedCommands
+
+	// history holds recent versions of the data to send clients deltas
+	// from. It is guarded by mu.
+	history dataHistory
+
+	// lastApplyErr is the last error from committing a command to raft.
+	applyMu      sync.Mutex
+	lastApplyErr error
+
+	// applyLatencies is a ring of the time recent commands took from submit
+	// to commit, written at applyN modulo its length. Both it and
+	// applyPending are guarded by applyMu.
+	applyLatencies [applyLatencyWindow]time.Duration
+	applyN         uint64
+	applyPending   int
+}
+
+// applyLatencyWindow is the number of recent commands RaftStats summarizes.
+const applyLatencyWindow = 256
+
+// RaftStats reports how long commands take to commit to the meta raft log and
+// how much is waiting to commit.
+type RaftStats struct {
+	// Applied is the number of commands this node has committed since it
+	// started.
+	Applied uint64 `json:"applied"`
+
+	// The apply latencies summarize the most recent commits, up to 256 of
+	// them, and are zero until a command has been committed.
+	LastApplyLatency time.Duration `json:"lastApplyLatency"`
+	MeanApplyLatency time.Duration `json:"meanApplyLatency"`
+	MaxApplyLatency  time.Duration `json:"maxApplyLatency"`
+
+	// PendingApplies is the number of commands submitted on this node that
+	// have not committed yet.
+	PendingApplies int `json:"pendingApplies"`
+
+	// UncommittedEntries is the number of entries in the raft log that have
+	// not been committed.
+	UncommittedEntries uint64 `json:"uncommittedEntries"`
 }
 
 // newStore will create a new metastore with the passed in config
 func newStore(c *Config, httpAddr, raftAddr string) *store {
 	s := store{
 		data: &Data{
-			Index: 1,
+			Index:                1,
+			Version:              DataVersion,
+			caseInsensitiveNames: c.CaseInsensitiveNames,
 		},
 		closing:     make(chan struct{}),
 		dataChanged: make(chan struct{}),
 		path:        c.Dir,
 		config:      c,
 		logger:      zap.NewNop(),
+		clock:       realClock{},
 		httpAddr:    httpAddr,
 		raftAddr:    raftAddr,
 	}
@@ -68,6 +123,9 @@ func newStore(c *Config, httpAddr, raftAddr string) *store {
 	return &s
 }
 
+// setClock replaces the clock used when applying time-dependent commands.
+func (s *store) setClock(clk clock) { s.clock = clk }
+
 func (s *store) withLogger(log *zap.Logger) {
 	if s.config.HTTPD.LoggingEnabled {
 		s.logger = log.With(zap.String("service", "meta-store"))
@@ -207,12 +265,59 @@ func (s *store) close() error {
 	}
 }
 
+// database returns a copy of the named database, or nil if it doesn't exist.
+func (s *store) database(name string) *DatabaseInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	di := s.data.Database(name)
+	if di == nil {
+		return nil
+	}
+	other := di.clone()
+	return &other
+}
+
 func (s *store) snapshot() (*Data, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.data.Clone(), nil
 }
 
+// leaderSnapshot returns a copy of the data that includes every committed
+// command. It returns ErrNotLeader if this node is not the leader.
+func (s *store) leaderSnapshot() (*Data, error) {
+	if s.raftState == nil {
+		return nil, fmt.Errorf("store not open")
+	}
+	if err := s.raftState.barrier(); err == raft.ErrNotLeader {
+		return nil, ErrNotLeader{Leader: s.leaderHTTP()}
+	} else if err != nil {
+		return nil, err
+	}
+	return s.snapshot()
+}
+
+// forceSnapshot snapshots the FSM and compacts the raft log. It returns
+// ErrNotLeader if this node is not the leader.
+func (s *store) forceSnapshot() (SnapshotReport, error) {
+	if s.raftState == nil {
+		return SnapshotReport{}, fmt.Errorf("store not open")
+	} else if !s.isLeader() {
+		return SnapshotReport{}, ErrNotLeader{Leader: s.leaderHTTP()}
+	}
+
+	report := SnapshotReport{PrevSnapshotIndex: s.raftState.lastSnapshotIndex()}
+	first := s.raftState.firstLogIndex()
+	if err := s.raftState.snapshot(); err != nil && err != raft.ErrNothingNewToSnapshot {
+		return SnapshotReport{}, err
+	}
+	report.SnapshotIndex = s.raftState.lastSnapshotIndex()
+	if n := s.raftState.firstLogIndex(); n > first {
+		report.CompactedEntries = n - first
+	}
+	return report, nil
+}
+
 // afterIndex returns a channel that will be closed to signal
 // the caller when an updated snapshot is available.
 func (s *store) afterIndex(index uint64) <-chan struct{} {
@@ -276,6 +381,25 @@ func (s *store) leader() string {
 	return string(s.raftState.raft.Leader())
 }
 
+// ready returns true if the store can serve requests: a leader is known, this
+// node is a meta node, and every committed log entry has been applied.
+func (s *store) ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.raftState == nil || s.raftState.raft == nil || s.raftState.raft.Leader() == "" {
+		return false
+	}
+
+	var member bool
+	for _, n := range s.data.MetaNodes {
+		if n.TCPHost == s.raftAddr {
+			member = true
+			break
+		}
+	}
+	return member && s.raftState.applied()
+}
+
 // leaderHTTP returns the HTTP API connection info for the metanode
 // that is the raft leader
 func (s *store) leaderHTTP() string {
@@ -329,6 +453,20 @@ func (s *store) index() uint64 {
 	return s.data.Index
 }
 
+// version returns the format version of the store's meta data.
+func (s *store) version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Version
+}
+
+// shardIDsPage returns a page of the store's shard IDs. See Data.ShardIDsPage.
+func (s *store) shardIDsPage(afterID uint64, limit int) ([]uint64, uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ShardIDsPage(afterID, limit)
+}
+
 // getNode returns the current store node.
 func (s *store) getNode() *NodeInfo {
 	s.mu.RLock()
@@ -342,10 +480,98 @@ func (s *store) getNode() *NodeInfo {
 
 // apply applies a command to raft.
 func (s *store) apply(b []byte) error {
+	_, err := s.applyResult(b)
+	return err
+}
+
+// applyResult applies a command to raft and returns the value it produced, if
+// any.
+func (s *store) applyResult(b []byte) (uint64, error) {
 	if s.raftState == nil {
-		return fmt.Errorf("store not open")
+		return 0, fmt.Errorf("store not open")
+	}
+
+	s.applyMu.Lock()
+	s.applyPending++
+	s.applyMu.Unlock()
+
+	// Latency is measured with the real clock, not the injected one.
+	start := time.Now()
+	res, err := s.raftState.apply(b)
+	d := time.Since(start)
+
+	// The command committed unless raft failed, even if it returned an error.
+	committed := true
+	switch err.(type) {
+	case nil:
+	case *applyError:
+		s.setLastApplyError(err)
+		committed = false
+	default:
+		if err == raft.ErrNotLeader {
+			err = ErrNotLeader{Leader: s.leaderHTTP()}
+			s.setLastApplyError(err)
+			committed = false
+		}
+	}
+	s.recordApply(d, committed)
+	return res, err
+}
+
+// recordApply marks a submitted command as finished, recording how long it
+// took to commit if it did.
+func (s *store) recordApply(d time.Duration, committed bool) {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	s.applyPending--
+	if committed {
+		s.applyLatencies[s.applyN%applyLatencyWindow] = d
+		s.applyN++
+	}
+}
+
+// raftStats returns the apply latency of recent commands and the raft backlog.
+func (s *store) raftStats() RaftStats {
+	s.applyMu.Lock()
+	stats := RaftStats{Applied: s.applyN, PendingApplies: s.applyPending}
+	n := s.applyN
+	if n > applyLatencyWindow {
+		n = applyLatencyWindow
+	}
+	if n > 0 {
+		stats.LastApplyLatency = s.applyLatencies[(s.applyN-1)%applyLatencyWindow]
+
+		var total time.Duration
+		for _, d := range s.applyLatencies[:n] {
+			total += d
+			if d > stats.MaxApplyLatency {
+				stats.MaxApplyLatency = d
+			}
+		}
+		stats.MeanApplyLatency = total / time.Duration(n)
+	}
+	s.applyMu.Unlock()
+
+	if s.raftState != nil {
+		stats.UncommittedEntries = s.raftState.uncommittedEntries()
 	}
-	return s.raftState.apply(b)
+	return stats
+}
+
+func (s *store) setLastApplyError(err error) {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+	s.lastApplyErr = err
+}
+
+// lastApplyError returns the last error from committing a command to raft,
+// or nil if none has occurred. Errors returned by the commands themselves
+// are not recorded.
+func (s *store) lastApplyError() error {
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+	return s.lastApplyErr
 }
 
 // joinCluster
@@ -375,16 +601,28 @@ func (s *store) joinCluster(peers []string) (*NodeInfo, error) {
 
 // addMetaNode adds a new server to the metaservice and raft
 func (s *store) addMetaNode(n *NodeInfo) (*NodeInfo, error) {
+	// Don't hold s.mu while waiting on raft: the FSM needs it to apply the
+	// entries committed before the configuration change.
 	s.mu.RLock()
-	if s.raftState == nil {
-		s.mu.RUnlock()
+	rs := s.raftState
+	s.mu.RUnlock()
+	if rs == nil {
 		return nil, fmt.Errorf("store not open")
 	}
-	if err := s.raftState.addVoter(n.TCPHost); err != nil {
-		s.mu.RUnlock()
+	if err := rs.addVoter(n.TCPHost); err != nil {
+		if err == raft.ErrNotLeader {
+			return nil, ErrNotLeader{Leader: s.leaderHTTP()}
+		}
+		return nil, err
+	}
+	// Let the configuration settle so the commands below are not routed
+	// by a stale view of the cluster.
+	if err := rs.waitForVoter(n.TCPHost, raftConfigCommitTimeout); err != nil {
+		if err == raft.ErrNotLeader {
+			return nil, ErrNotLeader{Leader: s.leaderHTTP()}
+		}
 		return nil, err
 	}
-	s.mu.RUnlock()
 
 	if err := s.callCreateMetaNode(n.Host, n.TCPHost); err != nil {
 		return nil, err
@@ -424,6 +662,9 @@ func (s *store) removeMetaNode(n *NodeInfo) (*NodeInfo, error) {
 	if err := s.raftState.removeVoter(n.TCPHost); err != nil {
 		s.mu.RUnlock()
 		s.logger.Error("removeMeta remove voter failed", zap.Error(err))
+		if err == raft.ErrNotLeader {
+			return nil, ErrNotLeader{Leader: s.leaderHTTP()}
+		}
 		return nil, err
 	}
 	s.mu.RUnlock()
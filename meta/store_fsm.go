@@ -1,12 +1,14 @@
 package meta
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"time"
 
 	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/cnosdb/cnosdb/pkg/logger"
 	"github.com/cnosdb/cnosdb/vend/cnosql"
 
 	"github.com/gogo/protobuf/proto"
@@ -29,82 +31,210 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	err := func() interface{} {
-		switch cmd.GetType() {
-		case internal.Command_RemovePeerCommand:
-			return fsm.applyRemovePeerCommand(&cmd)
-		case internal.Command_CreateNodeCommand:
-			// create node was in < 0.10.0 servers, we need the peers
-			// list to convert to the appropriate data/meta nodes now
-			peers, err := s.raftState.peers()
-			if err != nil {
-				return err
-			}
-			return fsm.applyCreateNodeCommand(&cmd, peers)
-		case internal.Command_DeleteNodeCommand:
-			return fsm.applyDeleteNodeCommand(&cmd)
-		case internal.Command_CreateDatabaseCommand:
-			return fsm.applyCreateDatabaseCommand(&cmd)
-		case internal.Command_DropDatabaseCommand:
-			return fsm.applyDropDatabaseCommand(&cmd)
-		case internal.Command_CreateRetentionPolicyCommand:
-			return fsm.applyCreateRetentionPolicyCommand(&cmd)
-		case internal.Command_DropRetentionPolicyCommand:
-			return fsm.applyDropRetentionPolicyCommand(&cmd)
-		case internal.Command_SetDefaultRetentionPolicyCommand:
-			return fsm.applySetDefaultRetentionPolicyCommand(&cmd)
-		case internal.Command_UpdateRetentionPolicyCommand:
-			return fsm.applyUpdateRetentionPolicyCommand(&cmd)
-		case internal.Command_CreateShardGroupCommand:
-			return fsm.applyCreateShardGroupCommand(&cmd)
-		case internal.Command_DeleteShardGroupCommand:
-			return fsm.applyDeleteShardGroupCommand(&cmd)
-		case internal.Command_CreateContinuousQueryCommand:
-			return fsm.applyCreateContinuousQueryCommand(&cmd)
-		case internal.Command_DropContinuousQueryCommand:
-			return fsm.applyDropContinuousQueryCommand(&cmd)
-		case internal.Command_CreateSubscriptionCommand:
-			return fsm.applyCreateSubscriptionCommand(&cmd)
-		case internal.Command_DropSubscriptionCommand:
-			return fsm.applyDropSubscriptionCommand(&cmd)
-		case internal.Command_CreateUserCommand:
-			return fsm.applyCreateUserCommand(&cmd)
-		case internal.Command_DropUserCommand:
-			return fsm.applyDropUserCommand(&cmd)
-		case internal.Command_UpdateUserCommand:
-			return fsm.applyUpdateUserCommand(&cmd)
-		case internal.Command_SetPrivilegeCommand:
-			return fsm.applySetPrivilegeCommand(&cmd)
-		case internal.Command_SetAdminPrivilegeCommand:
-			return fsm.applySetAdminPrivilegeCommand(&cmd)
-		case internal.Command_SetDataCommand:
-			return fsm.applySetDataCommand(&cmd)
-		case internal.Command_UpdateNodeCommand:
-			return fsm.applyUpdateNodeCommand(&cmd)
-		case internal.Command_CreateMetaNodeCommand:
-			return fsm.applyCreateMetaNodeCommand(&cmd)
-		case internal.Command_DeleteMetaNodeCommand:
-			return fsm.applyDeleteMetaNodeCommand(&cmd, s)
-		case internal.Command_SetMetaNodeCommand:
-			return fsm.applySetMetaNodeCommand(&cmd)
-		case internal.Command_CreateDataNodeCommand:
-			return fsm.applyCreateDataNodeCommand(&cmd)
-		case internal.Command_DeleteDataNodeCommand:
-			return fsm.applyDeleteDataNodeCommand(&cmd)
-		default:
-			panic(fmt.Errorf("cannot apply command: %x", l.Data))
+	key := cmd.GetIdempotencyKey()
+	res := func() interface{} {
+		// A resent command that has already been applied gets the result
+		// of the first attempt instead of being applied again.
+		if res, ok := s.applied.result(key); ok {
+			return res
+		}
+
+		// Only clearing maintenance mode changes frozen data.
+		if fsm.data.MaintenanceMode && cmd.GetType() != internal.Command_SetMaintenanceModeCommand {
+			return ErrMaintenanceMode
 		}
+
+		return fsm.applyCommand(&cmd, l.Data)
 	}()
+	s.applied.add(key, res)
 
 	// Copy term and index to new metadata.
 	fsm.data.Term = l.Term
 	fsm.data.Index = l.Index
+	s.history.add(l.Index, fsm.data)
 
 	// signal that the data changed
 	close(s.dataChanged)
 	s.dataChanged = make(chan struct{})
 
-	return err
+	return res
+}
+
+// commandResult is the value returned by a command that succeeded and
+// produced one, such as the first of the shard IDs it reserved. Any other
+// command returns nil or an error.
+type commandResult uint64
+
+// applyCommand applies cmd to the data. raw is the marshaled command, or the
+// transaction holding it, for reporting commands of unknown type.
+func (fsm *storeFSM) applyCommand(cmd *internal.Command, raw []byte) interface{} {
+	s := (*store)(fsm)
+
+	switch cmd.GetType() {
+	case internal.Command_RemovePeerCommand:
+		return fsm.applyRemovePeerCommand(cmd)
+	case internal.Command_CreateNodeCommand:
+		// create node was in < 0.10.0 servers, we need the peers
+		// list to convert to the appropriate data/meta nodes now
+		peers, err := s.raftState.peers()
+		if err != nil {
+			return err
+		}
+		return fsm.applyCreateNodeCommand(cmd, peers)
+	case internal.Command_DeleteNodeCommand:
+		return fsm.applyDeleteNodeCommand(cmd)
+	case internal.Command_CreateDatabaseCommand:
+		return fsm.applyCreateDatabaseCommand(cmd)
+	case internal.Command_DropDatabaseCommand:
+		return fsm.applyDropDatabaseCommand(cmd)
+	case internal.Command_CreateRetentionPolicyCommand:
+		return fsm.applyCreateRetentionPolicyCommand(cmd)
+	case internal.Command_DropRetentionPolicyCommand:
+		return fsm.applyDropRetentionPolicyCommand(cmd)
+	case internal.Command_SetDefaultRetentionPolicyCommand:
+		return fsm.applySetDefaultRetentionPolicyCommand(cmd)
+	case internal.Command_UpdateRetentionPolicyCommand:
+		return fsm.applyUpdateRetentionPolicyCommand(cmd)
+	case internal.Command_CopyRetentionPolicyCommand:
+		return fsm.applyCopyRetentionPolicyCommand(cmd)
+	case internal.Command_SetMeasurementRetentionCommand:
+		return fsm.applySetMeasurementRetentionCommand(cmd)
+	case internal.Command_FreezeRetentionPolicyCommand:
+		return fsm.applyFreezeRetentionPolicyCommand(cmd)
+	case internal.Command_SetDatabaseQuotaCommand:
+		return fsm.applySetDatabaseQuotaCommand(cmd)
+	case internal.Command_SetDatabaseDefaultShardGroupDurationCommand:
+		return fsm.applySetDatabaseDefaultShardGroupDurationCommand(cmd)
+	case internal.Command_CreateShardGroupCommand:
+		return fsm.applyCreateShardGroupCommand(cmd)
+	case internal.Command_CreateShardGroupsCommand:
+		return fsm.applyCreateShardGroupsCommand(cmd)
+	case internal.Command_HeartbeatDataNodeCommand:
+		return fsm.applyHeartbeatDataNodeCommand(cmd)
+	case internal.Command_SetDataNodeDrainingCommand:
+		return fsm.applySetDataNodeDrainingCommand(cmd)
+	case internal.Command_DeleteShardGroupCommand:
+		return fsm.applyDeleteShardGroupCommand(cmd)
+	case internal.Command_RebalanceShardGroupCommand:
+		return fsm.applyRebalanceShardGroupCommand(cmd)
+	case internal.Command_SetMaintenanceModeCommand:
+		return fsm.applySetMaintenanceModeCommand(cmd)
+	case internal.Command_ReserveShardIDsCommand:
+		return fsm.applyReserveShardIDsCommand(cmd)
+	case internal.Command_RepairDefaultsCommand:
+		return fsm.applyRepairDefaultsCommand(cmd)
+	case internal.Command_MarkShardGroupDeletedCommand:
+		return fsm.applyMarkShardGroupDeletedCommand(cmd)
+	case internal.Command_CreateContinuousQueryCommand:
+		return fsm.applyCreateContinuousQueryCommand(cmd)
+	case internal.Command_ReplaceContinuousQueryCommand:
+		return fsm.applyReplaceContinuousQueryCommand(cmd)
+	case internal.Command_DropContinuousQueryCommand:
+		return fsm.applyDropContinuousQueryCommand(cmd)
+	case internal.Command_CreateSubscriptionCommand:
+		return fsm.applyCreateSubscriptionCommand(cmd)
+	case internal.Command_DropSubscriptionCommand:
+		return fsm.applyDropSubscriptionCommand(cmd)
+	case internal.Command_CreateUserCommand:
+		return fsm.applyCreateUserCommand(cmd)
+	case internal.Command_DropUserCommand:
+		return fsm.applyDropUserCommand(cmd)
+	case internal.Command_UpdateUserCommand:
+		return fsm.applyUpdateUserCommand(cmd)
+	case internal.Command_SetPrivilegeCommand:
+		return fsm.applySetPrivilegeCommand(cmd)
+	case internal.Command_SetAdminPrivilegeCommand:
+		return fsm.applySetAdminPrivilegeCommand(cmd)
+	case internal.Command_SetDataCommand:
+		return fsm.applySetDataCommand(cmd)
+	case internal.Command_UpdateNodeCommand:
+		return fsm.applyUpdateNodeCommand(cmd)
+	case internal.Command_CreateMetaNodeCommand:
+		return fsm.applyCreateMetaNodeCommand(cmd)
+	case internal.Command_DeleteMetaNodeCommand:
+		return fsm.applyDeleteMetaNodeCommand(cmd, s)
+	case internal.Command_SetMetaNodeCommand:
+		return fsm.applySetMetaNodeCommand(cmd)
+	case internal.Command_CreateDataNodeCommand:
+		return fsm.applyCreateDataNodeCommand(cmd)
+	case internal.Command_DeleteDataNodeCommand:
+		return fsm.applyDeleteDataNodeCommand(cmd)
+	case internal.Command_TransactionCommand:
+		return fsm.applyTransactionCommand(cmd, raw)
+	default:
+		panic(fmt.Errorf("cannot apply command: %x", raw))
+	}
+}
+
+// appliedCommandsWindow is the number of idempotency keys whose results are
+// remembered.
+const appliedCommandsWindow = 1024
+
+// appliedCommands holds the results of the most recently applied commands by
+// idempotency key. Every node applies the same log and so holds the same
+// keys. They are saved in snapshots, so that a node restored from one
+// dedupes the same commands as the nodes that applied the log.
+type appliedCommands struct {
+	results map[string]interface{}
+	keys    []string // oldest first
+}
+
+// result returns the result of the command applied with key, if any.
+func (a *appliedCommands) result(key string) (interface{}, bool) {
+	if key == "" {
+		return nil, false
+	}
+	res, ok := a.results[key]
+	return res, ok
+}
+
+// add records the result of the command applied with key, forgetting the
+// oldest key once the window is full.
+func (a *appliedCommands) add(key string, res interface{}) {
+	if key == "" {
+		return
+	} else if _, ok := a.results[key]; ok {
+		return
+	}
+	if a.results == nil {
+		a.results = make(map[string]interface{})
+	}
+	if len(a.keys) >= appliedCommandsWindow {
+		delete(a.results, a.keys[0])
+		a.keys = a.keys[1:]
+	}
+	a.results[key] = res
+	a.keys = append(a.keys, key)
+}
+
+// marshal returns the window, oldest first. Errors are kept as their
+// messages.
+func (a *appliedCommands) marshal() []*internal.AppliedCommand {
+	pb := make([]*internal.AppliedCommand, len(a.keys))
+	for i, key := range a.keys {
+		pb[i] = &internal.AppliedCommand{Key: proto.String(key)}
+		switch res := a.results[key].(type) {
+		case error:
+			pb[i].Error = proto.String(res.Error())
+		case commandResult:
+			pb[i].Result = proto.Uint64(uint64(res))
+		}
+	}
+	return pb
+}
+
+// unmarshal replaces the window with the one in pb.
+func (a *appliedCommands) unmarshal(pb []*internal.AppliedCommand) {
+	*a = appliedCommands{}
+	for _, c := range pb {
+		var res interface{}
+		if c.Error != nil {
+			res = errors.New(c.GetError())
+		} else if c.Result != nil {
+			res = commandResult(c.GetResult())
+		}
+		a.add(c.GetKey(), res)
+	}
 }
 
 func (fsm *storeFSM) applyRemovePeerCommand(cmd *internal.Command) interface{} {
@@ -195,6 +325,34 @@ func (fsm *storeFSM) applyUpdateDataNodeCommand(cmd *internal.Command) interface
 	return nil
 }
 
+func (fsm *storeFSM) applyHeartbeatDataNodeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_HeartbeatDataNodeCommand_Command)
+	v := ext.(*internal.HeartbeatDataNodeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.HeartbeatDataNode(v.GetID(), time.Unix(0, v.GetTime())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetDataNodeDrainingCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDataNodeDrainingCommand_Command)
+	v := ext.(*internal.SetDataNodeDrainingCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDataNodeDraining(v.GetID(), v.GetDraining()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 // applyDeleteNodeCommand is from < 0.10.0. no op for this one
 func (fsm *storeFSM) applyDeleteNodeCommand(cmd *internal.Command) interface{} {
 	return nil
@@ -206,6 +364,9 @@ func (fsm *storeFSM) applyCreateDatabaseCommand(cmd *internal.Command) interface
 
 	// Copy data and update.
 	other := fsm.data.Clone()
+	if v.GetStrict() && other.Database(v.GetName()) != nil {
+		return ErrDatabaseExists
+	}
 	if err := other.CreateDatabase(v.GetName()); err != nil {
 		return err
 	}
@@ -217,6 +378,9 @@ func (fsm *storeFSM) applyCreateDatabaseCommand(cmd *internal.Command) interface
 			ReplicaN:           int(rpi.GetReplicaN()),
 			Duration:           time.Duration(rpi.GetDuration()),
 			ShardGroupDuration: time.Duration(rpi.GetShardGroupDuration()),
+			ShardsPerGroup:     int(rpi.GetShardsPerGroup()),
+			ShardKeyTags:       rpi.GetShardKeyTags(),
+			TenantNodes:        unmarshalTenantNodes(rpi.GetTenantNodes()),
 		}, true); err != nil {
 			if err == ErrRetentionPolicyExists {
 				return ErrRetentionPolicyConflict
@@ -251,9 +415,13 @@ func (fsm *storeFSM) applyDropDatabaseCommand(cmd *internal.Command) interface{}
 	ext, _ := proto.GetExtension(cmd, internal.E_DropDatabaseCommand_Command)
 	v := ext.(*internal.DropDatabaseCommand)
 
+	// Commands logged before dropping was refused for databases with
+	// subscriptions have no Force, and dropped them regardless.
+	force := v.Force == nil || v.GetForce()
+
 	// Copy data and update.
 	other := fsm.data.Clone()
-	if err := other.DropDatabase(v.GetName()); err != nil {
+	if err := other.dropDatabase(v.GetName(), force); err != nil {
 		return err
 	}
 	fsm.data = other
@@ -274,6 +442,9 @@ func (fsm *storeFSM) applyCreateRetentionPolicyCommand(cmd *internal.Command) in
 			ReplicaN:           int(pb.GetReplicaN()),
 			Duration:           time.Duration(pb.GetDuration()),
 			ShardGroupDuration: time.Duration(pb.GetShardGroupDuration()),
+			ShardsPerGroup:     int(pb.GetShardsPerGroup()),
+			ShardKeyTags:       pb.GetShardKeyTags(),
+			TenantNodes:        unmarshalTenantNodes(pb.GetTenantNodes()),
 		}, false); err != nil {
 		return err
 	}
@@ -310,6 +481,76 @@ func (fsm *storeFSM) applySetDefaultRetentionPolicyCommand(cmd *internal.Command
 	return nil
 }
 
+func (fsm *storeFSM) applyRepairDefaultsCommand(cmd *internal.Command) interface{} {
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if len(other.repairDefaultRetentionPolicies()) == 0 {
+		return nil
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetMeasurementRetentionCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetMeasurementRetentionCommand_Command)
+	v := ext.(*internal.SetMeasurementRetentionCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetMeasurementRetention(v.GetDatabase(), v.GetRetentionPolicy(), v.GetMeasurement(), time.Duration(v.GetDuration())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyFreezeRetentionPolicyCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_FreezeRetentionPolicyCommand_Command)
+	v := ext.(*internal.FreezeRetentionPolicyCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.FreezeRetentionPolicy(v.GetDatabase(), v.GetRetentionPolicy(), v.GetFrozen()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetDatabaseQuotaCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDatabaseQuotaCommand_Command)
+	v := ext.(*internal.SetDatabaseQuotaCommand)
+
+	var q DatabaseQuota
+	q.unmarshal(v.GetQuota())
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDatabaseQuota(v.GetDatabase(), q); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetDatabaseDefaultShardGroupDurationCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDatabaseDefaultShardGroupDurationCommand_Command)
+	v := ext.(*internal.SetDatabaseDefaultShardGroupDurationCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetDatabaseDefaultShardGroupDuration(v.GetDatabase(), time.Duration(v.GetDuration())); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyUpdateRetentionPolicyCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_UpdateRetentionPolicyCommand_Command)
 	v := ext.(*internal.UpdateRetentionPolicyCommand)
@@ -324,6 +565,10 @@ func (fsm *storeFSM) applyUpdateRetentionPolicyCommand(cmd *internal.Command) in
 		value := int(v.GetReplicaN())
 		rpu.ReplicaN = &value
 	}
+	if v.ShardsPerGroup != nil {
+		value := int(v.GetShardsPerGroup())
+		rpu.ShardsPerGroup = &value
+	}
 
 	// Copy data and update.
 	other := fsm.data.Clone()
@@ -335,6 +580,20 @@ func (fsm *storeFSM) applyUpdateRetentionPolicyCommand(cmd *internal.Command) in
 	return nil
 }
 
+func (fsm *storeFSM) applyCopyRetentionPolicyCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CopyRetentionPolicyCommand_Command)
+	v := ext.(*internal.CopyRetentionPolicyCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if _, err := other.CopyRetentionPolicy(v.GetDatabase(), v.GetSource(), v.GetDestination()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateShardGroupCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateShardGroupCommand_Command)
 	v := ext.(*internal.CreateShardGroupCommand)
@@ -344,6 +603,77 @@ func (fsm *storeFSM) applyCreateShardGroupCommand(cmd *internal.Command) interfa
 	if err := other.CreateShardGroup(v.GetDatabase(), v.GetRetentionPolicy(), time.Unix(0, v.GetTimestamp())); err != nil {
 		return err
 	}
+	fsm.warnShardGroupSkew(fsm.data, other)
+	prev := fsm.data
+	fsm.data = other
+
+	// Return the ID of the group if the command created it rather than
+	// finding it already there.
+	if other.MaxShardGroupID != prev.MaxShardGroupID {
+		return commandResult(other.MaxShardGroupID)
+	}
+	return nil
+}
+
+// warnShardGroupSkew logs a warning for each shard group created between prev
+// and data that starts further ahead of this node's clock than the configured
+// threshold, since the data node that asked for it likely has a clock that
+// runs ahead. Followers apply the same groups, so only the leader warns.
+func (fsm *storeFSM) warnShardGroupSkew(prev, data *Data) {
+	threshold := time.Duration(fsm.config.HTTPD.ShardGroupSkewWarning)
+	if threshold <= 0 || data.MaxShardGroupID == prev.MaxShardGroupID {
+		return
+	} else if fsm.raftState != nil && fsm.raftState.raft.State() != raft.Leader {
+		return
+	}
+
+	now := fsm.clock.Now()
+	for _, di := range data.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.ID <= prev.MaxShardGroupID {
+					continue
+				}
+				if skew := sgi.StartTime.Sub(now); skew > threshold {
+					fsm.logger.Warn("Created shard group starts in the future, check the clocks of the data nodes",
+						logger.Database(di.Name),
+						logger.RetentionPolicy(rpi.Name),
+						logger.ShardGroup(sgi.ID),
+						zap.Time("start", sgi.StartTime),
+						zap.Duration("skew", skew))
+				}
+			}
+		}
+	}
+}
+
+func (fsm *storeFSM) applyCreateShardGroupsCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_CreateShardGroupsCommand_Command)
+	v := ext.(*internal.CreateShardGroupsCommand)
+
+	reqs := make([]shardGroupRequest, len(v.GetGroups()))
+	for i, g := range v.GetGroups() {
+		reqs[i] = shardGroupRequest{
+			database:  g.GetDatabase(),
+			rp:        g.GetRetentionPolicy(),
+			timestamp: time.Unix(0, g.GetTimestamp()),
+		}
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	failed := other.createShardGroups(reqs)
+	for _, f := range failed {
+		fsm.logger.Warn("Skipped shard group that could not be created",
+			logger.Database(f.req.database),
+			logger.RetentionPolicy(f.req.rp),
+			zap.Time("timestamp", f.req.timestamp),
+			zap.Error(f.err))
+	}
+	if len(reqs) > 0 && len(failed) == len(reqs) {
+		return ErrShardGroupsNotCreated
+	}
+	fsm.warnShardGroupSkew(fsm.data, other)
 	fsm.data = other
 
 	return nil
@@ -355,7 +685,71 @@ func (fsm *storeFSM) applyDeleteShardGroupCommand(cmd *internal.Command) interfa
 
 	// Copy data and update.
 	other := fsm.data.Clone()
-	if err := other.DeleteShardGroup(v.GetDatabase(), v.GetRetentionPolicy(), v.GetShardGroupID()); err != nil {
+	if err := other.MarkShardGroupDeleted(v.GetDatabase(), v.GetRetentionPolicy(), v.GetShardGroupID(), fsm.clock.Now()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyRebalanceShardGroupCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RebalanceShardGroupCommand_Command)
+	v := ext.(*internal.RebalanceShardGroupCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.RebalanceShardGroup(v.GetDatabase(), v.GetRetentionPolicy(), v.GetShardGroupID()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applySetMaintenanceModeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetMaintenanceModeCommand_Command)
+	v := ext.(*internal.SetMaintenanceModeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	other.MaintenanceMode = v.GetOn()
+	fsm.data = other
+
+	return nil
+}
+
+func (fsm *storeFSM) applyReserveShardIDsCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_ReserveShardIDsCommand_Command)
+	v := ext.(*internal.ReserveShardIDsCommand)
+
+	// Reject the reservation if another took the requested IDs first.
+	if v.Start != nil && v.GetStart() != fsm.data.MaxShardID+1 {
+		return ErrShardIDsReserved
+	}
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	start, err := other.ReserveShardIDs(int(v.GetN()))
+	if err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return commandResult(start)
+}
+
+func (fsm *storeFSM) applyMarkShardGroupDeletedCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_MarkShardGroupDeletedCommand_Command)
+	v := ext.(*internal.MarkShardGroupDeletedCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	var at time.Time
+	if v.GetDeletedAt() != 0 {
+		at = time.Unix(0, v.GetDeletedAt())
+	}
+	if err := other.MarkShardGroupDeleted(v.GetDatabase(), v.GetRetentionPolicy(), v.GetShardGroupID(), at); err != nil {
 		return err
 	}
 	fsm.data = other
@@ -377,6 +771,20 @@ func (fsm *storeFSM) applyCreateContinuousQueryCommand(cmd *internal.Command) in
 	return nil
 }
 
+func (fsm *storeFSM) applyReplaceContinuousQueryCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_ReplaceContinuousQueryCommand_Command)
+	v := ext.(*internal.ReplaceContinuousQueryCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.CreateOrReplaceContinuousQuery(v.GetDatabase(), v.GetName(), v.GetQuery()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyDropContinuousQueryCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_DropContinuousQueryCommand_Command)
 	v := ext.(*internal.DropContinuousQueryCommand)
@@ -481,6 +889,11 @@ func (fsm *storeFSM) applySetAdminPrivilegeCommand(cmd *internal.Command) interf
 	if err := other.SetAdminPrivilege(v.GetUsername(), v.GetAdmin()); err != nil {
 		return err
 	}
+	if v.GetAdmin() && v.GetClearPrivileges() {
+		if err := other.ClearPrivileges(v.GetUsername()); err != nil {
+			return err
+		}
+	}
 	fsm.data = other
 	return nil
 }
@@ -490,8 +903,15 @@ func (fsm *storeFSM) applySetDataCommand(cmd *internal.Command) interface{} {
 	v := ext.(*internal.SetDataCommand)
 
 	// Overwrite data.
-	fsm.data = &Data{}
-	fsm.data.unmarshal(v.GetData())
+	other := &Data{caseInsensitiveNames: fsm.config.CaseInsensitiveNames}
+	other.unmarshal(v.GetData())
+	if v.GetPreserveNodes() {
+		other.preserveNodes(fsm.data)
+		if a := other.UnderReplicatedShards(); len(a) > 0 {
+			fsm.logger.Warn("Restored shards are under-replicated", zap.Int("shards", len(a)))
+		}
+	}
+	fsm.data = other
 
 	return nil
 }
@@ -574,19 +994,61 @@ func (fsm *storeFSM) applyDeleteDataNodeCommand(cmd *internal.Command) interface
 	v := ext.(*internal.DeleteDataNodeCommand)
 
 	other := fsm.data.Clone()
-	if err := other.DeleteDataNode(v.GetID()); err != nil {
+	if err := other.deleteDataNode(v.GetID(), fsm.clock.Now()); err != nil {
 		return err
 	}
 	fsm.data = other
 	return nil
 }
 
+// transactionCommands are the commands a Transaction builds, the only ones
+// a transaction may hold.
+var transactionCommands = map[internal.Command_Type]bool{
+	internal.Command_CreateDatabaseCommand:            true,
+	internal.Command_DropDatabaseCommand:              true,
+	internal.Command_CreateRetentionPolicyCommand:     true,
+	internal.Command_DropRetentionPolicyCommand:       true,
+	internal.Command_SetDefaultRetentionPolicyCommand: true,
+	internal.Command_UpdateRetentionPolicyCommand:     true,
+}
+
+func (fsm *storeFSM) applyTransactionCommand(cmd *internal.Command, raw []byte) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_TransactionCommand_Command)
+	v := ext.(*internal.TransactionCommand)
+
+	// Reject the whole transaction if any command isn't one a Transaction
+	// builds, before applying any of them.
+	cmds := make([]*internal.Command, len(v.GetCommands()))
+	for i, b := range v.GetCommands() {
+		var c internal.Command
+		if err := proto.Unmarshal(b, &c); err != nil {
+			panic(fmt.Errorf("cannot unmarshal transaction command: %x", raw))
+		}
+		if !transactionCommands[c.GetType()] {
+			return ErrInvalidTransactionCommand
+		}
+		cmds[i] = &c
+	}
+
+	// Each command replaces fsm.data with an updated copy, so putting back
+	// the data from before the first one undoes them all.
+	orig := fsm.data
+	for _, c := range cmds {
+		if err := fsm.applyCommand(c, raw); err != nil {
+			fsm.data = orig
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (fsm *storeFSM) Snapshot() (raft.FSMSnapshot, error) {
 	s := (*store)(fsm)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return &storeFSMSnapshot{Data: (*store)(fsm).data}, nil
+	return &storeFSMSnapshot{Data: s.data, Applied: s.applied.marshal()}, nil
 }
 
 func (fsm *storeFSM) Restore(r io.ReadCloser) error {
@@ -597,27 +1059,34 @@ func (fsm *storeFSM) Restore(r io.ReadCloser) error {
 	}
 
 	// Decode metadata.
-	data := &Data{}
-	if err := data.UnmarshalBinary(b); err != nil {
+	var pb internal.Data
+	if err := proto.Unmarshal(b, &pb); err != nil {
 		return err
 	}
+	data := &Data{caseInsensitiveNames: fsm.config.CaseInsensitiveNames}
+	data.unmarshal(&pb)
 
 	// Set metadata on store.
 	// NOTE: No lock because Hashicorp Raft doesn't call Restore concurrently
 	// with any other function.
 	fsm.data = data
+	fsm.applied.unmarshal(pb.GetAppliedCommands())
+	fsm.history.reset()
 
 	return nil
 }
 
 type storeFSMSnapshot struct {
-	Data *Data
+	Data    *Data
+	Applied []*internal.AppliedCommand
 }
 
 func (s *storeFSMSnapshot) Persist(sink raft.SnapshotSink) error {
 	err := func() error {
 		// Encode data.
-		p, err := s.Data.MarshalBinary()
+		pb := s.Data.marshal()
+		pb.AppliedCommands = s.Applied
+		p, err := proto.Marshal(pb)
 		if err != nil {
 			return err
 		}
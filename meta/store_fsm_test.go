@@ -0,0 +1,812 @@
+package meta
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStoreFSM_MarkShardGroupDeleted(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsm.data.CreateRetentionPolicy("db0", &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsm.data.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	sgi := fsm.data.Databases[0].RetentionPolicies[0].ShardGroups[0]
+
+	at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := applyTestCommand(t, fsm, internal.Command_MarkShardGroupDeletedCommand, internal.E_MarkShardGroupDeletedCommand_Command, &internal.MarkShardGroupDeletedCommand{
+		Database:        proto.String("db0"),
+		RetentionPolicy: proto.String("rp0"),
+		ShardGroupID:    proto.Uint64(sgi.ID),
+		DeletedAt:       proto.Int64(at.UnixNano()),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fsm.data.Databases[0].RetentionPolicies[0].ShardGroups[0].DeletedAt; !got.Equal(at) {
+		t.Fatalf("unexpected deleted at: got %s, exp %s", got, at)
+	}
+
+	// A command without a deletion time is rejected.
+	if err := applyTestCommand(t, fsm, internal.Command_MarkShardGroupDeletedCommand, internal.E_MarkShardGroupDeletedCommand_Command, &internal.MarkShardGroupDeletedCommand{
+		Database:        proto.String("db0"),
+		RetentionPolicy: proto.String("rp0"),
+		ShardGroupID:    proto.Uint64(sgi.ID),
+		DeletedAt:       proto.Int64(0),
+	}); err != ErrShardGroupDeletedAtRequired {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrShardGroupDeletedAtRequired)
+	}
+}
+
+func TestStoreFSM_HeartbeatDataNode(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDataNode("host0:8086", "host0:8088"); err != nil {
+		t.Fatal(err)
+	}
+	id := fsm.data.DataNodes[0].ID
+
+	heartbeat := func(id uint64, at time.Time) error {
+		return applyTestCommand(t, fsm, internal.Command_HeartbeatDataNodeCommand, internal.E_HeartbeatDataNodeCommand_Command, &internal.HeartbeatDataNodeCommand{
+			ID:   proto.Uint64(id),
+			Time: proto.Int64(at.UnixNano()),
+		})
+	}
+
+	at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := heartbeat(id, at); err != nil {
+		t.Fatal(err)
+	}
+	// A late heartbeat doesn't move LastSeen back.
+	if err := heartbeat(id, at.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := heartbeat(id+1, at); err != ErrNodeNotFound {
+		t.Fatalf("unexpected error for unknown node: %v", err)
+	}
+
+	// LastSeen survives a snapshot.
+	b, err := fsm.data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data Data
+	if err := data.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if got := data.DataNode(id).LastSeen; !got.Equal(at) {
+		t.Fatalf("unexpected last seen: got %s, exp %s", got, at)
+	}
+}
+
+func TestStoreFSM_CreateDatabase_Strict(t *testing.T) {
+	fsm := newTestStoreFSM()
+	fsm.config = NewConfig()
+
+	create := func(strict bool) error {
+		return applyTestCommand(t, fsm, internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, &internal.CreateDatabaseCommand{
+			Name:   proto.String("db0"),
+			Strict: proto.Bool(strict),
+		})
+	}
+
+	if err := create(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := create(false); err != nil {
+		t.Fatalf("unexpected error from lenient create: %v", err)
+	}
+	if err := create(true); err != ErrDatabaseExists {
+		t.Fatalf("unexpected error from strict create: %v", err)
+	}
+	if n := len(fsm.data.Databases); n != 1 {
+		t.Fatalf("unexpected database count: %d", n)
+	}
+}
+
+func TestStoreFSM_SetData_PreserveNodes(t *testing.T) {
+	fsm := newTestStoreFSM()
+	fsm.config = NewConfig()
+	fsm.data.ClusterID = 100
+	fsm.data.MetaNodes = []NodeInfo{{ID: 1, Host: "meta0:8091", TCPHost: "meta0:8089"}}
+	if err := fsm.data.CreateDataNode("data0:8086", "data0:8088"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The backup comes from another cluster with its own nodes.
+	backup := &Data{ClusterID: 200, MetaNodes: []NodeInfo{{ID: 1, Host: "other:8091", TCPHost: "other:8089"}}}
+	if err := backup.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.CreateUser("u0", "hash", false); err != nil {
+		t.Fatal(err)
+	}
+	// One backup node shares its address with data0, the other is gone.
+	backup.DataNodes = []NodeInfo{
+		{ID: 5, Host: "data0:8086", TCPHost: "data0:8088"},
+		{ID: 6, Host: "gone:8086", TCPHost: "gone:8088"},
+	}
+	backup.MaxNodeID = 6
+	rpi := &RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := backup.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.CreateShardGroup("db0", "rp0", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	setData := func(preserveNodes bool) {
+		t.Helper()
+		if err := applyTestCommand(t, fsm, internal.Command_SetDataCommand, internal.E_SetDataCommand_Command, &internal.SetDataCommand{
+			Data:          backup.marshal(),
+			PreserveNodes: proto.Bool(preserveNodes),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setData(true)
+	if fsm.data.ClusterID != 100 {
+		t.Fatalf("unexpected cluster id: %d", fsm.data.ClusterID)
+	} else if len(fsm.data.MetaNodes) != 1 || fsm.data.MetaNodes[0].Host != "meta0:8091" {
+		t.Fatalf("unexpected meta nodes: %+v", fsm.data.MetaNodes)
+	} else if len(fsm.data.DataNodes) != 1 || fsm.data.DataNodes[0].Host != "data0:8086" {
+		t.Fatalf("unexpected data nodes: %+v", fsm.data.DataNodes)
+	}
+	if fsm.data.Database("db0") == nil {
+		t.Fatal("expected database to be restored")
+	} else if fsm.data.user("u0") == nil {
+		t.Fatal("expected user to be restored")
+	}
+	sh := fsm.data.Database("db0").RetentionPolicy("rp0").ShardGroups[0].Shards[0]
+	if len(sh.Owners) != 1 || sh.Owners[0].NodeID != 1 {
+		t.Fatalf("unexpected shard owners: %+v", sh.Owners)
+	} else if a := fsm.data.UnderReplicatedShards(); len(a) != 1 || a[0].ShardID != sh.ID {
+		t.Fatalf("unexpected under-replicated shards: %+v", a)
+	} else if load := fsm.data.DataNodeLoad(); load[1] != 1 {
+		t.Fatalf("unexpected load: %v", load)
+	}
+
+	// Without the flag the nodes are overwritten as well.
+	setData(false)
+	if fsm.data.ClusterID != 200 {
+		t.Fatalf("unexpected cluster id: %d", fsm.data.ClusterID)
+	} else if len(fsm.data.MetaNodes) != 1 || fsm.data.MetaNodes[0].Host != "other:8091" {
+		t.Fatalf("unexpected meta nodes: %+v", fsm.data.MetaNodes)
+	} else if len(fsm.data.DataNodes) != 2 || fsm.data.DataNodes[0].ID != 5 {
+		t.Fatalf("unexpected data nodes: %+v", fsm.data.DataNodes)
+	}
+}
+
+func TestStoreFSM_CopyRetentionPolicy(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &RetentionPolicyInfo{Name: "rp0", ReplicaN: 2, Duration: 24 * time.Hour, ShardGroupDuration: time.Hour}
+	if err := fsm.data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	copyRP := func(dst string) error {
+		return applyTestCommand(t, fsm, internal.Command_CopyRetentionPolicyCommand, internal.E_CopyRetentionPolicyCommand_Command, &internal.CopyRetentionPolicyCommand{
+			Database:    proto.String("db0"),
+			Source:      proto.String("rp0"),
+			Destination: proto.String(dst),
+		})
+	}
+
+	if err := copyRP("rp1"); err != nil {
+		t.Fatal(err)
+	}
+	if rp := fsm.data.Database("db0").RetentionPolicy("rp1"); rp == nil || rp.ReplicaN != 2 || rp.Duration != 24*time.Hour {
+		t.Fatalf("unexpected copy: %+v", rp)
+	}
+	if err := copyRP("rp1"); err != ErrRetentionPolicyNameExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStoreFSM_ReplaceContinuousQuery_Mismatch(t *testing.T) {
+	fsm := newTestStoreFSM()
+	for _, name := range []string{"db0", "db1"} {
+		if err := fsm.data.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replaceCQ := func(database, name, query string) error {
+		return applyTestCommand(t, fsm, internal.Command_ReplaceContinuousQueryCommand, internal.E_ReplaceContinuousQueryCommand_Command, &internal.ReplaceContinuousQueryCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+			Query:    proto.String(query),
+		})
+	}
+
+	q := `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO foo_count FROM foo GROUP BY time(10m) END`
+	if err := replaceCQ("db0", "cq1", q); err != ErrContinuousQueryMismatch {
+		t.Fatalf("unexpected error for name mismatch: %v", err)
+	}
+	if err := replaceCQ("db1", "cq0", q); err != ErrContinuousQueryMismatch {
+		t.Fatalf("unexpected error for database mismatch: %v", err)
+	}
+	if n := len(fsm.data.Database("db0").ContinuousQueries) + len(fsm.data.Database("db1").ContinuousQueries); n != 0 {
+		t.Fatalf("unexpected continuous query count: %d", n)
+	}
+
+	if err := replaceCQ("db0", "cq0", q); err != nil {
+		t.Fatal(err)
+	} else if cqs := fsm.data.Database("db0").ContinuousQueries; len(cqs) != 1 || cqs[0].Query != q {
+		t.Fatalf("unexpected continuous queries: %+v", cqs)
+	}
+}
+
+func TestStoreFSM_Transaction(t *testing.T) {
+	fsm := newTestStoreFSM()
+	fsm.config = NewConfig()
+
+	applyTx := func(tx *Transaction) error {
+		cmd := &internal.TransactionCommand{}
+		for _, op := range tx.ops {
+			b, err := op.marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmd.Commands = append(cmd.Commands, b)
+		}
+		return applyTestCommand(t, fsm, internal.Command_TransactionCommand, internal.E_TransactionCommand_Command, cmd)
+	}
+
+	duration := time.Hour
+	spec := &RetentionPolicySpec{Name: "rp0", Duration: &duration}
+	if err := applyTx(new(Transaction).CreateDatabase("db0").CreateRetentionPolicy("db0", spec, true)); err != nil {
+		t.Fatal(err)
+	}
+	if di := fsm.data.Database("db0"); di == nil || di.DefaultRetentionPolicy != "rp0" {
+		t.Fatalf("unexpected database: %+v", di)
+	}
+
+	// A failure part way through undoes the commands before it.
+	before := fsm.data
+	tx := new(Transaction).
+		CreateDatabase("db1").
+		DropRetentionPolicy("db0", "rp0").
+		SetDefaultRetentionPolicy("db1", "missing")
+	if err := applyTx(tx); err == nil || err.Error() != "retention policy not found: missing" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fsm.data.Database("db1") != nil {
+		t.Fatal("expected db1 not to be created")
+	} else if fsm.data.Database("db0").RetentionPolicy("rp0") == nil {
+		t.Fatal("expected rp0 not to be dropped")
+	} else if len(fsm.data.Databases) != len(before.Databases) {
+		t.Fatalf("unexpected databases: %+v", fsm.data.Databases)
+	}
+
+	// Only the commands a Transaction builds are allowed, so a transaction
+	// can't smuggle in other changes or nest another transaction.
+	marshal := func(typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) []byte {
+		b, err := txOp{typ: typ, desc: desc, value: value}.marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	create := marshal(internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, &internal.CreateDatabaseCommand{Name: proto.String("db2")})
+	for _, b := range [][]byte{
+		marshal(internal.Command_CreateUserCommand, internal.E_CreateUserCommand_Command, &internal.CreateUserCommand{Name: proto.String("u0"), Hash: proto.String("x"), Admin: proto.Bool(true)}),
+		marshal(internal.Command_TransactionCommand, internal.E_TransactionCommand_Command, &internal.TransactionCommand{Commands: [][]byte{create}}),
+	} {
+		cmd := &internal.TransactionCommand{Commands: [][]byte{create, b}}
+		if err := applyTestCommand(t, fsm, internal.Command_TransactionCommand, internal.E_TransactionCommand_Command, cmd); err != ErrInvalidTransactionCommand {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if fsm.data.Database("db2") != nil {
+		t.Fatal("expected db2 not to be created")
+	} else if len(fsm.data.Users) != 0 {
+		t.Fatalf("unexpected users: %+v", fsm.data.Users)
+	}
+}
+
+func TestStoreFSM_RebalanceShardGroup(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDataNode("node1:8086", "node1:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}
+	if err := fsm.data.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateShardGroup("db0", "rp0", time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateDataNode("node2:8086", "node2:8088"); err != nil {
+		t.Fatal(err)
+	}
+
+	rebalance := func(id uint64) error {
+		return applyTestCommand(t, fsm, internal.Command_RebalanceShardGroupCommand, internal.E_RebalanceShardGroupCommand_Command, &internal.RebalanceShardGroupCommand{
+			Database:        proto.String("db0"),
+			RetentionPolicy: proto.String("rp0"),
+			ShardGroupID:    proto.Uint64(id),
+		})
+	}
+
+	// With no other shards, the group's single shard goes to the first node.
+	sgi := fsm.data.Database("db0").RetentionPolicy("rp0").ShardGroups[0]
+	if err := rebalance(sgi.ID); err != nil {
+		t.Fatal(err)
+	} else if owners := fsm.data.Database("db0").RetentionPolicy("rp0").ShardGroups[0].OwnerNodeIDs(); len(owners) != 1 || owners[0] != 1 {
+		t.Fatalf("unexpected owners: %v", owners)
+	}
+	if err := rebalance(sgi.ID + 1); err != ErrShardGroupNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStoreFSM_ReserveShardIDs(t *testing.T) {
+	fsm := newTestStoreFSM()
+	reserve := func(start, n uint64) error {
+		return applyTestCommand(t, fsm, internal.Command_ReserveShardIDsCommand, internal.E_ReserveShardIDsCommand_Command, &internal.ReserveShardIDsCommand{
+			Start: proto.Uint64(start),
+			N:     proto.Uint64(n),
+		})
+	}
+
+	if err := reserve(1, 2); err != nil {
+		t.Fatal(err)
+	} else if fsm.data.MaxShardID != 2 {
+		t.Fatalf("unexpected max shard id: %d", fsm.data.MaxShardID)
+	}
+
+	// A reservation made from stale data is rejected without changes.
+	if err := reserve(1, 2); err != ErrShardIDsReserved {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := reserve(3, 0); err != ErrInvalidShardIDCount {
+		t.Fatalf("unexpected error: %v", err)
+	} else if fsm.data.MaxShardID != 2 {
+		t.Fatalf("unexpected max shard id after rejected reservations: %d", fsm.data.MaxShardID)
+	}
+
+	// Without a start, the next unused IDs are reserved and the first is
+	// returned.
+	typ := internal.Command_ReserveShardIDsCommand
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, internal.E_ReserveShardIDsCommand_Command, &internal.ReserveShardIDsCommand{N: proto.Uint64(4)}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := fsm.Apply(&raft.Log{Index: fsm.data.Index + 1, Data: b}); res != commandResult(3) {
+		t.Fatalf("unexpected result: %#v", res)
+	} else if fsm.data.MaxShardID != 6 {
+		t.Fatalf("unexpected max shard id: %d", fsm.data.MaxShardID)
+	}
+}
+
+func TestStoreFSM_SetDatabaseDefaultShardGroupDuration(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyTestCommand(t, fsm, internal.Command_SetDatabaseDefaultShardGroupDurationCommand, internal.E_SetDatabaseDefaultShardGroupDurationCommand_Command, &internal.SetDatabaseDefaultShardGroupDurationCommand{
+		Database: proto.String("db0"),
+		Duration: proto.Int64(int64(time.Hour)),
+	}); err != nil {
+		t.Fatal(err)
+	} else if d := fsm.data.Database("db0").DefaultShardGroupDuration; d != time.Hour {
+		t.Fatalf("unexpected default shard group duration: %s", d)
+	}
+
+	// A retention policy created without a shard group duration gets the
+	// default the FSM holds, whatever the client's cache said.
+	month := 30 * 24 * time.Hour
+	spec := &RetentionPolicySpec{Name: "rp0", Duration: &month}
+	if err := applyTestCommand(t, fsm, internal.Command_CreateRetentionPolicyCommand, internal.E_CreateRetentionPolicyCommand_Command, &internal.CreateRetentionPolicyCommand{
+		Database:        proto.String("db0"),
+		RetentionPolicy: spec.commandRetentionPolicyInfo().marshal(),
+		Default:         proto.Bool(false),
+	}); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := fsm.data.RetentionPolicy("db0", "rp0"); rpi.ShardGroupDuration != time.Hour {
+		t.Fatalf("unexpected shard group duration: %s", rpi.ShardGroupDuration)
+	}
+}
+
+func TestStoreFSM_CreateRetentionPolicy_Tenancy(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &RetentionPolicySpec{
+		Name:         "rp0",
+		ShardKeyTags: []string{"tenant"},
+		TenantNodes:  map[string][]uint64{"acme": {1, 2}},
+	}
+	create := func() error {
+		return applyTestCommand(t, fsm, internal.Command_CreateRetentionPolicyCommand, internal.E_CreateRetentionPolicyCommand_Command, &internal.CreateRetentionPolicyCommand{
+			Database:        proto.String("db0"),
+			RetentionPolicy: spec.commandRetentionPolicyInfo().marshal(),
+			Default:         proto.Bool(false),
+		})
+	}
+	if err := create(); err != nil {
+		t.Fatal(err)
+	} else if rpi, _ := fsm.data.RetentionPolicy("db0", "rp0"); !spec.Matches(rpi) {
+		t.Fatalf("unexpected tenancy: %v %v", rpi.ShardKeyTags, rpi.TenantNodes)
+	}
+
+	// The same policy with other tenant nodes is a different policy.
+	spec.TenantNodes = map[string][]uint64{"acme": {3}}
+	if err := create(); err != ErrRetentionPolicyExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStoreFSM_FreezeRetentionPolicy(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateRetentionPolicy("db0", &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyTestCommand(t, fsm, internal.Command_FreezeRetentionPolicyCommand, internal.E_FreezeRetentionPolicyCommand_Command, &internal.FreezeRetentionPolicyCommand{
+		Database:        proto.String("db0"),
+		RetentionPolicy: proto.String("rp0"),
+		Frozen:          proto.Bool(true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := applyTestCommand(t, fsm, internal.Command_CreateShardGroupCommand, internal.E_CreateShardGroupCommand_Command, &internal.CreateShardGroupCommand{
+		Database:        proto.String("db0"),
+		RetentionPolicy: proto.String("rp0"),
+		Timestamp:       proto.Int64(time.Now().UnixNano()),
+	})
+	if err != ErrRetentionPolicyFrozen {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrRetentionPolicyFrozen)
+	}
+}
+
+func TestStoreFSM_DropDatabase_Subscriptions(t *testing.T) {
+	fsm := newTestStoreFSM()
+	for _, name := range []string{"db0", "db1"} {
+		if err := fsm.data.CreateDatabase(name); err != nil {
+			t.Fatal(err)
+		} else if err := fsm.data.CreateRetentionPolicy(name, &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+			t.Fatal(err)
+		} else if err := fsm.data.CreateSubscription(name, "rp0", "sub0", "ALL", []string{"udp://example.com:9090"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dropDatabase := func(cmd *internal.DropDatabaseCommand) error {
+		return applyTestCommand(t, fsm, internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command, cmd)
+	}
+
+	if err := dropDatabase(&internal.DropDatabaseCommand{Name: proto.String("db0"), Force: proto.Bool(false)}); err == nil {
+		t.Fatal("expected error for database with subscriptions")
+	} else if fsm.data.Database("db0") == nil {
+		t.Fatal("database dropped")
+	}
+	if err := dropDatabase(&internal.DropDatabaseCommand{Name: proto.String("db0"), Force: proto.Bool(true)}); err != nil {
+		t.Fatal(err)
+	} else if fsm.data.Database("db0") != nil {
+		t.Fatal("database not dropped")
+	}
+
+	// A command logged before Force was added drops the database as it did
+	// then.
+	if err := dropDatabase(&internal.DropDatabaseCommand{Name: proto.String("db1")}); err != nil {
+		t.Fatal(err)
+	} else if fsm.data.Database("db1") != nil {
+		t.Fatal("database not dropped")
+	}
+}
+
+func TestStoreFSM_CreateShardGroups_SkipsFailedGroups(t *testing.T) {
+	fsm := newTestStoreFSM()
+	core, logs := observer.New(zap.WarnLevel)
+	fsm.logger = zap.New(core)
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"rp0", "rp1"} {
+		if err := fsm.data.CreateRetentionPolicy("db0", &RetentionPolicyInfo{Name: name, ReplicaN: 1, ShardGroupDuration: time.Hour}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsm.data.Database("db0").RetentionPolicy("rp1").Frozen = true
+
+	ts := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := func(rp string) *internal.CreateShardGroupCommand {
+		return &internal.CreateShardGroupCommand{
+			Database:        proto.String("db0"),
+			RetentionPolicy: proto.String(rp),
+			Timestamp:       proto.Int64(ts.UnixNano()),
+		}
+	}
+
+	// The groups for the dropped and the frozen retention policies are
+	// skipped; the group for rp0 is still created.
+	if err := applyTestCommand(t, fsm, internal.Command_CreateShardGroupsCommand, internal.E_CreateShardGroupsCommand_Command, &internal.CreateShardGroupsCommand{
+		Groups: []*internal.CreateShardGroupCommand{group("dropped"), group("rp1"), group("rp0")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if sgi, err := fsm.data.ShardGroupByTimestamp("db0", "rp0", ts); err != nil {
+		t.Fatal(err)
+	} else if sgi == nil {
+		t.Fatal("expected shard group in rp0")
+	}
+	if n := logs.FilterMessage("Skipped shard group that could not be created").Len(); n != 2 {
+		t.Fatalf("unexpected skipped groups: got %d, exp 2", n)
+	} else if f := logs.FilterMessage("Skipped shard group that could not be created").All()[0].ContextMap(); f["db_instance"] != "db0" || f["db_rp"] != "dropped" {
+		t.Fatalf("unexpected log fields: %v", f)
+	}
+
+	// A batch in which nothing could be created fails.
+	maxID := fsm.data.MaxShardGroupID
+	if err := applyTestCommand(t, fsm, internal.Command_CreateShardGroupsCommand, internal.E_CreateShardGroupsCommand_Command, &internal.CreateShardGroupsCommand{
+		Groups: []*internal.CreateShardGroupCommand{group("dropped"), group("rp1")},
+	}); err != ErrShardGroupsNotCreated {
+		t.Fatalf("unexpected error: got %v, exp %v", err, ErrShardGroupsNotCreated)
+	} else if fsm.data.MaxShardGroupID != maxID {
+		t.Fatalf("unexpected max shard group id: got %d, exp %d", fsm.data.MaxShardGroupID, maxID)
+	}
+}
+
+func TestStoreFSM_CreateShardGroup_SkewWarning(t *testing.T) {
+	fsm := newTestStoreFSM()
+	clk := &testClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	fsm.clock = clk
+	core, logs := observer.New(zap.WarnLevel)
+	fsm.logger = zap.New(core)
+	if err := fsm.data.CreateDataNode("node1:8086", "node1:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := fsm.data.CreateRetentionPolicy("db0", &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, ShardGroupDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(ts time.Time) {
+		t.Helper()
+		if err := applyTestCommand(t, fsm, internal.Command_CreateShardGroupCommand, internal.E_CreateShardGroupCommand_Command, &internal.CreateShardGroupCommand{
+			Database:        proto.String("db0"),
+			RetentionPolicy: proto.String("rp0"),
+			Timestamp:       proto.Int64(ts.UnixNano()),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Groups for now and the next hour are expected.
+	create(clk.Now())
+	create(clk.Now().Add(time.Hour))
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("unexpected warnings: %d", n)
+	}
+
+	// A timestamp from a clock a day ahead is not.
+	create(clk.Now().Add(24 * time.Hour))
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected warnings: %+v", entries)
+	} else if skew := entries[0].ContextMap()["skew"]; skew != 24*time.Hour {
+		t.Fatalf("unexpected skew: %v", skew)
+	}
+
+	// An existing group isn't created again, so it isn't warned about again.
+	create(clk.Now().Add(24 * time.Hour))
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("unexpected warnings for an existing group: %d", n)
+	}
+}
+
+func TestStoreFSM_MaintenanceMode(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	setMaintenanceMode := func(on bool) {
+		t.Helper()
+		if err := applyTestCommand(t, fsm, internal.Command_SetMaintenanceModeCommand, internal.E_SetMaintenanceModeCommand_Command, &internal.SetMaintenanceModeCommand{
+			On: proto.Bool(on),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dropDatabase := func(name string) error {
+		return applyTestCommand(t, fsm, internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command, &internal.DropDatabaseCommand{
+			Name: proto.String(name),
+		})
+	}
+
+	setMaintenanceMode(true)
+	if err := dropDatabase("db0"); err != ErrMaintenanceMode {
+		t.Fatalf("unexpected error: %v", err)
+	} else if fsm.data.Database("db0") == nil {
+		t.Fatal("expected db0 not to be dropped")
+	}
+
+	// The flag survives a snapshot.
+	b, err := fsm.data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var other Data
+	if err := other.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	} else if !other.MaintenanceMode {
+		t.Fatal("expected maintenance mode after unmarshaling")
+	}
+
+	setMaintenanceMode(false)
+	if err := dropDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if fsm.data.Database("db0") != nil {
+		t.Fatal("expected db0 to be dropped")
+	}
+}
+
+func TestStoreFSM_SetAdminPrivilege_ClearPrivileges(t *testing.T) {
+	fsm := newTestStoreFSM()
+	if err := fsm.data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"u0", "u1"} {
+		if err := fsm.data.CreateUser(name, "hash", false); err != nil {
+			t.Fatal(err)
+		} else if err := fsm.data.SetPrivilege(name, "db0", cnosql.ReadPrivilege); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setAdmin := func(name string, admin, clear bool) {
+		t.Helper()
+		if err := applyTestCommand(t, fsm, internal.Command_SetAdminPrivilegeCommand, internal.E_SetAdminPrivilegeCommand_Command, &internal.SetAdminPrivilegeCommand{
+			Username:        proto.String(name),
+			Admin:           proto.Bool(admin),
+			ClearPrivileges: proto.Bool(clear),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the user promoted with the flag loses its grants.
+	setAdmin("u0", true, true)
+	setAdmin("u1", true, false)
+	if ui := fsm.data.user("u0"); !ui.Admin || len(ui.Privileges) != 0 {
+		t.Fatalf("unexpected u0: %+v", ui)
+	} else if ui := fsm.data.user("u1"); !ui.Admin || len(ui.Privileges) != 1 {
+		t.Fatalf("unexpected u1: %+v", ui)
+	}
+
+	// Demoting doesn't bring the grants back.
+	setAdmin("u0", false, true)
+	if ui := fsm.data.user("u0"); ui.Admin || len(ui.Privileges) != 0 {
+		t.Fatalf("unexpected u0 after demotion: %+v", ui)
+	}
+}
+
+func TestStoreFSM_Restore_AppliedCommands(t *testing.T) {
+	fsm := newTestStoreFSM()
+	apply := func(fsm *storeFSM, key string, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
+		t.Helper()
+		cmd := &internal.Command{Type: &typ}
+		if key != "" {
+			cmd.IdempotencyKey = proto.String(key)
+		}
+		if err := proto.SetExtension(cmd, desc, value); err != nil {
+			t.Fatal(err)
+		}
+		b, err := proto.Marshal(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := fsm.Apply(&raft.Log{Index: fsm.data.Index + 1, Data: b}); res != nil {
+			return res.(error)
+		}
+		return nil
+	}
+	createDatabase := func(fsm *storeFSM, key string) error {
+		return apply(fsm, key, internal.Command_CreateDatabaseCommand, internal.E_CreateDatabaseCommand_Command, &internal.CreateDatabaseCommand{
+			Name:   proto.String("db0"),
+			Strict: proto.Bool(true),
+		})
+	}
+
+	if err := createDatabase(fsm, "k0"); err != nil {
+		t.Fatal(err)
+	} else if err := createDatabase(fsm, "k1"); err != ErrDatabaseExists {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sink testSnapshotSink
+	if err := snap.Persist(&sink); err != nil {
+		t.Fatal(err)
+	}
+	other := newTestStoreFSM()
+	if err := other.Restore(ioutil.NopCloser(&sink.Buffer)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A retry after the snapshot gets the first results on the restored
+	// node too, rather than recreating the dropped database.
+	if err := apply(other, "", internal.Command_DropDatabaseCommand, internal.E_DropDatabaseCommand_Command, &internal.DropDatabaseCommand{
+		Name:  proto.String("db0"),
+		Force: proto.Bool(true),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := createDatabase(other, "k0"); err != nil {
+		t.Fatal(err)
+	} else if other.data.Database("db0") != nil {
+		t.Fatal("retried command applied again after restore")
+	}
+	if err := createDatabase(other, "k1"); err == nil || err.Error() != ErrDatabaseExists.Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// testSnapshotSink holds a persisted snapshot in memory.
+type testSnapshotSink struct {
+	bytes.Buffer
+}
+
+func (s *testSnapshotSink) ID() string    { return "test" }
+func (s *testSnapshotSink) Cancel() error { return nil }
+func (s *testSnapshotSink) Close() error  { return nil }
+
+func newTestStoreFSM() *storeFSM {
+	return &storeFSM{
+		data:        &Data{},
+		config:      &Config{HTTPD: NewServerConfig()},
+		dataChanged: make(chan struct{}),
+		clock:       realClock{},
+		logger:      zap.NewNop(),
+	}
+}
+
+// applyTestCommand applies a command to fsm as raft would and returns the result as an error.
+func applyTestCommand(t *testing.T, fsm *storeFSM, typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) error {
+	t.Helper()
+
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, desc, value); err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err, ok := fsm.Apply(&raft.Log{Index: fsm.data.Index + 1, Data: b}).(error); ok {
+		return err
+	}
+	return nil
+}
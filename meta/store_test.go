@@ -0,0 +1,269 @@
+package meta
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+	"github.com/gogo/protobuf/proto"
+	"github.com/hashicorp/raft"
+)
+
+func TestStore_Apply_NotLeader(t *testing.T) {
+	stores := newTestRaftCluster(t, "node0", "node1")
+	defer func() {
+		for _, s := range stores {
+			s.raftState.raft.Shutdown()
+		}
+	}()
+
+	leader, follower := waitForTestLeader(t, stores)
+
+	b, err := proto.Marshal(newTestCreateDatabaseCommand("db0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = follower.apply(b)
+	e, ok := err.(ErrNotLeader)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	} else if e.Leader != leader.httpAddr {
+		t.Fatalf("unexpected leader: got %q, exp %q", e.Leader, leader.httpAddr)
+	}
+	if got := follower.lastApplyError(); got != err {
+		t.Fatalf("unexpected last apply error: got %v, exp %v", got, err)
+	}
+
+	// Applying on the leader succeeds and leaves no apply error behind.
+	if err := leader.apply(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.lastApplyError(); err != nil {
+		t.Fatalf("unexpected last apply error on leader: %v", err)
+	}
+}
+
+func TestStore_RaftStats(t *testing.T) {
+	stores := newTestRaftCluster(t, "node0", "node1")
+	defer func() {
+		for _, s := range stores {
+			s.raftState.raft.Shutdown()
+		}
+	}()
+
+	leader, follower := waitForTestLeader(t, stores)
+	if stats := leader.raftStats(); stats.Applied != 0 || stats.LastApplyLatency != 0 {
+		t.Fatalf("unexpected stats before apply: %+v", stats)
+	}
+
+	b, err := proto.Marshal(newTestCreateDatabaseCommand("db0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.apply(b); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := leader.raftStats()
+	if stats.Applied != 1 || stats.PendingApplies != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	} else if stats.LastApplyLatency <= 0 || stats.MeanApplyLatency != stats.LastApplyLatency || stats.MaxApplyLatency != stats.LastApplyLatency {
+		t.Fatalf("unexpected apply latency: %+v", stats)
+	}
+
+	// A command rejected by a follower never committed, so it is not timed.
+	if err := follower.apply(b); err == nil {
+		t.Fatal("expected apply on follower to fail")
+	}
+	if stats := follower.raftStats(); stats.Applied != 0 || stats.PendingApplies != 0 {
+		t.Fatalf("unexpected follower stats: %+v", stats)
+	}
+}
+
+func TestStore_AddMetaNode(t *testing.T) {
+	configuration := raft.Configuration{Servers: []raft.Server{{ID: "node0", Address: "node0"}}}
+	leader, leaderTrans := newTestRaftStore(t, "node0", &configuration)
+	leader.data.MetaNodes = []NodeInfo{{ID: 1, Host: "http-node0", TCPHost: "node0"}}
+	joining, joiningTrans := newTestRaftStore(t, "node2", nil)
+	defer leader.raftState.raft.Shutdown()
+	defer joining.raftState.raft.Shutdown()
+	leaderTrans.Connect(joiningTrans.LocalAddr(), joiningTrans)
+	joiningTrans.Connect(leaderTrans.LocalAddr(), leaderTrans)
+
+	timeout := time.After(5 * time.Second)
+	for !leader.isLeader() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for leader")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	n, err := leader.addMetaNode(&NodeInfo{Host: "http-node2", TCPHost: "node2"})
+	if err != nil {
+		t.Fatal(err)
+	} else if n.TCPHost != "node2" {
+		t.Fatalf("unexpected node: %+v", n)
+	}
+
+	// The new node is part of the committed configuration on return.
+	var found bool
+	for _, p := range leader.peers() {
+		found = found || p == "node2"
+	}
+	if !found {
+		t.Fatalf("node2 missing from peers: %v", leader.peers())
+	}
+}
+
+func TestStore_AddMetaNode_ConcurrentApply(t *testing.T) {
+	configuration := raft.Configuration{Servers: []raft.Server{{ID: "node0", Address: "node0"}}}
+	leader, leaderTrans := newTestRaftStore(t, "node0", &configuration)
+	leader.data.MetaNodes = []NodeInfo{{ID: 1, Host: "http-node0", TCPHost: "node0"}}
+	joining, joiningTrans := newTestRaftStore(t, "node2", nil)
+	defer leader.raftState.raft.Shutdown()
+	defer joining.raftState.raft.Shutdown()
+	leaderTrans.Connect(joiningTrans.LocalAddr(), joiningTrans)
+	joiningTrans.Connect(leaderTrans.LocalAddr(), leaderTrans)
+
+	timeout := time.After(5 * time.Second)
+	for !leader.isLeader() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for leader")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// Commit a command while the store is locked, so the FSM is waiting to
+	// apply it when the node is added. Unlocking lets addMetaNode in first.
+	leader.mu.Lock()
+	b, err := proto.Marshal(newTestCreateDatabaseCommand("db0"))
+	if err != nil {
+		leader.mu.Unlock()
+		t.Fatal(err)
+	}
+	f := leader.raftState.raft.Apply(b, 0)
+	added := make(chan error, 1)
+	go func() {
+		_, err := leader.addMetaNode(&NodeInfo{Host: "http-node2", TCPHost: "node2"})
+		added <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	leader.mu.Unlock()
+
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out adding meta node")
+	}
+	if err := f.Error(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestRaftCluster returns stores for a raft cluster of the given raft
+// addresses, connected by an in-memory transport.
+func newTestRaftCluster(t *testing.T, addrs ...string) []*store {
+	t.Helper()
+
+	var configuration raft.Configuration
+	for _, addr := range addrs {
+		configuration.Servers = append(configuration.Servers, raft.Server{
+			ID:      raft.ServerID(addr),
+			Address: raft.ServerAddress(addr),
+		})
+	}
+
+	var stores []*store
+	var transports []*raft.InmemTransport
+	for _, addr := range addrs {
+		s, trans := newTestRaftStore(t, addr, &configuration)
+		for _, a := range addrs {
+			s.data.MetaNodes = append(s.data.MetaNodes, NodeInfo{Host: "http-" + a, TCPHost: a})
+		}
+		stores = append(stores, s)
+		transports = append(transports, trans)
+	}
+
+	for _, a := range transports {
+		for _, b := range transports {
+			a.Connect(b.LocalAddr(), b)
+		}
+	}
+	return stores
+}
+
+// newTestRaftStore returns a store for the raft address addr and its
+// in-memory transport. The store is bootstrapped with configuration if it is
+// not nil, otherwise it waits to be added to an existing cluster.
+func newTestRaftStore(t *testing.T, addr string, configuration *raft.Configuration) (*store, *raft.InmemTransport) {
+	t.Helper()
+
+	s := newStore(NewConfig(), "http-"+addr, addr)
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(addr)
+	config.HeartbeatTimeout = 50 * time.Millisecond
+	config.ElectionTimeout = 50 * time.Millisecond
+	config.LeaderLeaseTimeout = 50 * time.Millisecond
+	config.CommitTimeout = 5 * time.Millisecond
+	config.LogOutput = ioutil.Discard
+
+	_, trans := raft.NewInmemTransport(raft.ServerAddress(addr))
+	logs := raft.NewInmemStore()
+	snaps := raft.NewInmemSnapshotStore()
+	if configuration != nil {
+		if err := raft.BootstrapCluster(config, logs, logs, snaps, trans, *configuration); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ra, err := raft.NewRaft(config, (*storeFSM)(s), logs, logs, snaps, trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.raftState = &raftState{raft: ra, addr: addr}
+	return s, trans
+}
+
+// waitForTestLeader returns the leader and one follower of stores.
+func waitForTestLeader(t *testing.T, stores []*store) (leader, follower *store) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		for _, s := range stores {
+			if s.isLeader() {
+				leader = s
+			} else if s.leader() != "" {
+				follower = s
+			}
+		}
+		if leader != nil && follower != nil {
+			return leader, follower
+		}
+		leader, follower = nil, nil
+
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for leader")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func newTestCreateDatabaseCommand(name string) *internal.Command {
+	typ := internal.Command_CreateDatabaseCommand
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, internal.E_CreateDatabaseCommand_Command, &internal.CreateDatabaseCommand{
+		Name: proto.String(name),
+	}); err != nil {
+		panic(err)
+	}
+	return cmd
+}
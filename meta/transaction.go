@@ -0,0 +1,232 @@
+package meta
+
+import (
+	internal "github.com/cnosdb/cnosdb/meta/internal"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+// Transaction collects changes to the meta data and applies them together
+// when it is committed. Either every change takes effect or, if one of them
+// fails, none does, and readers and watchers never see the data with only
+// some of them applied.
+//
+// Changes are checked when the transaction is committed, each against the
+// data as left by the ones before it, so a retention policy may be created
+// on a database created earlier in the same transaction.
+type Transaction struct {
+	ops []txOp
+
+	// err is the first error found while adding a change. Commit returns
+	// it without applying anything.
+	err error
+
+	commit func(ops []txOp) error
+}
+
+// txOp is a single change in a transaction.
+type txOp struct {
+	// apply makes the change to the data of a local Client.
+	apply func(c *Client, data *Data) error
+
+	// typ, desc and value make up the command a RemoteClient sends for the
+	// change.
+	typ   internal.Command_Type
+	desc  *proto.ExtensionDesc
+	value interface{}
+}
+
+// marshal returns the binary form of the command for op.
+func (op txOp) marshal() ([]byte, error) {
+	cmd := &internal.Command{Type: &op.typ}
+	if err := proto.SetExtension(cmd, op.desc, op.value); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(cmd)
+}
+
+func (t *Transaction) add(op txOp) *Transaction {
+	t.ops = append(t.ops, op)
+	return t
+}
+
+// CreateDatabase adds the creation of a database. It does nothing if the
+// database already exists.
+func (t *Transaction) CreateDatabase(name string) *Transaction {
+	return t.add(txOp{
+		apply: func(c *Client, data *Data) error {
+			if data.Database(name) != nil {
+				return nil
+			}
+			if err := data.CreateDatabase(name); err != nil {
+				return err
+			}
+			if c.retentionPolicyAutoCreate {
+				return data.CreateRetentionPolicy(name, DefaultRetentionPolicyInfo(), true)
+			}
+			return nil
+		},
+		typ:  internal.Command_CreateDatabaseCommand,
+		desc: internal.E_CreateDatabaseCommand_Command,
+		value: &internal.CreateDatabaseCommand{
+			Name: proto.String(name),
+		},
+	})
+}
+
+// DropDatabase adds the removal of a database.
+func (t *Transaction) DropDatabase(name string) *Transaction {
+	return t.add(txOp{
+		apply: func(c *Client, data *Data) error {
+			return data.DropDatabase(name)
+		},
+		typ:  internal.Command_DropDatabaseCommand,
+		desc: internal.E_DropDatabaseCommand_Command,
+		value: &internal.DropDatabaseCommand{
+			Name:  proto.String(name),
+			Force: proto.Bool(false),
+		},
+	})
+}
+
+// CreateRetentionPolicy adds the creation of a retention policy on database.
+func (t *Transaction) CreateRetentionPolicy(database string, spec *RetentionPolicySpec, makeDefault bool) *Transaction {
+	if spec.Duration != nil && *spec.Duration < MinRetentionPolicyDuration && *spec.Duration != 0 {
+		if t.err == nil {
+			t.err = ErrRetentionPolicyDurationTooLow
+		}
+		return t
+	}
+
+	rpi := spec.commandRetentionPolicyInfo()
+	t.add(txOp{
+		apply: func(c *Client, data *Data) error {
+			return data.CreateRetentionPolicy(database, data.Database(database).NewRetentionPolicyInfo(spec), makeDefault)
+		},
+		typ:  internal.Command_CreateRetentionPolicyCommand,
+		desc: internal.E_CreateRetentionPolicyCommand_Command,
+		value: &internal.CreateRetentionPolicyCommand{
+			Database:        proto.String(database),
+			RetentionPolicy: rpi.marshal(),
+			Default:         proto.Bool(makeDefault),
+		},
+	})
+	if makeDefault {
+		// The metaservice creates the policy without making it the default,
+		// so a remote transaction sets it with a command of its own. The
+		// local change above has already done so.
+		return t.add(txOp{
+			apply: func(c *Client, data *Data) error { return nil },
+			typ:   internal.Command_SetDefaultRetentionPolicyCommand,
+			desc:  internal.E_SetDefaultRetentionPolicyCommand_Command,
+			value: &internal.SetDefaultRetentionPolicyCommand{
+				Database: proto.String(database),
+				Name:     proto.String(rpi.Name),
+			},
+		})
+	}
+	return t
+}
+
+// DropRetentionPolicy adds the removal of a retention policy from database.
+func (t *Transaction) DropRetentionPolicy(database, name string) *Transaction {
+	return t.add(txOp{
+		apply: func(c *Client, data *Data) error {
+			return data.DropRetentionPolicy(database, name)
+		},
+		typ:  internal.Command_DropRetentionPolicyCommand,
+		desc: internal.E_DropRetentionPolicyCommand_Command,
+		value: &internal.DropRetentionPolicyCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+		},
+	})
+}
+
+// SetDefaultRetentionPolicy adds setting the default retention policy of
+// database.
+func (t *Transaction) SetDefaultRetentionPolicy(database, name string) *Transaction {
+	return t.add(txOp{
+		apply: func(c *Client, data *Data) error {
+			return data.SetDefaultRetentionPolicy(database, name)
+		},
+		typ:  internal.Command_SetDefaultRetentionPolicyCommand,
+		desc: internal.E_SetDefaultRetentionPolicyCommand_Command,
+		value: &internal.SetDefaultRetentionPolicyCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+		},
+	})
+}
+
+// UpdateRetentionPolicy adds an update of a retention policy.
+func (t *Transaction) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) *Transaction {
+	cmd, err := newUpdateRetentionPolicyCommand(database, name, rpu, makeDefault)
+	if err != nil {
+		if t.err == nil {
+			t.err = err
+		}
+		return t
+	}
+
+	return t.add(txOp{
+		apply: func(c *Client, data *Data) error {
+			return data.UpdateRetentionPolicy(database, name, rpu, makeDefault)
+		},
+		typ:   internal.Command_UpdateRetentionPolicyCommand,
+		desc:  internal.E_UpdateRetentionPolicyCommand_Command,
+		value: cmd,
+	})
+}
+
+// Commit applies the changes in the transaction. If any of them fails, the
+// meta data is left as it was and the error is returned. A transaction may
+// only be committed once.
+func (t *Transaction) Commit() error {
+	if t.err != nil {
+		return t.err
+	} else if len(t.ops) == 0 {
+		return nil
+	}
+	return t.commit(t.ops)
+}
+
+// NewTransaction returns an empty transaction that is applied with a single
+// commit.
+func (c *Client) NewTransaction() *Transaction {
+	return &Transaction{commit: c.commitTransaction}
+}
+
+func (c *Client) commitTransaction(ops []txOp) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+
+	for _, op := range ops {
+		if err := op.apply(c, data); err != nil {
+			return err
+		}
+	}
+
+	return c.commit(data)
+}
+
+// NewTransaction returns an empty transaction that is sent to the metaservice
+// as a single command and applied in a single raft log entry.
+func (c *RemoteClient) NewTransaction() *Transaction {
+	return &Transaction{commit: c.commitTransaction}
+}
+
+func (c *RemoteClient) commitTransaction(ops []txOp) error {
+	cmd := &internal.TransactionCommand{}
+	for _, op := range ops {
+		b, err := op.marshal()
+		if err != nil {
+			return err
+		}
+		cmd.Commands = append(cmd.Commands, b)
+	}
+
+	return c.retryUntilExec(internal.Command_TransactionCommand, internal.E_TransactionCommand_Command, cmd)
+}
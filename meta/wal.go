@@ -0,0 +1,273 @@
+package meta
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cnosdb/cnosdb/vend/db/pkg/file"
+	"go.uber.org/zap"
+)
+
+// The meta WAL lets a standby follow a single-node Client without raft. Each
+// commit appends a record holding the marshaled Data as of that commit, and a
+// standby tails the log with TailWAL and applies every record with SetData.
+// Since a record carries the whole state, the log is rotated once it grows
+// past walMaxSize: it is replaced by a new log holding just the latest record.
+//
+// Replication is asynchronous and best effort:
+//   - The record is written after meta.db is. A failed write is logged but
+//     does not fail the change, so the standby misses it until the next
+//     successful commit, which carries the whole state again.
+//   - A standby lags the primary by at least walPollInterval and loses
+//     whatever it had not read when the primary fails. It skips the records
+//     that were rotated away before it read them.
+//   - Nothing prevents both nodes from accepting changes. Only the primary
+//     may be written to; a Client that is tailing a WAL stops writing to it.
+//
+// A record is a 4 byte big-endian payload length, the 4 byte CRC-32 (IEEE) of
+// the payload, then the payload.
+
+// walHeaderSize is the size of the header in front of every WAL record.
+const walHeaderSize = 8
+
+// walMaxSize is the size past which the WAL is rotated.
+const walMaxSize = 64 << 20
+
+// walPollInterval is how often TailWAL checks the log for new records once it
+// has read them all.
+const walPollInterval = 100 * time.Millisecond
+
+// errWALChecksum is returned when a WAL record does not match its checksum.
+var errWALChecksum = errors.New("meta wal record checksum mismatch")
+
+// walWriter appends records to the WAL at path.
+type walWriter struct {
+	path    string
+	maxSize int64
+
+	// size is the length of the log after the last record appended, or -1
+	// if the log must be rotated before the next append: it has not been
+	// written by this writer yet, or an append failed and may have left a
+	// partial record behind.
+	size int64
+}
+
+// newWALWriter returns a writer for the WAL at path that rotates it once it
+// grows past maxSize.
+func newWALWriter(path string, maxSize int64) *walWriter {
+	return &walWriter{path: path, maxSize: maxSize, size: -1}
+}
+
+// write appends the binary form of data to the log as a record and syncs it.
+// The log is rotated first if needed.
+func (w *walWriter) write(data *Data) error {
+	b, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	rec := make([]byte, walHeaderSize+len(b))
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(b)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(b))
+	copy(rec[walHeaderSize:], b)
+
+	if w.size < 0 || w.size+int64(len(rec)) > w.maxSize {
+		if err := rotateWAL(w.path, rec); err != nil {
+			w.size = -1
+			return err
+		}
+		w.size = int64(len(rec))
+		return nil
+	}
+
+	if err := appendWAL(w.path, rec); err != nil {
+		w.size = -1
+		return err
+	}
+	w.size += int64(len(rec))
+	return nil
+}
+
+// appendWAL appends rec to the log at path and syncs it.
+func appendWAL(path string, rec []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(rec); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// rotateWAL replaces the log at path with a new log holding only rec. The new
+// log is synced and then renamed over the old one, so a reader sees either of
+// them in full.
+func rotateWAL(path string, rec []byte) error {
+	tmpFile := path + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(rec); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	//close file handle before renaming to support Windows
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return file.RenameFile(tmpFile, path)
+}
+
+// readWALRecord reads the next record from r. It returns io.EOF at the end of
+// the log and io.ErrUnexpectedEOF if the last record is incomplete, as it is
+// while the writer is appending it.
+func readWALRecord(r io.Reader) ([]byte, error) {
+	var hdr [walHeaderSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, binary.BigEndian.Uint32(hdr[0:4]))
+	if _, err := io.ReadFull(r, b); err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(b) != binary.BigEndian.Uint32(hdr[4:8]) {
+		return nil, errWALChecksum
+	}
+	return b, nil
+}
+
+// TailWAL reads the WAL set by Config.WALPath and then follows it, sending
+// the Data of every record on the returned channel. The channel is closed
+// when ctx is done or the log cannot be read.
+//
+// Once TailWAL is called the client no longer writes to the WAL, so that the
+// records a standby applies are not written back to the log it follows.
+func (c *Client) TailWAL(ctx context.Context) <-chan Data {
+	c.mu.Lock()
+	c.walStandby = true
+	path, logger := c.walPath, c.logger
+	c.mu.Unlock()
+
+	ch := make(chan Data)
+	go func() {
+		defer close(ch)
+		if path == "" {
+			logger.Warn("Not tailing meta WAL, no WAL path is configured")
+			return
+		}
+		if err := tailWAL(ctx, path, ch); err != nil && err != ctx.Err() {
+			logger.Error("Failed to tail meta WAL", zap.String("path", path), zap.Error(err))
+		}
+	}()
+	return ch
+}
+
+// tailWAL sends the Data of every record in the WAL at path on ch until ctx
+// is done. It waits for the log to be created if it does not exist yet, and
+// reopens it each time the writer replaces it.
+func tailWAL(ctx context.Context, path string, ch chan<- Data) error {
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(walPollInterval):
+			return nil
+		}
+	}
+	open := func() (*os.File, error) {
+		f, err := os.Open(path)
+		for os.IsNotExist(err) {
+			if err := wait(); err != nil {
+				return nil, err
+			}
+			f, err = os.Open(path)
+		}
+		return f, err
+	}
+
+	f, err := open()
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	// offset is the end of the last complete record, where reading resumes
+	// after reaching the end of the log.
+	var offset int64
+	r := bufio.NewReader(f)
+	for {
+		b, err := readWALRecord(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if err := wait(); err != nil {
+				return err
+			}
+
+			replaced, err := walReplaced(f, path)
+			if err != nil {
+				return err
+			}
+			if replaced {
+				f.Close()
+				if f, err = open(); err != nil {
+					return err
+				}
+				offset = 0
+			} else if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+			r.Reset(f)
+			continue
+		} else if err != nil {
+			return err
+		}
+		offset += int64(walHeaderSize + len(b))
+
+		var data Data
+		if err := data.UnmarshalBinary(b); err != nil {
+			return err
+		}
+
+		select {
+		case ch <- data:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// walReplaced returns true if the WAL at path is no longer the file f.
+func walReplaced(f *os.File, path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		// Keep reading the old log until a new one is written.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	cur, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return !os.SameFile(fi, cur), nil
+}
@@ -0,0 +1,245 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWALWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnosdb-meta-wal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "meta.wal")
+
+	// Records are appended after the first write, which starts a new log.
+	w := newWALWriter(path, walMaxSize)
+	for i := uint64(1); i <= 3; i++ {
+		if err := w.write(&Data{Index: i, ClusterID: 100}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(b)
+	for i := uint64(1); i <= 3; i++ {
+		rec, err := readWALRecord(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data Data
+		if err := data.UnmarshalBinary(rec); err != nil {
+			t.Fatal(err)
+		} else if data.Index != i || data.ClusterID != 100 {
+			t.Fatalf("unexpected record: index %d, cluster id %d", data.Index, data.ClusterID)
+		}
+	}
+	if _, err := readWALRecord(r); err != io.EOF {
+		t.Fatalf("unexpected error at end of log: %v", err)
+	}
+
+	// A new writer, as after a restart, starts a new log rather than
+	// appending after a record that may be partial.
+	w = newWALWriter(path, walMaxSize)
+	if err := w.write(&Data{Index: 4}); err != nil {
+		t.Fatal(err)
+	} else if b, err = ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if n := countWALRecords(t, b); n != 1 {
+		t.Fatalf("unexpected record count after restart: got %d, exp 1", n)
+	}
+
+	// The log is rotated once the next record would take it past the
+	// maximum size.
+	w.maxSize = int64(len(b)) * 2
+	for i := uint64(5); i <= 6; i++ {
+		if err := w.write(&Data{Index: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b, err := ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if n := countWALRecords(t, b); n != 1 {
+		t.Fatalf("unexpected record count after rotation: got %d, exp 1", n)
+	}
+}
+
+func TestReadWALRecord(t *testing.T) {
+	b, err := (&Data{Index: 1}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := make([]byte, walHeaderSize+len(b))
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(b)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.ChecksumIEEE(b))
+	copy(rec[walHeaderSize:], b)
+
+	// A record still being appended is incomplete rather than corrupt.
+	r := bytes.NewReader(append(rec, rec[:len(rec)-1]...))
+	if _, err := readWALRecord(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readWALRecord(r); err != io.ErrUnexpectedEOF {
+		t.Fatalf("unexpected error for partial record: %v", err)
+	}
+
+	rec[len(rec)-1] ^= 0xff
+	if _, err := readWALRecord(bytes.NewReader(rec)); err != errWALChecksum {
+		t.Fatalf("unexpected error for corrupt record: %v", err)
+	}
+}
+
+func TestTailWAL_Rotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnosdb-meta-wal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "meta.wal")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Data)
+	done := make(chan error, 1)
+	go func() { done <- tailWAL(ctx, path, ch) }()
+
+	next := func(exp uint64) {
+		t.Helper()
+		select {
+		case data := <-ch:
+			if data.Index != exp {
+				t.Fatalf("unexpected record: index %d, exp %d", data.Index, exp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for index %d", exp)
+		}
+	}
+
+	// Appended records are read in order, and reading carries on in the
+	// new log after a rotation.
+	w := newWALWriter(path, walMaxSize)
+	for i := uint64(1); i <= 3; i++ {
+		if err := w.write(&Data{Index: i}); err != nil {
+			t.Fatal(err)
+		}
+		next(i)
+	}
+	w.maxSize = 0
+	if err := w.write(&Data{Index: 4}); err != nil {
+		t.Fatal(err)
+	}
+	next(4)
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_TailWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cnosdb-meta-wal-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	walPath := filepath.Join(dir, "meta.wal")
+
+	newWALClient := func(name string) *Client {
+		config := NewConfig()
+		config.Dir = filepath.Join(dir, name)
+		config.WALPath = walPath
+		if err := os.MkdirAll(config.Dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+		c := NewClient(config)
+		if err := c.Open(); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	primary := newWALClient("primary")
+	defer primary.Close()
+	if _, err := primary.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	standby := newWALClient("standby")
+	defer standby.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := standby.TailWAL(ctx)
+
+	// Records written before and after the standby starts are both applied.
+	apply := func(db string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for standby.Database(db) == nil {
+			select {
+			case data := <-ch:
+				if err := standby.SetData(&data); err != nil {
+					t.Fatal(err)
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %s", db)
+			}
+		}
+	}
+	apply("db0")
+	if _, err := primary.CreateDatabase("db1"); err != nil {
+		t.Fatal(err)
+	}
+	apply("db1")
+
+	// The log holds the primary's commits only; the standby's own commits
+	// are not written back to it.
+	b, err := ioutil.ReadFile(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last Data
+	for r := bytes.NewReader(b); ; {
+		rec, err := readWALRecord(r)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		} else if err := last.UnmarshalBinary(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if last.Index != primary.Data().Index {
+		t.Fatalf("unexpected last record: index %d, exp %d", last.Index, primary.Data().Index)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+// countWALRecords returns the number of complete records in the log b.
+func countWALRecords(t *testing.T, b []byte) int {
+	t.Helper()
+
+	r := bytes.NewReader(b)
+	var n int
+	for {
+		if _, err := readWALRecord(r); err == io.EOF {
+			return n
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+}
@@ -0,0 +1,266 @@
+package meta
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ShardGroupEventType is the kind of change reported by a ShardGroupEvent.
+type ShardGroupEventType int
+
+const (
+	// ShardGroupCreated is sent when a shard group is added.
+	ShardGroupCreated ShardGroupEventType = iota + 1
+	// ShardGroupDeleted is sent when a shard group is marked deleted or removed.
+	ShardGroupDeleted
+	// ShardGroupExpired is sent when a shard group ends before the retention
+	// policy duration.
+	ShardGroupExpired
+	// ShardGroupResync is sent in place of events dropped because the watcher
+	// fell behind. The reader should re-read the shard groups it tracks.
+	ShardGroupResync
+)
+
+// maxShardGroupEvents is the number of events queued for a watcher before
+// they are dropped in favour of a ShardGroupResync event.
+const maxShardGroupEvents = 1024
+
+func (t ShardGroupEventType) String() string {
+	switch t {
+	case ShardGroupCreated:
+		return "created"
+	case ShardGroupDeleted:
+		return "deleted"
+	case ShardGroupExpired:
+		return "expired"
+	case ShardGroupResync:
+		return "resync"
+	}
+	return "unknown"
+}
+
+// ShardGroupEvent describes a change to a shard group.
+type ShardGroupEvent struct {
+	Type            ShardGroupEventType
+	Database        string
+	RetentionPolicy string
+	ShardGroup      ShardGroupInfo
+}
+
+// shardGroupWatchers fans shard group events out to watchers. The zero value
+// is ready to use.
+type shardGroupWatchers struct {
+	mu       sync.Mutex
+	watchers map[*shardGroupWatcher]struct{}
+	closed   bool
+}
+
+// watch returns a channel of events that is closed when ctx is done or the
+// watchers are closed.
+func (ws *shardGroupWatchers) watch(ctx context.Context) <-chan ShardGroupEvent {
+	w := &shardGroupWatcher{
+		ch:      make(chan ShardGroupEvent),
+		notify:  make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		expired: make(map[uint64]struct{}),
+	}
+
+	ws.mu.Lock()
+	if ws.closed {
+		ws.mu.Unlock()
+		close(w.ch)
+		return w.ch
+	}
+	if ws.watchers == nil {
+		ws.watchers = make(map[*shardGroupWatcher]struct{})
+	}
+	ws.watchers[w] = struct{}{}
+	ws.mu.Unlock()
+
+	go w.run(ctx, func() {
+		ws.mu.Lock()
+		delete(ws.watchers, w)
+		ws.mu.Unlock()
+	})
+	return w.ch
+}
+
+// close stops every watcher and closes their channels. Later calls to watch
+// return a closed channel.
+func (ws *shardGroupWatchers) close() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return
+	}
+	ws.closed = true
+	for w := range ws.watchers {
+		close(w.stop)
+	}
+	ws.watchers = nil
+}
+
+// publish sends the events between prev and next to every watcher.
+func (ws *shardGroupWatchers) publish(prev, next *Data, now time.Time) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if len(ws.watchers) == 0 {
+		return
+	}
+
+	events, expired := diffShardGroups(prev, next, now)
+	for w := range ws.watchers {
+		w.push(events, expired)
+	}
+}
+
+// diffShardGroups returns the shard group creations and deletions between prev
+// and next, and an expiry event for every shard group in next that has expired
+// as of now.
+func diffShardGroups(prev, next *Data, now time.Time) ([]ShardGroupEvent, []ShardGroupEvent) {
+	type key struct {
+		db, rp string
+		id     uint64
+	}
+
+	live := make(map[key]bool)
+	if prev != nil {
+		for _, di := range prev.Databases {
+			for _, rpi := range di.RetentionPolicies {
+				for _, sgi := range rpi.ShardGroups {
+					live[key{di.Name, rpi.Name, sgi.ID}] = !sgi.Deleted()
+				}
+			}
+		}
+	}
+
+	var events, expired []ShardGroupEvent
+	for _, di := range next.Databases {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				k := key{di.Name, rpi.Name, sgi.ID}
+				wasLive, existed := live[k]
+				delete(live, k)
+
+				ev := ShardGroupEvent{Database: di.Name, RetentionPolicy: rpi.Name, ShardGroup: sgi}
+				switch {
+				case sgi.Deleted():
+					if wasLive {
+						ev.Type = ShardGroupDeleted
+						events = append(events, ev)
+					}
+					continue
+				case !existed:
+					ev.Type = ShardGroupCreated
+					events = append(events, ev)
+				}
+
+				if rpi.Duration != 0 && sgi.EndTime.Before(now.Add(-rpi.Duration)) {
+					ev.Type = ShardGroupExpired
+					expired = append(expired, ev)
+				}
+			}
+		}
+	}
+
+	// Live groups missing from next were removed with their database or
+	// retention policy.
+	if prev != nil {
+		for _, di := range prev.Databases {
+			for _, rpi := range di.RetentionPolicies {
+				for _, sgi := range rpi.ShardGroups {
+					if live[key{di.Name, rpi.Name, sgi.ID}] {
+						events = append(events, ShardGroupEvent{
+							Type:            ShardGroupDeleted,
+							Database:        di.Name,
+							RetentionPolicy: rpi.Name,
+							ShardGroup:      sgi,
+						})
+					}
+				}
+			}
+		}
+	}
+	return events, expired
+}
+
+// shardGroupWatcher queues events for a single watcher so that a slow reader
+// never blocks the publisher.
+type shardGroupWatcher struct {
+	ch     chan ShardGroupEvent
+	notify chan struct{}
+	stop   chan struct{}
+
+	// expired holds the IDs of shard groups already reported to this
+	// watcher as expired. It is guarded by the shardGroupWatchers lock.
+	expired map[uint64]struct{}
+
+	mu    sync.Mutex
+	queue []ShardGroupEvent
+}
+
+// push queues events and the expiries not yet reported to w. If the queue
+// grows past maxShardGroupEvents it is replaced by a single resync event.
+func (w *shardGroupWatcher) push(events, expired []ShardGroupEvent) {
+	// events is shared by every watcher, so appending must not write to its
+	// backing array.
+	events = events[:len(events):len(events)]
+	reported := make(map[uint64]struct{}, len(expired))
+	for _, ev := range expired {
+		reported[ev.ShardGroup.ID] = struct{}{}
+		if _, ok := w.expired[ev.ShardGroup.ID]; !ok {
+			events = append(events, ev)
+		}
+	}
+	w.expired = reported
+	if len(events) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	if len(w.queue)+len(events) > maxShardGroupEvents {
+		w.queue = []ShardGroupEvent{{Type: ShardGroupResync}}
+	} else {
+		w.queue = append(w.queue, events...)
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events until ctx is done or w is stopped, then closes
+// the channel.
+func (w *shardGroupWatcher) run(ctx context.Context, done func()) {
+	defer close(w.ch)
+	defer done()
+
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.stop:
+				return
+			case <-w.notify:
+			}
+			continue
+		}
+		ev := w.queue[0]
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+
+		select {
+		case w.ch <- ev:
+		case <-ctx.Done():
+			return
+		case <-w.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package meta
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func recvShardGroupEvent(t *testing.T, ch <-chan ShardGroupEvent) (ShardGroupEvent, bool) {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		return ev, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for shard group event")
+	}
+	return ShardGroupEvent{}, false
+}
+
+func TestShardGroupWatchers_ExpiredPerWatcher(t *testing.T) {
+	prev := &Data{}
+	if err := prev.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+	rpi := &RetentionPolicyInfo{Name: "rp0", ReplicaN: 1, Duration: time.Hour, ShardGroupDuration: time.Hour}
+	if err := prev.CreateRetentionPolicy("db0", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	next := prev.Clone()
+	now := time.Now()
+	if err := next.CreateShardGroup("db0", "rp0", now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ws shardGroupWatchers
+	first := ws.watch(ctx)
+	ws.publish(prev, next, now)
+	for _, exp := range []ShardGroupEventType{ShardGroupCreated, ShardGroupExpired} {
+		if ev, _ := recvShardGroupEvent(t, first); ev.Type != exp {
+			t.Fatalf("unexpected event: got %s, exp %s", ev.Type, exp)
+		}
+	}
+
+	// A watcher added later still hears about the expiry, but the first
+	// watcher isn't told again.
+	second := ws.watch(ctx)
+	ws.publish(next, next, now)
+	if ev, _ := recvShardGroupEvent(t, second); ev.Type != ShardGroupExpired {
+		t.Fatalf("unexpected event: got %s, exp %s", ev.Type, ShardGroupExpired)
+	}
+	select {
+	case ev := <-first:
+		t.Fatalf("unexpected repeated event: %s %+v", ev.Type, ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestShardGroupWatchers_Close(t *testing.T) {
+	var ws shardGroupWatchers
+	ch := ws.watch(context.Background())
+
+	ws.close()
+	if ev, ok := recvShardGroupEvent(t, ch); ok {
+		t.Fatalf("unexpected event after close: %+v", ev)
+	}
+
+	// Watching after close returns a closed channel.
+	if ev, ok := recvShardGroupEvent(t, ws.watch(context.Background())); ok {
+		t.Fatalf("unexpected event after close: %+v", ev)
+	}
+}
+
+func TestShardGroupWatcher_Overflow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var ws shardGroupWatchers
+	ch := ws.watch(ctx)
+
+	// The watcher isn't read, so the queue overflows and collapses into a
+	// single resync event.
+	events := make([]ShardGroupEvent, maxShardGroupEvents+2)
+	for i := range events {
+		events[i] = ShardGroupEvent{Type: ShardGroupCreated, ShardGroup: ShardGroupInfo{ID: uint64(i + 1)}}
+	}
+	ws.mu.Lock()
+	for w := range ws.watchers {
+		w.push(events[:1], nil)
+		w.push(events[1:], nil)
+	}
+	ws.mu.Unlock()
+
+	// The first event may already be in flight to the channel.
+	ev, _ := recvShardGroupEvent(t, ch)
+	if ev.Type == ShardGroupCreated {
+		ev, _ = recvShardGroupEvent(t, ch)
+	}
+	if ev.Type != ShardGroupResync {
+		t.Fatalf("unexpected event: got %s, exp %s", ev.Type, ShardGroupResync)
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("unexpected event after resync: %s %+v", ev.Type, ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
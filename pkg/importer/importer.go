@@ -26,6 +26,10 @@ type Config struct {
 	Compressed bool // Whether import data is gzipped.
 	PPS        int  // points per second importer imports with.
 
+	// ValidateOnly checks the syntax of the file without connecting to the
+	// server or writing anything.
+	ValidateOnly bool
+
 	ClientConfig     *client.HTTPConfig
 	Precision        string
 	WriteConsistency string
@@ -67,6 +71,13 @@ func NewImporter(c Config) *Importer {
 
 // Import processes the specified file in the Config and writes the data to the databases in chunks specified by batchSize
 func (i *Importer) Import() error {
+	if i.config.ValidateOnly {
+		if i.config.Path == "" {
+			return fmt.Errorf("file argument required")
+		}
+		return i.validate()
+	}
+
 	// Create a client and try to connect.
 	cl, err := client.NewHTTPClient(*i.config.ClientConfig)
 	if err != nil {
@@ -117,7 +128,8 @@ func (i *Importer) Import() error {
 	scanner := bufio.NewReader(r)
 
 	// Process the DDL
-	if err := i.processDDL(scanner); err != nil {
+	first, err := i.processDDL(scanner)
+	if err != nil {
 		return fmt.Errorf("reading standard input: %s", err)
 	}
 
@@ -130,6 +142,9 @@ func (i *Importer) Import() error {
 	i.lastWrite = time.Now()
 
 	// Process the DML
+	if first != "" {
+		i.batchAccumulator(first)
+	}
 	if err := i.processDML(scanner); err != nil {
 		return fmt.Errorf("reading standard input: %s", err)
 	}
@@ -148,17 +163,25 @@ func (i *Importer) Import() error {
 	return nil
 }
 
-func (i *Importer) processDDL(scanner *bufio.Reader) error {
+// processDDL executes the statements of the DDL section, which starts at a
+// "# DDL" line and ends at a "# DML" line. A file without a DDL section is
+// all DML, so its first line of data is returned to be written.
+func (i *Importer) processDDL(scanner *bufio.Reader) (string, error) {
+	ddl := false
 	for {
 		line, err := scanner.ReadString(byte('\n'))
 		if err != nil && err != io.EOF {
-			return err
+			return "", err
 		} else if err == io.EOF {
-			return nil
+			return "", nil
 		}
 		// If we find the DML token, we are done with DDL
 		if strings.HasPrefix(line, "# DML") {
-			return nil
+			return "", nil
+		}
+		if strings.HasPrefix(line, "# DDL") {
+			ddl = true
+			continue
 		}
 		if strings.HasPrefix(line, "#") {
 			continue
@@ -167,6 +190,9 @@ func (i *Importer) processDDL(scanner *bufio.Reader) error {
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
+		if !ddl {
+			return line, nil
+		}
 		i.queryExecutor(line)
 	}
 }
@@ -0,0 +1,25 @@
+package importer
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestImporter_processDDL_NoDDLSection(t *testing.T) {
+	// Without a DDL section, nothing is executed and the data starts at the
+	// first line that isn't a comment.
+	i := NewImporter(Config{})
+	r := bufio.NewReader(strings.NewReader("# new shard group\n\ncpu value=1\nmem value=2\n"))
+	first, err := i.processDDL(r)
+	if err != nil {
+		t.Fatal(err)
+	} else if first != "cpu value=1\n" {
+		t.Fatalf("unexpected first line: %q", first)
+	} else if i.totalCommands != 0 {
+		t.Fatalf("unexpected commands: %d", i.totalCommands)
+	}
+	if rest, _ := r.ReadString('\n'); rest != "mem value=2\n" {
+		t.Fatalf("unexpected next line: %q", rest)
+	}
+}
@@ -0,0 +1,311 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// LineError is a syntax error in a line of line protocol. Line and Column
+// are 1-based; Column counts bytes.
+type LineError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Validate checks the syntax of the DML lines of an export read from r
+// without parsing them into points, and returns an error for each invalid
+// line. Statements in a DDL section are not checked. Only the grammar of the measurement,
+// tags, fields and timestamp is checked; duplicate keys, field types that
+// conflict with existing data and the like are left to the server. Unsigned
+// field values are accepted, although servers built without uint support
+// reject them.
+func Validate(r io.Reader) ([]LineError, error) {
+	br := bufio.NewReader(r)
+
+	var errs []LineError
+	var n int
+	ddl := false
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Lines longer than the buffer are rare; copy what was read
+			// before reading the rest.
+			long := append([]byte(nil), line...)
+			var rest []byte
+			rest, err = br.ReadBytes('\n')
+			line = append(long, rest...)
+		}
+		if err != nil && err != io.EOF {
+			return errs, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return errs, nil
+		}
+		n++
+
+		switch {
+		case bytes.HasPrefix(line, []byte("# DDL")):
+			ddl = true
+		case bytes.HasPrefix(line, []byte("# DML")):
+			ddl = false
+		case ddl, len(line) > 0 && line[0] == '#', len(bytes.TrimSpace(line)) == 0:
+		default:
+			if col, msg := validateLine(line); msg != "" {
+				errs = append(errs, LineError{Line: n, Column: col, Msg: msg})
+			}
+		}
+
+		if err == io.EOF {
+			return errs, nil
+		}
+	}
+}
+
+// validate checks the file in the Config and reports each invalid line.
+func (i *Importer) validate() error {
+	f, err := os.Open(i.config.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if i.config.Compressed {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	errs, err := Validate(r)
+	for _, e := range errs {
+		i.stderrLogger.Println(e)
+	}
+	if err != nil {
+		return err
+	} else if len(errs) > 0 {
+		return fmt.Errorf("%d invalid lines", len(errs))
+	}
+	i.stdoutLogger.Printf("No invalid lines found in %s\n", i.config.Path)
+	return nil
+}
+
+// lineScanner walks a line of line protocol.
+type lineScanner struct {
+	b []byte
+	i int
+}
+
+// validateLine checks the syntax of a line of line protocol. If the line is
+// invalid it returns the 1-based column of the error and a description.
+func validateLine(b []byte) (int, string) {
+	b = bytes.TrimRight(b, " \t\r\n")
+	s := lineScanner{b: b}
+
+	// Measurement, ending at the first unescaped comma or space.
+	if n := s.scanKey(); n == 0 {
+		return s.col(), "missing measurement"
+	}
+
+	// Tags.
+	for s.peek() == ',' {
+		s.i++
+		if s.scanKey() == 0 {
+			return s.col(), "missing tag key"
+		} else if s.peek() != '=' {
+			return s.col(), "missing tag value"
+		}
+		s.i++
+		if s.scanKey() == 0 {
+			return s.col(), "missing tag value"
+		}
+	}
+
+	if !s.skipSpaces() {
+		return s.col(), "missing fields"
+	}
+
+	// Fields.
+	for {
+		if s.scanKey() == 0 {
+			return s.col(), "missing field key"
+		} else if s.peek() != '=' {
+			return s.col(), "missing field value"
+		}
+		s.i++
+		if msg := s.scanFieldValue(); msg != "" {
+			return s.col(), msg
+		}
+		if s.peek() != ',' {
+			break
+		}
+		s.i++
+	}
+
+	if s.i == len(s.b) {
+		return 0, ""
+	} else if !s.skipSpaces() {
+		return s.col(), "invalid field value"
+	}
+
+	// Timestamp.
+	start := s.i
+	if s.peek() == '-' {
+		s.i++
+	}
+	if s.scanDigits() == 0 || s.i != len(s.b) {
+		return start + 1, "invalid timestamp"
+	}
+	return 0, ""
+}
+
+func (s *lineScanner) col() int { return s.i + 1 }
+
+func (s *lineScanner) peek() byte {
+	if s.i < len(s.b) {
+		return s.b[s.i]
+	}
+	return 0
+}
+
+// skipSpaces skips the spaces before the next section of the line and
+// returns false if there are none or the line ends.
+func (s *lineScanner) skipSpaces() bool {
+	start := s.i
+	for s.i < len(s.b) && s.b[s.i] == ' ' {
+		s.i++
+	}
+	return s.i > start && s.i < len(s.b)
+}
+
+// scanKey scans a measurement, tag key, tag value or field key up to the next
+// unescaped comma, equals sign or space and returns its length.
+func (s *lineScanner) scanKey() int {
+	start := s.i
+	for s.i < len(s.b) {
+		switch s.b[s.i] {
+		case '\\':
+			s.i++
+			if s.i < len(s.b) {
+				s.i++
+			}
+			continue
+		case ',', '=', ' ':
+			return s.i - start
+		}
+		s.i++
+	}
+	return s.i - start
+}
+
+// scanFieldValue scans a field value and returns a description of the error
+// if it is not a valid string, boolean, integer or float.
+func (s *lineScanner) scanFieldValue() string {
+	switch c := s.peek(); {
+	case c == '"':
+		s.i++
+		for s.i < len(s.b) {
+			switch s.b[s.i] {
+			case '\\':
+				s.i += 2
+				continue
+			case '"':
+				s.i++
+				return ""
+			}
+			s.i++
+		}
+		return "unterminated string field value"
+	case c == 't' || c == 'T' || c == 'f' || c == 'F':
+		start := s.i
+		s.scanKey()
+		switch string(s.b[start:s.i]) {
+		case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+			return ""
+		}
+		s.i = start
+		return "invalid boolean field value"
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return s.scanNumber()
+	case c == 0 || c == ',' || c == ' ':
+		return "missing field value"
+	}
+	return "invalid field value"
+}
+
+// scanNumber scans an integer, unsigned integer or float field value.
+func (s *lineScanner) scanNumber() string {
+	start := s.i
+	neg := s.peek() == '-'
+	if neg {
+		s.i++
+	}
+	digits := s.scanDigits()
+
+	switch s.peek() {
+	case 'i':
+		s.i++
+		if digits == 0 {
+			s.i = start
+			return "invalid integer field value"
+		}
+		return s.endOfValue(start, "invalid integer field value")
+	case 'u':
+		s.i++
+		if digits == 0 || neg {
+			s.i = start
+			return "invalid unsigned field value"
+		}
+		return s.endOfValue(start, "invalid unsigned field value")
+	}
+
+	if s.peek() == '.' {
+		s.i++
+		digits += s.scanDigits()
+	}
+	if digits == 0 {
+		s.i = start
+		return "invalid number field value"
+	}
+	if c := s.peek(); c == 'e' || c == 'E' {
+		s.i++
+		if c := s.peek(); c == '+' || c == '-' {
+			s.i++
+		}
+		if s.scanDigits() == 0 {
+			s.i = start
+			return "invalid float field value"
+		}
+	}
+	return s.endOfValue(start, "invalid number field value")
+}
+
+// endOfValue checks that a field value ends at the current position. If not,
+// it rewinds to start and returns msg.
+func (s *lineScanner) endOfValue(start int, msg string) string {
+	switch s.peek() {
+	case 0, ',', ' ':
+		return ""
+	}
+	s.i = start
+	return msg
+}
+
+func (s *lineScanner) scanDigits() int {
+	start := s.i
+	for s.i < len(s.b) && s.b[s.i] >= '0' && s.b[s.i] <= '9' {
+		s.i++
+	}
+	return s.i - start
+}
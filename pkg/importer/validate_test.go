@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cnosdb/cnosdb/vend/db/models"
+)
+
+func TestValidate(t *testing.T) {
+	export := strings.Join([]string{
+		"# DDL",
+		"CREATE DATABASE db0 WITH NAME autogen",
+		"this is not line protocol, but DDL is not checked",
+		"# DML",
+		"# CONTEXT-DATABASE: db0",
+		"",
+		`cpu,host=a,region=us\ west value=1.5,count=3i,ok=true,msg="hi, \"there\"" 1600000000000000000`,
+		`mem free=10u`,
+		`cpu,host=a value=`,
+		`cpu,host value=1`,
+		`cpu value=1 12x`,
+		`cpu value=tru`,
+		`cpu value="open`,
+		`cpu`,
+		`,host=a value=1`,
+		`disk used=-1.5e+3,avail=.5 -10`,
+		`disk free=-1u`,
+	}, "\n")
+
+	errs, err := Validate(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []LineError{
+		{Line: 9, Column: 18, Msg: "missing field value"},
+		{Line: 10, Column: 9, Msg: "missing tag value"},
+		{Line: 11, Column: 13, Msg: "invalid timestamp"},
+		{Line: 12, Column: 11, Msg: "invalid boolean field value"},
+		{Line: 13, Column: 16, Msg: "unterminated string field value"},
+		{Line: 14, Column: 4, Msg: "missing fields"},
+		{Line: 15, Column: 1, Msg: "missing measurement"},
+		{Line: 17, Column: 11, Msg: "invalid unsigned field value"},
+	}
+	if !reflect.DeepEqual(errs, exp) {
+		t.Fatalf("unexpected errors:\ngot %v\nexp %v", errs, exp)
+	}
+
+	// Every line found invalid is also rejected by the full parser, and
+	// every other line is accepted by it. Validate accepts unsigned values,
+	// which the parser only does when built with uint support.
+	models.EnableUintSupport()
+	invalid := make(map[int]bool)
+	for _, e := range errs {
+		invalid[e.Line] = true
+	}
+	for n, line := range strings.Split(export, "\n")[6:] {
+		_, err := models.ParsePointsString(line)
+		if invalid[n+7] != (err != nil) {
+			t.Errorf("line %d: validation and parsing disagree: %q: %v", n+7, line, err)
+		}
+	}
+}
+
+func TestValidate_NoDDLSection(t *testing.T) {
+	errs, err := Validate(strings.NewReader("cpu value=1\ncpu value=\n"))
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := []LineError{{Line: 2, Column: 11, Msg: "missing field value"}}; !reflect.DeepEqual(errs, exp) {
+		t.Fatalf("unexpected errors: got %v, exp %v", errs, exp)
+	}
+}
+
+func TestValidate_LongLine(t *testing.T) {
+	line := "cpu value=\"" + strings.Repeat("x", 8192) + "\""
+	errs, err := Validate(strings.NewReader("# DML\n" + line + "\n" + line + " x\n"))
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := []LineError{{Line: 3, Column: len(line) + 2, Msg: "invalid timestamp"}}; !reflect.DeepEqual(errs, exp) {
+		t.Fatalf("unexpected errors: got %v, exp %v", errs, exp)
+	}
+}
+
+func benchmarkExport(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# DML\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "cpu,host=server%d,region=us-west usage_user=%d.5,usage_idle=%di,active=true,note=\"ok\" %d\n", i%100, i, i, 1600000000000000000+i)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkValidate(b *testing.B) {
+	export := benchmarkExport(10000)
+	b.SetBytes(int64(len(export)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if errs, err := Validate(bytes.NewReader(export)); err != nil || len(errs) > 0 {
+			b.Fatal(err, errs)
+		}
+	}
+}
+
+// BenchmarkParsePoints parses the same export fully, for comparison.
+func BenchmarkParsePoints(b *testing.B) {
+	export := benchmarkExport(10000)
+	lines := bytes.Split(bytes.TrimSpace(export), []byte("\n"))[1:]
+	b.SetBytes(int64(len(export)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			if _, err := models.ParsePoints(line); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
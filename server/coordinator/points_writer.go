@@ -240,7 +240,7 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 			continue
 		}
 
-		sh := rg.ShardFor(p)
+		sh := rg.ShardForTenant(rp.Tenant(p.Tags()), p)
 		mapping.MapPoint(&sh, p)
 	}
 	return mapping, nil
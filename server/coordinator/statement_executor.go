@@ -260,8 +260,6 @@ func (e *StatementExecutor) executeCreateContinuousQueryStatement(q *cnosql.Crea
 
 func (e *StatementExecutor) executeCreateDatabaseStatement(stmt *cnosql.CreateDatabaseStatement) error {
 	if !meta.ValidName(stmt.Name) {
-		// TODO This should probably be in `(*meta.Data).CreateDatabase`
-		// but can't go there until 1.1 is used everywhere
 		return meta.ErrInvalidName
 	}
 
@@ -290,8 +288,6 @@ func (e *StatementExecutor) executeCreateDatabaseStatement(stmt *cnosql.CreateDa
 
 func (e *StatementExecutor) executeCreateRetentionPolicyStatement(stmt *cnosql.CreateRetentionPolicyStatement) error {
 	if !meta.ValidName(stmt.Name) {
-		// TODO This should probably be in `(*meta.Data).CreateRetentionPolicy`
-		// but can't go there until 1.1 is used everywhere
 		return meta.ErrInvalidName
 	}
 
@@ -336,10 +332,17 @@ func (e *StatementExecutor) executeDropContinuousQueryStatement(q *cnosql.DropCo
 // It does not return an error if the database was not found on any of
 // the nodes, or in the Meta store.
 func (e *StatementExecutor) executeDropDatabaseStatement(stmt *cnosql.DropDatabaseStatement) error {
-	if e.MetaClient.Database(stmt.Name) == nil {
+	dbi := e.MetaClient.Database(stmt.Name)
+	if dbi == nil {
 		return nil
 	}
 
+	// The meta store refuses to drop a database with subscriptions, so check
+	// before its data is deleted.
+	if err := dbi.CheckDrop(); err != nil {
+		return err
+	}
+
 	// Locally delete the datababse.
 	if err := e.TSDBStore.DeleteDatabase(stmt.Name); err != nil {
 		return err
@@ -519,12 +522,14 @@ func (e *StatementExecutor) executeRevokeStatement(stmt *cnosql.RevokeStatement)
 
 	// Revoking all privileges means there's no need to look at existing user privileges.
 	if stmt.Privilege != cnosql.AllPrivileges {
-		p, err := e.MetaClient.UserPrivilege(stmt.User, stmt.On)
+		// Read the stored grants rather than UserPrivilege, which reports
+		// AllPrivileges for everyone when authentication is disabled.
+		privs, err := e.MetaClient.UserPrivileges(stmt.User)
 		if err != nil {
 			return err
 		}
 		// Bit clear (AND NOT) the user's privilege with the revoked privilege.
-		priv = *p &^ stmt.Privilege
+		priv = privs[stmt.On] &^ stmt.Privilege
 	}
 
 	return e.MetaClient.SetPrivilege(stmt.User, stmt.On, priv)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cnosdb/cnosdb/meta"
 	"github.com/cnosdb/cnosdb/pkg/logger"
+	"github.com/cnosdb/cnosdb/vend/cnosql"
 
 	"go.uber.org/zap"
 )
@@ -21,6 +22,7 @@ type Service struct {
 	TSDBStore interface {
 		ShardIDs() []uint64
 		DeleteShard(shardID uint64) error
+		DeleteRetentionPolicySeries(database, rp string, sources []cnosql.Source, condition cnosql.Expr) error
 	}
 
 	config Config
@@ -148,6 +150,24 @@ func (s *Service) run() {
 				}
 			}
 
+			// Drop data for measurements kept for less time than their
+			// retention policy.
+			now := time.Now().UTC()
+			for _, d := range dbs {
+				for _, r := range d.RetentionPolicies {
+					for name, dur := range r.MeasurementRetention {
+						if err := s.deleteExpiredSeries(d.Name, r.Name, name, now.Add(-dur)); err != nil {
+							log.Info("Failed to delete expired measurement data",
+								logger.Database(d.Name),
+								logger.RetentionPolicy(r.Name),
+								zap.String("measurement", name),
+								zap.Error(err))
+							retryNeeded = true
+						}
+					}
+				}
+			}
+
 			if err := s.MetaClient.PruneShardGroups(); err != nil {
 				log.Info("Problem pruning shard groups", zap.Error(err))
 				retryNeeded = true
@@ -161,3 +181,15 @@ func (s *Service) run() {
 		}
 	}
 }
+
+// deleteExpiredSeries removes the local data of a measurement written before
+// cutoff.
+func (s *Service) deleteExpiredSeries(database, rp, name string, cutoff time.Time) error {
+	sources := []cnosql.Source{&cnosql.Measurement{Database: database, RetentionPolicy: rp, Name: name}}
+	cond := &cnosql.BinaryExpr{
+		Op:  cnosql.LT,
+		LHS: &cnosql.VarRef{Val: "time"},
+		RHS: &cnosql.TimeLiteral{Val: cutoff},
+	}
+	return s.TSDBStore.DeleteRetentionPolicySeries(database, rp, sources, cond)
+}
@@ -353,7 +353,12 @@ func (s *Server) initMetaClient() error {
 		metaCli.WithLogger(s.Logger)
 	} else {
 		s.Logger.Info("waiting to be added to cluster")
-		metaCli = meta.NewRemoteClient()
+		remoteCli := meta.NewRemoteClient()
+		remoteCli.SetCaseInsensitiveNames(s.Config.Meta.CaseInsensitiveNames)
+		if err := remoteCli.ApplyConfig(s.Config.Meta); err != nil {
+			return err
+		}
+		metaCli = remoteCli
 		metaCli.WithLogger(s.Logger)
 		for {
 			if len(s.Node.Peers) == 0 {
@@ -365,15 +370,22 @@ func (s *Server) initMetaClient() error {
 		}
 		s.Logger.Info("joined cluster", zap.String("peers", strings.Join(s.Node.Peers, ",")))
 	}
+	metaCli.SetAuthEnabled(s.Config.HTTPD.AuthEnabled)
 	s.MetaClient = metaCli
 
-	// s.metaClient.SetTLS(s.metaUseTLS)
-
 	if err := s.MetaClient.Open(); err != nil {
 		return err
 	}
 	s.MetaClient.WithLogger(s.Logger)
 
+	// Meta data older than this build, including unversioned data reported
+	// as version 0, is compatible; only newer formats are refused.
+	if v, err := s.MetaClient.MetaVersion(); err != nil {
+		return err
+	} else if v > meta.DataVersion {
+		return meta.ErrUnsupportedMetaVersion(v)
+	}
+
 	// if the node ID is > 0 then we need to initialize the metaclient
 	if s.Node.ID > 0 {
 		s.MetaClient.WaitForDataChanged()
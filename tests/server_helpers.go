@@ -220,7 +220,7 @@ func (s *LocalServer) DropDatabase(db string) error {
 	if err := s.TSDBStore.DeleteDatabase(db); err != nil {
 		return err
 	}
-	return s.MetaClient.DropDatabase(db)
+	return s.MetaClient.DropDatabaseForce(db)
 }
 
 func (s *LocalServer) Reset() error {
@@ -976,6 +976,14 @@ func byDatabase(name string) func(sh *Shard) bool {
 	}
 }
 
+// byRetentionPolicy provides a predicate for filterShards that matches on the
+// database and retention policy passed in.
+func byRetentionPolicy(database, rp string) func(sh *Shard) bool {
+	return func(sh *Shard) bool {
+		return sh.database == database && sh.retentionPolicy == rp
+	}
+}
+
 // walkShards apply a function to each shard in parallel. fn must be safe for
 // concurrent use. If any of the functions return an error, the first error is
 // returned.
@@ -1261,6 +1269,16 @@ func (s *Store) ShardRelativePath(id uint64) (string, error) {
 // DeleteSeries loops through the local shards and deletes the series data for
 // the passed in series keys.
 func (s *Store) DeleteSeries(database string, sources []cnosql.Source, condition cnosql.Expr) error {
+	return s.deleteSeries(database, byDatabase(database), sources, condition)
+}
+
+// DeleteRetentionPolicySeries is like DeleteSeries but only deletes from the
+// local shards of the given retention policy.
+func (s *Store) DeleteRetentionPolicySeries(database, rp string, sources []cnosql.Source, condition cnosql.Expr) error {
+	return s.deleteSeries(database, byRetentionPolicy(database, rp), sources, condition)
+}
+
+func (s *Store) deleteSeries(database string, filter func(sh *Shard) bool, sources []cnosql.Source, condition cnosql.Expr) error {
 	// Expand regex expressions in the FROM clause.
 	a, err := s.ExpandSources(sources)
 	if err != nil {
@@ -1299,7 +1317,7 @@ func (s *Store) DeleteSeries(database string, sources []cnosql.Source, condition
 		// No series file means nothing has been written to this DB and thus nothing to delete.
 		return nil
 	}
-	shards := s.filterShards(byDatabase(database))
+	shards := s.filterShards(filter)
 	epochs := s.epochsForShards(shards)
 	s.mu.RUnlock()
 